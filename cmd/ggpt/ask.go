@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/go-coders/git_gpt/internal/agent"
+	"github.com/go-coders/git_gpt/internal/app"
+	"github.com/go-coders/git_gpt/internal/config"
+	"github.com/go-coders/git_gpt/internal/version"
+	"github.com/go-coders/git_gpt/pkg/utils"
+)
+
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+func runAsk(cfg *config.Config, logger *utils.LoggerImpl, args []string) int {
+	fs := flag.NewFlagSet("ask", flag.ContinueOnError)
+	format := fs.String("format", formatText, "Output format: text or json")
+	allowModify := fs.Bool("allow-modify", false, "Execute commands that modify the repository")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if *format != formatText && *format != formatJSON {
+		fmt.Fprintf(os.Stderr, "invalid --format value: %s\n", *format)
+		return exitUsage
+	}
+
+	query, err := readQuery(fs.Args(), os.Stdin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read query: %v\n", err)
+		return exitError
+	}
+	if query == "" {
+		fmt.Fprintln(os.Stderr, "usage: ggpt ask [--format=text|json] [--allow-modify] <question>")
+		return exitUsage
+	}
+
+	application, err := app.New(app.Options{
+		Config:         cfg,
+		Logger:         logger,
+		Version:        version.Version,
+		NonInteractive: true,
+		Dir:            *workDir,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize application: %v\n", err)
+		return exitCodeFor(err)
+	}
+
+	result, err := application.Ask(context.Background(), query, agent.AskOptions{
+		AllowModify: *allowModify,
+	})
+	if result != nil {
+		if printErr := printAskResult(os.Stdout, result, *format); printErr != nil {
+			fmt.Fprintf(os.Stderr, "Failed to print result: %v\n", printErr)
+			return exitError
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitCodeFor(err)
+	}
+
+	return exitOK
+}
+
+// readQuery joins the positional arguments, or reads stdin when there are none
+// or the only argument is "-".
+func readQuery(args []string, stdin io.Reader) (string, error) {
+	if len(args) > 0 && !(len(args) == 1 && args[0] == "-") {
+		return strings.TrimSpace(strings.Join(args, " ")), nil
+	}
+
+	data, err := io.ReadAll(stdin)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func printAskResult(w io.Writer, result *agent.AskResult, format string) error {
+	if format == formatJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+
+	if !result.Executed && len(result.Commands) > 0 {
+		for _, cmd := range result.Commands {
+			fmt.Fprintf(w, "git %s\n", strings.Join(cmd.Args, " "))
+		}
+	}
+	if result.CommandType == agent.CommandTypeModify {
+		for _, res := range result.Results {
+			if res.Output != "" {
+				fmt.Fprintln(w, res.Output)
+			}
+		}
+	}
+	if result.Summary != "" {
+		fmt.Fprintln(w, result.Summary)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/go-coders/git_gpt/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsk_JSONKeepsWarningsOffStdout(t *testing.T) {
+	testRepo(t)
+	cfg := fakeModel(t,
+		`{"type": "execute", "commandType": "query", "commands": [{"args": ["status", "--short"]}], "reason": "Check the tree"}`,
+		"The working tree is clean.",
+	)
+	// One step makes the step limit warning fire
+	cfg.Agent.MaxSteps = 1
+
+	var code int
+	stdout, stderr := captureOutput(t, func() {
+		code = runAsk(cfg, utils.NewLogger(false), []string{"--format=json", "is the tree clean?"})
+	})
+	require.Equal(t, exitOK, code, stderr)
+
+	var result struct {
+		Summary  string `json:"summary"`
+		Executed bool   `json:"executed"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &result), "stdout is not JSON:\n%s", stdout)
+	assert.Equal(t, "The working tree is clean.", result.Summary)
+	assert.True(t, result.Executed)
+	assert.Contains(t, stderr, "Reached the limit of 1 steps")
+}
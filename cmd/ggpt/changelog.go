@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/go-coders/git_gpt/internal/agent"
+	"github.com/go-coders/git_gpt/internal/config"
+	"github.com/go-coders/git_gpt/pkg/apierrors"
+	"github.com/go-coders/git_gpt/pkg/utils"
+)
+
+const changelogUsage = "usage: ggpt changelog [--version=VERSION] [--date=DATE] [--no-llm] [--output=FILE] [FROM..TO]"
+
+// runChangelog prints the changelog section of a revision range, or adds it
+// to the top of a changelog file
+func runChangelog(cfg *config.Config, logger *utils.LoggerImpl, args []string) int {
+	fs := flag.NewFlagSet("changelog", flag.ContinueOnError)
+	version := fs.String("version", "", "Name the section after this version instead of Unreleased")
+	date := fs.String("date", "", "Date the section with YYYY-MM-DD (default: today, or the date of the tag)")
+	noLLM := fs.Bool("no-llm", false, "Keep the entries as written in the commits")
+	output := fs.String("output", "", "Add the section to the top of this file, such as CHANGELOG.md")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() > 1 {
+		fmt.Fprintln(os.Stderr, changelogUsage)
+		return exitUsage
+	}
+
+	ctx := context.Background()
+	opts := agent.ChangelogOptions{Range: fs.Arg(0), Version: *version, Date: *date, NoLLM: *noLLM}
+	section, err := changelogSection(ctx, cfg, logger, opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitCodeFor(err)
+	}
+
+	if *output != "" {
+		if err := agent.PrependChangelog(*output, section); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitError
+		}
+		return exitOK
+	}
+	fmt.Print(section)
+	return exitOK
+}
+
+// changelogSection renders opts with the model, or from the commit messages
+// alone with --no-llm so that it runs without an API key
+func changelogSection(ctx context.Context, cfg *config.Config, logger *utils.LoggerImpl, opts agent.ChangelogOptions) (string, error) {
+	if opts.NoLLM {
+		gitClient := newGitClient()
+		if !gitClient.IsGitRepository(ctx) {
+			return "", apierrors.NewNotGitRepoError()
+		}
+		return agent.OfflineChangelog(ctx, gitClient, opts)
+	}
+
+	application, err := newCommandApp(cfg, logger)
+	if err != nil {
+		return "", fmt.Errorf("failed to initialize application: %w", err)
+	}
+	return application.Changelog(ctx, opts)
+}
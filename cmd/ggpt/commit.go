@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/go-coders/git_gpt/internal/agent"
+	"github.com/go-coders/git_gpt/internal/app"
+	"github.com/go-coders/git_gpt/internal/config"
+	"github.com/go-coders/git_gpt/internal/version"
+	"github.com/go-coders/git_gpt/pkg/utils"
+)
+
+// messageFlags are the flags that choose and finish a commit message, shared
+// by commit and reword
+type messageFlags struct {
+	yes      *bool
+	pick     *int
+	dryRun   *bool
+	edit     *bool
+	trailers []agent.Trailer
+}
+
+func addMessageFlags(fs *flag.FlagSet) *messageFlags {
+	f := &messageFlags{
+		yes:    fs.Bool("yes", false, "Use the first suggestion without prompting"),
+		pick:   fs.Int("pick", 0, "Use the n-th suggestion without prompting"),
+		dryRun: fs.Bool("dry-run", false, "Print the chosen message without committing"),
+		edit:   fs.Bool("edit", false, "Open the chosen message in $EDITOR before committing"),
+	}
+	fs.Func("trailer", `Add a trailer such as "Co-authored-by: Name <email>" (repeatable)`, func(value string) error {
+		trailer, err := agent.ParseTrailer(value)
+		if err != nil {
+			return err
+		}
+		f.trailers = append(f.trailers, trailer)
+		return nil
+	})
+	return f
+}
+
+func (f *messageFlags) validate() bool {
+	if *f.pick < 0 {
+		fmt.Fprintln(os.Stderr, "--pick must be a positive number")
+		return false
+	}
+	return true
+}
+
+func (f *messageFlags) options() agent.CommitOptions {
+	return agent.CommitOptions{
+		Pick:     *f.pick,
+		Yes:      *f.yes,
+		DryRun:   *f.dryRun,
+		Edit:     *f.edit,
+		Trailers: f.trailers,
+	}
+}
+
+// newCommandApp creates the application for a one-shot command
+func newCommandApp(cfg *config.Config, logger *utils.LoggerImpl) (*app.Application, error) {
+	return app.New(app.Options{
+		Config:         cfg,
+		Logger:         logger,
+		Version:        version.Version,
+		NonInteractive: true,
+		Dir:            *workDir,
+	})
+}
+
+func runCommit(cfg *config.Config, logger *utils.LoggerImpl, args []string) int {
+	fs := flag.NewFlagSet("commit", flag.ContinueOnError)
+	message := addMessageFlags(fs)
+	stage := fs.String("stage", agent.StageModeNone, "Stage changes before committing: all, tracked or none")
+	split := fs.Bool("split", false, "Commit the staged changes as several logical commits")
+	amend := fs.Bool("amend", false, "Replace the last commit, describing it together with the staged changes")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	switch *stage {
+	case agent.StageModeAll, agent.StageModeTracked, agent.StageModeNone:
+	default:
+		fmt.Fprintf(os.Stderr, "invalid --stage value: %s\n", *stage)
+		return exitUsage
+	}
+	if !message.validate() {
+		return exitUsage
+	}
+	if *split && *message.pick > 0 {
+		fmt.Fprintln(os.Stderr, "--pick cannot be used with --split")
+		return exitUsage
+	}
+	if *split && (*message.edit || len(message.trailers) > 0) {
+		fmt.Fprintln(os.Stderr, "--edit and --trailer cannot be used with --split")
+		return exitUsage
+	}
+	if *split && *amend {
+		fmt.Fprintln(os.Stderr, "--amend cannot be used with --split")
+		return exitUsage
+	}
+
+	application, err := newCommandApp(cfg, logger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize application: %v\n", err)
+		return exitCodeFor(err)
+	}
+
+	opts := message.options()
+	opts.Stage = *stage
+	opts.Split = *split
+	opts.Amend = *amend
+	result, err := application.Commit(context.Background(), opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitCodeFor(err)
+	}
+
+	fmt.Println(result)
+	return exitOK
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-coders/git_gpt/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommit_PromptsOffStdout(t *testing.T) {
+	dir := testRepo(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "hello.go"), []byte("package hello\n"), 0644))
+	runGit(t, dir, "add", "hello.go")
+	cfg := fakeModel(t, `{"summary": "Adds a package.", "suggestions": [{"message": "feat: add the hello package"}]}`)
+
+	// The selection is answered on stdin
+	stdin, err := os.CreateTemp(t.TempDir(), "stdin")
+	require.NoError(t, err)
+	defer stdin.Close()
+	_, err = stdin.WriteString("1\n")
+	require.NoError(t, err)
+	_, err = stdin.Seek(0, 0)
+	require.NoError(t, err)
+	previous := os.Stdin
+	os.Stdin = stdin
+	defer func() { os.Stdin = previous }()
+
+	var code int
+	stdout, stderr := captureOutput(t, func() {
+		code = runCommit(cfg, utils.NewLogger(false), nil)
+	})
+	require.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stderr, "Select a message")
+	assert.NotContains(t, stdout, "Select a message")
+	assert.Equal(t, 1, strings.Count(stdout, "\n"), "stdout is only the result:\n%s", stdout)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/go-coders/git_gpt/internal/app"
+	"github.com/go-coders/git_gpt/internal/config"
+	"github.com/go-coders/git_gpt/internal/version"
+	"github.com/go-coders/git_gpt/pkg/utils"
+)
+
+const hookUsage = "usage: ggpt hook install [--force] | uninstall | run <msg-file> [source] [sha]"
+
+func runHook(cfg *config.Config, logger *utils.LoggerImpl, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, hookUsage)
+		return exitUsage
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "install":
+		fs := flag.NewFlagSet("hook install", flag.ContinueOnError)
+		force := fs.Bool("force", false, "Replace an existing prepare-commit-msg hook")
+		if err := fs.Parse(args[1:]); err != nil {
+			return exitUsage
+		}
+
+		path, err := app.InstallHook(ctx, newGitClient(), *force)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitCodeFor(err)
+		}
+		fmt.Printf("Installed hook: %s\n", path)
+		return exitOK
+
+	case "uninstall":
+		path, err := app.UninstallHook(ctx, newGitClient())
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitCodeFor(err)
+		}
+		fmt.Printf("Removed hook: %s\n", path)
+		return exitOK
+
+	case "run":
+		return runHookEntrypoint(ctx, cfg, logger, args[1:])
+
+	default:
+		fmt.Fprintln(os.Stderr, hookUsage)
+		return exitUsage
+	}
+}
+
+// runHookEntrypoint is called by the installed prepare-commit-msg hook. It
+// never fails the commit: problems are reported and the message is left as is.
+func runHookEntrypoint(ctx context.Context, cfg *config.Config, logger *utils.LoggerImpl, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, hookUsage)
+		return exitUsage
+	}
+
+	var source string
+	if len(args) > 1 {
+		source = args[1]
+	}
+	if !app.ShouldPrepareMessage(source) {
+		return exitOK
+	}
+
+	application, err := app.New(app.Options{
+		Config:         cfg,
+		Logger:         logger,
+		Version:        version.Version,
+		NonInteractive: true,
+		Dir:            *workDir,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ggpt: skipping commit message suggestions: %v\n", err)
+		return exitOK
+	}
+
+	if err := application.PrepareCommitMessage(ctx, args[0]); err != nil {
+		fmt.Fprintf(os.Stderr, "ggpt: skipping commit message suggestions: %v\n", err)
+	}
+	return exitOK
+}
@@ -2,19 +2,37 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
+	"fmt"
 	"log"
+	"os"
 	"runtime/debug"
 
 	"github.com/go-coders/git_gpt/internal/app"
 	"github.com/go-coders/git_gpt/internal/config"
+	"github.com/go-coders/git_gpt/internal/git"
 	"github.com/go-coders/git_gpt/internal/version"
+	"github.com/go-coders/git_gpt/pkg/apierrors"
 	"github.com/go-coders/git_gpt/pkg/utils"
 )
 
 var (
 	debugMode  = flag.Bool("debug", false, "Enable debug mode")
 	configPath = flag.String("config", "", "Path to config file")
+	workDir    = flag.String("C", "", "Run as if ggpt was started in this directory")
+)
+
+// Exit codes returned by subcommands
+const (
+	exitOK        = 0
+	exitError     = 1
+	exitUsage     = 2
+	exitNoChanges = 3
+	exitCancelled = 4
+	exitNotRepo   = 5
+	exitNoModify  = 6
+	exitSecret    = 7
 )
 
 func main() {
@@ -28,10 +46,15 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	if flag.NArg() > 0 {
+		os.Exit(runSubcommand(cfg, logger, flag.Arg(0), flag.Args()[1:]))
+	}
+
 	application, err := app.New(app.Options{
 		Config:  cfg,
 		Logger:  logger,
 		Version: version.Version,
+		Dir:     *workDir,
 	})
 	if err != nil {
 		log.Fatalf("Failed to initialize application: %v", err)
@@ -43,6 +66,61 @@ func main() {
 	}
 }
 
+func runSubcommand(cfg *config.Config, logger *utils.LoggerImpl, name string, args []string) int {
+	switch name {
+	case "commit":
+		return runCommit(cfg, logger, args)
+	case "ask":
+		return runAsk(cfg, logger, args)
+	case "hook":
+		return runHook(cfg, logger, args)
+	case "undo":
+		return runUndo(args)
+	case "reword":
+		return runReword(cfg, logger, args)
+	case "pr":
+		return runPR(cfg, logger, args)
+	case "changelog":
+		return runChangelog(cfg, logger, args)
+	case "release":
+		return runRelease(cfg, logger, args)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command: %s\n", name)
+		return exitUsage
+	}
+}
+
+// exitCodeFor maps application errors to the documented exit codes
+func exitCodeFor(err error) int {
+	var appErr *apierrors.AppError
+	if !errors.As(err, &appErr) {
+		return exitError
+	}
+
+	switch appErr.Type {
+	case apierrors.ErrNothingToCommit, apierrors.ErrNothingToUndo:
+		return exitNoChanges
+	case apierrors.ErrCancelled:
+		return exitCancelled
+	case apierrors.ErrGitNotInitialized:
+		return exitNotRepo
+	case apierrors.ErrModifyNotAllowed, apierrors.ErrPublishedCommit:
+		return exitNoModify
+	case apierrors.ErrSecretDetected:
+		return exitSecret
+	default:
+		return exitError
+	}
+}
+
+// newGitClient returns an executor for the directory given with -C
+func newGitClient() *git.GitExecutor {
+	if *workDir != "" {
+		return git.NewExecutorInDir(*workDir)
+	}
+	return git.NewExecutor()
+}
+
 func initVersion() {
 	info, ok := debug.ReadBuildInfo()
 	if !ok {
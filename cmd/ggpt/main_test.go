@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/go-coders/git_gpt/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeModel serves replies in order from an Ollama compatible endpoint
+func fakeModel(t *testing.T, replies ...string) *config.Config {
+	t.Helper()
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(replies) == 0 {
+			http.Error(w, "no more replies", http.StatusInternalServerError)
+			return
+		}
+		reply := replies[0]
+		replies = replies[1:]
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": map[string]string{"role": "assistant", "content": reply},
+			"done":    true,
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := &config.Config{ConfigPath: filepath.Join(t.TempDir(), "config.json")}
+	cfg.LLM.Provider = config.ProviderOllama
+	cfg.LLM.BaseURL = server.URL
+	cfg.SetDefaultValue()
+	return cfg
+}
+
+// testRepo creates a repository with one commit and makes it the directory
+// subcommands run in
+func testRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"config", "user.name", "Test"},
+		{"config", "user.email", "test@example.com"},
+		{"commit", "-q", "--allow-empty", "-m", "feat: first"},
+	} {
+		runGit(t, dir, args...)
+	}
+
+	previous := *workDir
+	*workDir = dir
+	t.Cleanup(func() { *workDir = previous })
+	return dir
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, "git %v: %s", args, out)
+}
+
+// captureOutput runs fn with stdout and stderr redirected to files and returns
+// what was written to each
+func captureOutput(t *testing.T, fn func()) (string, string) {
+	t.Helper()
+	stdout, stderr := os.Stdout, os.Stderr
+	defer func() { os.Stdout, os.Stderr = stdout, stderr }()
+
+	files := make([]*os.File, 2)
+	for i := range files {
+		f, err := os.CreateTemp(t.TempDir(), "output")
+		require.NoError(t, err)
+		defer f.Close()
+		files[i] = f
+	}
+	os.Stdout, os.Stderr = files[0], files[1]
+	fn()
+
+	var output [2]string
+	for i, f := range files {
+		_, err := f.Seek(0, io.SeekStart)
+		require.NoError(t, err)
+		data, err := io.ReadAll(f)
+		require.NoError(t, err)
+		output[i] = string(data)
+	}
+	return output[0], output[1]
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/go-coders/git_gpt/internal/agent"
+	"github.com/go-coders/git_gpt/internal/config"
+	"github.com/go-coders/git_gpt/pkg/utils"
+)
+
+const prUsage = "usage: ggpt pr [--base=BRANCH] [--output=FILE]"
+
+// runPR prints a pull request description for the current branch, or writes
+// it to a file
+func runPR(cfg *config.Config, logger *utils.LoggerImpl, args []string) int {
+	fs := flag.NewFlagSet("pr", flag.ContinueOnError)
+	base := fs.String("base", "", "Branch the pull request targets (default: the remote's default branch)")
+	output := fs.String("output", "", "Write the description to this file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, prUsage)
+		return exitUsage
+	}
+
+	application, err := newCommandApp(cfg, logger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize application: %v\n", err)
+		return exitCodeFor(err)
+	}
+
+	markdown, err := application.PR(context.Background(), agent.PROptions{Base: *base})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitCodeFor(err)
+	}
+
+	if *output != "" {
+		if err := os.WriteFile(*output, []byte(markdown), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write %s: %v\n", *output, err)
+			return exitError
+		}
+		return exitOK
+	}
+	fmt.Print(markdown)
+	return exitOK
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/go-coders/git_gpt/internal/agent"
+	"github.com/go-coders/git_gpt/internal/config"
+	"github.com/go-coders/git_gpt/pkg/utils"
+)
+
+const releaseUsage = "usage: ggpt release [--bump=major|minor|patch] [--pre=NAME] [--yes] [--dry-run]"
+
+// runRelease tags the next version and prints the tag name
+func runRelease(cfg *config.Config, logger *utils.LoggerImpl, args []string) int {
+	fs := flag.NewFlagSet("release", flag.ContinueOnError)
+	bump := fs.String("bump", "", "Bump major, minor or patch instead of working it out from the commits")
+	pre := fs.String("pre", "", "Release a prerelease with this name, such as rc")
+	yes := fs.Bool("yes", false, "Create the tag without asking")
+	dryRun := fs.Bool("dry-run", false, "Show the next version and tag message without tagging")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, releaseUsage)
+		return exitUsage
+	}
+
+	application, err := newCommandApp(cfg, logger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize application: %v\n", err)
+		return exitCodeFor(err)
+	}
+
+	tag, err := application.Release(context.Background(), agent.ReleaseOptions{
+		Bump:       *bump,
+		Prerelease: *pre,
+		Yes:        *yes,
+		DryRun:     *dryRun,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitCodeFor(err)
+	}
+	fmt.Println(tag)
+	return exitOK
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/go-coders/git_gpt/internal/config"
+	"github.com/go-coders/git_gpt/pkg/utils"
+)
+
+const rewordUsage = "usage: ggpt reword [--yes | --pick=N] [--dry-run] [--edit] [--trailer=T] <rev>"
+
+// runReword writes a new message for an unpushed commit from its diff
+func runReword(cfg *config.Config, logger *utils.LoggerImpl, args []string) int {
+	fs := flag.NewFlagSet("reword", flag.ContinueOnError)
+	message := addMessageFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, rewordUsage)
+		return exitUsage
+	}
+	// Flags may also follow the revision
+	rev := fs.Arg(0)
+	if err := fs.Parse(fs.Args()[1:]); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, rewordUsage)
+		return exitUsage
+	}
+	if !message.validate() {
+		return exitUsage
+	}
+
+	application, err := newCommandApp(cfg, logger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize application: %v\n", err)
+		return exitCodeFor(err)
+	}
+
+	result, err := application.Reword(context.Background(), rev, message.options())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitCodeFor(err)
+	}
+
+	fmt.Println(result)
+	return exitOK
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/go-coders/git_gpt/internal/app"
+)
+
+// runUndo restores the state before the last confirmed modification, or
+// lists the recorded operations with --list
+func runUndo(args []string) int {
+	fs := flag.NewFlagSet("undo", flag.ContinueOnError)
+	list := fs.Bool("list", false, "List recorded operations instead of undoing")
+	count := fs.Int("n", app.DefaultUndoListSize, "Number of operations to list")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() > 0 || *count <= 0 {
+		fmt.Fprintln(os.Stderr, "usage: ggpt undo [--list [-n N]]")
+		return exitUsage
+	}
+
+	ctx := context.Background()
+	gitClient := newGitClient()
+
+	if *list {
+		snapshots, err := app.UndoHistory(ctx, gitClient, *count)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitCodeFor(err)
+		}
+		for i, snapshot := range snapshots {
+			fmt.Printf("%d) %s\n", i+1, app.FormatUndoPoint(snapshot))
+		}
+		return exitOK
+	}
+
+	snapshot, backup, err := app.Undo(ctx, gitClient)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitCodeFor(err)
+	}
+	fmt.Printf("Restored the state before: %s\n", snapshot.Query)
+	fmt.Printf("The replaced state is kept in %s\n", backup)
+	return exitOK
+}
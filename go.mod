@@ -9,6 +9,7 @@ require (
 	github.com/pkoukk/tiktoken-go v0.1.7
 	github.com/sashabaranov/go-openai v1.32.5
 	github.com/stretchr/testify v1.8.2
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -21,5 +22,4 @@ require (
 	github.com/stretchr/objx v0.5.0 // indirect
 	golang.org/x/sys v0.25.0 // indirect
 	golang.org/x/term v0.1.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -15,6 +15,7 @@ type BaseAgent struct {
 	logger  Logger
 	reader  *bufio.Reader
 	prompts *PromptManager
+	secrets *SecretScanner
 }
 
 func NewBaseAgent(config AgentConfig) (*BaseAgent, error) {
@@ -27,6 +28,11 @@ func NewBaseAgent(config AgentConfig) (*BaseAgent, error) {
 		return nil, fmt.Errorf("failed to initialize prompt manager: %w", err)
 	}
 
+	secrets := config.Secrets
+	if secrets == nil {
+		secrets = DefaultSecretScanner()
+	}
+
 	return &BaseAgent{
 		git:     config.Git,
 		llm:     config.LLM,
@@ -34,6 +40,7 @@ func NewBaseAgent(config AgentConfig) (*BaseAgent, error) {
 		logger:  config.Logger,
 		reader:  bufio.NewReader(config.Reader),
 		prompts: prompts,
+		secrets: secrets,
 	}, nil
 }
 
@@ -75,8 +82,40 @@ func (a *BaseAgent) executeCommands(ctx context.Context, commands []Command) ([]
 	return results, nil
 }
 
+// recordUndoPoint saves the repository state before query modifies it. A
+// failure only costs the undo point, so the commands still run.
+func (a *BaseAgent) recordUndoPoint(ctx context.Context, query string) {
+	if _, err := a.git.CreateSnapshot(ctx, query); err != nil {
+		a.display.ShowWarning(fmt.Sprintf("Could not record an undo point: %s", err))
+	}
+}
+
+// redactOutput removes secrets from git output before it goes into a prompt
+// and warns when something was redacted
+func (a *BaseAgent) redactOutput(output string) string {
+	redacted, findings := a.secrets.Redact(output)
+	if len(findings) > 0 {
+		a.display.ShowWarning(fmt.Sprintf("Redacted %d possible secret(s) (%s) from git output before sending it to the model",
+			len(findings), strings.Join(findingRules(findings), ", ")))
+	}
+	return redacted
+}
+
+// findingRules lists the distinct rules of findings in order
+func findingRules(findings []SecretFinding) []string {
+	var rules []string
+	seen := make(map[string]bool)
+	for _, f := range findings {
+		if !seen[f.Rule] {
+			seen[f.Rule] = true
+			rules = append(rules, f.Rule)
+		}
+	}
+	return rules
+}
+
 func (a *BaseAgent) promptForConfirmation(prompt string) (bool, error) {
-	fmt.Print(prompt)
+	a.display.ShowQuestion(prompt)
 	input, err := a.reader.ReadString('\n')
 	if err != nil {
 		return false, fmt.Errorf("failed to read input: %w", err)
@@ -93,7 +132,7 @@ func (a *BaseAgent) handleCommandResults(ctx context.Context, results []CommandR
 		cmdStr := fmt.Sprintf("git %s", strings.Join(result.Command.Args, " "))
 		a.display.ShowSuccess(fmt.Sprintf("Executed: %s", cmdStr))
 		if result.Output != "" {
-			fmt.Println(result.Output)
+			a.display.ShowOutput(result.Output)
 		}
 	}
 	return nil
@@ -103,5 +142,15 @@ func cleanJSONResponse(response string) string {
 	response = strings.TrimPrefix(response, "```json")
 	response = strings.TrimPrefix(response, "```")
 	response = strings.TrimSuffix(response, "```")
-	return strings.TrimSpace(response)
+	response = strings.TrimSpace(response)
+
+	// Drop any prose the model wrapped around the object
+	if !strings.HasPrefix(response, "{") {
+		start := strings.Index(response, "{")
+		end := strings.LastIndex(response, "}")
+		if start >= 0 && end > start {
+			return response[start : end+1]
+		}
+	}
+	return response
 }
@@ -31,6 +31,9 @@ func (s *BaseAgentTestSuite) SetupTest() {
 	s.logger = new(mocks.Logger)
 	s.input = new(bytes.Buffer)
 
+	// Prompts and command output carry no assertions of their own
+	s.display.On("ShowQuestion", mock.Anything).Return().Maybe()
+	s.display.On("ShowOutput", mock.Anything).Return().Maybe()
 }
 
 func (s *BaseAgentTestSuite) newTestAgent() (*BaseAgent, error) {
@@ -238,9 +241,10 @@ func (s *BaseAgentTestSuite) TestHandleCommandResults() {
 			agent.display = display
 
 			if !tc.expectError {
-				// For successful case, only expect ShowSuccess
+				// For successful case, expect ShowSuccess and the output
 				display.On("ShowSuccess", fmt.Sprintf("Executed: git %s",
 					strings.Join(tc.results[0].Command.Args, " "))).Return()
+				display.On("ShowOutput", tc.results[0].Output).Return()
 			} else {
 				// For error case, only expect ShowError
 				display.On("ShowError", mock.Anything).Return()
@@ -283,6 +287,11 @@ func (s *BaseAgentTestSuite) TestCleanJSONResponse() {
 			input:    "  {\"type\": \"test\"}  ",
 			expected: `{"type": "test"}`,
 		},
+		{
+			name:     "json with surrounding text",
+			input:    "Here is the response:\n```json\n{\"type\": \"test\"}\n```\nLet me know if you need more.",
+			expected: `{"type": "test"}`,
+		},
 	}
 
 	for _, tc := range testCases {
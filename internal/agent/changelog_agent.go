@@ -0,0 +1,185 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-coders/git_gpt/internal/changelog"
+)
+
+// ChangelogAgent writes Keep a Changelog sections from the conventional
+// commits of a revision range
+type ChangelogAgent struct {
+	*BaseAgent
+	// now dates a release named with ChangelogOptions.Version
+	now func() time.Time
+}
+
+func NewChangelogAgent(config AgentConfig) (*ChangelogAgent, error) {
+	base, err := NewBaseAgent(config)
+	if err != nil {
+		return nil, err
+	}
+	return &ChangelogAgent{BaseAgent: base, now: time.Now}, nil
+}
+
+// Changelog renders the commits of opts.Range as a changelog section. Unless
+// opts.NoLLM is set the model rewords the entries for users; when that fails
+// the entries are kept as parsed and a warning is shown.
+func (a *ChangelogAgent) Changelog(ctx context.Context, opts ChangelogOptions) (string, error) {
+	release, err := collectChangelog(ctx, a.git, opts, a.now)
+	if err != nil {
+		return "", err
+	}
+	a.logger.Debug("Changelog range %s..%s", release.From, release.To)
+
+	if !opts.NoLLM && len(release.Sections) > 0 {
+		if err := a.reword(ctx, release.Sections); err != nil {
+			a.logger.Debug("Rewording the changelog failed: %v", err)
+			a.display.ShowWarning(fmt.Sprintf("Could not reword the changelog, using the commit messages: %v", err))
+		}
+	}
+	return release.Render(), nil
+}
+
+// OfflineChangelog renders the section of opts from the commit messages
+// alone, so it needs neither the model nor an API key
+func OfflineChangelog(ctx context.Context, git GitExecutor, opts ChangelogOptions) (string, error) {
+	release, err := collectChangelog(ctx, git, opts, time.Now)
+	if err != nil {
+		return "", err
+	}
+	return release.Render(), nil
+}
+
+// collectChangelog reads the release of opts.Range and names it after
+// opts.Version, dated today unless opts.Date is set
+func collectChangelog(ctx context.Context, git GitExecutor, opts ChangelogOptions, now func() time.Time) (*changelog.Release, error) {
+	release, err := changelog.Collect(ctx, git, opts.Range)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Version != "" {
+		release.Version = opts.Version
+		release.Date = now().Format("2006-01-02")
+	}
+	if opts.Date != "" {
+		release.Date = opts.Date
+	}
+	return release, nil
+}
+
+// reword asks the model to rewrite the entries of sections for users. Entries
+// the model leaves out keep their commit text.
+func (a *ChangelogAgent) reword(ctx context.Context, sections []changelog.Section) error {
+	prompt, err := a.prompts.GetChangelogPrompt(a.changelogItems(sections))
+	if err != nil {
+		return fmt.Errorf("failed to generate changelog prompt: %w", err)
+	}
+
+	a.display.StartSpinner("Writing the changelog...")
+	response, err := a.llm.Chat(ctx, prompt)
+	a.display.StopSpinner()
+	if err != nil {
+		return fmt.Errorf("failed to get LLM response: %w", err)
+	}
+
+	cleanedResponse := cleanJSONResponse(response)
+	a.logger.Debug("Cleaned LLM response: %s", cleanedResponse)
+
+	var reworded ChangelogResponse
+	if err := json.Unmarshal([]byte(cleanedResponse), &reworded); err != nil {
+		return fmt.Errorf("failed to parse the changelog entries: %w", err)
+	}
+	if len(reworded.Entries) == 0 {
+		return fmt.Errorf("the model returned no entries")
+	}
+
+	byID := make(map[int]ChangelogEntry, len(reworded.Entries))
+	for _, entry := range reworded.Entries {
+		byID[entry.ID] = entry
+	}
+	id := 0
+	for i := range sections {
+		for j := range sections[i].Entries {
+			id++
+			entry := &sections[i].Entries[j]
+			if text := cleanEntryText(byID[id].Text); text != "" {
+				entry.Text = text
+			}
+			if note := cleanEntryText(byID[id].Note); note != "" && entry.Note != "" {
+				entry.Note = note
+			}
+		}
+	}
+	return nil
+}
+
+// changelogItems numbers the entries of sections for a prompt, with secrets
+// in their text and commit bodies redacted
+func (a *BaseAgent) changelogItems(sections []changelog.Section) []ChangelogItem {
+	var items []ChangelogItem
+	var found []string
+	for _, section := range sections {
+		for _, entry := range section.Entries {
+			text, textFindings := a.secrets.Redact(entry.Text)
+			body, bodyFindings := a.secrets.Redact(entry.Change.Body)
+			for _, f := range append(textFindings, bodyFindings...) {
+				found = append(found, fmt.Sprintf("commit %.7s (%s)", entry.Change.Hash, f.Rule))
+			}
+			items = append(items, ChangelogItem{
+				ID:      len(items) + 1,
+				Section: section.Title,
+				Scope:   entry.Scope,
+				Text:    text,
+				Note:    entry.Note,
+				Body:    body,
+			})
+		}
+	}
+	if len(found) > 0 {
+		a.display.ShowWarning(fmt.Sprintf("Possible secrets in the commits were redacted before sending: %s",
+			strings.Join(uniqueStrings(found), ", ")))
+	}
+	return items
+}
+
+// cleanEntryText drops the list marker the model sometimes adds
+func cleanEntryText(text string) string {
+	return strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(text), "- "))
+}
+
+// HandleChangelog shows the changelog section of opts in the REPL, or adds it
+// to the top of output when that is set
+func (a *ChangelogAgent) HandleChangelog(ctx context.Context, opts ChangelogOptions, output string) error {
+	section, err := a.Changelog(ctx, opts)
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		a.display.ShowSection("Changelog", section, map[string]string{"icon": "📜"})
+		return nil
+	}
+	if err := PrependChangelog(output, section); err != nil {
+		return err
+	}
+	a.display.ShowSuccess(fmt.Sprintf("Added the changelog section to %s", output))
+	return nil
+}
+
+// PrependChangelog adds section above the newest release in the changelog
+// file at path, creating the file when it does not exist
+func PrependChangelog(path, section string) error {
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, []byte(changelog.Prepend(string(existing), section)), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package agent
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-coders/git_gpt/internal/common"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type ChangelogAgentTestSuite struct {
+	BaseAgentTestSuite
+	agent *ChangelogAgent
+}
+
+func (s *ChangelogAgentTestSuite) SetupTest() {
+	s.BaseAgentTestSuite.SetupTest()
+
+	s.display.On("StartSpinner", mock.Anything).Return().Maybe()
+	s.display.On("StopSpinner").Return().Maybe()
+	s.logger.On("Debug", mock.Anything, mock.Anything).Return()
+	s.logger.On("Debug", mock.Anything, mock.Anything, mock.Anything).Return()
+
+	agent, err := NewChangelogAgent(AgentConfig{
+		Git:     s.git,
+		LLM:     s.llm,
+		Display: s.display,
+		Logger:  s.logger,
+		Reader:  s.input,
+	})
+	s.Require().NoError(err)
+	agent.now = func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) }
+	s.agent = agent
+}
+
+func TestChangelogAgent(t *testing.T) {
+	suite.Run(t, new(ChangelogAgentTestSuite))
+}
+
+// expectUnreleased sets up three commits since v1.0.0
+func (s *ChangelogAgentTestSuite) expectUnreleased() {
+	s.git.On("LastTag", s.ctx, "HEAD", "").Return("v1.0.0", nil).Once()
+	s.git.On("CommitLog", s.ctx, "v1.0.0", "HEAD").Return([]common.Commit{
+		{Hash: "3333333", Subject: "feat(api)!: drop v1", Body: "BREAKING CHANGE: call /v2"},
+		{Hash: "2222222", Subject: "fix: keep sessions", Body: "Refresh no longer logs users out."},
+		{Hash: "1111111", Subject: "feat(auth): retry logins"},
+	}, nil).Once()
+	s.git.On("TagDate", s.ctx, "HEAD").Return("", errors.New("HEAD is not a tag")).Once()
+}
+
+func (s *ChangelogAgentTestSuite) TestChangelog_Reworded() {
+	s.expectUnreleased()
+	s.llm.On("Chat", s.ctx, mock.MatchedBy(func(prompt string) bool {
+		return s.Contains(prompt, "[1] Breaking Changes (api): Drop v1\nBreaking change: Call /v2\n") &&
+			s.Contains(prompt, "[2] Added (auth): Retry logins\n") &&
+			s.Contains(prompt, "[3] Fixed: Keep sessions\nCommit body:\nRefresh no longer logs users out.\n")
+	})).Return(`{"entries": [
+		{"id": 1, "text": "The v1 API was removed.", "note": "Move clients to the /v2 endpoints."},
+		{"id": 2, "text": "- Failed logins are retried."},
+		{"id": 3, "text": "", "note": "ignored for entries that are not breaking"}
+	]}`, nil).Once()
+
+	section, err := s.agent.Changelog(s.ctx, ChangelogOptions{})
+	s.Require().NoError(err)
+	s.Assert().Equal("## [Unreleased]\n\n"+
+		"### Breaking Changes\n\n- **api:** The v1 API was removed.\n  Move clients to the /v2 endpoints.\n\n"+
+		"### Added\n\n- **auth:** Failed logins are retried.\n\n"+
+		"### Fixed\n\n- Keep sessions\n", section)
+	s.git.AssertExpectations(s.T())
+}
+
+func (s *ChangelogAgentTestSuite) TestChangelog_FallsBackToCommits() {
+	s.expectUnreleased()
+	s.llm.On("Chat", s.ctx, mock.Anything).Return("not json", nil).Once()
+	s.display.On("ShowWarning", mock.MatchedBy(func(message string) bool {
+		return s.Contains(message, "Could not reword the changelog, using the commit messages")
+	})).Return().Once()
+
+	section, err := s.agent.Changelog(s.ctx, ChangelogOptions{Version: "1.1.0"})
+	s.Require().NoError(err)
+	s.Assert().Equal("## [1.1.0] - 2024-05-01\n\n"+
+		"### Breaking Changes\n\n- **api:** Drop v1\n  Call /v2\n\n"+
+		"### Added\n\n- **auth:** Retry logins\n\n"+
+		"### Fixed\n\n- Keep sessions\n", section)
+	s.display.AssertExpectations(s.T())
+}
+
+func (s *ChangelogAgentTestSuite) TestChangelog_NoLLM() {
+	s.git.On("LastTag", s.ctx, "v1.1.0", "").Return("v1.1.0", nil).Once()
+	s.git.On("LastTag", s.ctx, "v1.1.0^", "").Return("v1.0.0", nil).Once()
+	s.git.On("CommitLog", s.ctx, "v1.0.0", "v1.1.0").Return([]common.Commit{
+		{Hash: "1111111", Subject: "fix(export): quote commas"},
+	}, nil).Once()
+	s.git.On("TagDate", s.ctx, "v1.1.0").Return("2024-04-02", nil).Once()
+
+	section, err := s.agent.Changelog(s.ctx, ChangelogOptions{Range: "..v1.1.0", NoLLM: true})
+	s.Require().NoError(err)
+	s.Assert().Equal("## [1.1.0] - 2024-04-02\n\n### Fixed\n\n- **export:** Quote commas\n", section)
+	s.llm.AssertNotCalled(s.T(), "Chat", mock.Anything, mock.Anything)
+}
+
+func (s *ChangelogAgentTestSuite) TestHandleChangelog_Prepends() {
+	s.git.On("CommitLog", s.ctx, "v1.0.0", "HEAD").Return([]common.Commit{
+		{Hash: "1111111", Subject: "feat: export to CSV"},
+	}, nil).Once()
+	s.git.On("TagDate", s.ctx, "HEAD").Return("", errors.New("HEAD is not a tag")).Once()
+
+	output := filepath.Join(s.T().TempDir(), "CHANGELOG.md")
+	s.Require().NoError(os.WriteFile(output, []byte("# Changelog\n\n## [1.0.0] - 2024-01-01\n\n- First\n"), 0644))
+	s.display.On("ShowSuccess", "Added the changelog section to "+output).Return().Once()
+
+	s.Require().NoError(s.agent.HandleChangelog(s.ctx, ChangelogOptions{Range: "v1.0.0", NoLLM: true}, output))
+	data, err := os.ReadFile(output)
+	s.Require().NoError(err)
+	s.Assert().Equal("# Changelog\n\n## [Unreleased]\n\n### Added\n\n- Export to CSV\n\n## [1.0.0] - 2024-01-01\n\n- First\n", string(data))
+}
@@ -3,14 +3,26 @@ package agent
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 
+	"github.com/go-coders/git_gpt/internal/config"
+	"github.com/go-coders/git_gpt/internal/llm"
 	"github.com/go-coders/git_gpt/pkg/apierrors"
 )
 
+// defaultMaxSteps applies when AgentConfig.MaxSteps is not set, as it does
+// when the config file leaves agent.max_steps out
+const defaultMaxSteps = config.DefaultMaxSteps
+
 type ChatAgent struct {
 	*BaseAgent
+	maxSteps int
+	policy   *Policy
+	// textOnly is set once tool calling failed, so later turns go straight
+	// to JSON text
+	textOnly bool
 }
 
 func NewChatAgent(config AgentConfig) (*ChatAgent, error) {
@@ -19,8 +31,20 @@ func NewChatAgent(config AgentConfig) (*ChatAgent, error) {
 		return nil, err
 	}
 
+	maxSteps := config.MaxSteps
+	if maxSteps <= 0 {
+		maxSteps = defaultMaxSteps
+	}
+
+	policy := config.Policy
+	if policy == nil {
+		policy = DefaultPolicy()
+	}
+
 	agent := &ChatAgent{
 		BaseAgent: base,
+		maxSteps:  maxSteps,
+		policy:    policy,
 	}
 
 	if err := agent.ResetChat(); err != nil {
@@ -43,13 +67,129 @@ func (a *ChatAgent) Chat(ctx context.Context, query string) error {
 	return a.handleResponse(ctx, query, response)
 }
 
+// Ask answers a single query without prompting and returns the structured
+// result. Modification commands are only executed when opts.AllowModify is set.
+func (a *ChatAgent) Ask(ctx context.Context, query string, opts AskOptions) (*AskResult, error) {
+	if !a.git.IsGitRepository(ctx) {
+		return nil, apierrors.NewNotGitRepoError()
+	}
+
+	response, err := a.getCommandResponse(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &AskResult{
+		Query: query,
+		Type:  response.Type,
+	}
+
+	execute := func(ctx context.Context, query string, step int, response Response) ([]CommandResult, bool, error) {
+		result.Commands = append(result.Commands, response.Commands...)
+		if result.CommandType != CommandTypeModify {
+			result.CommandType = response.CommandType
+		}
+
+		switch response.CommandType {
+		case CommandTypeQuery:
+			results := a.runQueryCommands(ctx, response.Commands)
+			result.Executed = true
+			return results, true, nil
+
+		case CommandTypeModify:
+			if !opts.AllowModify {
+				result.Summary = response.Reason
+				return nil, false, apierrors.NewModifyNotAllowedError()
+			}
+
+			a.recordUndoPoint(ctx, query)
+			results, err := a.executeCommands(ctx, response.Commands)
+			result.Executed = true
+			if err != nil {
+				return results, false, fmt.Errorf("command execution failed: %w", err)
+			}
+			return results, true, nil
+
+		default:
+			return nil, false, fmt.Errorf("unknown command type: %s", response.CommandType)
+		}
+	}
+
+	final, observed, err := a.runSteps(ctx, query, response, execute)
+	result.Results = observed
+	if err != nil {
+		return result, err
+	}
+
+	switch final.Type {
+	case ResponseTypeAnswer:
+		result.Summary = final.Content
+	case ResponseTypeDone:
+		if result.Summary, err = a.generateSummary(ctx, query, observed); err != nil {
+			return result, err
+		}
+	default:
+		return result, fmt.Errorf("unknown response type: %s", final.Type)
+	}
+	return result, nil
+}
+
 func (a *ChatAgent) getCommandResponse(ctx context.Context, query string) (Response, error) {
-	prompt, err := a.prompts.GetGenerateCommandsPrompt(query)
+	return a.requestResponse(ctx, "Analyzing query...", func(tools bool) (string, error) {
+		prompt, err := a.prompts.GetGenerateCommandsPrompt(query, tools)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate prompt: %w", err)
+		}
+		return prompt, nil
+	})
+}
+
+// getNextStep shows the model the output of the last step and asks it for
+// more commands or for the go-ahead to answer
+func (a *ChatAgent) getNextStep(ctx context.Context, query string, step int, results []CommandResult) (Response, error) {
+	output := a.redactOutput(formatResults(results))
+	return a.requestResponse(ctx, "Reviewing results...", func(tools bool) (string, error) {
+		prompt, err := a.prompts.GetNextStepPrompt(query, step, a.maxSteps, output, tools)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate next step prompt: %w", err)
+		}
+		return prompt, nil
+	})
+}
+
+// requestResponse asks the model for its next response, with the prompt that
+// render writes for tool calls or for JSON text. Tool calls are used when the
+// provider supports them, otherwise the JSON is parsed from the text. When the
+// server rejects the tools or the model answers without calling one, the
+// request is repeated as text and later turns stay with text.
+func (a *ChatAgent) requestResponse(ctx context.Context, spinnerMessage string, render func(tools bool) (string, error)) (Response, error) {
+	if a.llm.SupportsTools() && !a.textOnly {
+		prompt, err := render(true)
+		if err != nil {
+			return Response{}, err
+		}
+
+		a.display.StartSpinner(spinnerMessage)
+		call, err := a.llm.ChatWithTools(ctx, prompt, commandTools)
+		a.display.StopSpinner()
+
+		switch {
+		case err == nil:
+			return responseFromToolCall(call)
+		case errors.Is(err, llm.ErrToolsNotSupported) || errors.Is(err, llm.ErrNoToolCall):
+			a.logger.Debug("Tool calling failed, asking for JSON text instead: %v", err)
+			a.textOnly = true
+		default:
+			return Response{}, fmt.Errorf("LLM error: %w", err)
+		}
+	}
+
+	prompt, err := render(false)
 	if err != nil {
-		return Response{}, fmt.Errorf("failed to generate prompt: %w", err)
+		return Response{}, err
 	}
 
-	a.display.StartSpinner("Analyzing query...")
+	a.display.StartSpinner(spinnerMessage)
 	llmResponse, err := a.llm.Chat(ctx, prompt)
 	a.display.StopSpinner()
 
@@ -65,71 +205,208 @@ func (a *ChatAgent) getCommandResponse(ctx context.Context, query string) (Respo
 	return response, nil
 }
 
+// stepFunc executes the commands of one step. It returns false when the loop
+// should stop without an answer, for example when the user declines.
+type stepFunc func(ctx context.Context, query string, step int, response Response) ([]CommandResult, bool, error)
+
+// runSteps drives the plan, execute, observe loop. Each executed step is fed
+// back to the model until it answers, reports that it is done, or the step
+// limit is reached. It returns the final response and every observed result;
+// an empty response type means the loop was stopped.
+func (a *ChatAgent) runSteps(ctx context.Context, query string, response Response, execute stepFunc) (Response, []CommandResult, error) {
+	var observed []CommandResult
+	for step := 1; ; step++ {
+		if response.Type != ResponseTypeExecute {
+			return response, observed, nil
+		}
+
+		var denied []CommandResult
+		response, denied = a.applyPolicy(response)
+
+		results := denied
+		if len(denied) == 0 {
+			var proceed bool
+			var err error
+			results, proceed, err = execute(ctx, query, step, response)
+			if err != nil || !proceed {
+				return Response{}, append(observed, results...), err
+			}
+		}
+		observed = append(observed, results...)
+
+		if step >= a.maxSteps {
+			a.display.ShowWarning(fmt.Sprintf("Reached the limit of %d steps, answering with the results so far", a.maxSteps))
+			return Response{Type: ResponseTypeDone}, observed, nil
+		}
+
+		var err error
+		response, err = a.getNextStep(ctx, query, step, results)
+		if err != nil {
+			return Response{}, observed, err
+		}
+	}
+}
+
+// applyPolicy classifies the commands with the local policy and replaces the
+// command type chosen by the model: a step only runs without confirmation
+// when every command is read-only or allowed. When a command is denied the
+// step is not run and the denied commands are returned as failed results.
+func (a *ChatAgent) applyPolicy(response Response) (Response, []CommandResult) {
+	commandType := CommandTypeQuery
+	commands := make([]Command, len(response.Commands))
+	var denied []CommandResult
+
+	for i, cmd := range response.Commands {
+		decision := a.policy.Evaluate(cmd.Args)
+		cmd.Risk = decision.Risk
+		cmd.Type = CommandTypeQuery
+		if decision.Risk != RiskReadOnly && !decision.Allowed {
+			cmd.Type = CommandTypeModify
+			commandType = CommandTypeModify
+		}
+		commands[i] = cmd
+
+		if decision.Denied {
+			err := fmt.Errorf("%w (rule %q)", ErrCommandDenied, decision.Rule)
+			a.display.ShowWarning(fmt.Sprintf("Blocked: git %s: %s", strings.Join(cmd.Args, " "), err))
+			denied = append(denied, CommandResult{Command: cmd, Error: err})
+		}
+	}
+
+	response.Commands = commands
+	response.CommandType = commandType
+	return response, denied
+}
+
 func (a *ChatAgent) handleResponse(ctx context.Context, query string, response Response) error {
-	switch response.Type {
-	case "answer":
-		a.display.ShowSuccess(response.Content)
+	final, observed, err := a.runSteps(ctx, query, response, a.executeStep)
+	if err != nil {
+		return err
+	}
+
+	switch final.Type {
+	case ResponseTypeAnswer:
+		a.display.ShowSuccess(final.Content)
 		return nil
 
-	case "execute":
-		return a.handleExecuteResponse(ctx, query, response)
+	case ResponseTypeDone:
+		return a.summarizeResults(ctx, query, observed)
+
+	case "":
+		return nil
 
 	default:
-		return fmt.Errorf("unknown response type: %s", response.Type)
+		return fmt.Errorf("unknown response type: %s", final.Type)
 	}
 }
 
-func (a *ChatAgent) handleExecuteResponse(ctx context.Context, query string, response Response) error {
+func (a *ChatAgent) executeStep(ctx context.Context, query string, step int, response Response) ([]CommandResult, bool, error) {
+	a.showStep(step, response)
+
 	switch response.CommandType {
 	case CommandTypeQuery:
-		return a.handleQueryCommands(ctx, query, response.Commands)
+		return a.handleQueryCommands(ctx, response.Commands), true, nil
 
 	case CommandTypeModify:
-		return a.handleModificationCommands(ctx, response.Commands)
+		return a.handleModificationCommands(ctx, query, response.Commands)
 
 	default:
-		return fmt.Errorf("unknown command type: %s", response.CommandType)
+		return nil, false, fmt.Errorf("unknown command type: %s", response.CommandType)
 	}
 }
 
-func (a *ChatAgent) handleQueryCommands(ctx context.Context, query string, commands []Command) error {
-	results, err := a.executeCommands(ctx, commands)
-	if err != nil {
-		return fmt.Errorf("command execution failed: %w", err)
+func (a *ChatAgent) showStep(step int, response Response) {
+	message := fmt.Sprintf("Step %d", step)
+	if response.Reason != "" {
+		message = fmt.Sprintf("%s: %s", message, response.Reason)
 	}
-
-	return a.summarizeResults(ctx, query, results)
+	a.display.ShowInfo(message)
 }
 
-func (a *ChatAgent) summarizeResults(ctx context.Context, query string, results []CommandResult) error {
-	var builder strings.Builder
+func (a *ChatAgent) handleQueryCommands(ctx context.Context, commands []Command) []CommandResult {
+	for _, cmd := range commands {
+		a.display.ShowCommand(fmt.Sprintf("git %s", strings.Join(cmd.Args, " ")))
+	}
+
+	results := a.runQueryCommands(ctx, commands)
 	for _, result := range results {
-		builder.WriteString(fmt.Sprintf("Command: git %s\n", strings.Join(result.Command.Args, " ")))
-		builder.WriteString(fmt.Sprintf("Output:\n%s\n\n", result.Output))
+		if result.Error != nil {
+			a.display.ShowWarning(fmt.Sprintf("Command failed: %s", result.Error))
+		}
+	}
+	return results
+}
+
+// runQueryCommands executes read-only commands. A failing command does not
+// abort the step; its error is kept in the results so the model can react.
+func (a *ChatAgent) runQueryCommands(ctx context.Context, commands []Command) []CommandResult {
+	results := make([]CommandResult, 0, len(commands))
+	for _, cmd := range commands {
+		output, err := a.git.Execute(ctx, cmd.Args...)
+		results = append(results, CommandResult{
+			Command: cmd,
+			Output:  output,
+			Error:   err,
+		})
 	}
+	return results
+}
 
-	prompt, err := a.prompts.GetSummarizeResultsPrompt(query, builder.String())
+func (a *ChatAgent) summarizeResults(ctx context.Context, query string, results []CommandResult) error {
+	prompt, err := a.prompts.GetSummarizeResultsPrompt(query, a.redactOutput(formatResults(results)))
 	if err != nil {
 		return fmt.Errorf("failed to generate summary prompt: %w", err)
 	}
 
+	// The summary is free text, so it is streamed; the spinner stops on the first token
 	a.display.StartSpinner("Analyzing results...")
-	summary, err := a.llm.Chat(ctx, prompt)
+	_, err = a.llm.ChatStream(ctx, prompt, a.display.ShowStreamChunk)
 	a.display.StopSpinner()
+	a.display.FinishStream()
 
 	if err != nil {
 		return fmt.Errorf("failed to generate summary: %w", err)
 	}
-
-	a.display.ShowSuccess(summary)
 	return nil
 }
 
-func (a *ChatAgent) handleModificationCommands(ctx context.Context, commands []Command) error {
+func (a *ChatAgent) generateSummary(ctx context.Context, query string, results []CommandResult) (string, error) {
+	prompt, err := a.prompts.GetSummarizeResultsPrompt(query, a.redactOutput(formatResults(results)))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate summary prompt: %w", err)
+	}
+
+	a.display.StartSpinner("Analyzing results...")
+	summary, err := a.llm.Chat(ctx, prompt)
+	a.display.StopSpinner()
+
+	if err != nil {
+		return "", fmt.Errorf("failed to generate summary: %w", err)
+	}
+
+	return summary, nil
+}
+
+func formatResults(results []CommandResult) string {
+	var builder strings.Builder
+	for _, result := range results {
+		builder.WriteString(fmt.Sprintf("Command: git %s\n", strings.Join(result.Command.Args, " ")))
+		builder.WriteString(fmt.Sprintf("Output:\n%s\n", result.Output))
+		if result.Error != nil {
+			builder.WriteString(fmt.Sprintf("Error: %s\n", result.Error))
+		}
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+// handleModificationCommands asks for confirmation and runs the commands.
+// It reports false when the user declined.
+func (a *ChatAgent) handleModificationCommands(ctx context.Context, query string, commands []Command) ([]CommandResult, bool, error) {
 	a.display.ShowWarning("The following commands will modify the repository:")
 
 	for i, cmd := range commands {
-		fmt.Println()
+		a.display.ShowOutput("")
 		cmdStr := fmt.Sprintf("git %s", strings.Join(cmd.Args, " "))
 		a.display.ShowInfo(fmt.Sprintf("Command %d: %s", i+1, cmdStr))
 
@@ -139,24 +416,96 @@ func (a *ChatAgent) handleModificationCommands(ctx context.Context, commands []C
 		if cmd.Impact != "" {
 			a.display.ShowWarning(fmt.Sprintf("Impact: %s", cmd.Impact))
 		}
+		if cmd.Risk != "" && cmd.Risk != RiskMutating {
+			a.display.ShowWarning(fmt.Sprintf("Risk: %s", cmd.Risk))
+		}
 	}
 
-	confirmed, err := a.promptForConfirmation("\nDo you want to execute these commands? (y/n): ")
+	confirmed, err := a.confirmModification(ctx, commands)
 	if err != nil {
-		return err
+		return nil, false, err
 	}
 
 	if !confirmed {
 		a.display.ShowInfo("Operation cancelled")
-		return nil
+		return nil, false, nil
 	}
 
+	a.recordUndoPoint(ctx, query)
 	results, err := a.executeCommands(ctx, commands)
 	if err != nil {
-		return err
+		return results, false, err
+	}
+
+	return results, true, a.handleCommandResults(ctx, results)
+}
+
+// confirmModification asks before commands run. Answering "p" first runs
+// them in a throwaway copy of the repository and shows the outcome.
+func (a *ChatAgent) confirmModification(ctx context.Context, commands []Command) (bool, error) {
+	a.display.ShowQuestion("\nDo you want to execute these commands? (y/n, p to preview): ")
+	input, err := a.reader.ReadString('\n')
+	if err != nil {
+		return false, fmt.Errorf("failed to read input: %w", err)
+	}
+	if strings.TrimSpace(input) != "p" {
+		return strings.TrimSpace(input) == "y", nil
 	}
 
-	return a.handleCommandResults(ctx, results)
+	a.showPreview(ctx, commands)
+	return a.promptForConfirmation("\nDo you want to execute these commands? (y/n): ")
+}
+
+// showPreview runs commands in a temporary copy and shows the outcome. The
+// commands are previewed up to the first one that could reach outside the copy.
+func (a *ChatAgent) showPreview(ctx context.Context, commands []Command) {
+	var args [][]string
+	var refused error
+	for _, cmd := range commands {
+		if err := checkPreviewable(cmd.Args); err != nil {
+			refused = fmt.Errorf("git %s: %w", strings.Join(cmd.Args, " "), err)
+			break
+		}
+		args = append(args, cmd.Args)
+	}
+	if len(args) == 0 {
+		a.display.ShowWarning(fmt.Sprintf("Cannot preview %s", refused))
+		return
+	}
+
+	a.display.StartSpinner("Previewing in a temporary copy...")
+	preview, err := a.git.Preview(ctx, args)
+	a.display.StopSpinner()
+	if err != nil {
+		a.display.ShowWarning(fmt.Sprintf("Preview failed: %s", err))
+		return
+	}
+
+	a.display.ShowSection("Preview", "", map[string]string{"icon": "🔍"})
+	for _, step := range preview.Steps {
+		cmdStr := fmt.Sprintf("git %s", strings.Join(step.Args, " "))
+		if step.Error != "" {
+			a.display.ShowError(fmt.Sprintf("Would fail: %s: %s", cmdStr, step.Error))
+			continue
+		}
+		a.display.ShowSuccess(fmt.Sprintf("Would run: %s", cmdStr))
+	}
+	skipped := len(commands) - len(preview.Steps)
+	if refused != nil && len(preview.Steps) == len(args) {
+		a.display.ShowWarning(fmt.Sprintf("Not previewed: %s", refused))
+		skipped--
+	}
+	if skipped > 0 {
+		a.display.ShowWarning(fmt.Sprintf("%d later command(s) not run", skipped))
+	}
+
+	a.display.ShowSection("Resulting Log", preview.Log, nil)
+	a.display.ShowSection("Resulting Status", preview.Status, nil)
+	diffStat := preview.DiffStat
+	if diffStat == "" {
+		diffStat = "No changes to the files in your working tree"
+	}
+	a.display.ShowSection("Changes Compared With Your Repository", diffStat, nil)
 }
 
 func (a *ChatAgent) ResetChat() error {
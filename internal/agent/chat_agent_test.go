@@ -2,8 +2,15 @@ package agent
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 
+	"github.com/go-coders/git_gpt/internal/agent/mocks"
+	"github.com/go-coders/git_gpt/internal/common"
+	"github.com/go-coders/git_gpt/internal/llm"
+	"github.com/go-coders/git_gpt/pkg/apierrors"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
@@ -18,6 +25,7 @@ func (s *ChatAgentTestSuite) SetupTest() {
 
 	s.llm.On("SetSystemMessage", mock.Anything).Return()
 	s.llm.On("ClearHistory").Return()
+	s.llm.On("SupportsTools").Return(false).Maybe()
 
 	config := AgentConfig{
 		Git:     s.git,
@@ -32,6 +40,24 @@ func (s *ChatAgentTestSuite) SetupTest() {
 	s.agent = agent
 }
 
+// withTools rebuilds the agent on an LLM client with native tool calling
+func (s *ChatAgentTestSuite) withTools() {
+	s.llm = new(mocks.LLMClient)
+	s.llm.On("SetSystemMessage", mock.Anything).Return()
+	s.llm.On("ClearHistory").Return()
+	s.llm.On("SupportsTools").Return(true)
+
+	agent, err := NewChatAgent(AgentConfig{
+		Git:     s.git,
+		LLM:     s.llm,
+		Display: s.display,
+		Logger:  s.logger,
+		Reader:  s.input,
+	})
+	s.Require().NoError(err)
+	s.agent = agent
+}
+
 func TestChatAgent(t *testing.T) {
 	suite.Run(t, new(ChatAgentTestSuite))
 }
@@ -69,18 +95,32 @@ func (s *ChatAgentTestSuite) TestChat_QueryCommand() {
 	s.git.On("Execute", s.ctx, "log", "-n", "1").
 		Return("commit abc123\nAuthor: Test\nDate: 2024\n\nTest commit", nil)
 
-	// Mock LLM summary
+	// The model has what it needs after the first step
 	s.llm.On("Chat", s.ctx, mock.Anything).
+		Return(`{"type": "done", "reason": "The log shows the last commit"}`, nil).Once()
+
+	// Mock streamed LLM summary
+	s.llm.On("ChatStream", s.ctx, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			onToken := args.Get(2).(func(string))
+			onToken("Last commit was ")
+			onToken("by Test on 2024")
+		}).
 		Return("Last commit was by Test on 2024", nil).Once()
 
 	// Display expectations
 	s.display.On("StartSpinner", mock.Anything).Return()
 	s.display.On("StopSpinner").Return()
+	s.display.On("ShowInfo", "Step 1").Return().Once()
 	s.display.On("ShowCommand", mock.Anything).Return()
-	s.display.On("ShowSuccess", "Last commit was by Test on 2024").Return()
+	s.display.On("ShowStreamChunk", "Last commit was ").Return().Once()
+	s.display.On("ShowStreamChunk", "by Test on 2024").Return().Once()
+	s.display.On("FinishStream").Return().Once()
 
 	err := s.agent.Chat(s.ctx, "show last commit")
 	s.Assert().NoError(err)
+	s.display.AssertNumberOfCalls(s.T(), "ShowStreamChunk", 2)
+	s.display.AssertCalled(s.T(), "FinishStream")
 }
 
 func (s *ChatAgentTestSuite) TestChat_ModifyCommand() {
@@ -141,6 +181,264 @@ func (s *ChatAgentTestSuite) TestChat_DirectAnswer() {
 	s.Assert().NoError(err)
 }
 
+func (s *ChatAgentTestSuite) TestChat_MultiStep() {
+	s.git.On("IsGitRepository", s.ctx).Return(true)
+
+	s.llm.On("Chat", s.ctx, mock.Anything).
+		Return(`{"type": "execute", "commandType": "query", "reason": "Find the commit", "commands": [{"args": ["log", "--format=%h", "-n", "1", "--", "main.go"]}]}`, nil).Once()
+	s.git.On("Execute", s.ctx, "log", "--format=%h", "-n", "1", "--", "main.go").
+		Return("abc123", nil).Once()
+
+	// The second step is planned from the output of the first one
+	s.llm.On("Chat", s.ctx, mock.MatchedBy(func(prompt string) bool {
+		return strings.Contains(prompt, "abc123")
+	})).Return(`{"type": "execute", "commandType": "query", "reason": "Show the commit", "commands": [{"args": ["show", "abc123"]}]}`, nil).Once()
+	s.git.On("Execute", s.ctx, "show", "abc123").
+		Return("commit abc123\n\n    Fix main", nil).Once()
+
+	s.llm.On("Chat", s.ctx, mock.Anything).Return(`{"type": "done"}`, nil).Once()
+	s.llm.On("ChatStream", s.ctx, mock.MatchedBy(func(prompt string) bool {
+		return strings.Contains(prompt, "Fix main") && strings.Contains(prompt, "git log")
+	}), mock.Anything).Return("It fixed main", nil).Once()
+
+	s.display.On("StartSpinner", mock.Anything).Return()
+	s.display.On("StopSpinner").Return()
+	s.display.On("ShowInfo", "Step 1: Find the commit").Return().Once()
+	s.display.On("ShowInfo", "Step 2: Show the commit").Return().Once()
+	s.display.On("ShowCommand", mock.Anything).Return()
+	s.display.On("FinishStream").Return()
+
+	err := s.agent.Chat(s.ctx, "what did the last change to main.go do?")
+	s.Require().NoError(err)
+	s.llm.AssertExpectations(s.T())
+	s.git.AssertExpectations(s.T())
+	s.display.AssertCalled(s.T(), "ShowInfo", "Step 2: Show the commit")
+}
+
+func (s *ChatAgentTestSuite) TestChat_StepLimit() {
+	s.agent.maxSteps = 2
+	s.git.On("IsGitRepository", s.ctx).Return(true)
+
+	step := `{"type": "execute", "commandType": "query", "commands": [{"args": ["status"]}]}`
+	s.llm.On("Chat", s.ctx, mock.Anything).Return(step, nil).Twice()
+	s.git.On("Execute", s.ctx, "status").Return("clean", nil)
+	s.llm.On("ChatStream", s.ctx, mock.Anything, mock.Anything).Return("Nothing to do", nil).Once()
+
+	s.display.On("StartSpinner", mock.Anything).Return()
+	s.display.On("StopSpinner").Return()
+	s.display.On("ShowInfo", mock.Anything).Return()
+	s.display.On("ShowCommand", mock.Anything).Return()
+	s.display.On("ShowWarning", mock.Anything).Return().Once()
+	s.display.On("FinishStream").Return()
+
+	err := s.agent.Chat(s.ctx, "keep going")
+	s.Require().NoError(err)
+	s.llm.AssertNumberOfCalls(s.T(), "Chat", 2)
+	s.git.AssertNumberOfCalls(s.T(), "Execute", 2)
+	s.display.AssertCalled(s.T(), "ShowWarning", mock.Anything)
+}
+
+func (s *ChatAgentTestSuite) TestChat_FailedQueryIsObserved() {
+	s.git.On("IsGitRepository", s.ctx).Return(true)
+
+	s.llm.On("Chat", s.ctx, mock.Anything).
+		Return(`{"type": "execute", "commandType": "query", "commands": [{"args": ["show", "nope"]}]}`, nil).Once()
+	s.git.On("Execute", s.ctx, "show", "nope").
+		Return("", errors.New("unknown revision")).Once()
+	s.llm.On("Chat", s.ctx, mock.MatchedBy(func(prompt string) bool {
+		return strings.Contains(prompt, "Error: unknown revision")
+	})).Return(`{"type": "answer", "content": "There is no such revision"}`, nil).Once()
+
+	s.display.On("StartSpinner", mock.Anything).Return()
+	s.display.On("StopSpinner").Return()
+	s.display.On("ShowInfo", mock.Anything).Return()
+	s.display.On("ShowCommand", mock.Anything).Return()
+	s.display.On("ShowWarning", mock.Anything).Return()
+	s.display.On("ShowSuccess", "There is no such revision").Return().Once()
+
+	err := s.agent.Chat(s.ctx, "show nope")
+	s.Require().NoError(err)
+	s.display.AssertCalled(s.T(), "ShowSuccess", "There is no such revision")
+}
+
+func (s *ChatAgentTestSuite) TestChat_ToolCalls() {
+	s.withTools()
+	s.git.On("IsGitRepository", s.ctx).Return(true)
+
+	s.llm.On("ChatWithTools", s.ctx, mock.MatchedBy(func(prompt string) bool {
+		return strings.Contains(prompt, "calling exactly one tool")
+	}), commandTools).Return(&common.ToolCall{
+		Name:      ToolRunGitQuery,
+		Arguments: `{"commands": [{"args": ["log", "-n", "1"], "purpose": "Show last commit"}], "reason": "Look at the log"}`,
+	}, nil).Once()
+	s.git.On("Execute", s.ctx, "log", "-n", "1").Return("commit abc123", nil).Once()
+	s.llm.On("ChatWithTools", s.ctx, mock.MatchedBy(func(prompt string) bool {
+		return strings.Contains(prompt, "commit abc123")
+	}), commandTools).Return(&common.ToolCall{
+		Name:      ToolAnswer,
+		Arguments: `{"content": "The last commit is abc123"}`,
+	}, nil).Once()
+
+	s.display.On("StartSpinner", mock.Anything).Return()
+	s.display.On("StopSpinner").Return()
+	s.display.On("ShowInfo", "Step 1: Look at the log").Return().Once()
+	s.display.On("ShowCommand", "git log -n 1").Return().Once()
+	s.display.On("ShowSuccess", "The last commit is abc123").Return().Once()
+
+	err := s.agent.Chat(s.ctx, "show last commit")
+	s.Require().NoError(err)
+	s.llm.AssertExpectations(s.T())
+	s.display.AssertExpectations(s.T())
+	s.llm.AssertNotCalled(s.T(), "Chat", mock.Anything, mock.Anything)
+}
+
+func (s *ChatAgentTestSuite) TestChat_ToolCallsFallBackToText() {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"tools rejected", fmt.Errorf("%w: status 400", llm.ErrToolsNotSupported)},
+		{"no tool called", llm.ErrNoToolCall},
+	}
+
+	for _, tc := range tests {
+		s.Run(tc.name, func() {
+			s.SetupTest()
+			s.withTools()
+			s.logger.On("Debug", mock.Anything, mock.Anything).Return()
+			s.git.On("IsGitRepository", s.ctx).Return(true)
+
+			// Tools are tried once; every later turn goes straight to text
+			s.llm.On("ChatWithTools", s.ctx, mock.Anything, commandTools).Return(nil, tc.err).Once()
+			s.llm.On("Chat", s.ctx, mock.MatchedBy(func(prompt string) bool {
+				return !strings.Contains(prompt, "calling exactly one tool")
+			})).Return(`{"type": "execute", "commandType": "query", "commands": [{"args": ["log", "-n", "1"]}], "reason": "Look at the log"}`, nil).Once()
+			s.git.On("Execute", s.ctx, "log", "-n", "1").Return("commit abc123", nil).Once()
+			s.llm.On("Chat", s.ctx, mock.MatchedBy(func(prompt string) bool {
+				return strings.Contains(prompt, "commit abc123")
+			})).Return(`{"type": "answer", "content": "The last commit is abc123"}`, nil).Once()
+
+			s.display.On("StartSpinner", mock.Anything).Return()
+			s.display.On("StopSpinner").Return()
+			s.display.On("ShowInfo", "Step 1: Look at the log").Return().Once()
+			s.display.On("ShowCommand", "git log -n 1").Return().Once()
+			s.display.On("ShowSuccess", "The last commit is abc123").Return().Once()
+
+			err := s.agent.Chat(s.ctx, "show last commit")
+			s.Require().NoError(err)
+			s.llm.AssertExpectations(s.T())
+			s.llm.AssertNumberOfCalls(s.T(), "ChatWithTools", 1)
+			s.display.AssertExpectations(s.T())
+		})
+	}
+}
+
+func (s *ChatAgentTestSuite) TestChat_ToolCallErrorIsReturned() {
+	s.withTools()
+	s.git.On("IsGitRepository", s.ctx).Return(true)
+	s.llm.On("ChatWithTools", s.ctx, mock.Anything, commandTools).Return(nil, errors.New("connection refused")).Once()
+	s.display.On("StartSpinner", mock.Anything).Return()
+	s.display.On("StopSpinner").Return()
+
+	err := s.agent.Chat(s.ctx, "show last commit")
+	s.Require().ErrorContains(err, "connection refused")
+	s.llm.AssertNotCalled(s.T(), "Chat", mock.Anything, mock.Anything)
+}
+
+func (s *ChatAgentTestSuite) TestResponseFromToolCall() {
+	response, err := responseFromToolCall(&common.ToolCall{
+		Name:      ToolProposeGitModification,
+		Arguments: `{"commands": [{"args": ["checkout", "-b", "login"], "impact": "Creates a branch"}], "reason": "New feature"}`,
+	})
+	s.Require().NoError(err)
+	s.Assert().Equal(ResponseTypeExecute, response.Type)
+	s.Assert().Equal(CommandTypeModify, response.CommandType)
+	s.Require().Len(response.Commands, 1)
+	s.Assert().Equal(CommandTypeModify, response.Commands[0].Type)
+	s.Assert().Equal("Creates a branch", response.Commands[0].Impact)
+
+	_, err = responseFromToolCall(&common.ToolCall{Name: "rm_rf", Arguments: "{}"})
+	s.Assert().ErrorContains(err, "unknown tool")
+
+	_, err = responseFromToolCall(&common.ToolCall{Name: ToolAnswer, Arguments: "not json"})
+	s.Assert().Error(err)
+}
+
+func (s *ChatAgentTestSuite) TestCommandToolSchemas() {
+	schemas := make(map[string]map[string]interface{})
+	for _, tool := range commandTools {
+		schemas[tool.Name] = tool.Parameters
+	}
+
+	query := schemas[ToolRunGitQuery]
+	s.Assert().Equal([]string{"commands", "reason"}, query["required"])
+	commands := query["properties"].(map[string]interface{})["commands"].(map[string]interface{})
+	s.Assert().Equal("array", commands["type"])
+	command := commands["items"].(map[string]interface{})
+	s.Assert().Contains(command["properties"], "args")
+	s.Assert().NotContains(command["properties"], "impact")
+
+	modify := schemas[ToolProposeGitModification]
+	command = modify["properties"].(map[string]interface{})["commands"].(map[string]interface{})["items"].(map[string]interface{})
+	s.Assert().Contains(command["properties"], "impact")
+	s.Assert().Equal([]string{"args", "purpose"}, command["required"])
+
+	s.Assert().Equal([]string{"content"}, schemas[ToolAnswer]["required"])
+
+	_, err := json.Marshal(commandTools)
+	s.Assert().NoError(err)
+}
+
+func (s *ChatAgentTestSuite) TestChat_PolicyOverridesQueryType() {
+	s.git.On("IsGitRepository", s.ctx).Return(true)
+
+	// The model calls a destructive command a query
+	s.llm.On("Chat", s.ctx, mock.Anything).
+		Return(`{"type": "execute", "commandType": "query", "commands": [{"args": ["reset", "--hard", "HEAD~1"]}]}`, nil).Once()
+
+	s.display.On("StartSpinner", mock.Anything).Return()
+	s.display.On("StopSpinner").Return()
+	s.display.On("ShowInfo", mock.Anything).Return()
+	s.display.On("ShowWarning", mock.Anything).Return()
+
+	s.input.WriteString("n\n")
+
+	err := s.agent.Chat(s.ctx, "show me the previous commit")
+	s.Require().NoError(err)
+	s.display.AssertCalled(s.T(), "ShowWarning", "Risk: destructive")
+	s.git.AssertNotCalled(s.T(), "Execute", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *ChatAgentTestSuite) TestChat_PolicyAllowAndDeny() {
+	s.agent.policy = &Policy{Allow: []string{"fetch"}, Deny: []string{"push --force"}}
+	s.git.On("IsGitRepository", s.ctx).Return(true)
+
+	// Allowed commands run without confirmation
+	s.llm.On("Chat", s.ctx, mock.Anything).
+		Return(`{"type": "execute", "commandType": "modify", "commands": [{"args": ["fetch", "origin"]}]}`, nil).Once()
+	s.git.On("Execute", s.ctx, "fetch", "origin").Return("", nil).Once()
+
+	// Denied commands are reported back instead of running
+	s.llm.On("Chat", s.ctx, mock.Anything).
+		Return(`{"type": "execute", "commandType": "modify", "commands": [{"args": ["push", "--force"]}]}`, nil).Once()
+	s.llm.On("Chat", s.ctx, mock.MatchedBy(func(prompt string) bool {
+		return strings.Contains(prompt, "command denied by policy")
+	})).Return(`{"type": "answer", "content": "Force pushing is not allowed"}`, nil).Once()
+
+	s.display.On("StartSpinner", mock.Anything).Return()
+	s.display.On("StopSpinner").Return()
+	s.display.On("ShowInfo", mock.Anything).Return()
+	s.display.On("ShowCommand", "git fetch origin").Return().Once()
+	s.display.On("ShowWarning", mock.Anything).Return()
+	s.display.On("ShowSuccess", "Force pushing is not allowed").Return().Once()
+
+	err := s.agent.Chat(s.ctx, "sync with origin")
+	s.Require().NoError(err)
+	s.llm.AssertExpectations(s.T())
+	s.git.AssertExpectations(s.T())
+	s.git.AssertNotCalled(s.T(), "Execute", s.ctx, "push", "--force")
+}
+
 func (s *ChatAgentTestSuite) TestGetCommandResponse() {
 	// Mock LLM response
 	commandResponse := Response{
@@ -194,6 +492,236 @@ func (s *ChatAgentTestSuite) TestHandleModificationCommands_Rejected() {
 	// Simulate user rejecting the operation
 	s.input.WriteString("n\n")
 
-	err := s.agent.handleModificationCommands(s.ctx, commands)
+	results, confirmed, err := s.agent.handleModificationCommands(s.ctx, "reset to previous commit", commands)
 	s.Assert().NoError(err)
+	s.Assert().False(confirmed)
+	s.Assert().Empty(results)
+}
+
+func (s *ChatAgentTestSuite) TestHandleModificationCommands_RecordsUndoPoint() {
+	commands := []Command{
+		{Type: CommandTypeModify, Args: []string{"checkout", "-b", "feature/login"}},
+	}
+
+	var calls []string
+	s.git.On("CreateSnapshot", s.ctx, "start the login feature").
+		Run(func(mock.Arguments) { calls = append(calls, "snapshot") }).
+		Return(&common.Snapshot{Ref: "refs/ggpt/undo/1"}, nil).Once()
+	s.git.On("Execute", s.ctx, "checkout", "-b", "feature/login").
+		Run(func(mock.Arguments) { calls = append(calls, "execute") }).
+		Return("Switched to a new branch 'feature/login'", nil).Once()
+
+	s.display.On("ShowWarning", mock.Anything).Return()
+	s.display.On("ShowInfo", mock.Anything).Return()
+	s.display.On("ShowSuccess", mock.Anything).Return()
+
+	s.input.WriteString("y\n")
+
+	results, confirmed, err := s.agent.handleModificationCommands(s.ctx, "start the login feature", commands)
+	s.Require().NoError(err)
+	s.Assert().True(confirmed)
+	s.Assert().Len(results, 1)
+	s.Assert().Equal([]string{"snapshot", "execute"}, calls)
+}
+
+func (s *ChatAgentTestSuite) TestHandleModificationCommands_PreviewThenConfirm() {
+	commands := []Command{
+		{Type: CommandTypeModify, Args: []string{"rebase", "main"}},
+		{Type: CommandTypeModify, Args: []string{"push", "--force-with-lease"}},
+	}
+
+	// The push is not previewed, since it would leave the copy
+	s.git.On("Preview", s.ctx, [][]string{{"rebase", "main"}}).
+		Return(&common.Preview{
+			Steps: []common.PreviewStep{
+				{Args: []string{"rebase", "main"}},
+			},
+			Log:    "abc123 login",
+			Status: "## feature/login",
+		}, nil).Once()
+	s.git.On("CreateSnapshot", s.ctx, "rebase and push").Return(&common.Snapshot{}, nil).Once()
+	s.git.On("Execute", s.ctx, "rebase", "main").Return("", nil).Once()
+	s.git.On("Execute", s.ctx, "push", "--force-with-lease").Return("", nil).Once()
+
+	s.display.On("ShowWarning", "Not previewed: git push --force-with-lease: network commands are not previewed").Return().Once()
+	s.display.On("ShowWarning", mock.Anything).Return()
+	s.display.On("ShowInfo", mock.Anything).Return()
+	s.display.On("StartSpinner", mock.Anything).Return()
+	s.display.On("StopSpinner").Return()
+	s.display.On("ShowSection", "Preview", "", mock.Anything).Return().Once()
+	s.display.On("ShowSuccess", "Would run: git rebase main").Return().Once()
+	s.display.On("ShowSection", "Resulting Log", "abc123 login", mock.Anything).Return().Once()
+	s.display.On("ShowSection", "Resulting Status", "## feature/login", mock.Anything).Return().Once()
+	s.display.On("ShowSection", "Changes Compared With Your Repository", mock.Anything, mock.Anything).Return().Once()
+	s.display.On("ShowSuccess", mock.Anything).Return()
+
+	s.input.WriteString("p\ny\n")
+
+	results, confirmed, err := s.agent.handleModificationCommands(s.ctx, "rebase and push", commands)
+	s.Require().NoError(err)
+	s.Assert().True(confirmed)
+	s.Assert().Len(results, 2)
+	s.git.AssertExpectations(s.T())
+	s.display.AssertExpectations(s.T())
+}
+
+func (s *ChatAgentTestSuite) TestHandleModificationCommands_PreviewThenCancel() {
+	commands := []Command{{Type: CommandTypeModify, Args: []string{"reset", "--hard", "HEAD~1"}}}
+
+	s.git.On("Preview", s.ctx, [][]string{{"reset", "--hard", "HEAD~1"}}).
+		Return(nil, errors.New("preview needs a repository with at least one commit")).Once()
+
+	s.display.On("ShowWarning", mock.Anything).Return()
+	s.display.On("ShowInfo", mock.Anything).Return()
+	s.display.On("StartSpinner", mock.Anything).Return()
+	s.display.On("StopSpinner").Return()
+
+	s.input.WriteString("p\nn\n")
+
+	results, confirmed, err := s.agent.handleModificationCommands(s.ctx, "undo the last commit", commands)
+	s.Require().NoError(err)
+	s.Assert().False(confirmed)
+	s.Assert().Empty(results)
+	s.git.AssertExpectations(s.T())
+	s.display.AssertCalled(s.T(), "ShowWarning", "Preview failed: preview needs a repository with at least one commit")
+	s.git.AssertNotCalled(s.T(), "CreateSnapshot", mock.Anything, mock.Anything)
+}
+
+func (s *ChatAgentTestSuite) TestHandleModificationCommands_PreviewsDestructive() {
+	commands := []Command{
+		{Type: CommandTypeModify, Args: []string{"reset", "--hard", "HEAD~1"}},
+		{Type: CommandTypeModify, Args: []string{"clean", "-fd"}},
+	}
+
+	// Discarding work only discards it in the copy
+	s.git.On("Preview", s.ctx, [][]string{{"reset", "--hard", "HEAD~1"}, {"clean", "-fd"}}).
+		Return(&common.Preview{
+			Steps: []common.PreviewStep{
+				{Args: []string{"reset", "--hard", "HEAD~1"}},
+				{Args: []string{"clean", "-fd"}},
+			},
+			Log:    "def456 first",
+			Status: "## main",
+		}, nil).Once()
+
+	s.display.On("ShowWarning", mock.Anything).Return()
+	s.display.On("ShowInfo", mock.Anything).Return()
+	s.display.On("StartSpinner", mock.Anything).Return()
+	s.display.On("StopSpinner").Return()
+	s.display.On("ShowSection", mock.Anything, mock.Anything, mock.Anything).Return()
+	s.display.On("ShowSuccess", "Would run: git reset --hard HEAD~1").Return().Once()
+	s.display.On("ShowSuccess", "Would run: git clean -fd").Return().Once()
+
+	s.input.WriteString("p\nn\n")
+
+	_, confirmed, err := s.agent.handleModificationCommands(s.ctx, "throw away the last commit", commands)
+	s.Require().NoError(err)
+	s.Assert().False(confirmed)
+	s.git.AssertExpectations(s.T())
+	s.display.AssertExpectations(s.T())
+	s.display.AssertNotCalled(s.T(), "ShowWarning", mock.MatchedBy(func(message string) bool {
+		return strings.HasPrefix(message, "Not previewed") || strings.HasPrefix(message, "Cannot preview")
+	}))
+	s.git.AssertNotCalled(s.T(), "Execute", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *ChatAgentTestSuite) TestHandleModificationCommands_PreviewRefused() {
+	commands := []Command{{Type: CommandTypeModify, Args: []string{"-C", "..", "commit", "-am", "fix"}}}
+
+	s.display.On("ShowWarning", mock.Anything).Return()
+	s.display.On("ShowInfo", mock.Anything).Return()
+
+	s.input.WriteString("p\nn\n")
+
+	_, confirmed, err := s.agent.handleModificationCommands(s.ctx, "commit the parent", commands)
+	s.Require().NoError(err)
+	s.Assert().False(confirmed)
+	s.display.AssertCalled(s.T(), "ShowWarning", "Cannot preview git -C .. commit -am fix: the global option -C is not previewed")
+	s.git.AssertNotCalled(s.T(), "Preview", mock.Anything, mock.Anything)
+}
+
+func (s *ChatAgentTestSuite) TestAsk_QueryCommand() {
+	s.git.On("IsGitRepository", s.ctx).Return(true)
+
+	commandResponse := Response{
+		Type:        "execute",
+		CommandType: CommandTypeQuery,
+		Commands: []Command{
+			{Type: CommandTypeQuery, Args: []string{"log", "-n", "1"}},
+		},
+	}
+	commandJSON, _ := json.Marshal(commandResponse)
+
+	s.llm.On("Chat", s.ctx, mock.Anything).Return(string(commandJSON), nil).Once()
+	s.git.On("Execute", s.ctx, "log", "-n", "1").Return("commit abc123", nil).Once()
+	s.llm.On("Chat", s.ctx, mock.Anything).Return(`{"type": "done"}`, nil).Once()
+	s.llm.On("Chat", s.ctx, mock.Anything).Return("Last commit is abc123", nil).Once()
+	s.display.On("StartSpinner", mock.Anything).Return()
+	s.display.On("StopSpinner").Return()
+
+	result, err := s.agent.Ask(s.ctx, "show last commit", AskOptions{})
+	s.Require().NoError(err)
+	s.Assert().True(result.Executed)
+	s.Assert().Equal("Last commit is abc123", result.Summary)
+	s.Require().Len(result.Results, 1)
+	s.Assert().Equal("commit abc123", result.Results[0].Output)
+
+	data, err := json.Marshal(result)
+	s.Require().NoError(err)
+	s.Assert().Contains(string(data), `"output":"commit abc123"`)
+}
+
+func (s *ChatAgentTestSuite) TestAsk_ModifyNotAllowed() {
+	s.git.On("IsGitRepository", s.ctx).Return(true)
+
+	commandResponse := Response{
+		Type:        "execute",
+		CommandType: CommandTypeModify,
+		Commands: []Command{
+			{Type: CommandTypeModify, Args: []string{"reset", "--hard", "HEAD~1"}},
+		},
+		Reason: "Drop the last commit",
+	}
+	commandJSON, _ := json.Marshal(commandResponse)
+
+	s.llm.On("Chat", s.ctx, mock.Anything).Return(string(commandJSON), nil).Once()
+	s.display.On("StartSpinner", mock.Anything).Return()
+	s.display.On("StopSpinner").Return()
+
+	result, err := s.agent.Ask(s.ctx, "drop last commit", AskOptions{})
+	var appErr *apierrors.AppError
+	s.Require().ErrorAs(err, &appErr)
+	s.Assert().Equal(apierrors.ErrModifyNotAllowed, appErr.Type)
+	s.Require().NotNil(result)
+	s.Assert().False(result.Executed)
+	s.Assert().Len(result.Commands, 1)
+	s.git.AssertNotCalled(s.T(), "CreateSnapshot", mock.Anything, mock.Anything)
+}
+
+func (s *ChatAgentTestSuite) TestChat_RedactsSecretsInOutput() {
+	s.git.On("IsGitRepository", s.ctx).Return(true)
+
+	s.llm.On("Chat", s.ctx, mock.Anything).
+		Return(`{"type": "execute", "commandType": "query", "reason": "Read the config", "commands": [{"args": ["show", "HEAD:.env"]}]}`, nil).Once()
+	s.git.On("Execute", s.ctx, "show", "HEAD:.env").
+		Return("AWS_KEY="+testAWSKey, nil).Once()
+
+	s.llm.On("Chat", s.ctx, mock.MatchedBy(func(prompt string) bool {
+		return strings.Contains(prompt, "AWS_KEY=[REDACTED:aws-access-key]") && !strings.Contains(prompt, testAWSKey)
+	})).Return(`{"type": "done"}`, nil).Once()
+	s.llm.On("ChatStream", s.ctx, mock.MatchedBy(func(prompt string) bool {
+		return !strings.Contains(prompt, testAWSKey)
+	}), mock.Anything).Return("The file holds an AWS key", nil).Once()
+
+	s.display.On("StartSpinner", mock.Anything).Return()
+	s.display.On("StopSpinner").Return()
+	s.display.On("ShowInfo", mock.Anything).Return()
+	s.display.On("ShowCommand", mock.Anything).Return()
+	s.display.On("FinishStream").Return()
+	s.display.On("ShowWarning", "Redacted 1 possible secret(s) (aws-access-key) from git output before sending it to the model").Return().Twice()
+
+	err := s.agent.Chat(s.ctx, "what is in .env?")
+	s.Require().NoError(err)
+	s.llm.AssertExpectations(s.T())
+	s.display.AssertExpectations(s.T())
 }
@@ -4,13 +4,20 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
 	"strings"
 
+	"github.com/go-coders/git_gpt/internal/commitlint"
 	"github.com/go-coders/git_gpt/internal/common"
+	"github.com/go-coders/git_gpt/pkg/apierrors"
 )
 
 type CommitAgent struct {
 	*BaseAgent
+	diffIgnore     []string
+	abortOnSecrets bool
+	lint           *commitlint.Linter
+	ticket         *TicketRule
 }
 
 func NewCommitAgent(config AgentConfig) (*CommitAgent, error) {
@@ -20,7 +27,11 @@ func NewCommitAgent(config AgentConfig) (*CommitAgent, error) {
 	}
 
 	return &CommitAgent{
-		BaseAgent: base,
+		BaseAgent:      base,
+		diffIgnore:     config.DiffIgnore,
+		abortOnSecrets: config.AbortOnSecrets,
+		lint:           config.Lint,
+		ticket:         config.Ticket,
 	}, nil
 }
 
@@ -58,6 +69,183 @@ func (a *CommitAgent) HandleCommit(ctx context.Context) error {
 	}
 }
 
+// CommitWithOptions runs the commit flow for scripts and CI. Staging and message
+// selection are taken from opts, and the chosen message is returned.
+func (a *CommitAgent) CommitWithOptions(ctx context.Context, opts CommitOptions) (string, error) {
+	if opts.Split {
+		messages, err := a.SplitCommit(ctx, opts)
+		return strings.Join(messages, "\n"), err
+	}
+	if opts.Amend {
+		return a.AmendCommit(ctx, opts)
+	}
+
+	if err := a.stageForOptions(ctx, opts.Stage); err != nil {
+		return "", err
+	}
+
+	staged, _, err := a.git.GetStatus(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get status: %w", err)
+	}
+	if len(staged) == 0 {
+		return "", apierrors.NewNothingToCommitError()
+	}
+
+	message, err := a.chooseMessage(ctx, staged, diffSource{}, opts)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.DryRun {
+		return message, nil
+	}
+
+	if err := a.git.Commit(ctx, message); err != nil {
+		return "", fmt.Errorf("failed to commit: %w", err)
+	}
+	return message, nil
+}
+
+func (a *CommitAgent) stageForOptions(ctx context.Context, mode string) error {
+	switch mode {
+	case StageModeAll:
+		return a.git.StageAll(ctx)
+	case StageModeTracked:
+		return a.git.StageTracked(ctx)
+	case StageModeNone, "":
+		return nil
+	default:
+		return fmt.Errorf("invalid stage mode: %s", mode)
+	}
+}
+
+// chooseMessage selects a message for the changes in files as opts say,
+// adds the requested trailers and opens it in the editor when asked
+func (a *CommitAgent) chooseMessage(ctx context.Context, files []common.FileChange, src diffSource, opts CommitOptions) (string, error) {
+	message, err := a.selectCommitMessage(ctx, files, src, opts)
+	if err != nil {
+		return "", err
+	}
+
+	message = appendTrailers(message, opts.Trailers)
+	if opts.Edit {
+		if message, err = a.editCommitMessage(ctx, message); err != nil {
+			return "", err
+		}
+		if message == "" {
+			return "", apierrors.NewCancelledError()
+		}
+	}
+	return message, nil
+}
+
+func (a *CommitAgent) selectCommitMessage(ctx context.Context, staged []common.FileChange, src diffSource, opts CommitOptions) (string, error) {
+	for {
+		suggestions, err := a.generateSuggestions(ctx, staged, src)
+		if err != nil {
+			return "", err
+		}
+		if len(suggestions.Suggestions) == 0 {
+			return "", fmt.Errorf("no commit suggestions found")
+		}
+
+		switch {
+		case opts.Pick > 0:
+			message, _, err := a.processNumberedSelection(fmt.Sprint(opts.Pick), suggestions.Suggestions)
+			return message, err
+		case opts.Yes:
+			return suggestions.Suggestions[0].Text(), nil
+		}
+
+		a.displayStagedChanges(staged)
+		a.displayCommitSuggestions(suggestions)
+
+		message, regenerate, err := a.getCommitMessage(ctx, suggestions.Suggestions)
+		if err != nil {
+			return "", err
+		}
+		if regenerate {
+			continue
+		}
+		if message == "" {
+			return "", apierrors.NewCancelledError()
+		}
+		return message, nil
+	}
+}
+
+// PrepareCommitMessage fills the commit message file passed to the
+// prepare-commit-msg hook. The top suggestion becomes the message and the
+// remaining ones are added as comment lines for the editor.
+func (a *CommitAgent) PrepareCommitMessage(ctx context.Context, path string) error {
+	existing, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read commit message file: %w", err)
+	}
+
+	commentChar := a.commentChar(ctx)
+	if hasMessageContent(string(existing), commentChar) {
+		return nil
+	}
+
+	staged, _, err := a.git.GetStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get status: %w", err)
+	}
+	if len(staged) == 0 {
+		return nil
+	}
+
+	suggestions, err := a.generateCommitSuggestions(ctx, staged)
+	if err != nil {
+		return err
+	}
+	if len(suggestions.Suggestions) == 0 {
+		return nil
+	}
+
+	message := buildHookMessage(suggestions.Suggestions, string(existing), commentChar)
+	if err := os.WriteFile(path, []byte(message), 0644); err != nil {
+		return fmt.Errorf("failed to write commit message file: %w", err)
+	}
+	return nil
+}
+
+func (a *CommitAgent) commentChar(ctx context.Context) string {
+	char, err := a.git.Execute(ctx, "config", "core.commentChar")
+	if err != nil || char == "" || char == "auto" {
+		return "#"
+	}
+	return char
+}
+
+func hasMessageContent(message, commentChar string) bool {
+	for _, line := range strings.Split(message, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, commentChar) {
+			return true
+		}
+	}
+	return false
+}
+
+func buildHookMessage(suggestions []CommitSuggestion, existing, commentChar string) string {
+	var b strings.Builder
+	b.WriteString(suggestions[0].Text())
+	b.WriteString("\n\n")
+	if len(suggestions) > 1 {
+		fmt.Fprintf(&b, "%s Other suggestions from ggpt:\n", commentChar)
+		for _, suggestion := range suggestions[1:] {
+			fmt.Fprintf(&b, "%s   %s\n", commentChar, suggestion.Message)
+		}
+		fmt.Fprintf(&b, "%s\n", commentChar)
+	}
+
+	b.WriteString(strings.TrimLeft(existing, "\n"))
+	return b.String()
+}
+
 type commitStatus struct {
 	staged      []common.FileChange
 	unstaged    []common.FileChange
@@ -100,12 +288,17 @@ func (a *CommitAgent) handleUnstagedChanges(ctx context.Context, unstaged []comm
 	a.displayUnstagedChanges(modified, untracked)
 
 	// Prompt for staging
-	confirmed, err := a.promptForConfirmation("\nWould you like to stage all changes? (y/n): ")
+	a.display.ShowQuestion("\nWould you like to stage all changes? (y/n, s to select): ")
+	input, err := a.reader.ReadString('\n')
 	if err != nil {
 		return false, fmt.Errorf("failed to prompt for confirmation: %w", err)
 	}
-	fmt.Println(confirmed, err, "confirmed")
-	if !confirmed {
+
+	switch strings.TrimSpace(input) {
+	case "y":
+	case "s":
+		return a.selectChanges(ctx, unstaged)
+	default:
 		a.display.ShowInfo("Commit cancelled")
 		return false, nil
 	}
@@ -128,7 +321,7 @@ func (a *CommitAgent) processStagedChanges(ctx context.Context, status *commitSt
 	a.displayStagedChanges(status.staged)
 	a.displayCommitSuggestions(status.suggestions)
 
-	message, regenerate, err := a.getCommitMessage(status.suggestions.Suggestions)
+	message, regenerate, err := a.getCommitMessage(ctx, status.suggestions.Suggestions)
 	if err != nil {
 		return err
 	}
@@ -146,22 +339,25 @@ func (a *CommitAgent) processStagedChanges(ctx context.Context, status *commitSt
 		return fmt.Errorf("failed to commit: %w", err)
 	}
 
-	a.display.ShowSuccess(fmt.Sprintf("Changes committed successfully with message: %s", message))
+	subject, _, _ := strings.Cut(message, "\n")
+	a.display.ShowSuccess(fmt.Sprintf("Changes committed successfully with message: %s", subject))
 	return nil
 }
 
 func (a *CommitAgent) generateCommitSuggestions(ctx context.Context, files []common.FileChange) (*CommitResponse, error) {
+	return a.generateSuggestions(ctx, files, diffSource{})
+}
+
+// generateSuggestions asks for messages describing files, whose diff is
+// taken from src
+func (a *CommitAgent) generateSuggestions(ctx context.Context, files []common.FileChange, src diffSource) (*CommitResponse, error) {
 	a.display.StartSpinner("Analyzing changes and generating suggestions...")
 	defer a.display.StopSpinner()
 
-	diff, err := a.git.GetDiff(ctx, true)
+	ticket := a.branchTicket(ctx)
+	prompt, err := a.commitPrompt(ctx, files, src, ticket)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get diff: %w", err)
-	}
-
-	prompt, err := a.prompts.GetCommitPrompt(files, diff)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate commit prompt: %w", err)
+		return nil, err
 	}
 
 	response, err := a.llm.Chat(ctx, prompt)
@@ -177,9 +373,170 @@ func (a *CommitAgent) generateCommitSuggestions(ctx context.Context, files []com
 		return nil, fmt.Errorf("failed to parse suggestions: %w", err)
 	}
 
+	// The reference is added before the rules are checked, and again in case
+	// a repair dropped it
+	for i := range result.Suggestions {
+		result.Suggestions[i] = ticket.Apply(result.Suggestions[i])
+	}
+	result.Suggestions = a.repairSuggestions(ctx, result.Suggestions, ticket)
+	for i := range result.Suggestions {
+		result.Suggestions[i] = ticket.Apply(result.Suggestions[i])
+	}
 	return &result, nil
 }
 
+// maxRepairRounds bounds how often suggestions that break the commit rules
+// are sent back to the model
+const maxRepairRounds = 2
+
+// repairSuggestions sends suggestions that break the commit rules back to
+// the model with their violations. Suggestions still failing afterwards are
+// dropped, unless none pass. The ticket reference is exempt from the rules.
+func (a *CommitAgent) repairSuggestions(ctx context.Context, suggestions []CommitSuggestion, ticket *TicketRef) []CommitSuggestion {
+	if a.lint == nil {
+		return suggestions
+	}
+
+	for round := 0; round < maxRepairRounds; round++ {
+		var repairs []RepairItem
+		var failing []int
+		for i, suggestion := range suggestions {
+			if errs := commitlint.Errors(a.lint.Lint(ticket.exempt(suggestion.Text()))); len(errs) > 0 {
+				repairs = append(repairs, RepairItem{Message: suggestion.Text(), Violations: violationTexts(errs)})
+				failing = append(failing, i)
+			}
+		}
+		if len(repairs) == 0 {
+			return suggestions
+		}
+
+		fixed, err := a.requestRepairs(ctx, repairs)
+		if err != nil {
+			// The suggestions are still usable, only less polished
+			a.logger.Debug("Failed to repair commit suggestions: %v", err)
+			break
+		}
+		for j, i := range failing {
+			if j < len(fixed) && strings.TrimSpace(fixed[j].Message) != "" {
+				suggestions[i] = fixed[j]
+			}
+		}
+	}
+
+	var valid []CommitSuggestion
+	for _, suggestion := range suggestions {
+		if len(commitlint.Errors(a.lint.Lint(ticket.exempt(suggestion.Text())))) == 0 {
+			valid = append(valid, suggestion)
+		}
+	}
+	if len(valid) == 0 {
+		return suggestions
+	}
+	return valid
+}
+
+func (a *CommitAgent) requestRepairs(ctx context.Context, repairs []RepairItem) ([]CommitSuggestion, error) {
+	prompt, err := a.prompts.GetRepairCommitPrompt(repairs, a.lint.Describe())
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := a.llm.Chat(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	var result CommitResponse
+	if err := json.Unmarshal([]byte(cleanJSONResponse(response)), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse repaired suggestions: %w", err)
+	}
+	return result.Suggestions, nil
+}
+
+// lintRules describes the commit rules for prompts
+func (a *CommitAgent) lintRules() []string {
+	if a.lint == nil {
+		return nil
+	}
+	return a.lint.Describe()
+}
+
+// styleProfile returns the repository's commit style, or nil to fall back to
+// the conventional commits guidelines
+func (a *CommitAgent) styleProfile(ctx context.Context) *StyleProfile {
+	profile, err := LoadStyleProfile(ctx, a.git)
+	if err != nil {
+		a.logger.Debug("Failed to learn the commit style: %v", err)
+		return nil
+	}
+	return profile
+}
+
+func violationTexts(violations []commitlint.Violation) []string {
+	texts := make([]string, len(violations))
+	for i, v := range violations {
+		texts[i] = v.String()
+	}
+	return texts
+}
+
+// redactDiff removes secrets from a staged diff before it goes into a prompt.
+// path names the file for diffs without a header, such as a single hunk. It
+// returns the redacted diff, "path (rule)" for each finding, and whether a
+// secret is on an added line and so about to be committed.
+func (a *CommitAgent) redactDiff(diff, path string) (string, []string, bool) {
+	redacted, findings := a.secrets.Redact(diff)
+	if len(findings) == 0 {
+		return diff, nil, false
+	}
+
+	lines := strings.Split(diff, "\n")
+	var locations []string
+	added := false
+	for _, f := range findings {
+		file := path
+		for i := f.Line - 1; i >= 0; i-- {
+			if name, ok := strings.CutPrefix(lines[i], "+++ b/"); ok {
+				file = name
+				break
+			}
+		}
+		locations = append(locations, fmt.Sprintf("%s (%s)", file, f.Rule))
+
+		line := lines[f.Line-1]
+		if strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++") {
+			added = true
+		}
+	}
+	return redacted, locations, added
+}
+
+// reportSecrets warns about redacted secrets, and aborts the commit when one
+// is being added and the agent is configured to stop
+func (a *CommitAgent) reportSecrets(locations []string, added bool) error {
+	if len(locations) == 0 {
+		return nil
+	}
+	if added && a.abortOnSecrets {
+		return apierrors.NewSecretDetectedError(uniqueStrings(locations))
+	}
+	a.display.ShowWarning(fmt.Sprintf("Possible secrets in the staged changes were redacted before sending: %s",
+		strings.Join(uniqueStrings(locations), ", ")))
+	return nil
+}
+
+func uniqueStrings(values []string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
 func (a *CommitAgent) categorizeChanges(changes []common.FileChange) (modified, untracked []string) {
 	modified = make([]string, 0)
 	untracked = make([]string, 0)
@@ -213,9 +570,16 @@ func (a *CommitAgent) displayUnstagedChanges(modified, untracked []string) {
 func (a *CommitAgent) displayStagedChanges(changes []common.FileChange) {
 	items := make([][2]string, 0, len(changes))
 	for _, change := range changes {
+		stats := fmt.Sprintf("(%d+/%d-)", change.Additions, change.Deletions)
+		if change.Binary {
+			stats = "(binary)"
+		}
+		if change.DiffOmitted != "" && change.DiffOmitted != omitBinary {
+			stats = fmt.Sprintf("%s summarized: %s", stats, change.DiffOmitted)
+		}
 		items = append(items, [2]string{
-			fmt.Sprintf("%s %s", getStatusSymbol(change.Status), change.Path),
-			fmt.Sprintf("(%d+/%d-)", change.Additions, change.Deletions),
+			fmt.Sprintf("%s %s", getStatusSymbol(change.Status), change.DisplayPath()),
+			stats,
 		})
 	}
 
@@ -236,7 +600,7 @@ func (a *CommitAgent) displayCommitSuggestions(suggestions *CommitResponse) {
 	for _, suggestion := range suggestions.Suggestions {
 		items = append(items, [2]string{
 			suggestion.Message,
-			suggestion.Description,
+			suggestion.details(),
 		})
 	}
 
@@ -244,38 +608,102 @@ func (a *CommitAgent) displayCommitSuggestions(suggestions *CommitResponse) {
 	a.display.ShowNumberedList(items)
 }
 
-func (a *CommitAgent) getCommitMessage(suggestions []CommitSuggestion) (string, bool, error) {
-	fmt.Print("\nSelect a message (1-3), 'r' to regenerate, 'c' to cancel, or 'm' for manual input: ")
+func (a *CommitAgent) getCommitMessage(ctx context.Context, suggestions []CommitSuggestion) (string, bool, error) {
+	a.display.ShowQuestion("\nSelect a message (1-3), 'e N' to edit message N, 'r' to regenerate, 'c' to cancel, or 'm' for manual input: ")
 	input, err := a.reader.ReadString('\n')
 	if err != nil {
 		return "", false, fmt.Errorf("failed to read input: %w", err)
 	}
 
-	return a.processCommitMessageInput(strings.TrimSpace(input), suggestions)
+	return a.processCommitMessageInput(ctx, strings.TrimSpace(input), suggestions)
 }
 
-func (a *CommitAgent) processCommitMessageInput(input string, suggestions []CommitSuggestion) (string, bool, error) {
-	switch input {
-	case "c":
+func (a *CommitAgent) processCommitMessageInput(ctx context.Context, input string, suggestions []CommitSuggestion) (string, bool, error) {
+	switch {
+	case input == "c":
 		return "", false, nil
-	case "m":
-		return a.getManualCommitMessage()
-	case "r":
+	case input == "m":
+		return a.getManualCommitMessage(ctx)
+	case input == "r":
 		return "", true, nil
-	case "":
+	case input == "":
 		return "", false, nil
+	case strings.HasPrefix(input, "e"):
+		message, _, err := a.processNumberedSelection(strings.TrimSpace(input[1:]), suggestions)
+		if err != nil {
+			return "", false, err
+		}
+		message, err = a.editCommitMessage(ctx, message)
+		return message, false, err
 	default:
 		return a.processNumberedSelection(input, suggestions)
 	}
 }
 
-func (a *CommitAgent) getManualCommitMessage() (string, bool, error) {
-	fmt.Print("Enter your commit message: ")
-	message, err := a.reader.ReadString('\n')
-	if err != nil {
-		return "", false, fmt.Errorf("failed to read input: %w", err)
+// editCommitMessage opens message in the user's editor until the result
+// passes the commit rules or the user accepts it anyway. An empty result
+// cancels the commit.
+func (a *CommitAgent) editCommitMessage(ctx context.Context, message string) (string, error) {
+	for {
+		edited, err := a.git.EditMessage(ctx, message)
+		if err != nil {
+			return "", err
+		}
+		if edited == "" {
+			return "", nil
+		}
+
+		ok, err := a.checkCommitRules(ctx, edited)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return edited, nil
+		}
+		message = edited
+	}
+}
+
+// getManualCommitMessage reads a message from the user. A message that breaks
+// the commit rules is only used when the user insists.
+func (a *CommitAgent) getManualCommitMessage(ctx context.Context) (string, bool, error) {
+	for {
+		a.display.ShowQuestion("Enter your commit message: ")
+		input, err := a.reader.ReadString('\n')
+		if err != nil {
+			return "", false, fmt.Errorf("failed to read input: %w", err)
+		}
+		message := strings.TrimSpace(input)
+		if message == "" {
+			return message, false, nil
+		}
+
+		ok, err := a.checkCommitRules(ctx, message)
+		if err != nil {
+			return "", false, err
+		}
+		if ok {
+			return message, false, nil
+		}
+	}
+}
+
+// checkCommitRules warns about the rules message breaks and reports whether
+// it should be used: it passes, or the user accepts it anyway. The branch's
+// ticket reference is exempt from the rules.
+func (a *CommitAgent) checkCommitRules(ctx context.Context, message string) (bool, error) {
+	if a.lint == nil {
+		return true, nil
+	}
+
+	violations := a.lint.Lint(a.branchTicket(ctx).exempt(message))
+	for _, v := range violations {
+		a.display.ShowWarning(fmt.Sprintf("Commit rule %s", v))
+	}
+	if len(commitlint.Errors(violations)) == 0 {
+		return true, nil
 	}
-	return strings.TrimSpace(message), false, nil
+	return a.promptForConfirmation("Use this message anyway? (y/n): ")
 }
 
 func (a *CommitAgent) processNumberedSelection(input string, suggestions []CommitSuggestion) (string, bool, error) {
@@ -288,7 +716,7 @@ func (a *CommitAgent) processNumberedSelection(input string, suggestions []Commi
 		return "", false, fmt.Errorf("invalid selection: must be between 1 and %d", len(suggestions))
 	}
 
-	return suggestions[selection-1].Message, false, nil
+	return suggestions[selection-1].Text(), false, nil
 }
 
 func getStatusSymbol(status string) string {
@@ -1,9 +1,14 @@
 package agent
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/go-coders/git_gpt/internal/agent/mocks"
 	"github.com/go-coders/git_gpt/internal/common"
+	"github.com/go-coders/git_gpt/pkg/apierrors"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
@@ -23,6 +28,10 @@ func (s *CommitAgentTestSuite) SetupTest() {
 	s.logger.On("Debug", mock.Anything, mock.Anything).Return()
 	s.logger.On("Info", mock.Anything).Return()
 	s.logger.On("Error", mock.Anything).Return()
+	s.llm.On("AvailableTokens").Return(0).Maybe()
+	s.git.On("CheckAttributes", mock.Anything, mock.Anything, "linguist-generated", "linguist-vendored", "diff").
+		Return(map[string]map[string]string{}, nil).Maybe()
+	withoutHistory(s.git)
 
 	config := AgentConfig{
 		Git:     s.git,
@@ -37,6 +46,13 @@ func (s *CommitAgentTestSuite) SetupTest() {
 	s.agent = agent
 }
 
+// withoutHistory gives git no commits to learn a style from, so prompts use
+// the default guidelines
+func withoutHistory(git *mocks.GitExecutor) {
+	git.On("GitPath", mock.Anything, mock.Anything).Return("", fmt.Errorf("no git directory")).Maybe()
+	git.On("RecentSubjects", mock.Anything, mock.Anything).Return([]string{}, nil).Maybe()
+}
+
 func TestCommitAgent(t *testing.T) {
 	suite.Run(t, new(CommitAgentTestSuite))
 }
@@ -213,3 +229,165 @@ func (s *CommitAgentTestSuite) TestHandleCommit_UnstagedChanges() {
 	err := s.agent.HandleCommit(s.ctx)
 	s.Assert().NoError(err)
 }
+
+func (s *CommitAgentTestSuite) TestHandleUnstagedChanges_SelectFilesAndHunks() {
+	unstaged := []common.FileChange{
+		{Path: "main.go", Status: "modified"},
+		{Path: "notes.txt", Status: "untracked"},
+		{Path: "old.go", Status: "deleted"},
+	}
+	diff := &common.FileDiff{
+		Path:   "main.go",
+		Header: "diff --git a/main.go b/main.go\n",
+		Hunks: []common.Hunk{
+			{Header: "@@ -1 +1 @@", Text: "@@ -1 +1 @@\n-a\n+b\n"},
+			{Header: "@@ -9 +9 @@", Text: "@@ -9 +9 @@\n-c\n+d\n"},
+			{Header: "@@ -20 +20 @@", Text: "@@ -20 +20 @@\n-e\n+f\n"},
+		},
+	}
+
+	s.git.On("GetFileDiff", s.ctx, "main.go", false).Return(diff, nil).Once()
+	s.git.On("StageFiles", s.ctx, []string{"notes.txt"}).Return(nil).Once()
+	s.git.On("StageHunks", s.ctx, diff, []int{0, 2}).Return(nil).Once()
+
+	s.display.On("ShowSection", mock.Anything, mock.Anything, mock.Anything).Return()
+	s.display.On("ShowInfo", mock.Anything).Return()
+	s.display.On("ShowWarning", "old.go can only be staged as a whole").Return().Once()
+	s.display.On("ShowWarning", "invalid selection: 7").Return().Once()
+	s.display.On("ShowNumberedList", mock.Anything).Return()
+	s.display.On("ShowSuccess", "Selected changes staged successfully").Return().Once()
+
+	// Select, pick hunks 1 and 3 of main.go, try hunks of a deleted file,
+	// toggle notes.txt, an invalid number, then stage
+	s.input.WriteString("s\nh 1\ny\nn\ny\nh 3\n2 7\n\n")
+
+	staged, err := s.agent.handleUnstagedChanges(s.ctx, unstaged)
+	s.Require().NoError(err)
+	s.Assert().True(staged)
+	s.git.AssertExpectations(s.T())
+	s.display.AssertCalled(s.T(), "ShowNumberedList", [][2]string{
+		{"[~] 📝 main.go", "2/3 hunks"},
+		{"[x] ❓ notes.txt", ""},
+		{"[ ] ➖ old.go", ""},
+	})
+}
+
+func (s *CommitAgentTestSuite) TestHandleUnstagedChanges_SelectNothing() {
+	unstaged := []common.FileChange{{Path: "main.go", Status: "modified"}}
+
+	s.display.On("ShowSection", mock.Anything, mock.Anything, mock.Anything).Return()
+	s.display.On("ShowInfo", mock.Anything).Return()
+	s.display.On("ShowNumberedList", mock.Anything).Return()
+
+	// Select all, then none, then stage
+	s.input.WriteString("s\na\nn\n\n")
+
+	staged, err := s.agent.handleUnstagedChanges(s.ctx, unstaged)
+	s.Require().NoError(err)
+	s.Assert().False(staged)
+	s.display.AssertCalled(s.T(), "ShowInfo", "Nothing selected, commit cancelled")
+	s.git.AssertNotCalled(s.T(), "StageFiles", mock.Anything, mock.Anything)
+}
+
+func (s *CommitAgentTestSuite) TestCommitWithOptions_PickDryRun() {
+	stagedFiles := []common.FileChange{{Path: "test1.txt", Status: "modified"}}
+
+	s.git.On("GetStatus", s.ctx).Return(stagedFiles, []common.FileChange{}, nil).Once()
+	s.git.On("GetDiff", s.ctx, true).Return("test diff", nil).Once()
+
+	llmResponse := `{
+			"summary": "Test changes",
+			"suggestions": [
+					{"message": "feat: test commit 1"},
+					{"message": "fix: test commit 2"}
+			]
+	}`
+	s.llm.On("Chat", s.ctx, mock.Anything).Return(llmResponse, nil).Once()
+
+	message, err := s.agent.CommitWithOptions(s.ctx, CommitOptions{Pick: 2, DryRun: true})
+	s.Assert().NoError(err)
+	s.Assert().Equal("fix: test commit 2", message)
+	s.git.AssertNotCalled(s.T(), "Commit", mock.Anything, mock.Anything)
+}
+
+func (s *CommitAgentTestSuite) TestCommitWithOptions_StageAllYes() {
+	stagedFiles := []common.FileChange{{Path: "test1.txt", Status: "modified"}}
+
+	s.git.On("StageAll", s.ctx).Return(nil).Once()
+	s.git.On("GetStatus", s.ctx).Return(stagedFiles, []common.FileChange{}, nil).Once()
+	s.git.On("GetDiff", s.ctx, true).Return("test diff", nil).Once()
+	s.llm.On("Chat", s.ctx, mock.Anything).
+		Return(`{"summary": "Test", "suggestions": [{"message": "feat: test commit"}]}`, nil).Once()
+	s.git.On("Commit", s.ctx, "feat: test commit").Return(nil).Once()
+
+	message, err := s.agent.CommitWithOptions(s.ctx, CommitOptions{Stage: StageModeAll, Yes: true})
+	s.Assert().NoError(err)
+	s.Assert().Equal("feat: test commit", message)
+	s.git.AssertExpectations(s.T())
+}
+
+func (s *CommitAgentTestSuite) TestCommitWithOptions_Errors() {
+	s.Run("nothing staged", func() {
+		s.git.On("GetStatus", s.ctx).Return([]common.FileChange{}, []common.FileChange{}, nil).Once()
+
+		_, err := s.agent.CommitWithOptions(s.ctx, CommitOptions{Yes: true})
+		var appErr *apierrors.AppError
+		s.Require().ErrorAs(err, &appErr)
+		s.Assert().Equal(apierrors.ErrNothingToCommit, appErr.Type)
+	})
+
+	s.Run("pick out of range", func() {
+		s.git.On("GetStatus", s.ctx).Return([]common.FileChange{{Path: "a.txt"}}, []common.FileChange{}, nil).Once()
+		s.git.On("GetDiff", s.ctx, true).Return("test diff", nil).Once()
+		s.llm.On("Chat", s.ctx, mock.Anything).
+			Return(`{"summary": "Test", "suggestions": [{"message": "feat: test commit"}]}`, nil).Once()
+
+		_, err := s.agent.CommitWithOptions(s.ctx, CommitOptions{Pick: 3})
+		s.Assert().ErrorContains(err, "invalid selection")
+	})
+
+	s.Run("invalid stage mode", func() {
+		_, err := s.agent.CommitWithOptions(s.ctx, CommitOptions{Stage: "bogus"})
+		s.Assert().ErrorContains(err, "invalid stage mode")
+	})
+}
+
+func (s *CommitAgentTestSuite) TestPrepareCommitMessage() {
+	path := filepath.Join(s.T().TempDir(), "COMMIT_EDITMSG")
+	template := "\n# Please enter the commit message for your changes.\n"
+	s.Require().NoError(os.WriteFile(path, []byte(template), 0644))
+
+	stagedFiles := []common.FileChange{{Path: "test1.txt", Status: "modified"}}
+	s.git.On("Execute", s.ctx, "config", "core.commentChar").Return("", fmt.Errorf("not set")).Once()
+	s.git.On("GetStatus", s.ctx).Return(stagedFiles, []common.FileChange{}, nil).Once()
+	s.git.On("GetDiff", s.ctx, true).Return("test diff", nil).Once()
+	s.llm.On("Chat", s.ctx, mock.Anything).Return(`{
+			"summary": "Test changes",
+			"suggestions": [
+					{"message": "feat: test commit 1"},
+					{"message": "fix: test commit 2"}
+			]
+	}`, nil).Once()
+
+	err := s.agent.PrepareCommitMessage(s.ctx, path)
+	s.Require().NoError(err)
+
+	content, err := os.ReadFile(path)
+	s.Require().NoError(err)
+	s.Assert().Equal("feat: test commit 1\n\n"+
+		"# Other suggestions from ggpt:\n"+
+		"#   fix: test commit 2\n"+
+		"#\n"+
+		"# Please enter the commit message for your changes.\n", string(content))
+}
+
+func (s *CommitAgentTestSuite) TestPrepareCommitMessage_KeepsExistingMessage() {
+	path := filepath.Join(s.T().TempDir(), "COMMIT_EDITMSG")
+	s.Require().NoError(os.WriteFile(path, []byte("fix: typed by hand\n# comment\n"), 0644))
+
+	s.git.On("Execute", s.ctx, "config", "core.commentChar").Return("#", nil).Once()
+
+	err := s.agent.PrepareCommitMessage(s.ctx, path)
+	s.Require().NoError(err)
+	s.git.AssertNotCalled(s.T(), "GetStatus", mock.Anything)
+}
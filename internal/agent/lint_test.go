@@ -0,0 +1,66 @@
+package agent
+
+import (
+	"strings"
+
+	"github.com/go-coders/git_gpt/internal/commitlint"
+	"github.com/go-coders/git_gpt/internal/common"
+	"github.com/stretchr/testify/mock"
+)
+
+func (s *CommitAgentTestSuite) TestGenerateCommitSuggestions_RepairsRuleViolations() {
+	s.agent.lint = commitlint.Conventional()
+	s.git.On("GetDiff", s.ctx, true).Return("test diff", nil).Once()
+
+	s.llm.On("Chat", s.ctx, mock.MatchedBy(func(prompt string) bool {
+		return strings.Contains(prompt, "Generate exactly 3") &&
+			strings.Contains(prompt, "- The type must be one of: build, chore")
+	})).Return(`{"suggestions": [
+		{"message": "feat(agent): add rules"},
+		{"message": "Feature: Add rules."},
+		{"message": "feat: Add rules"}
+	]}`, nil).Once()
+
+	// Only the two failing messages go back, with their violations
+	s.llm.On("Chat", s.ctx, mock.MatchedBy(func(prompt string) bool {
+		return strings.Contains(prompt, "Feature: Add rules.\nViolations:\n- subject-case:") &&
+			strings.Contains(prompt, "- type-case: type must be lower-case") &&
+			strings.Contains(prompt, "feat: Add rules\nViolations:\n- subject-case:") &&
+			!strings.Contains(prompt, "feat(agent): add rules")
+	})).Return(`{"suggestions": [{"message": "feat: add rules"}, {"message": "Feat: still wrong"}]}`, nil).Once()
+	s.llm.On("Chat", s.ctx, mock.MatchedBy(func(prompt string) bool {
+		return strings.Contains(prompt, "Feat: still wrong") && !strings.Contains(prompt, "feat: add rules")
+	})).Return(`{"suggestions": [{"message": "Feat: again wrong"}]}`, nil).Once()
+
+	response, err := s.agent.generateCommitSuggestions(s.ctx, []common.FileChange{{Path: "a.go", Status: "modified"}})
+	s.Require().NoError(err)
+	s.Assert().Equal([]CommitSuggestion{
+		{Message: "feat(agent): add rules"},
+		{Message: "feat: add rules"},
+	}, response.Suggestions)
+	s.llm.AssertExpectations(s.T())
+}
+
+func (s *CommitAgentTestSuite) TestGetManualCommitMessage_ChecksRules() {
+	s.agent.lint = commitlint.Conventional()
+	s.display.On("ShowWarning", "Commit rule subject-empty: subject may not be empty").Return()
+	s.display.On("ShowWarning", "Commit rule type-empty: type may not be empty").Return()
+
+	// Rejected once, then a valid message
+	s.input.WriteString("update stuff\nn\nfix: update stuff\n")
+	message, regenerate, err := s.agent.getManualCommitMessage(s.ctx)
+	s.Require().NoError(err)
+	s.Assert().False(regenerate)
+	s.Assert().Equal("fix: update stuff", message)
+	s.display.AssertNumberOfCalls(s.T(), "ShowWarning", 2)
+}
+
+func (s *CommitAgentTestSuite) TestGetManualCommitMessage_UseAnyway() {
+	s.agent.lint = commitlint.Conventional()
+	s.display.On("ShowWarning", mock.Anything).Return()
+
+	s.input.WriteString("update stuff\ny\n")
+	message, _, err := s.agent.getManualCommitMessage(s.ctx)
+	s.Require().NoError(err)
+	s.Assert().Equal("update stuff", message)
+}
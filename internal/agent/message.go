@@ -0,0 +1,164 @@
+package agent
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// bodyWidth is the column commit message bodies are wrapped at
+const bodyWidth = 72
+
+var (
+	trailerKey  = regexp.MustCompile(`^(?:[A-Za-z0-9][A-Za-z0-9-]*|BREAKING CHANGE)$`)
+	trailerLine = regexp.MustCompile(`^(?:[A-Za-z0-9][A-Za-z0-9-]*|BREAKING CHANGE): \S`)
+	bulletItem  = regexp.MustCompile(`^(?:[-*+]|\d+[.)])\s+`)
+)
+
+// Text returns the full commit message: the subject, the body wrapped at
+// bodyWidth and the trailers. Trailers with an invalid key are left out.
+func (s CommitSuggestion) Text() string {
+	message := strings.TrimSpace(s.Message)
+	if body := wrapText(s.Body, bodyWidth); body != "" {
+		message += "\n\n" + body
+	}
+	return appendTrailers(message, s.Trailers)
+}
+
+// details is the part of the message after the subject, for display
+func (s CommitSuggestion) details() string {
+	return strings.TrimSpace(strings.TrimPrefix(s.Text(), strings.TrimSpace(s.Message)))
+}
+
+// ParseTrailer parses "Key: value" or "Key=value", as git commit --trailer
+// accepts them
+func ParseTrailer(text string) (Trailer, error) {
+	i := strings.IndexAny(text, ":=")
+	if i < 0 {
+		return Trailer{}, fmt.Errorf("invalid trailer %q: expected \"Key: value\"", text)
+	}
+	trailer := Trailer{Key: strings.TrimSpace(text[:i]), Value: strings.TrimSpace(text[i+1:])}
+	if !trailerKey.MatchString(trailer.Key) || trailer.Value == "" {
+		return Trailer{}, fmt.Errorf("invalid trailer %q: expected \"Key: value\"", text)
+	}
+	return trailer, nil
+}
+
+// appendTrailers adds trailers to the trailer block that ends message,
+// starting one when the last paragraph is not made of trailers
+func appendTrailers(message string, trailers []Trailer) string {
+	var lines []string
+	for _, t := range trailers {
+		key, value := strings.TrimSpace(t.Key), strings.Join(strings.Fields(t.Value), " ")
+		if !trailerKey.MatchString(key) || value == "" {
+			continue
+		}
+		lines = append(lines, key+": "+value)
+	}
+
+	message = strings.TrimRight(message, "\n")
+	if len(lines) == 0 {
+		return message
+	}
+	if hasTrailerBlock(message) {
+		return message + "\n" + strings.Join(lines, "\n")
+	}
+	return message + "\n\n" + strings.Join(lines, "\n")
+}
+
+// hasTrailerBlock reports whether the last paragraph of message, after the
+// subject, consists of trailers
+func hasTrailerBlock(message string) bool {
+	paragraphs := strings.Split(message, "\n\n")
+	if len(paragraphs) < 2 {
+		return false
+	}
+	for _, line := range strings.Split(paragraphs[len(paragraphs)-1], "\n") {
+		if !trailerLine.MatchString(line) {
+			return false
+		}
+	}
+	return true
+}
+
+// wrapText wraps each paragraph of text at width columns. List items keep
+// their marker with the continuation lines indented under the text, and
+// indented lines such as code are left alone.
+func wrapText(text string, width int) string {
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n"))
+	if text == "" {
+		return ""
+	}
+
+	var paragraphs []string
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		if paragraph = strings.Trim(paragraph, "\n"); paragraph == "" {
+			continue
+		}
+
+		var (
+			lines []string
+			item  string // the list item or sentence being collected
+		)
+		flush := func() {
+			if item == "" {
+				return
+			}
+			indent := 0
+			if m := bulletItem.FindString(item); m != "" {
+				indent = utf8.RuneCountInString(m)
+			}
+			lines = append(lines, wrapLine(item, width, indent)...)
+			item = ""
+		}
+		for _, line := range strings.Split(paragraph, "\n") {
+			switch {
+			case strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t"):
+				flush()
+				lines = append(lines, strings.TrimRight(line, " \t"))
+			case bulletItem.MatchString(strings.TrimSpace(line)):
+				flush()
+				item = strings.TrimSpace(line)
+			default:
+				if item != "" {
+					item += " "
+				}
+				item += strings.TrimSpace(line)
+			}
+		}
+		flush()
+		paragraphs = append(paragraphs, strings.Join(lines, "\n"))
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// wrapLine breaks text between words so lines fit in width. Continuation
+// lines are indented by indent spaces; a word longer than a line, such as a
+// URL, gets a line of its own.
+func wrapLine(text string, width, indent int) []string {
+	var (
+		lines   []string
+		current string
+	)
+	prefix := strings.Repeat(" ", indent)
+	for _, word := range strings.Fields(text) {
+		if current == "" {
+			current = word
+			if len(lines) > 0 {
+				current = prefix + word
+			}
+			continue
+		}
+		if utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) > width {
+			lines = append(lines, current)
+			current = prefix + word
+			continue
+		}
+		current += " " + word
+	}
+	if current != "" {
+		lines = append(lines, current)
+	}
+	return lines
+}
@@ -0,0 +1,172 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-coders/git_gpt/internal/common"
+	"github.com/go-coders/git_gpt/pkg/apierrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCommitSuggestion_Text(t *testing.T) {
+	tests := []struct {
+		name       string
+		suggestion CommitSuggestion
+		want       string
+	}{
+		{
+			name:       "subject only",
+			suggestion: CommitSuggestion{Message: "fix: handle empty input "},
+			want:       "fix: handle empty input",
+		},
+		{
+			name: "body is wrapped",
+			suggestion: CommitSuggestion{
+				Message: "feat(git): commit with -F",
+				Body:    "Commit messages were passed with -m, so the body and the trailers that the model suggested never reached the repository.",
+			},
+			want: "feat(git): commit with -F\n\n" +
+				"Commit messages were passed with -m, so the body and the trailers that\n" +
+				"the model suggested never reached the repository.",
+		},
+		{
+			name: "list items and code keep their shape",
+			suggestion: CommitSuggestion{
+				Message: "refactor: split the parser",
+				Body: "Changes:\n- move tokenizing into its own type so the parser only deals with the grammar rules\n- drop the unused lookahead buffer\n\n" +
+					"    go test ./parser/...",
+			},
+			want: "refactor: split the parser\n\n" +
+				"Changes:\n" +
+				"- move tokenizing into its own type so the parser only deals with the\n" +
+				"  grammar rules\n" +
+				"- drop the unused lookahead buffer\n\n" +
+				"    go test ./parser/...",
+		},
+		{
+			name: "trailers",
+			suggestion: CommitSuggestion{
+				Message: "feat!: drop the v1 API",
+				Body:    "The v1 endpoints are gone.",
+				Trailers: []Trailer{
+					{Key: "Refs", Value: "#42"},
+					{Key: "BREAKING CHANGE", Value: "clients must use /v2"},
+					{Key: "not a key", Value: "dropped"},
+					{Key: "Reviewed-by", Value: ""},
+				},
+			},
+			want: "feat!: drop the v1 API\n\nThe v1 endpoints are gone.\n\nRefs: #42\nBREAKING CHANGE: clients must use /v2",
+		},
+		{
+			name: "long word gets its own line",
+			suggestion: CommitSuggestion{
+				Message: "docs: link the design",
+				Body:    "See https://example.com/a/very/long/path/to/the/design/document/that/does/not/fit for details.",
+			},
+			want: "docs: link the design\n\n" +
+				"See\n" +
+				"https://example.com/a/very/long/path/to/the/design/document/that/does/not/fit\n" +
+				"for details.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.suggestion.Text())
+		})
+	}
+}
+
+func TestParseTrailer(t *testing.T) {
+	trailer, err := ParseTrailer("Co-authored-by: Jane Doe <jane@example.com>")
+	assert.NoError(t, err)
+	assert.Equal(t, Trailer{Key: "Co-authored-by", Value: "Jane Doe <jane@example.com>"}, trailer)
+
+	trailer, err = ParseTrailer("Refs=#12")
+	assert.NoError(t, err)
+	assert.Equal(t, Trailer{Key: "Refs", Value: "#12"}, trailer)
+
+	for _, invalid := range []string{"no separator", "Bad key: value", "Refs:", ": value"} {
+		_, err := ParseTrailer(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestAppendTrailers(t *testing.T) {
+	refs := []Trailer{{Key: "Refs", Value: "#7"}}
+
+	assert.Equal(t, "fix: typo\n\nRefs: #7", appendTrailers("fix: typo\n", refs))
+	assert.Equal(t, "fix: typo\n\nSigned-off-by: A <a@b.c>\nRefs: #7",
+		appendTrailers("fix: typo\n\nSigned-off-by: A <a@b.c>", refs))
+	// A body paragraph that is not made of trailers starts a new block
+	assert.Equal(t, "fix: typo\n\nNote: this is prose, not a trailer.\nMore prose.\n\nRefs: #7",
+		appendTrailers("fix: typo\n\nNote: this is prose, not a trailer.\nMore prose.", refs))
+	assert.Equal(t, "fix: typo", appendTrailers("fix: typo", nil))
+}
+
+func (s *CommitAgentTestSuite) TestCommitWithOptions_BodyAndTrailers() {
+	s.git.On("GetStatus", s.ctx).Return([]common.FileChange{{Path: "a.go", Status: "modified"}}, []common.FileChange{}, nil).Once()
+	s.git.On("GetDiff", s.ctx, true).Return("test diff", nil).Once()
+	s.llm.On("Chat", s.ctx, mock.MatchedBy(func(prompt string) bool {
+		return strings.Contains(prompt, `"trailers": [`)
+	})).Return(`{"suggestions": [{
+		"message": "fix: retry failed uploads",
+		"body": "Uploads failed for good on the first timeout.",
+		"trailers": [{"key": "Refs", "value": "#9"}]
+	}]}`, nil).Once()
+
+	want := "fix: retry failed uploads\n\nUploads failed for good on the first timeout.\n\nRefs: #9\nCo-authored-by: Jane <jane@example.com>"
+	s.git.On("Commit", s.ctx, want).Return(nil).Once()
+
+	message, err := s.agent.CommitWithOptions(s.ctx, CommitOptions{
+		Yes:      true,
+		Trailers: []Trailer{{Key: "Co-authored-by", Value: "Jane <jane@example.com>"}},
+	})
+	s.Require().NoError(err)
+	s.Assert().Equal(want, message)
+	s.git.AssertExpectations(s.T())
+}
+
+func (s *CommitAgentTestSuite) TestCommitWithOptions_Edit() {
+	s.Run("edited message is committed", func() {
+		s.git.On("GetStatus", s.ctx).Return([]common.FileChange{{Path: "a.go"}}, []common.FileChange{}, nil).Once()
+		s.git.On("GetDiff", s.ctx, true).Return("test diff", nil).Once()
+		s.llm.On("Chat", s.ctx, mock.Anything).
+			Return(`{"suggestions": [{"message": "fix: a", "body": "Why."}]}`, nil).Once()
+		s.git.On("EditMessage", s.ctx, "fix: a\n\nWhy.").Return("fix: edited\n\nBecause.", nil).Once()
+		s.git.On("Commit", s.ctx, "fix: edited\n\nBecause.").Return(nil).Once()
+
+		message, err := s.agent.CommitWithOptions(s.ctx, CommitOptions{Yes: true, Edit: true})
+		s.Require().NoError(err)
+		s.Assert().Equal("fix: edited\n\nBecause.", message)
+	})
+
+	s.Run("empty message cancels", func() {
+		s.git.On("GetStatus", s.ctx).Return([]common.FileChange{{Path: "a.go"}}, []common.FileChange{}, nil).Once()
+		s.git.On("GetDiff", s.ctx, true).Return("test diff", nil).Once()
+		s.llm.On("Chat", s.ctx, mock.Anything).Return(`{"suggestions": [{"message": "fix: b"}]}`, nil).Once()
+		s.git.On("EditMessage", s.ctx, "fix: b").Return("", nil).Once()
+
+		_, err := s.agent.CommitWithOptions(s.ctx, CommitOptions{Yes: true, Edit: true})
+		var appErr *apierrors.AppError
+		s.Require().ErrorAs(err, &appErr)
+		s.Assert().Equal(apierrors.ErrCancelled, appErr.Type)
+	})
+
+	s.git.AssertNotCalled(s.T(), "Commit", s.ctx, "fix: b")
+}
+
+func (s *CommitAgentTestSuite) TestProcessCommitMessageInput_Edit() {
+	suggestions := []CommitSuggestion{{Message: "feat: one"}, {Message: "feat: two", Body: "Details."}}
+	s.git.On("EditMessage", s.ctx, "feat: two\n\nDetails.").Return("feat: two, edited", nil).Once()
+
+	message, regenerate, err := s.agent.processCommitMessageInput(s.ctx, "e 2", suggestions)
+	s.Require().NoError(err)
+	s.Assert().False(regenerate)
+	s.Assert().Equal("feat: two, edited", message)
+
+	_, _, err = s.agent.processCommitMessageInput(s.ctx, "e 5", suggestions)
+	s.Assert().Error(err)
+}
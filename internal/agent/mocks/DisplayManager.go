@@ -17,6 +17,38 @@ func (_m *DisplayManager) EXPECT() *DisplayManager_Expecter {
 	return &DisplayManager_Expecter{mock: &_m.Mock}
 }
 
+// FinishStream provides a mock function with given fields:
+func (_m *DisplayManager) FinishStream() {
+	_m.Called()
+}
+
+// DisplayManager_FinishStream_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FinishStream'
+type DisplayManager_FinishStream_Call struct {
+	*mock.Call
+}
+
+// FinishStream is a helper method to define mock.On call
+func (_e *DisplayManager_Expecter) FinishStream() *DisplayManager_FinishStream_Call {
+	return &DisplayManager_FinishStream_Call{Call: _e.mock.On("FinishStream")}
+}
+
+func (_c *DisplayManager_FinishStream_Call) Run(run func()) *DisplayManager_FinishStream_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *DisplayManager_FinishStream_Call) Return() *DisplayManager_FinishStream_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *DisplayManager_FinishStream_Call) RunAndReturn(run func()) *DisplayManager_FinishStream_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // ShowCommand provides a mock function with given fields: command
 func (_m *DisplayManager) ShowCommand(command string) {
 	_m.Called(command)
@@ -149,6 +181,72 @@ func (_c *DisplayManager_ShowNumberedList_Call) RunAndReturn(run func([][2]strin
 	return _c
 }
 
+// ShowOutput provides a mock function with given fields: output
+func (_m *DisplayManager) ShowOutput(output string) {
+	_m.Called(output)
+}
+
+// DisplayManager_ShowOutput_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ShowOutput'
+type DisplayManager_ShowOutput_Call struct {
+	*mock.Call
+}
+
+// ShowOutput is a helper method to define mock.On call
+//   - output string
+func (_e *DisplayManager_Expecter) ShowOutput(output interface{}) *DisplayManager_ShowOutput_Call {
+	return &DisplayManager_ShowOutput_Call{Call: _e.mock.On("ShowOutput", output)}
+}
+
+func (_c *DisplayManager_ShowOutput_Call) Run(run func(output string)) *DisplayManager_ShowOutput_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *DisplayManager_ShowOutput_Call) Return() *DisplayManager_ShowOutput_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *DisplayManager_ShowOutput_Call) RunAndReturn(run func(string)) *DisplayManager_ShowOutput_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ShowQuestion provides a mock function with given fields: question
+func (_m *DisplayManager) ShowQuestion(question string) {
+	_m.Called(question)
+}
+
+// DisplayManager_ShowQuestion_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ShowQuestion'
+type DisplayManager_ShowQuestion_Call struct {
+	*mock.Call
+}
+
+// ShowQuestion is a helper method to define mock.On call
+//   - question string
+func (_e *DisplayManager_Expecter) ShowQuestion(question interface{}) *DisplayManager_ShowQuestion_Call {
+	return &DisplayManager_ShowQuestion_Call{Call: _e.mock.On("ShowQuestion", question)}
+}
+
+func (_c *DisplayManager_ShowQuestion_Call) Run(run func(question string)) *DisplayManager_ShowQuestion_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *DisplayManager_ShowQuestion_Call) Return() *DisplayManager_ShowQuestion_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *DisplayManager_ShowQuestion_Call) RunAndReturn(run func(string)) *DisplayManager_ShowQuestion_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // ShowSection provides a mock function with given fields: title, content, opts
 func (_m *DisplayManager) ShowSection(title string, content string, opts map[string]string) {
 	_m.Called(title, content, opts)
@@ -184,6 +282,39 @@ func (_c *DisplayManager_ShowSection_Call) RunAndReturn(run func(string, string,
 	return _c
 }
 
+// ShowStreamChunk provides a mock function with given fields: chunk
+func (_m *DisplayManager) ShowStreamChunk(chunk string) {
+	_m.Called(chunk)
+}
+
+// DisplayManager_ShowStreamChunk_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ShowStreamChunk'
+type DisplayManager_ShowStreamChunk_Call struct {
+	*mock.Call
+}
+
+// ShowStreamChunk is a helper method to define mock.On call
+//   - chunk string
+func (_e *DisplayManager_Expecter) ShowStreamChunk(chunk interface{}) *DisplayManager_ShowStreamChunk_Call {
+	return &DisplayManager_ShowStreamChunk_Call{Call: _e.mock.On("ShowStreamChunk", chunk)}
+}
+
+func (_c *DisplayManager_ShowStreamChunk_Call) Run(run func(chunk string)) *DisplayManager_ShowStreamChunk_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *DisplayManager_ShowStreamChunk_Call) Return() *DisplayManager_ShowStreamChunk_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *DisplayManager_ShowStreamChunk_Call) RunAndReturn(run func(string)) *DisplayManager_ShowStreamChunk_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // ShowSuccess provides a mock function with given fields: message
 func (_m *DisplayManager) ShowSuccess(message string) {
 	_m.Called(message)
@@ -23,6 +23,127 @@ func (_m *GitExecutor) EXPECT() *GitExecutor_Expecter {
 	return &GitExecutor_Expecter{mock: &_m.Mock}
 }
 
+// Amend provides a mock function with given fields: ctx, message
+func (_m *GitExecutor) Amend(ctx context.Context, message string) error {
+	ret := _m.Called(ctx, message)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Amend")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, message)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GitExecutor_Amend_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Amend'
+type GitExecutor_Amend_Call struct {
+	*mock.Call
+}
+
+// Amend is a helper method to define mock.On call
+//   - ctx context.Context
+//   - message string
+func (_e *GitExecutor_Expecter) Amend(ctx interface{}, message interface{}) *GitExecutor_Amend_Call {
+	return &GitExecutor_Amend_Call{Call: _e.mock.On("Amend", ctx, message)}
+}
+
+func (_c *GitExecutor_Amend_Call) Run(run func(ctx context.Context, message string)) *GitExecutor_Amend_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *GitExecutor_Amend_Call) Return(_a0 error) *GitExecutor_Amend_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *GitExecutor_Amend_Call) RunAndReturn(run func(context.Context, string) error) *GitExecutor_Amend_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CheckAttributes provides a mock function with given fields: ctx, paths, attrs
+func (_m *GitExecutor) CheckAttributes(ctx context.Context, paths []string, attrs ...string) (map[string]map[string]string, error) {
+	_va := make([]interface{}, len(attrs))
+	for _i := range attrs {
+		_va[_i] = attrs[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, paths)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CheckAttributes")
+	}
+
+	var r0 map[string]map[string]string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string, ...string) (map[string]map[string]string, error)); ok {
+		return rf(ctx, paths, attrs...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []string, ...string) map[string]map[string]string); ok {
+		r0 = rf(ctx, paths, attrs...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]map[string]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []string, ...string) error); ok {
+		r1 = rf(ctx, paths, attrs...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GitExecutor_CheckAttributes_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CheckAttributes'
+type GitExecutor_CheckAttributes_Call struct {
+	*mock.Call
+}
+
+// CheckAttributes is a helper method to define mock.On call
+//   - ctx context.Context
+//   - paths []string
+//   - attrs ...string
+func (_e *GitExecutor_Expecter) CheckAttributes(ctx interface{}, paths interface{}, attrs ...interface{}) *GitExecutor_CheckAttributes_Call {
+	return &GitExecutor_CheckAttributes_Call{Call: _e.mock.On("CheckAttributes",
+		append([]interface{}{ctx, paths}, attrs...)...)}
+}
+
+func (_c *GitExecutor_CheckAttributes_Call) Run(run func(ctx context.Context, paths []string, attrs ...string)) *GitExecutor_CheckAttributes_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]string, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(string)
+			}
+		}
+		run(args[0].(context.Context), args[1].([]string), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *GitExecutor_CheckAttributes_Call) Return(_a0 map[string]map[string]string, _a1 error) *GitExecutor_CheckAttributes_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GitExecutor_CheckAttributes_Call) RunAndReturn(run func(context.Context, []string, ...string) (map[string]map[string]string, error)) *GitExecutor_CheckAttributes_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Commit provides a mock function with given fields: ctx, message
 func (_m *GitExecutor) Commit(ctx context.Context, message string) error {
 	ret := _m.Called(ctx, message)
@@ -31,73 +152,1304 @@ func (_m *GitExecutor) Commit(ctx context.Context, message string) error {
 		panic("no return value specified for Commit")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
-		r0 = rf(ctx, message)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, message)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GitExecutor_Commit_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Commit'
+type GitExecutor_Commit_Call struct {
+	*mock.Call
+}
+
+// Commit is a helper method to define mock.On call
+//   - ctx context.Context
+//   - message string
+func (_e *GitExecutor_Expecter) Commit(ctx interface{}, message interface{}) *GitExecutor_Commit_Call {
+	return &GitExecutor_Commit_Call{Call: _e.mock.On("Commit", ctx, message)}
+}
+
+func (_c *GitExecutor_Commit_Call) Run(run func(ctx context.Context, message string)) *GitExecutor_Commit_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *GitExecutor_Commit_Call) Return(_a0 error) *GitExecutor_Commit_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *GitExecutor_Commit_Call) RunAndReturn(run func(context.Context, string) error) *GitExecutor_Commit_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CommitLog provides a mock function with given fields: ctx, from, to
+func (_m *GitExecutor) CommitLog(ctx context.Context, from string, to string) ([]common.Commit, error) {
+	ret := _m.Called(ctx, from, to)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CommitLog")
+	}
+
+	var r0 []common.Commit
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) ([]common.Commit, error)); ok {
+		return rf(ctx, from, to)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []common.Commit); ok {
+		r0 = rf(ctx, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]common.Commit)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GitExecutor_CommitLog_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CommitLog'
+type GitExecutor_CommitLog_Call struct {
+	*mock.Call
+}
+
+// CommitLog is a helper method to define mock.On call
+//   - ctx context.Context
+//   - from string
+//   - to string
+func (_e *GitExecutor_Expecter) CommitLog(ctx interface{}, from interface{}, to interface{}) *GitExecutor_CommitLog_Call {
+	return &GitExecutor_CommitLog_Call{Call: _e.mock.On("CommitLog", ctx, from, to)}
+}
+
+func (_c *GitExecutor_CommitLog_Call) Run(run func(ctx context.Context, from string, to string)) *GitExecutor_CommitLog_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *GitExecutor_CommitLog_Call) Return(_a0 []common.Commit, _a1 error) *GitExecutor_CommitLog_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GitExecutor_CommitLog_Call) RunAndReturn(run func(context.Context, string, string) ([]common.Commit, error)) *GitExecutor_CommitLog_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CommitSplit provides a mock function with given fields: ctx, steps
+func (_m *GitExecutor) CommitSplit(ctx context.Context, steps []common.CommitStep) error {
+	ret := _m.Called(ctx, steps)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CommitSplit")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []common.CommitStep) error); ok {
+		r0 = rf(ctx, steps)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GitExecutor_CommitSplit_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CommitSplit'
+type GitExecutor_CommitSplit_Call struct {
+	*mock.Call
+}
+
+// CommitSplit is a helper method to define mock.On call
+//   - ctx context.Context
+//   - steps []common.CommitStep
+func (_e *GitExecutor_Expecter) CommitSplit(ctx interface{}, steps interface{}) *GitExecutor_CommitSplit_Call {
+	return &GitExecutor_CommitSplit_Call{Call: _e.mock.On("CommitSplit", ctx, steps)}
+}
+
+func (_c *GitExecutor_CommitSplit_Call) Run(run func(ctx context.Context, steps []common.CommitStep)) *GitExecutor_CommitSplit_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].([]common.CommitStep))
+	})
+	return _c
+}
+
+func (_c *GitExecutor_CommitSplit_Call) Return(_a0 error) *GitExecutor_CommitSplit_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *GitExecutor_CommitSplit_Call) RunAndReturn(run func(context.Context, []common.CommitStep) error) *GitExecutor_CommitSplit_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateSnapshot provides a mock function with given fields: ctx, query
+func (_m *GitExecutor) CreateSnapshot(ctx context.Context, query string) (*common.Snapshot, error) {
+	ret := _m.Called(ctx, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateSnapshot")
+	}
+
+	var r0 *common.Snapshot
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*common.Snapshot, error)); ok {
+		return rf(ctx, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *common.Snapshot); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*common.Snapshot)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GitExecutor_CreateSnapshot_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateSnapshot'
+type GitExecutor_CreateSnapshot_Call struct {
+	*mock.Call
+}
+
+// CreateSnapshot is a helper method to define mock.On call
+//   - ctx context.Context
+//   - query string
+func (_e *GitExecutor_Expecter) CreateSnapshot(ctx interface{}, query interface{}) *GitExecutor_CreateSnapshot_Call {
+	return &GitExecutor_CreateSnapshot_Call{Call: _e.mock.On("CreateSnapshot", ctx, query)}
+}
+
+func (_c *GitExecutor_CreateSnapshot_Call) Run(run func(ctx context.Context, query string)) *GitExecutor_CreateSnapshot_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *GitExecutor_CreateSnapshot_Call) Return(_a0 *common.Snapshot, _a1 error) *GitExecutor_CreateSnapshot_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GitExecutor_CreateSnapshot_Call) RunAndReturn(run func(context.Context, string) (*common.Snapshot, error)) *GitExecutor_CreateSnapshot_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateTag provides a mock function with given fields: ctx, name, message
+func (_m *GitExecutor) CreateTag(ctx context.Context, name string, message string) error {
+	ret := _m.Called(ctx, name, message)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateTag")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, name, message)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GitExecutor_CreateTag_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateTag'
+type GitExecutor_CreateTag_Call struct {
+	*mock.Call
+}
+
+// CreateTag is a helper method to define mock.On call
+//   - ctx context.Context
+//   - name string
+//   - message string
+func (_e *GitExecutor_Expecter) CreateTag(ctx interface{}, name interface{}, message interface{}) *GitExecutor_CreateTag_Call {
+	return &GitExecutor_CreateTag_Call{Call: _e.mock.On("CreateTag", ctx, name, message)}
+}
+
+func (_c *GitExecutor_CreateTag_Call) Run(run func(ctx context.Context, name string, message string)) *GitExecutor_CreateTag_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *GitExecutor_CreateTag_Call) Return(_a0 error) *GitExecutor_CreateTag_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *GitExecutor_CreateTag_Call) RunAndReturn(run func(context.Context, string, string) error) *GitExecutor_CreateTag_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DefaultBranch provides a mock function with given fields: ctx
+func (_m *GitExecutor) DefaultBranch(ctx context.Context) (string, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DefaultBranch")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (string, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) string); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GitExecutor_DefaultBranch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DefaultBranch'
+type GitExecutor_DefaultBranch_Call struct {
+	*mock.Call
+}
+
+// DefaultBranch is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *GitExecutor_Expecter) DefaultBranch(ctx interface{}) *GitExecutor_DefaultBranch_Call {
+	return &GitExecutor_DefaultBranch_Call{Call: _e.mock.On("DefaultBranch", ctx)}
+}
+
+func (_c *GitExecutor_DefaultBranch_Call) Run(run func(ctx context.Context)) *GitExecutor_DefaultBranch_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *GitExecutor_DefaultBranch_Call) Return(_a0 string, _a1 error) *GitExecutor_DefaultBranch_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GitExecutor_DefaultBranch_Call) RunAndReturn(run func(context.Context) (string, error)) *GitExecutor_DefaultBranch_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// EditMessage provides a mock function with given fields: ctx, message
+func (_m *GitExecutor) EditMessage(ctx context.Context, message string) (string, error) {
+	ret := _m.Called(ctx, message)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EditMessage")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+		return rf(ctx, message)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+		r0 = rf(ctx, message)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, message)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GitExecutor_EditMessage_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'EditMessage'
+type GitExecutor_EditMessage_Call struct {
+	*mock.Call
+}
+
+// EditMessage is a helper method to define mock.On call
+//   - ctx context.Context
+//   - message string
+func (_e *GitExecutor_Expecter) EditMessage(ctx interface{}, message interface{}) *GitExecutor_EditMessage_Call {
+	return &GitExecutor_EditMessage_Call{Call: _e.mock.On("EditMessage", ctx, message)}
+}
+
+func (_c *GitExecutor_EditMessage_Call) Run(run func(ctx context.Context, message string)) *GitExecutor_EditMessage_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *GitExecutor_EditMessage_Call) Return(_a0 string, _a1 error) *GitExecutor_EditMessage_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GitExecutor_EditMessage_Call) RunAndReturn(run func(context.Context, string) (string, error)) *GitExecutor_EditMessage_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Execute provides a mock function with given fields: ctx, args
+func (_m *GitExecutor) Execute(ctx context.Context, args ...string) (string, error) {
+	_va := make([]interface{}, len(args))
+	for _i := range args {
+		_va[_i] = args[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Execute")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, ...string) (string, error)); ok {
+		return rf(ctx, args...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, ...string) string); ok {
+		r0 = rf(ctx, args...)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, ...string) error); ok {
+		r1 = rf(ctx, args...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GitExecutor_Execute_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Execute'
+type GitExecutor_Execute_Call struct {
+	*mock.Call
+}
+
+// Execute is a helper method to define mock.On call
+//   - ctx context.Context
+//   - args ...string
+func (_e *GitExecutor_Expecter) Execute(ctx interface{}, args ...interface{}) *GitExecutor_Execute_Call {
+	return &GitExecutor_Execute_Call{Call: _e.mock.On("Execute",
+		append([]interface{}{ctx}, args...)...)}
+}
+
+func (_c *GitExecutor_Execute_Call) Run(run func(ctx context.Context, args ...string)) *GitExecutor_Execute_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]string, len(args)-1)
+		for i, a := range args[1:] {
+			if a != nil {
+				variadicArgs[i] = a.(string)
+			}
+		}
+		run(args[0].(context.Context), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *GitExecutor_Execute_Call) Return(_a0 string, _a1 error) *GitExecutor_Execute_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GitExecutor_Execute_Call) RunAndReturn(run func(context.Context, ...string) (string, error)) *GitExecutor_Execute_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetCurrentBranch provides a mock function with given fields: ctx
+func (_m *GitExecutor) GetCurrentBranch(ctx context.Context) (string, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCurrentBranch")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (string, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) string); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GitExecutor_GetCurrentBranch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetCurrentBranch'
+type GitExecutor_GetCurrentBranch_Call struct {
+	*mock.Call
+}
+
+// GetCurrentBranch is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *GitExecutor_Expecter) GetCurrentBranch(ctx interface{}) *GitExecutor_GetCurrentBranch_Call {
+	return &GitExecutor_GetCurrentBranch_Call{Call: _e.mock.On("GetCurrentBranch", ctx)}
+}
+
+func (_c *GitExecutor_GetCurrentBranch_Call) Run(run func(ctx context.Context)) *GitExecutor_GetCurrentBranch_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *GitExecutor_GetCurrentBranch_Call) Return(_a0 string, _a1 error) *GitExecutor_GetCurrentBranch_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GitExecutor_GetCurrentBranch_Call) RunAndReturn(run func(context.Context) (string, error)) *GitExecutor_GetCurrentBranch_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetDiff provides a mock function with given fields: ctx, staged, exclude
+func (_m *GitExecutor) GetDiff(ctx context.Context, staged bool, exclude ...string) (string, error) {
+	_va := make([]interface{}, len(exclude))
+	for _i := range exclude {
+		_va[_i] = exclude[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, staged)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetDiff")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, bool, ...string) (string, error)); ok {
+		return rf(ctx, staged, exclude...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, bool, ...string) string); ok {
+		r0 = rf(ctx, staged, exclude...)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, bool, ...string) error); ok {
+		r1 = rf(ctx, staged, exclude...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GitExecutor_GetDiff_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetDiff'
+type GitExecutor_GetDiff_Call struct {
+	*mock.Call
+}
+
+// GetDiff is a helper method to define mock.On call
+//   - ctx context.Context
+//   - staged bool
+//   - exclude ...string
+func (_e *GitExecutor_Expecter) GetDiff(ctx interface{}, staged interface{}, exclude ...interface{}) *GitExecutor_GetDiff_Call {
+	return &GitExecutor_GetDiff_Call{Call: _e.mock.On("GetDiff",
+		append([]interface{}{ctx, staged}, exclude...)...)}
+}
+
+func (_c *GitExecutor_GetDiff_Call) Run(run func(ctx context.Context, staged bool, exclude ...string)) *GitExecutor_GetDiff_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]string, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(string)
+			}
+		}
+		run(args[0].(context.Context), args[1].(bool), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *GitExecutor_GetDiff_Call) Return(_a0 string, _a1 error) *GitExecutor_GetDiff_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GitExecutor_GetDiff_Call) RunAndReturn(run func(context.Context, bool, ...string) (string, error)) *GitExecutor_GetDiff_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetDiffStat provides a mock function with given fields: ctx, staged
+func (_m *GitExecutor) GetDiffStat(ctx context.Context, staged bool) (string, error) {
+	ret := _m.Called(ctx, staged)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetDiffStat")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, bool) (string, error)); ok {
+		return rf(ctx, staged)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, bool) string); ok {
+		r0 = rf(ctx, staged)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, bool) error); ok {
+		r1 = rf(ctx, staged)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GitExecutor_GetDiffStat_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetDiffStat'
+type GitExecutor_GetDiffStat_Call struct {
+	*mock.Call
+}
+
+// GetDiffStat is a helper method to define mock.On call
+//   - ctx context.Context
+//   - staged bool
+func (_e *GitExecutor_Expecter) GetDiffStat(ctx interface{}, staged interface{}) *GitExecutor_GetDiffStat_Call {
+	return &GitExecutor_GetDiffStat_Call{Call: _e.mock.On("GetDiffStat", ctx, staged)}
+}
+
+func (_c *GitExecutor_GetDiffStat_Call) Run(run func(ctx context.Context, staged bool)) *GitExecutor_GetDiffStat_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(bool))
+	})
+	return _c
+}
+
+func (_c *GitExecutor_GetDiffStat_Call) Return(_a0 string, _a1 error) *GitExecutor_GetDiffStat_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GitExecutor_GetDiffStat_Call) RunAndReturn(run func(context.Context, bool) (string, error)) *GitExecutor_GetDiffStat_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetFileDiff provides a mock function with given fields: ctx, path, staged
+func (_m *GitExecutor) GetFileDiff(ctx context.Context, path string, staged bool) (*common.FileDiff, error) {
+	ret := _m.Called(ctx, path, staged)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetFileDiff")
+	}
+
+	var r0 *common.FileDiff
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) (*common.FileDiff, error)); ok {
+		return rf(ctx, path, staged)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) *common.FileDiff); ok {
+		r0 = rf(ctx, path, staged)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*common.FileDiff)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, bool) error); ok {
+		r1 = rf(ctx, path, staged)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GitExecutor_GetFileDiff_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetFileDiff'
+type GitExecutor_GetFileDiff_Call struct {
+	*mock.Call
+}
+
+// GetFileDiff is a helper method to define mock.On call
+//   - ctx context.Context
+//   - path string
+//   - staged bool
+func (_e *GitExecutor_Expecter) GetFileDiff(ctx interface{}, path interface{}, staged interface{}) *GitExecutor_GetFileDiff_Call {
+	return &GitExecutor_GetFileDiff_Call{Call: _e.mock.On("GetFileDiff", ctx, path, staged)}
+}
+
+func (_c *GitExecutor_GetFileDiff_Call) Run(run func(ctx context.Context, path string, staged bool)) *GitExecutor_GetFileDiff_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(bool))
+	})
+	return _c
+}
+
+func (_c *GitExecutor_GetFileDiff_Call) Return(_a0 *common.FileDiff, _a1 error) *GitExecutor_GetFileDiff_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GitExecutor_GetFileDiff_Call) RunAndReturn(run func(context.Context, string, bool) (*common.FileDiff, error)) *GitExecutor_GetFileDiff_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetRangeChanges provides a mock function with given fields: ctx, from, to
+func (_m *GitExecutor) GetRangeChanges(ctx context.Context, from string, to string) ([]common.FileChange, error) {
+	ret := _m.Called(ctx, from, to)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRangeChanges")
+	}
+
+	var r0 []common.FileChange
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) ([]common.FileChange, error)); ok {
+		return rf(ctx, from, to)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []common.FileChange); ok {
+		r0 = rf(ctx, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]common.FileChange)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GitExecutor_GetRangeChanges_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetRangeChanges'
+type GitExecutor_GetRangeChanges_Call struct {
+	*mock.Call
+}
+
+// GetRangeChanges is a helper method to define mock.On call
+//   - ctx context.Context
+//   - from string
+//   - to string
+func (_e *GitExecutor_Expecter) GetRangeChanges(ctx interface{}, from interface{}, to interface{}) *GitExecutor_GetRangeChanges_Call {
+	return &GitExecutor_GetRangeChanges_Call{Call: _e.mock.On("GetRangeChanges", ctx, from, to)}
+}
+
+func (_c *GitExecutor_GetRangeChanges_Call) Run(run func(ctx context.Context, from string, to string)) *GitExecutor_GetRangeChanges_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *GitExecutor_GetRangeChanges_Call) Return(_a0 []common.FileChange, _a1 error) *GitExecutor_GetRangeChanges_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GitExecutor_GetRangeChanges_Call) RunAndReturn(run func(context.Context, string, string) ([]common.FileChange, error)) *GitExecutor_GetRangeChanges_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetRangeDiff provides a mock function with given fields: ctx, from, to, exclude
+func (_m *GitExecutor) GetRangeDiff(ctx context.Context, from string, to string, exclude ...string) (string, error) {
+	_va := make([]interface{}, len(exclude))
+	for _i := range exclude {
+		_va[_i] = exclude[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, from, to)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRangeDiff")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, ...string) (string, error)); ok {
+		return rf(ctx, from, to, exclude...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, ...string) string); ok {
+		r0 = rf(ctx, from, to, exclude...)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, ...string) error); ok {
+		r1 = rf(ctx, from, to, exclude...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GitExecutor_GetRangeDiff_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetRangeDiff'
+type GitExecutor_GetRangeDiff_Call struct {
+	*mock.Call
+}
+
+// GetRangeDiff is a helper method to define mock.On call
+//   - ctx context.Context
+//   - from string
+//   - to string
+//   - exclude ...string
+func (_e *GitExecutor_Expecter) GetRangeDiff(ctx interface{}, from interface{}, to interface{}, exclude ...interface{}) *GitExecutor_GetRangeDiff_Call {
+	return &GitExecutor_GetRangeDiff_Call{Call: _e.mock.On("GetRangeDiff",
+		append([]interface{}{ctx, from, to}, exclude...)...)}
+}
+
+func (_c *GitExecutor_GetRangeDiff_Call) Run(run func(ctx context.Context, from string, to string, exclude ...string)) *GitExecutor_GetRangeDiff_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]string, len(args)-3)
+		for i, a := range args[3:] {
+			if a != nil {
+				variadicArgs[i] = a.(string)
+			}
+		}
+		run(args[0].(context.Context), args[1].(string), args[2].(string), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *GitExecutor_GetRangeDiff_Call) Return(_a0 string, _a1 error) *GitExecutor_GetRangeDiff_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GitExecutor_GetRangeDiff_Call) RunAndReturn(run func(context.Context, string, string, ...string) (string, error)) *GitExecutor_GetRangeDiff_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetRangeDiffStat provides a mock function with given fields: ctx, from, to
+func (_m *GitExecutor) GetRangeDiffStat(ctx context.Context, from string, to string) (string, error) {
+	ret := _m.Called(ctx, from, to)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRangeDiffStat")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (string, error)); ok {
+		return rf(ctx, from, to)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
+		r0 = rf(ctx, from, to)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GitExecutor_GetRangeDiffStat_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetRangeDiffStat'
+type GitExecutor_GetRangeDiffStat_Call struct {
+	*mock.Call
+}
+
+// GetRangeDiffStat is a helper method to define mock.On call
+//   - ctx context.Context
+//   - from string
+//   - to string
+func (_e *GitExecutor_Expecter) GetRangeDiffStat(ctx interface{}, from interface{}, to interface{}) *GitExecutor_GetRangeDiffStat_Call {
+	return &GitExecutor_GetRangeDiffStat_Call{Call: _e.mock.On("GetRangeDiffStat", ctx, from, to)}
+}
+
+func (_c *GitExecutor_GetRangeDiffStat_Call) Run(run func(ctx context.Context, from string, to string)) *GitExecutor_GetRangeDiffStat_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *GitExecutor_GetRangeDiffStat_Call) Return(_a0 string, _a1 error) *GitExecutor_GetRangeDiffStat_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GitExecutor_GetRangeDiffStat_Call) RunAndReturn(run func(context.Context, string, string) (string, error)) *GitExecutor_GetRangeDiffStat_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetStatus provides a mock function with given fields: ctx
+func (_m *GitExecutor) GetStatus(ctx context.Context) ([]common.FileChange, []common.FileChange, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetStatus")
+	}
+
+	var r0 []common.FileChange
+	var r1 []common.FileChange
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]common.FileChange, []common.FileChange, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []common.FileChange); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]common.FileChange)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) []common.FileChange); ok {
+		r1 = rf(ctx)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]common.FileChange)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context) error); ok {
+		r2 = rf(ctx)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GitExecutor_GetStatus_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetStatus'
+type GitExecutor_GetStatus_Call struct {
+	*mock.Call
+}
+
+// GetStatus is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *GitExecutor_Expecter) GetStatus(ctx interface{}) *GitExecutor_GetStatus_Call {
+	return &GitExecutor_GetStatus_Call{Call: _e.mock.On("GetStatus", ctx)}
+}
+
+func (_c *GitExecutor_GetStatus_Call) Run(run func(ctx context.Context)) *GitExecutor_GetStatus_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *GitExecutor_GetStatus_Call) Return(staged []common.FileChange, unstaged []common.FileChange, err error) *GitExecutor_GetStatus_Call {
+	_c.Call.Return(staged, unstaged, err)
+	return _c
+}
+
+func (_c *GitExecutor_GetStatus_Call) RunAndReturn(run func(context.Context) ([]common.FileChange, []common.FileChange, error)) *GitExecutor_GetStatus_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GitPath provides a mock function with given fields: ctx, name
+func (_m *GitExecutor) GitPath(ctx context.Context, name string) (string, error) {
+	ret := _m.Called(ctx, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GitPath")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+		return rf(ctx, name)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GitExecutor_GitPath_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GitPath'
+type GitExecutor_GitPath_Call struct {
+	*mock.Call
+}
+
+// GitPath is a helper method to define mock.On call
+//   - ctx context.Context
+//   - name string
+func (_e *GitExecutor_Expecter) GitPath(ctx interface{}, name interface{}) *GitExecutor_GitPath_Call {
+	return &GitExecutor_GitPath_Call{Call: _e.mock.On("GitPath", ctx, name)}
+}
+
+func (_c *GitExecutor_GitPath_Call) Run(run func(ctx context.Context, name string)) *GitExecutor_GitPath_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *GitExecutor_GitPath_Call) Return(_a0 string, _a1 error) *GitExecutor_GitPath_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GitExecutor_GitPath_Call) RunAndReturn(run func(context.Context, string) (string, error)) *GitExecutor_GitPath_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// IsGitRepository provides a mock function with given fields: ctx
+func (_m *GitExecutor) IsGitRepository(ctx context.Context) bool {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IsGitRepository")
+	}
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context) bool); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// GitExecutor_IsGitRepository_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IsGitRepository'
+type GitExecutor_IsGitRepository_Call struct {
+	*mock.Call
+}
+
+// IsGitRepository is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *GitExecutor_Expecter) IsGitRepository(ctx interface{}) *GitExecutor_IsGitRepository_Call {
+	return &GitExecutor_IsGitRepository_Call{Call: _e.mock.On("IsGitRepository", ctx)}
+}
+
+func (_c *GitExecutor_IsGitRepository_Call) Run(run func(ctx context.Context)) *GitExecutor_IsGitRepository_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *GitExecutor_IsGitRepository_Call) Return(_a0 bool) *GitExecutor_IsGitRepository_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *GitExecutor_IsGitRepository_Call) RunAndReturn(run func(context.Context) bool) *GitExecutor_IsGitRepository_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// LastTag provides a mock function with given fields: ctx, rev, match
+func (_m *GitExecutor) LastTag(ctx context.Context, rev string, match string) (string, error) {
+	ret := _m.Called(ctx, rev, match)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LastTag")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (string, error)); ok {
+		return rf(ctx, rev, match)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
+		r0 = rf(ctx, rev, match)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, rev, match)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GitExecutor_LastTag_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'LastTag'
+type GitExecutor_LastTag_Call struct {
+	*mock.Call
+}
+
+// LastTag is a helper method to define mock.On call
+//   - ctx context.Context
+//   - rev string
+//   - match string
+func (_e *GitExecutor_Expecter) LastTag(ctx interface{}, rev interface{}, match interface{}) *GitExecutor_LastTag_Call {
+	return &GitExecutor_LastTag_Call{Call: _e.mock.On("LastTag", ctx, rev, match)}
+}
+
+func (_c *GitExecutor_LastTag_Call) Run(run func(ctx context.Context, rev string, match string)) *GitExecutor_LastTag_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *GitExecutor_LastTag_Call) Return(_a0 string, _a1 error) *GitExecutor_LastTag_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GitExecutor_LastTag_Call) RunAndReturn(run func(context.Context, string, string) (string, error)) *GitExecutor_LastTag_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// MergeBase provides a mock function with given fields: ctx, a, b
+func (_m *GitExecutor) MergeBase(ctx context.Context, a string, b string) (string, error) {
+	ret := _m.Called(ctx, a, b)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MergeBase")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (string, error)); ok {
+		return rf(ctx, a, b)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
+		r0 = rf(ctx, a, b)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, a, b)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GitExecutor_MergeBase_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MergeBase'
+type GitExecutor_MergeBase_Call struct {
+	*mock.Call
+}
+
+// MergeBase is a helper method to define mock.On call
+//   - ctx context.Context
+//   - a string
+//   - b string
+func (_e *GitExecutor_Expecter) MergeBase(ctx interface{}, a interface{}, b interface{}) *GitExecutor_MergeBase_Call {
+	return &GitExecutor_MergeBase_Call{Call: _e.mock.On("MergeBase", ctx, a, b)}
+}
+
+func (_c *GitExecutor_MergeBase_Call) Run(run func(ctx context.Context, a string, b string)) *GitExecutor_MergeBase_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *GitExecutor_MergeBase_Call) Return(_a0 string, _a1 error) *GitExecutor_MergeBase_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GitExecutor_MergeBase_Call) RunAndReturn(run func(context.Context, string, string) (string, error)) *GitExecutor_MergeBase_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ParentOf provides a mock function with given fields: ctx, rev
+func (_m *GitExecutor) ParentOf(ctx context.Context, rev string) (string, error) {
+	ret := _m.Called(ctx, rev)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ParentOf")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+		return rf(ctx, rev)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+		r0 = rf(ctx, rev)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, rev)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GitExecutor_ParentOf_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ParentOf'
+type GitExecutor_ParentOf_Call struct {
+	*mock.Call
+}
+
+// ParentOf is a helper method to define mock.On call
+//   - ctx context.Context
+//   - rev string
+func (_e *GitExecutor_Expecter) ParentOf(ctx interface{}, rev interface{}) *GitExecutor_ParentOf_Call {
+	return &GitExecutor_ParentOf_Call{Call: _e.mock.On("ParentOf", ctx, rev)}
+}
+
+func (_c *GitExecutor_ParentOf_Call) Run(run func(ctx context.Context, rev string)) *GitExecutor_ParentOf_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *GitExecutor_ParentOf_Call) Return(_a0 string, _a1 error) *GitExecutor_ParentOf_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GitExecutor_ParentOf_Call) RunAndReturn(run func(context.Context, string) (string, error)) *GitExecutor_ParentOf_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Preview provides a mock function with given fields: ctx, commands
+func (_m *GitExecutor) Preview(ctx context.Context, commands [][]string) (*common.Preview, error) {
+	ret := _m.Called(ctx, commands)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Preview")
+	}
+
+	var r0 *common.Preview
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, [][]string) (*common.Preview, error)); ok {
+		return rf(ctx, commands)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, [][]string) *common.Preview); ok {
+		r0 = rf(ctx, commands)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*common.Preview)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, [][]string) error); ok {
+		r1 = rf(ctx, commands)
 	} else {
-		r0 = ret.Error(0)
+		r1 = ret.Error(1)
 	}
 
-	return r0
+	return r0, r1
 }
 
-// GitExecutor_Commit_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Commit'
-type GitExecutor_Commit_Call struct {
+// GitExecutor_Preview_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Preview'
+type GitExecutor_Preview_Call struct {
 	*mock.Call
 }
 
-// Commit is a helper method to define mock.On call
+// Preview is a helper method to define mock.On call
 //   - ctx context.Context
-//   - message string
-func (_e *GitExecutor_Expecter) Commit(ctx interface{}, message interface{}) *GitExecutor_Commit_Call {
-	return &GitExecutor_Commit_Call{Call: _e.mock.On("Commit", ctx, message)}
+//   - commands [][]string
+func (_e *GitExecutor_Expecter) Preview(ctx interface{}, commands interface{}) *GitExecutor_Preview_Call {
+	return &GitExecutor_Preview_Call{Call: _e.mock.On("Preview", ctx, commands)}
 }
 
-func (_c *GitExecutor_Commit_Call) Run(run func(ctx context.Context, message string)) *GitExecutor_Commit_Call {
+func (_c *GitExecutor_Preview_Call) Run(run func(ctx context.Context, commands [][]string)) *GitExecutor_Preview_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(string))
+		run(args[0].(context.Context), args[1].([][]string))
 	})
 	return _c
 }
 
-func (_c *GitExecutor_Commit_Call) Return(_a0 error) *GitExecutor_Commit_Call {
-	_c.Call.Return(_a0)
+func (_c *GitExecutor_Preview_Call) Return(_a0 *common.Preview, _a1 error) *GitExecutor_Preview_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *GitExecutor_Commit_Call) RunAndReturn(run func(context.Context, string) error) *GitExecutor_Commit_Call {
+func (_c *GitExecutor_Preview_Call) RunAndReturn(run func(context.Context, [][]string) (*common.Preview, error)) *GitExecutor_Preview_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// Execute provides a mock function with given fields: ctx, args
-func (_m *GitExecutor) Execute(ctx context.Context, args ...string) (string, error) {
-	_va := make([]interface{}, len(args))
-	for _i := range args {
-		_va[_i] = args[_i]
-	}
-	var _ca []interface{}
-	_ca = append(_ca, ctx)
-	_ca = append(_ca, _va...)
-	ret := _m.Called(_ca...)
+// RecentSubjects provides a mock function with given fields: ctx, n
+func (_m *GitExecutor) RecentSubjects(ctx context.Context, n int) ([]string, error) {
+	ret := _m.Called(ctx, n)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Execute")
+		panic("no return value specified for RecentSubjects")
 	}
 
-	var r0 string
+	var r0 []string
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, ...string) (string, error)); ok {
-		return rf(ctx, args...)
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]string, error)); ok {
+		return rf(ctx, n)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, ...string) string); ok {
-		r0 = rf(ctx, args...)
+	if rf, ok := ret.Get(0).(func(context.Context, int) []string); ok {
+		r0 = rf(ctx, n)
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, ...string) error); ok {
-		r1 = rf(ctx, args...)
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, n)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -105,63 +1457,58 @@ func (_m *GitExecutor) Execute(ctx context.Context, args ...string) (string, err
 	return r0, r1
 }
 
-// GitExecutor_Execute_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Execute'
-type GitExecutor_Execute_Call struct {
+// GitExecutor_RecentSubjects_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RecentSubjects'
+type GitExecutor_RecentSubjects_Call struct {
 	*mock.Call
 }
 
-// Execute is a helper method to define mock.On call
+// RecentSubjects is a helper method to define mock.On call
 //   - ctx context.Context
-//   - args ...string
-func (_e *GitExecutor_Expecter) Execute(ctx interface{}, args ...interface{}) *GitExecutor_Execute_Call {
-	return &GitExecutor_Execute_Call{Call: _e.mock.On("Execute",
-		append([]interface{}{ctx}, args...)...)}
+//   - n int
+func (_e *GitExecutor_Expecter) RecentSubjects(ctx interface{}, n interface{}) *GitExecutor_RecentSubjects_Call {
+	return &GitExecutor_RecentSubjects_Call{Call: _e.mock.On("RecentSubjects", ctx, n)}
 }
 
-func (_c *GitExecutor_Execute_Call) Run(run func(ctx context.Context, args ...string)) *GitExecutor_Execute_Call {
+func (_c *GitExecutor_RecentSubjects_Call) Run(run func(ctx context.Context, n int)) *GitExecutor_RecentSubjects_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		variadicArgs := make([]string, len(args)-1)
-		for i, a := range args[1:] {
-			if a != nil {
-				variadicArgs[i] = a.(string)
-			}
-		}
-		run(args[0].(context.Context), variadicArgs...)
+		run(args[0].(context.Context), args[1].(int))
 	})
 	return _c
 }
 
-func (_c *GitExecutor_Execute_Call) Return(_a0 string, _a1 error) *GitExecutor_Execute_Call {
+func (_c *GitExecutor_RecentSubjects_Call) Return(_a0 []string, _a1 error) *GitExecutor_RecentSubjects_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *GitExecutor_Execute_Call) RunAndReturn(run func(context.Context, ...string) (string, error)) *GitExecutor_Execute_Call {
+func (_c *GitExecutor_RecentSubjects_Call) RunAndReturn(run func(context.Context, int) ([]string, error)) *GitExecutor_RecentSubjects_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetDiff provides a mock function with given fields: ctx, staged
-func (_m *GitExecutor) GetDiff(ctx context.Context, staged bool) (string, error) {
-	ret := _m.Called(ctx, staged)
+// RemoteBranchesContaining provides a mock function with given fields: ctx, rev
+func (_m *GitExecutor) RemoteBranchesContaining(ctx context.Context, rev string) ([]string, error) {
+	ret := _m.Called(ctx, rev)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetDiff")
+		panic("no return value specified for RemoteBranchesContaining")
 	}
 
-	var r0 string
+	var r0 []string
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, bool) (string, error)); ok {
-		return rf(ctx, staged)
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]string, error)); ok {
+		return rf(ctx, rev)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, bool) string); ok {
-		r0 = rf(ctx, staged)
+	if rf, ok := ret.Get(0).(func(context.Context, string) []string); ok {
+		r0 = rf(ctx, rev)
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, bool) error); ok {
-		r1 = rf(ctx, staged)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, rev)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -169,144 +1516,135 @@ func (_m *GitExecutor) GetDiff(ctx context.Context, staged bool) (string, error)
 	return r0, r1
 }
 
-// GitExecutor_GetDiff_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetDiff'
-type GitExecutor_GetDiff_Call struct {
+// GitExecutor_RemoteBranchesContaining_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoteBranchesContaining'
+type GitExecutor_RemoteBranchesContaining_Call struct {
 	*mock.Call
 }
 
-// GetDiff is a helper method to define mock.On call
+// RemoteBranchesContaining is a helper method to define mock.On call
 //   - ctx context.Context
-//   - staged bool
-func (_e *GitExecutor_Expecter) GetDiff(ctx interface{}, staged interface{}) *GitExecutor_GetDiff_Call {
-	return &GitExecutor_GetDiff_Call{Call: _e.mock.On("GetDiff", ctx, staged)}
+//   - rev string
+func (_e *GitExecutor_Expecter) RemoteBranchesContaining(ctx interface{}, rev interface{}) *GitExecutor_RemoteBranchesContaining_Call {
+	return &GitExecutor_RemoteBranchesContaining_Call{Call: _e.mock.On("RemoteBranchesContaining", ctx, rev)}
 }
 
-func (_c *GitExecutor_GetDiff_Call) Run(run func(ctx context.Context, staged bool)) *GitExecutor_GetDiff_Call {
+func (_c *GitExecutor_RemoteBranchesContaining_Call) Run(run func(ctx context.Context, rev string)) *GitExecutor_RemoteBranchesContaining_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(bool))
+		run(args[0].(context.Context), args[1].(string))
 	})
 	return _c
 }
 
-func (_c *GitExecutor_GetDiff_Call) Return(_a0 string, _a1 error) *GitExecutor_GetDiff_Call {
+func (_c *GitExecutor_RemoteBranchesContaining_Call) Return(_a0 []string, _a1 error) *GitExecutor_RemoteBranchesContaining_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *GitExecutor_GetDiff_Call) RunAndReturn(run func(context.Context, bool) (string, error)) *GitExecutor_GetDiff_Call {
+func (_c *GitExecutor_RemoteBranchesContaining_Call) RunAndReturn(run func(context.Context, string) ([]string, error)) *GitExecutor_RemoteBranchesContaining_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetStatus provides a mock function with given fields: ctx
-func (_m *GitExecutor) GetStatus(ctx context.Context) ([]common.FileChange, []common.FileChange, error) {
-	ret := _m.Called(ctx)
+// Reword provides a mock function with given fields: ctx, rev, message
+func (_m *GitExecutor) Reword(ctx context.Context, rev string, message string) error {
+	ret := _m.Called(ctx, rev, message)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetStatus")
-	}
-
-	var r0 []common.FileChange
-	var r1 []common.FileChange
-	var r2 error
-	if rf, ok := ret.Get(0).(func(context.Context) ([]common.FileChange, []common.FileChange, error)); ok {
-		return rf(ctx)
-	}
-	if rf, ok := ret.Get(0).(func(context.Context) []common.FileChange); ok {
-		r0 = rf(ctx)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]common.FileChange)
-		}
-	}
-
-	if rf, ok := ret.Get(1).(func(context.Context) []common.FileChange); ok {
-		r1 = rf(ctx)
-	} else {
-		if ret.Get(1) != nil {
-			r1 = ret.Get(1).([]common.FileChange)
-		}
+		panic("no return value specified for Reword")
 	}
 
-	if rf, ok := ret.Get(2).(func(context.Context) error); ok {
-		r2 = rf(ctx)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, rev, message)
 	} else {
-		r2 = ret.Error(2)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1, r2
+	return r0
 }
 
-// GitExecutor_GetStatus_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetStatus'
-type GitExecutor_GetStatus_Call struct {
+// GitExecutor_Reword_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Reword'
+type GitExecutor_Reword_Call struct {
 	*mock.Call
 }
 
-// GetStatus is a helper method to define mock.On call
+// Reword is a helper method to define mock.On call
 //   - ctx context.Context
-func (_e *GitExecutor_Expecter) GetStatus(ctx interface{}) *GitExecutor_GetStatus_Call {
-	return &GitExecutor_GetStatus_Call{Call: _e.mock.On("GetStatus", ctx)}
+//   - rev string
+//   - message string
+func (_e *GitExecutor_Expecter) Reword(ctx interface{}, rev interface{}, message interface{}) *GitExecutor_Reword_Call {
+	return &GitExecutor_Reword_Call{Call: _e.mock.On("Reword", ctx, rev, message)}
 }
 
-func (_c *GitExecutor_GetStatus_Call) Run(run func(ctx context.Context)) *GitExecutor_GetStatus_Call {
+func (_c *GitExecutor_Reword_Call) Run(run func(ctx context.Context, rev string, message string)) *GitExecutor_Reword_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context))
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
 	})
 	return _c
 }
 
-func (_c *GitExecutor_GetStatus_Call) Return(staged []common.FileChange, unstaged []common.FileChange, err error) *GitExecutor_GetStatus_Call {
-	_c.Call.Return(staged, unstaged, err)
+func (_c *GitExecutor_Reword_Call) Return(_a0 error) *GitExecutor_Reword_Call {
+	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *GitExecutor_GetStatus_Call) RunAndReturn(run func(context.Context) ([]common.FileChange, []common.FileChange, error)) *GitExecutor_GetStatus_Call {
+func (_c *GitExecutor_Reword_Call) RunAndReturn(run func(context.Context, string, string) error) *GitExecutor_Reword_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// IsGitRepository provides a mock function with given fields: ctx
-func (_m *GitExecutor) IsGitRepository(ctx context.Context) bool {
+// Root provides a mock function with given fields: ctx
+func (_m *GitExecutor) Root(ctx context.Context) (string, error) {
 	ret := _m.Called(ctx)
 
 	if len(ret) == 0 {
-		panic("no return value specified for IsGitRepository")
+		panic("no return value specified for Root")
 	}
 
-	var r0 bool
-	if rf, ok := ret.Get(0).(func(context.Context) bool); ok {
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (string, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) string); ok {
 		r0 = rf(ctx)
 	} else {
-		r0 = ret.Get(0).(bool)
+		r0 = ret.Get(0).(string)
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// GitExecutor_IsGitRepository_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IsGitRepository'
-type GitExecutor_IsGitRepository_Call struct {
+// GitExecutor_Root_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Root'
+type GitExecutor_Root_Call struct {
 	*mock.Call
 }
 
-// IsGitRepository is a helper method to define mock.On call
+// Root is a helper method to define mock.On call
 //   - ctx context.Context
-func (_e *GitExecutor_Expecter) IsGitRepository(ctx interface{}) *GitExecutor_IsGitRepository_Call {
-	return &GitExecutor_IsGitRepository_Call{Call: _e.mock.On("IsGitRepository", ctx)}
+func (_e *GitExecutor_Expecter) Root(ctx interface{}) *GitExecutor_Root_Call {
+	return &GitExecutor_Root_Call{Call: _e.mock.On("Root", ctx)}
 }
 
-func (_c *GitExecutor_IsGitRepository_Call) Run(run func(ctx context.Context)) *GitExecutor_IsGitRepository_Call {
+func (_c *GitExecutor_Root_Call) Run(run func(ctx context.Context)) *GitExecutor_Root_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run(args[0].(context.Context))
 	})
 	return _c
 }
 
-func (_c *GitExecutor_IsGitRepository_Call) Return(_a0 bool) *GitExecutor_IsGitRepository_Call {
-	_c.Call.Return(_a0)
+func (_c *GitExecutor_Root_Call) Return(_a0 string, _a1 error) *GitExecutor_Root_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *GitExecutor_IsGitRepository_Call) RunAndReturn(run func(context.Context) bool) *GitExecutor_IsGitRepository_Call {
+func (_c *GitExecutor_Root_Call) RunAndReturn(run func(context.Context) (string, error)) *GitExecutor_Root_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -404,6 +1742,157 @@ func (_c *GitExecutor_StageFiles_Call) RunAndReturn(run func(context.Context, []
 	return _c
 }
 
+// StageHunks provides a mock function with given fields: ctx, diff, hunks
+func (_m *GitExecutor) StageHunks(ctx context.Context, diff *common.FileDiff, hunks []int) error {
+	ret := _m.Called(ctx, diff, hunks)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StageHunks")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *common.FileDiff, []int) error); ok {
+		r0 = rf(ctx, diff, hunks)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GitExecutor_StageHunks_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StageHunks'
+type GitExecutor_StageHunks_Call struct {
+	*mock.Call
+}
+
+// StageHunks is a helper method to define mock.On call
+//   - ctx context.Context
+//   - diff *common.FileDiff
+//   - hunks []int
+func (_e *GitExecutor_Expecter) StageHunks(ctx interface{}, diff interface{}, hunks interface{}) *GitExecutor_StageHunks_Call {
+	return &GitExecutor_StageHunks_Call{Call: _e.mock.On("StageHunks", ctx, diff, hunks)}
+}
+
+func (_c *GitExecutor_StageHunks_Call) Run(run func(ctx context.Context, diff *common.FileDiff, hunks []int)) *GitExecutor_StageHunks_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*common.FileDiff), args[2].([]int))
+	})
+	return _c
+}
+
+func (_c *GitExecutor_StageHunks_Call) Return(_a0 error) *GitExecutor_StageHunks_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *GitExecutor_StageHunks_Call) RunAndReturn(run func(context.Context, *common.FileDiff, []int) error) *GitExecutor_StageHunks_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// StageTracked provides a mock function with given fields: ctx
+func (_m *GitExecutor) StageTracked(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StageTracked")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GitExecutor_StageTracked_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StageTracked'
+type GitExecutor_StageTracked_Call struct {
+	*mock.Call
+}
+
+// StageTracked is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *GitExecutor_Expecter) StageTracked(ctx interface{}) *GitExecutor_StageTracked_Call {
+	return &GitExecutor_StageTracked_Call{Call: _e.mock.On("StageTracked", ctx)}
+}
+
+func (_c *GitExecutor_StageTracked_Call) Run(run func(ctx context.Context)) *GitExecutor_StageTracked_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *GitExecutor_StageTracked_Call) Return(_a0 error) *GitExecutor_StageTracked_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *GitExecutor_StageTracked_Call) RunAndReturn(run func(context.Context) error) *GitExecutor_StageTracked_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// TagDate provides a mock function with given fields: ctx, name
+func (_m *GitExecutor) TagDate(ctx context.Context, name string) (string, error) {
+	ret := _m.Called(ctx, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TagDate")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+		return rf(ctx, name)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GitExecutor_TagDate_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'TagDate'
+type GitExecutor_TagDate_Call struct {
+	*mock.Call
+}
+
+// TagDate is a helper method to define mock.On call
+//   - ctx context.Context
+//   - name string
+func (_e *GitExecutor_Expecter) TagDate(ctx interface{}, name interface{}) *GitExecutor_TagDate_Call {
+	return &GitExecutor_TagDate_Call{Call: _e.mock.On("TagDate", ctx, name)}
+}
+
+func (_c *GitExecutor_TagDate_Call) Run(run func(ctx context.Context, name string)) *GitExecutor_TagDate_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *GitExecutor_TagDate_Call) Return(_a0 string, _a1 error) *GitExecutor_TagDate_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GitExecutor_TagDate_Call) RunAndReturn(run func(context.Context, string) (string, error)) *GitExecutor_TagDate_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewGitExecutor creates a new instance of GitExecutor. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewGitExecutor(t interface {
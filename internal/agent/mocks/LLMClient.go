@@ -5,6 +5,8 @@ package mocks
 import (
 	context "context"
 
+	common "github.com/go-coders/git_gpt/internal/common"
+
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -21,6 +23,51 @@ func (_m *LLMClient) EXPECT() *LLMClient_Expecter {
 	return &LLMClient_Expecter{mock: &_m.Mock}
 }
 
+// AvailableTokens provides a mock function with given fields:
+func (_m *LLMClient) AvailableTokens() int {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for AvailableTokens")
+	}
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func() int); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	return r0
+}
+
+// LLMClient_AvailableTokens_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AvailableTokens'
+type LLMClient_AvailableTokens_Call struct {
+	*mock.Call
+}
+
+// AvailableTokens is a helper method to define mock.On call
+func (_e *LLMClient_Expecter) AvailableTokens() *LLMClient_AvailableTokens_Call {
+	return &LLMClient_AvailableTokens_Call{Call: _e.mock.On("AvailableTokens")}
+}
+
+func (_c *LLMClient_AvailableTokens_Call) Run(run func()) *LLMClient_AvailableTokens_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *LLMClient_AvailableTokens_Call) Return(_a0 int) *LLMClient_AvailableTokens_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *LLMClient_AvailableTokens_Call) RunAndReturn(run func() int) *LLMClient_AvailableTokens_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Chat provides a mock function with given fields: ctx, content
 func (_m *LLMClient) Chat(ctx context.Context, content string) (string, error) {
 	ret := _m.Called(ctx, content)
@@ -78,6 +125,124 @@ func (_c *LLMClient_Chat_Call) RunAndReturn(run func(context.Context, string) (s
 	return _c
 }
 
+// ChatStream provides a mock function with given fields: ctx, content, onToken
+func (_m *LLMClient) ChatStream(ctx context.Context, content string, onToken func(string)) (string, error) {
+	ret := _m.Called(ctx, content, onToken)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChatStream")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, func(string)) (string, error)); ok {
+		return rf(ctx, content, onToken)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, func(string)) string); ok {
+		r0 = rf(ctx, content, onToken)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, func(string)) error); ok {
+		r1 = rf(ctx, content, onToken)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LLMClient_ChatStream_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ChatStream'
+type LLMClient_ChatStream_Call struct {
+	*mock.Call
+}
+
+// ChatStream is a helper method to define mock.On call
+//   - ctx context.Context
+//   - content string
+//   - onToken func(string)
+func (_e *LLMClient_Expecter) ChatStream(ctx interface{}, content interface{}, onToken interface{}) *LLMClient_ChatStream_Call {
+	return &LLMClient_ChatStream_Call{Call: _e.mock.On("ChatStream", ctx, content, onToken)}
+}
+
+func (_c *LLMClient_ChatStream_Call) Run(run func(ctx context.Context, content string, onToken func(string))) *LLMClient_ChatStream_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(func(string)))
+	})
+	return _c
+}
+
+func (_c *LLMClient_ChatStream_Call) Return(_a0 string, _a1 error) *LLMClient_ChatStream_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *LLMClient_ChatStream_Call) RunAndReturn(run func(context.Context, string, func(string)) (string, error)) *LLMClient_ChatStream_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ChatWithTools provides a mock function with given fields: ctx, content, tools
+func (_m *LLMClient) ChatWithTools(ctx context.Context, content string, tools []common.Tool) (*common.ToolCall, error) {
+	ret := _m.Called(ctx, content, tools)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChatWithTools")
+	}
+
+	var r0 *common.ToolCall
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []common.Tool) (*common.ToolCall, error)); ok {
+		return rf(ctx, content, tools)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, []common.Tool) *common.ToolCall); ok {
+		r0 = rf(ctx, content, tools)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*common.ToolCall)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, []common.Tool) error); ok {
+		r1 = rf(ctx, content, tools)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LLMClient_ChatWithTools_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ChatWithTools'
+type LLMClient_ChatWithTools_Call struct {
+	*mock.Call
+}
+
+// ChatWithTools is a helper method to define mock.On call
+//   - ctx context.Context
+//   - content string
+//   - tools []common.Tool
+func (_e *LLMClient_Expecter) ChatWithTools(ctx interface{}, content interface{}, tools interface{}) *LLMClient_ChatWithTools_Call {
+	return &LLMClient_ChatWithTools_Call{Call: _e.mock.On("ChatWithTools", ctx, content, tools)}
+}
+
+func (_c *LLMClient_ChatWithTools_Call) Run(run func(ctx context.Context, content string, tools []common.Tool)) *LLMClient_ChatWithTools_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].([]common.Tool))
+	})
+	return _c
+}
+
+func (_c *LLMClient_ChatWithTools_Call) Return(_a0 *common.ToolCall, _a1 error) *LLMClient_ChatWithTools_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *LLMClient_ChatWithTools_Call) RunAndReturn(run func(context.Context, string, []common.Tool) (*common.ToolCall, error)) *LLMClient_ChatWithTools_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // ClearHistory provides a mock function with given fields:
 func (_m *LLMClient) ClearHistory() {
 	_m.Called()
@@ -110,6 +275,52 @@ func (_c *LLMClient_ClearHistory_Call) RunAndReturn(run func()) *LLMClient_Clear
 	return _c
 }
 
+// CountTokens provides a mock function with given fields: content
+func (_m *LLMClient) CountTokens(content string) int {
+	ret := _m.Called(content)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountTokens")
+	}
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(string) int); ok {
+		r0 = rf(content)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	return r0
+}
+
+// LLMClient_CountTokens_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CountTokens'
+type LLMClient_CountTokens_Call struct {
+	*mock.Call
+}
+
+// CountTokens is a helper method to define mock.On call
+//   - content string
+func (_e *LLMClient_Expecter) CountTokens(content interface{}) *LLMClient_CountTokens_Call {
+	return &LLMClient_CountTokens_Call{Call: _e.mock.On("CountTokens", content)}
+}
+
+func (_c *LLMClient_CountTokens_Call) Run(run func(content string)) *LLMClient_CountTokens_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *LLMClient_CountTokens_Call) Return(_a0 int) *LLMClient_CountTokens_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *LLMClient_CountTokens_Call) RunAndReturn(run func(string) int) *LLMClient_CountTokens_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // SetSystemMessage provides a mock function with given fields: message
 func (_m *LLMClient) SetSystemMessage(message string) {
 	_m.Called(message)
@@ -143,6 +354,51 @@ func (_c *LLMClient_SetSystemMessage_Call) RunAndReturn(run func(string)) *LLMCl
 	return _c
 }
 
+// SupportsTools provides a mock function with given fields:
+func (_m *LLMClient) SupportsTools() bool {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for SupportsTools")
+	}
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func() bool); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// LLMClient_SupportsTools_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SupportsTools'
+type LLMClient_SupportsTools_Call struct {
+	*mock.Call
+}
+
+// SupportsTools is a helper method to define mock.On call
+func (_e *LLMClient_Expecter) SupportsTools() *LLMClient_SupportsTools_Call {
+	return &LLMClient_SupportsTools_Call{Call: _e.mock.On("SupportsTools")}
+}
+
+func (_c *LLMClient_SupportsTools_Call) Run(run func()) *LLMClient_SupportsTools_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *LLMClient_SupportsTools_Call) Return(_a0 bool) *LLMClient_SupportsTools_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *LLMClient_SupportsTools_Call) RunAndReturn(run func() bool) *LLMClient_SupportsTools_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewLLMClient creates a new instance of LLMClient. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewLLMClient(t interface {
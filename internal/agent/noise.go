@@ -0,0 +1,101 @@
+package agent
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"github.com/go-coders/git_gpt/internal/common"
+)
+
+// Reasons a file's diff is left out of the commit prompt
+const (
+	omitIgnored   = "ignored"
+	omitGenerated = "generated"
+	omitVendored  = "vendored"
+	omitNoDiff    = "-diff"
+	omitBinary    = "binary"
+)
+
+// markOmittedDiffs sets DiffOmitted on the files whose diffs would only be
+// noise in the prompt: binary files, files matching the ignore globs, and
+// files marked linguist-generated, linguist-vendored or -diff in
+// .gitattributes. It returns the paths to leave out of the diff.
+func (a *CommitAgent) markOmittedDiffs(ctx context.Context, files []common.FileChange) []string {
+	paths := make([]string, len(files))
+	for i, file := range files {
+		paths[i] = file.Path
+	}
+
+	// Attributes are best effort; without them the diffs are simply sent
+	attrs, err := a.git.CheckAttributes(ctx, paths, "linguist-generated", "linguist-vendored", "diff")
+	if err != nil {
+		a.logger.Debug("Failed to check attributes: %v", err)
+	}
+
+	var omitted []string
+	for i := range files {
+		files[i].DiffOmitted = a.omitReason(files[i], attrs[files[i].Path])
+		if files[i].DiffOmitted != "" {
+			omitted = append(omitted, files[i].Path)
+		}
+	}
+	return omitted
+}
+
+func (a *CommitAgent) omitReason(file common.FileChange, attrs map[string]string) string {
+	switch {
+	case file.Binary:
+		return omitBinary
+	case attrIsSet(attrs["linguist-generated"]):
+		return omitGenerated
+	case attrIsSet(attrs["linguist-vendored"]):
+		return omitVendored
+	case attrs["diff"] == "unset":
+		return omitNoDiff
+	}
+	for _, glob := range a.diffIgnore {
+		if matchGlob(glob, file.Path) {
+			return omitIgnored
+		}
+	}
+	return ""
+}
+
+// attrIsSet reports whether a gitattribute is set, either bare or as "true"
+func attrIsSet(value string) bool {
+	return value == "set" || value == "true"
+}
+
+// matchGlob matches a slash separated path against a glob in the style of
+// .gitignore: a pattern without a slash matches the base name at any depth,
+// and a "**" segment matches any number of directories.
+func matchGlob(pattern, name string) bool {
+	pattern = strings.TrimPrefix(pattern, "/")
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(name))
+		return ok
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
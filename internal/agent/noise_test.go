@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-coders/git_gpt/internal/agent/mocks"
+	"github.com/go-coders/git_gpt/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMatchGlob(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{"go.sum", "go.sum", true},
+		{"go.sum", "tools/go.sum", true},
+		{"*.min.js", "web/static/app.min.js", true},
+		{"*.min.js", "web/static/app.js", false},
+		{"vendor/**", "vendor/github.com/x/y.go", true},
+		{"vendor/**", "internal/vendor/y.go", false},
+		{"/vendor/**", "vendor/y.go", true},
+		{"**/testdata/*.golden", "internal/git/testdata/a.golden", true},
+		{"**/testdata/*.golden", "testdata/a.golden", true},
+		{"docs/*.md", "docs/api/x.md", false},
+		{"docs/**/*.md", "docs/api/x.md", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.pattern+" "+tc.path, func(t *testing.T) {
+			assert.Equal(t, tc.match, matchGlob(tc.pattern, tc.path))
+		})
+	}
+}
+
+func (s *CommitAgentTestSuite) TestGenerateCommitSuggestions_OmitsNoisyDiffs() {
+	git := new(mocks.GitExecutor)
+	withoutHistory(git)
+	s.agent.git = git
+	s.agent.diffIgnore = []string{"go.sum", "vendor/**"}
+
+	files := []common.FileChange{
+		{Path: "main.go", Status: "modified", Additions: 3, Deletions: 1},
+		{Path: "go.sum", Status: "modified", Additions: 40, Deletions: 2},
+		{Path: "api/api.pb.go", Status: "modified", Additions: 900, Deletions: 850},
+		{Path: "assets/logo.png", Status: "added", Binary: true},
+		{Path: "data/dump.sql", Status: "modified", Additions: 5, Deletions: 5},
+	}
+	paths := []string{"main.go", "go.sum", "api/api.pb.go", "assets/logo.png", "data/dump.sql"}
+
+	git.On("CheckAttributes", s.ctx, paths, "linguist-generated", "linguist-vendored", "diff").Return(map[string]map[string]string{
+		"main.go":       {"linguist-generated": "unspecified", "diff": "unspecified"},
+		"api/api.pb.go": {"linguist-generated": "set"},
+		"data/dump.sql": {"diff": "unset"},
+	}, nil).Once()
+	git.On("GetDiff", s.ctx, true, "go.sum", "api/api.pb.go", "assets/logo.png", "data/dump.sql").
+		Return("diff --git a/main.go b/main.go\n+fmt.Println()\n", nil).Once()
+
+	s.llm.On("Chat", s.ctx, mock.MatchedBy(func(prompt string) bool {
+		return strings.Contains(prompt, "- modified: main.go (3+/1-)\n") &&
+			strings.Contains(prompt, "- modified: go.sum (40+/2-) [diff omitted: ignored]") &&
+			strings.Contains(prompt, "- modified: api/api.pb.go (900+/850-) [diff omitted: generated]") &&
+			strings.Contains(prompt, "- added: assets/logo.png (binary) [diff omitted: binary]") &&
+			strings.Contains(prompt, "- modified: data/dump.sql (5+/5-) [diff omitted: -diff]")
+	})).Return(`{"suggestions": [{"message": "feat: print"}]}`, nil).Once()
+
+	_, err := s.agent.generateCommitSuggestions(s.ctx, files)
+	s.Require().NoError(err)
+	git.AssertExpectations(s.T())
+
+	s.display.On("ShowSection", "Staged Files", "", mock.Anything).Return()
+	s.display.On("ShowNumberedList", [][2]string{
+		{"📝 main.go", "(3+/1-)"},
+		{"📝 go.sum", "(40+/2-) summarized: ignored"},
+		{"📝 api/api.pb.go", "(900+/850-) summarized: generated"},
+		{"➕ assets/logo.png", "(binary)"},
+		{"📝 data/dump.sql", "(5+/5-) summarized: -diff"},
+	}).Return().Once()
+	s.agent.displayStagedChanges(files)
+	s.display.AssertExpectations(s.T())
+}
+
+func (s *CommitAgentTestSuite) TestMarkOmittedDiffs_AttributesUnavailable() {
+	git := new(mocks.GitExecutor)
+	s.agent.git = git
+	s.agent.diffIgnore = []string{"*.lock"}
+
+	git.On("CheckAttributes", s.ctx, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, errors.New("check-attr failed")).Once()
+
+	files := []common.FileChange{{Path: "Cargo.lock"}, {Path: "src/main.rs"}}
+	omitted := s.agent.markOmittedDiffs(s.ctx, files)
+	s.Assert().Equal([]string{"Cargo.lock"}, omitted)
+	s.Assert().Equal("ignored", files[0].DiffOmitted)
+	s.Assert().Empty(files[1].DiffOmitted)
+}
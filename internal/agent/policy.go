@@ -0,0 +1,669 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// Risk levels assigned to git commands by the local policy
+const (
+	RiskReadOnly    = "read-only"
+	RiskMutating    = "mutating"
+	RiskNetwork     = "network"
+	RiskDestructive = "destructive"
+)
+
+var ErrCommandDenied = errors.New("command denied by policy")
+
+// riskRank orders the risk levels from least to most severe
+var riskRank = map[string]int{
+	RiskReadOnly:    0,
+	RiskMutating:    1,
+	RiskNetwork:     2,
+	RiskDestructive: 3,
+}
+
+// Policy decides how generated git commands may run, whatever type the model
+// gave them. Entries are git command lines without the leading "git", such as
+// "push --force" or "stash list". The first word must match the subcommand
+// and every further word must match one of its arguments; words may use
+// path.Match wildcards ("push --force*", "* --output*").
+type Policy struct {
+	// Allow lists commands that run without confirmation
+	Allow []string `json:"allow"`
+	// Deny lists commands that never run; deny wins over allow
+	Deny []string `json:"deny"`
+}
+
+// Decision is the verdict of a Policy for one command
+type Decision struct {
+	Risk    string
+	Allowed bool
+	Denied  bool
+	Rule    string // the policy entry that matched, if any
+}
+
+// gitInvocation is a git command line split around its subcommand
+type gitInvocation struct {
+	global     []string
+	subcommand string
+	args       []string
+}
+
+// Global options that take their value as the next argument
+var globalValueOptions = map[string]bool{
+	"-C": true, "-c": true, "--git-dir": true, "--work-tree": true,
+	"--namespace": true, "--config-env": true,
+}
+
+var readOnlyCommands = map[string]bool{
+	"annotate": true, "blame": true, "cat-file": true, "check-attr": true,
+	"check-ignore": true, "check-ref-format": true, "cherry": true,
+	"count-objects": true, "describe": true, "diff": true, "diff-files": true,
+	"diff-index": true, "diff-tree": true, "for-each-ref": true, "fsck": true,
+	"help": true, "log": true, "ls-files": true, "ls-tree": true,
+	"merge-base": true, "name-rev": true, "range-diff": true, "rev-list": true,
+	"rev-parse": true, "shortlog": true, "show": true, "show-branch": true,
+	"show-ref": true, "status": true, "var": true, "verify-commit": true,
+	"verify-tag": true, "version": true, "whatchanged": true,
+}
+
+var networkCommands = map[string]bool{
+	"clone": true, "fetch": true, "ls-remote": true, "pull": true,
+}
+
+var destructiveCommands = map[string]bool{
+	"filter-branch": true, "filter-repo": true, "prune": true,
+}
+
+// subcommandClassifiers handle subcommands whose risk depends on their arguments
+var subcommandClassifiers = map[string]func(args []string) string{
+	"add":             classifyAdd,
+	"apply":           classifyApply,
+	"bisect":          classifyBisect,
+	"branch":          classifyBranch,
+	"checkout":        classifyCheckout,
+	"clean":           classifyClean,
+	"config":          classifyConfig,
+	"gc":              classifyGC,
+	"grep":            classifyGrep,
+	"notes":           classifyNotes,
+	"push":            classifyPush,
+	"reflog":          classifyReflog,
+	"remote":          classifyRemote,
+	"reset":           classifyReset,
+	"restore":         classifyRestore,
+	"rm":              classifyRm,
+	"sparse-checkout": classifySparseCheckout,
+	"stash":           classifyStash,
+	"submodule":       classifySubmodule,
+	"switch":          classifySwitch,
+	"tag":             classifyTag,
+	"update-ref":      classifyUpdateRef,
+	"worktree":        classifyWorktree,
+}
+
+// DefaultPolicy returns the policy used when no policy file exists. It adds
+// nothing to the built-in classification.
+func DefaultPolicy() *Policy {
+	return &Policy{
+		Allow: []string{},
+		Deny:  []string{},
+	}
+}
+
+// LoadPolicy reads a policy file, falling back to DefaultPolicy when the file
+// does not exist
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return DefaultPolicy(), nil
+		}
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+
+	policy := DefaultPolicy()
+	if err := json.Unmarshal(data, policy); err != nil {
+		return nil, fmt.Errorf("invalid policy file format: %w", err)
+	}
+	return policy, nil
+}
+
+// Evaluate classifies a git command line and applies the allow and deny lists
+func (p *Policy) Evaluate(args []string) Decision {
+	decision := Decision{Risk: Classify(args)}
+	inv := parseInvocation(args)
+
+	for _, rule := range p.Deny {
+		if rule = strings.TrimSpace(rule); rule != "" && inv.matches(rule) {
+			decision.Denied = true
+			decision.Rule = rule
+			return decision
+		}
+	}
+
+	for _, rule := range p.Allow {
+		if rule = strings.TrimSpace(rule); rule != "" && inv.matches(rule) {
+			decision.Allowed = true
+			decision.Rule = rule
+			return decision
+		}
+	}
+	return decision
+}
+
+// Classify returns the risk of running git with args. Unknown subcommands are
+// treated as mutating.
+func Classify(args []string) string {
+	inv := parseInvocation(args)
+	if inv.subcommand == "" {
+		return RiskMutating
+	}
+
+	risk := classifySubcommand(inv.subcommand, inv.args)
+
+	// Configuration overrides such as core.fsmonitor or core.sshCommand can
+	// run arbitrary programs, even for read-only subcommands
+	for _, opt := range inv.global {
+		if opt == "-c" || opt == "--config-env" || strings.HasPrefix(opt, "--config-env=") || strings.HasPrefix(opt, "--exec-path") {
+			risk = maxRisk(risk, RiskDestructive)
+		}
+	}
+
+	// Options that make git run a different program on the other end
+	if hasFlag(inv.args, "--upload-pack", "--receive-pack", "--exec") {
+		risk = maxRisk(risk, RiskDestructive)
+	}
+
+	// Writing output files changes the working tree
+	if hasFlag(inv.args, "--output", "--output-directory") {
+		risk = maxRisk(risk, RiskMutating)
+	}
+
+	return risk
+}
+
+// previewGlobalOptions are the global options a preview may use. The others
+// point git at other directories or configuration.
+var previewGlobalOptions = map[string]bool{
+	"--no-pager": true, "--no-replace-objects": true, "--no-optional-locks": true,
+	"--literal-pathspecs": true, "--glob-pathspecs": true, "--noglob-pathspecs": true,
+	"--icase-pathspecs": true,
+}
+
+// checkPreviewable returns why git with args must not run in a preview copy.
+// Whatever a command does inside the copy is thrown away, so only commands
+// that could reach outside it are refused: anything that talks to remotes,
+// runs programs, or writes configuration or files elsewhere.
+func checkPreviewable(args []string) error {
+	inv := parseInvocation(args)
+	for _, opt := range inv.global {
+		if !previewGlobalOptions[opt] {
+			return fmt.Errorf("the global option %s is not previewed", opt)
+		}
+	}
+
+	// Destructive changes are fine in the copy, but its origin is the real
+	// repository, so even a forced push is refused as a network command
+	if Classify(args) == RiskNetwork || networkCommands[inv.subcommand] || inv.subcommand == "push" {
+		return fmt.Errorf("%s commands are not previewed", RiskNetwork)
+	}
+	if runsProgram(inv) {
+		return fmt.Errorf("commands that run other programs are not previewed")
+	}
+
+	if hasFlag(inv.args, "--output", "--output-directory") {
+		return fmt.Errorf("commands that write output files are not previewed")
+	}
+	switch inv.subcommand {
+	case "config":
+		if hasFlag(inv.args, "--global", "--system", "-f", "--file") {
+			return fmt.Errorf("configuration outside the repository is not previewed")
+		}
+	case "worktree":
+		if verb := subverb(inv.args); verb != "list" && verb != "lock" && verb != "unlock" {
+			return fmt.Errorf("worktree %s is not previewed", verb)
+		}
+	case "init":
+		return fmt.Errorf("init is not previewed")
+	}
+	return nil
+}
+
+// runsProgram reports whether the invocation makes git run a program other
+// than itself, which could reach outside a preview copy
+func runsProgram(inv gitInvocation) bool {
+	if hasFlag(inv.args, "--upload-pack", "--receive-pack", "--exec") {
+		return true
+	}
+	switch inv.subcommand {
+	case "filter-branch", "filter-repo":
+		return true
+	case "rebase":
+		return hasFlag(inv.args, "-x")
+	case "submodule":
+		return subverb(inv.args) == "foreach"
+	case "bisect":
+		return subverb(inv.args) == "run"
+	case "grep":
+		return classifyGrep(inv.args) == RiskDestructive
+	}
+	return false
+}
+
+func classifySubcommand(subcommand string, args []string) string {
+	if classify, ok := subcommandClassifiers[subcommand]; ok {
+		return classify(args)
+	}
+
+	switch {
+	case readOnlyCommands[subcommand]:
+		return RiskReadOnly
+	case networkCommands[subcommand]:
+		return RiskNetwork
+	case destructiveCommands[subcommand]:
+		return RiskDestructive
+	default:
+		return RiskMutating
+	}
+}
+
+func parseInvocation(args []string) gitInvocation {
+	var inv gitInvocation
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			inv.subcommand = arg
+			inv.args = args[i+1:]
+			return inv
+		}
+
+		inv.global = append(inv.global, arg)
+		if globalValueOptions[arg] && i+1 < len(args) {
+			i++
+		}
+	}
+	return inv
+}
+
+// matches reports whether a policy entry applies to the invocation
+func (inv gitInvocation) matches(rule string) bool {
+	words := strings.Fields(rule)
+	if len(words) == 0 || !matchWord(words[0], inv.subcommand) {
+		return false
+	}
+
+	for _, word := range words[1:] {
+		found := false
+		for _, arg := range inv.args {
+			if matchWord(word, arg) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func matchWord(pattern, word string) bool {
+	ok, err := path.Match(pattern, word)
+	return err == nil && ok
+}
+
+func maxRisk(a, b string) string {
+	if riskRank[b] > riskRank[a] {
+		return b
+	}
+	return a
+}
+
+// hasFlag reports whether args contain one of flags before a "--" separator.
+// Long flags also match their "--flag=value" form and single letter flags
+// match inside combined short options such as "-fd".
+func hasFlag(args []string, flags ...string) bool {
+	for _, arg := range args {
+		if arg == "--" {
+			return false
+		}
+		for _, flag := range flags {
+			switch {
+			case arg == flag:
+				return true
+			case strings.HasPrefix(flag, "--") && strings.HasPrefix(arg, flag+"="):
+				return true
+			case len(flag) == 2 && flag[0] == '-' && flag[1] != '-' &&
+				len(arg) > 2 && arg[0] == '-' && arg[1] != '-' && strings.ContainsRune(arg[1:], rune(flag[1])):
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// positionals returns the arguments that are not options, skipping the
+// values of valueOptions
+func positionals(args []string, valueOptions ...string) []string {
+	var result []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			return append(result, args[i+1:]...)
+		case strings.HasPrefix(arg, "-"):
+			for _, opt := range valueOptions {
+				if arg == opt {
+					i++
+					break
+				}
+			}
+		default:
+			result = append(result, arg)
+		}
+	}
+	return result
+}
+
+// subverb returns the first positional argument, used by commands like
+// "git stash list"
+func subverb(args []string) string {
+	if rest := positionals(args); len(rest) > 0 {
+		return rest[0]
+	}
+	return ""
+}
+
+func classifyAdd(args []string) string {
+	if hasFlag(args, "-n", "--dry-run") {
+		return RiskReadOnly
+	}
+	return RiskMutating
+}
+
+func classifyApply(args []string) string {
+	if hasFlag(args, "--check", "--stat", "--numstat", "--summary") && !hasFlag(args, "--apply", "--index", "--cached") {
+		return RiskReadOnly
+	}
+	return RiskMutating
+}
+
+func classifyBisect(args []string) string {
+	switch subverb(args) {
+	case "log", "visualize", "view":
+		return RiskReadOnly
+	case "run":
+		// Runs an arbitrary program at every step
+		return RiskDestructive
+	default:
+		return RiskMutating
+	}
+}
+
+func classifyBranch(args []string) string {
+	switch {
+	case hasFlag(args, "-D", "-M", "-C"),
+		hasFlag(args, "-d", "--delete") && hasFlag(args, "-f", "--force"):
+		return RiskDestructive
+	case hasFlag(args, "-d", "--delete", "-m", "--move", "-c", "--copy",
+		"-u", "--set-upstream-to", "--unset-upstream", "--edit-description", "-f", "--force"):
+		return RiskMutating
+	case hasFlag(args, "-l", "--list", "--show-current"):
+		return RiskReadOnly
+	}
+
+	rest := positionals(args, "--contains", "--no-contains", "--merged", "--no-merged",
+		"--points-at", "--sort", "--format")
+	if len(rest) > 0 {
+		return RiskMutating
+	}
+	return RiskReadOnly
+}
+
+func classifyCheckout(args []string) string {
+	if hasFlag(args, "-f", "--force", "-B", "--ours", "--theirs", "-p", "--patch") {
+		return RiskDestructive
+	}
+	for _, arg := range args {
+		// Checking out paths overwrites local changes
+		if arg == "--" || arg == "." {
+			return RiskDestructive
+		}
+	}
+	return RiskMutating
+}
+
+func classifyClean(args []string) string {
+	if hasFlag(args, "-n", "--dry-run") {
+		return RiskReadOnly
+	}
+	return RiskDestructive
+}
+
+func classifyConfig(args []string) string {
+	if hasFlag(args, "--unset", "--unset-all", "--add", "--replace-all", "--remove-section",
+		"--rename-section", "-e", "--edit") {
+		return RiskMutating
+	}
+	if hasFlag(args, "--get", "--get-all", "--get-regexp", "--get-urlmatch", "-l", "--list") {
+		return RiskReadOnly
+	}
+
+	rest := positionals(args, "-f", "--file", "--blob", "--type", "--default")
+	switch {
+	case len(rest) == 0:
+		return RiskReadOnly
+	case rest[0] == "get" || rest[0] == "list":
+		return RiskReadOnly
+	case len(rest) == 1 && rest[0] != "edit":
+		return RiskReadOnly
+	default:
+		return RiskMutating
+	}
+}
+
+func classifyGC(args []string) string {
+	for _, arg := range args {
+		if arg == "--prune=now" || arg == "--prune=all" {
+			return RiskDestructive
+		}
+	}
+	return RiskMutating
+}
+
+// grepValueOptions are the short options of git grep that take a value, which
+// may be attached: "-e-O" searches for "-O"
+const grepValueOptions = "efABCm"
+
+// classifyGrep treats --open-files-in-pager (-O) as running a program: its
+// value, or core.pager, is started with the matching files
+func classifyGrep(args []string) string {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			return RiskReadOnly
+		case strings.HasPrefix(arg, "--"):
+			// Long options may be abbreviated to any unique prefix
+			name, _, _ := strings.Cut(arg, "=")
+			if len(name) >= len("--op") && strings.HasPrefix("--open-files-in-pager", name) {
+				return RiskDestructive
+			}
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			for j, c := range arg[1:] {
+				if c == 'O' {
+					return RiskDestructive
+				}
+				if strings.ContainsRune(grepValueOptions, c) {
+					if j == len(arg)-2 {
+						i++ // the value is the next argument
+					}
+					break
+				}
+			}
+		}
+	}
+	return RiskReadOnly
+}
+
+func classifyNotes(args []string) string {
+	switch subverb(args) {
+	case "", "list", "show":
+		return RiskReadOnly
+	default:
+		return RiskMutating
+	}
+}
+
+func classifyPush(args []string) string {
+	if hasFlag(args, "-f", "--force", "--force-with-lease", "--force-if-includes",
+		"-d", "--delete", "--mirror", "--prune") {
+		return RiskDestructive
+	}
+	for _, refspec := range positionals(args, "-o", "--push-option", "--repo") {
+		// "+ref" forces the update and ":ref" deletes the remote ref
+		if strings.HasPrefix(refspec, "+") || strings.HasPrefix(refspec, ":") {
+			return RiskDestructive
+		}
+	}
+	return RiskNetwork
+}
+
+func classifyReflog(args []string) string {
+	switch subverb(args) {
+	case "expire", "delete":
+		return RiskDestructive
+	default:
+		return RiskReadOnly
+	}
+}
+
+func classifyRemote(args []string) string {
+	switch subverb(args) {
+	case "", "get-url":
+		return RiskReadOnly
+	case "show":
+		if hasFlag(args, "-n") {
+			return RiskReadOnly
+		}
+		return RiskNetwork
+	case "update", "prune":
+		return RiskNetwork
+	default:
+		return RiskMutating
+	}
+}
+
+func classifyReset(args []string) string {
+	if hasFlag(args, "--hard", "--merge", "--keep") {
+		return RiskDestructive
+	}
+	return RiskMutating
+}
+
+func classifyRestore(args []string) string {
+	// Only unstaging keeps the working tree intact
+	if hasFlag(args, "-S", "--staged") && !hasFlag(args, "-W", "--worktree") {
+		return RiskMutating
+	}
+	return RiskDestructive
+}
+
+func classifyRm(args []string) string {
+	if hasFlag(args, "-n", "--dry-run") {
+		return RiskReadOnly
+	}
+	if hasFlag(args, "-f", "--force") {
+		return RiskDestructive
+	}
+	return RiskMutating
+}
+
+func classifySparseCheckout(args []string) string {
+	if subverb(args) == "list" {
+		return RiskReadOnly
+	}
+	return RiskMutating
+}
+
+func classifyStash(args []string) string {
+	switch subverb(args) {
+	case "list", "show":
+		return RiskReadOnly
+	case "drop", "clear":
+		return RiskDestructive
+	default:
+		return RiskMutating
+	}
+}
+
+func classifySubmodule(args []string) string {
+	switch subverb(args) {
+	case "", "status", "summary":
+		return RiskReadOnly
+	case "update":
+		return RiskNetwork
+	case "foreach":
+		// Runs an arbitrary shell command in every submodule
+		return RiskDestructive
+	case "deinit":
+		if hasFlag(args, "-f", "--force") {
+			return RiskDestructive
+		}
+		return RiskMutating
+	default:
+		return RiskMutating
+	}
+}
+
+func classifySwitch(args []string) string {
+	if hasFlag(args, "-f", "--force", "--discard-changes", "-C", "--force-create") {
+		return RiskDestructive
+	}
+	return RiskMutating
+}
+
+func classifyTag(args []string) string {
+	switch {
+	case hasFlag(args, "-f", "--force"):
+		return RiskDestructive
+	case hasFlag(args, "-d", "--delete", "-a", "--annotate", "-s", "--sign", "-m", "--message", "-F", "--file"):
+		return RiskMutating
+	case hasFlag(args, "-l", "--list", "-v", "--verify"):
+		return RiskReadOnly
+	}
+
+	rest := positionals(args, "--contains", "--no-contains", "--merged", "--no-merged",
+		"--points-at", "--sort", "--format", "-n")
+	if len(rest) > 0 {
+		return RiskMutating
+	}
+	return RiskReadOnly
+}
+
+func classifyUpdateRef(args []string) string {
+	if hasFlag(args, "-d") {
+		return RiskDestructive
+	}
+	return RiskMutating
+}
+
+func classifyWorktree(args []string) string {
+	switch subverb(args) {
+	case "list":
+		return RiskReadOnly
+	case "remove":
+		if hasFlag(args, "-f", "--force") {
+			return RiskDestructive
+		}
+		return RiskMutating
+	default:
+		return RiskMutating
+	}
+}
@@ -0,0 +1,263 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassify(t *testing.T) {
+	testCases := []struct {
+		command  string
+		expected string
+	}{
+		// Read-only inspection
+		{"log --oneline -n 10", RiskReadOnly},
+		{"log --name-status --since=2024-11-01", RiskReadOnly},
+		{"show HEAD~1 --stat", RiskReadOnly},
+		{"status --porcelain", RiskReadOnly},
+		{"diff --cached", RiskReadOnly},
+		{"blame -L 10,20 main.go", RiskReadOnly},
+		{"shortlog -sn", RiskReadOnly},
+		{"rev-parse --abbrev-ref HEAD", RiskReadOnly},
+		{"ls-files --others --exclude-standard", RiskReadOnly},
+		{"grep -n TODO", RiskReadOnly},
+		{"grep -e -O TODO", RiskReadOnly},
+		{"grep -eTODO -- -O", RiskReadOnly},
+		{"describe --tags --abbrev=0", RiskReadOnly},
+		{"-C sub log -1", RiskReadOnly},
+		{"--no-pager log -1", RiskReadOnly},
+		{"branch", RiskReadOnly},
+		{"branch -a -vv", RiskReadOnly},
+		{"branch --contains abc123", RiskReadOnly},
+		{"branch --sort=-committerdate", RiskReadOnly},
+		{"branch --list feature/*", RiskReadOnly},
+		{"branch --show-current", RiskReadOnly},
+		{"tag", RiskReadOnly},
+		{"tag -l v1.*", RiskReadOnly},
+		{"tag --sort=-v:refname", RiskReadOnly},
+		{"stash list", RiskReadOnly},
+		{"stash show -p stash@{0}", RiskReadOnly},
+		{"remote -v", RiskReadOnly},
+		{"remote get-url origin", RiskReadOnly},
+		{"config user.name", RiskReadOnly},
+		{"config --get remote.origin.url", RiskReadOnly},
+		{"config --list --show-origin", RiskReadOnly},
+		{"reflog", RiskReadOnly},
+		{"reflog show main", RiskReadOnly},
+		{"worktree list", RiskReadOnly},
+		{"clean -n -d", RiskReadOnly},
+		{"clean --dry-run", RiskReadOnly},
+		{"add --dry-run .", RiskReadOnly},
+		{"apply --check fix.patch", RiskReadOnly},
+		{"submodule status", RiskReadOnly},
+		{"bisect log", RiskReadOnly},
+
+		// Changes that can be undone
+		{"add .", RiskMutating},
+		{"commit -m fix", RiskMutating},
+		{"bisect start", RiskMutating},
+		{"bisect good v1.0.0", RiskMutating},
+		{"commit --amend --no-edit", RiskMutating},
+		{"checkout -b feature/login", RiskMutating},
+		{"checkout main", RiskMutating},
+		{"switch -c feature/login", RiskMutating},
+		{"branch feature/login", RiskMutating},
+		{"branch -d old", RiskMutating},
+		{"branch -m old new", RiskMutating},
+		{"tag v1.0.0", RiskMutating},
+		{"tag -a v1.0.0 -m release", RiskMutating},
+		{"merge feature/login", RiskMutating},
+		{"rebase -i HEAD~3", RiskMutating},
+		{"cherry-pick abc123", RiskMutating},
+		{"revert HEAD", RiskMutating},
+		{"reset HEAD~1", RiskMutating},
+		{"reset --soft HEAD~1", RiskMutating},
+		{"restore --staged main.go", RiskMutating},
+		{"stash", RiskMutating},
+		{"stash pop", RiskMutating},
+		{"rm --cached secrets.env", RiskMutating},
+		{"mv old.go new.go", RiskMutating},
+		{"config user.name Alice", RiskMutating},
+		{"config --unset user.email", RiskMutating},
+		{"remote add upstream https://example.com/repo.git", RiskMutating},
+		{"gc", RiskMutating},
+		{"diff --output=changes.patch", RiskMutating},
+		{"format-patch -3", RiskMutating},
+		{"frobnicate", RiskMutating},
+		{"", RiskMutating},
+
+		// Talking to remotes
+		{"fetch origin", RiskNetwork},
+		{"fetch --all --prune", RiskNetwork},
+		{"pull --rebase", RiskNetwork},
+		{"push", RiskNetwork},
+		{"push -u origin feature/login", RiskNetwork},
+		{"clone https://example.com/repo.git", RiskNetwork},
+		{"ls-remote --tags origin", RiskNetwork},
+		{"remote show origin", RiskNetwork},
+		{"remote update", RiskNetwork},
+		{"submodule update --init", RiskNetwork},
+
+		// Changes that lose data or rewrite shared history
+		{"reset --hard HEAD~1", RiskDestructive},
+		{"reset --merge", RiskDestructive},
+		{"push --force", RiskDestructive},
+		{"push -f origin main", RiskDestructive},
+		{"push --force-with-lease", RiskDestructive},
+		{"push --force-with-lease=main:abc123", RiskDestructive},
+		{"push origin +main", RiskDestructive},
+		{"push origin :old-branch", RiskDestructive},
+		{"push origin --delete old-branch", RiskDestructive},
+		{"push --mirror", RiskDestructive},
+		{"clean -fd", RiskDestructive},
+		{"clean -fdx", RiskDestructive},
+		{"checkout -- main.go", RiskDestructive},
+		{"checkout .", RiskDestructive},
+		{"checkout -f main", RiskDestructive},
+		{"checkout HEAD~1 -- main.go", RiskDestructive},
+		{"restore main.go", RiskDestructive},
+		{"restore --staged --worktree main.go", RiskDestructive},
+		{"switch --discard-changes main", RiskDestructive},
+		{"branch -D feature/login", RiskDestructive},
+		{"branch --delete --force feature/login", RiskDestructive},
+		{"branch -M main", RiskDestructive},
+		{"tag -f v1.0.0", RiskDestructive},
+		{"stash drop", RiskDestructive},
+		{"stash clear", RiskDestructive},
+		{"rm -rf build", RiskDestructive},
+		{"reflog expire --expire=now --all", RiskDestructive},
+		{"gc --prune=now", RiskDestructive},
+		{"filter-branch --tree-filter 'rm secrets' HEAD", RiskDestructive},
+		{"update-ref -d refs/heads/old", RiskDestructive},
+		{"worktree remove --force ../wt", RiskDestructive},
+		{"submodule foreach rm -rf .", RiskDestructive},
+		{"bisect run ./test.sh", RiskDestructive},
+		{"bisect run make test", RiskDestructive},
+		{"-c core.fsmonitor=./evil status", RiskDestructive},
+		{"ls-remote --upload-pack=./evil origin", RiskDestructive},
+		{"grep -O./evil TODO", RiskDestructive},
+		{"grep -nO TODO", RiskDestructive},
+		{"grep --open-files-in-pager=./evil TODO", RiskDestructive},
+		{"grep --open-files-in-pager TODO", RiskDestructive},
+		{"grep --open=./evil TODO", RiskDestructive},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.command, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Classify(strings.Fields(tc.command)))
+		})
+	}
+}
+
+func TestPolicyEvaluate(t *testing.T) {
+	policy := &Policy{
+		Allow: []string{"fetch", "stash push", "commit --amend"},
+		Deny:  []string{"push --force*", "push -f", "filter-branch", "* --output*"},
+	}
+
+	testCases := []struct {
+		command string
+		allowed bool
+		denied  bool
+		rule    string
+	}{
+		{command: "fetch origin", allowed: true, rule: "fetch"},
+		{command: "stash push -m wip", allowed: true, rule: "stash push"},
+		{command: "stash pop"},
+		{command: "commit --amend --no-edit", allowed: true, rule: "commit --amend"},
+		{command: "commit -m fix"},
+		{command: "push --force-with-lease", denied: true, rule: "push --force*"},
+		{command: "push -f origin main", denied: true, rule: "push -f"},
+		{command: "push origin main"},
+		{command: "filter-branch HEAD", denied: true, rule: "filter-branch"},
+		{command: "log --output=log.txt", denied: true, rule: "* --output*"},
+		{command: "-C sub fetch", allowed: true, rule: "fetch"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.command, func(t *testing.T) {
+			args := strings.Fields(tc.command)
+			decision := policy.Evaluate(args)
+			assert.Equal(t, tc.allowed, decision.Allowed)
+			assert.Equal(t, tc.denied, decision.Denied)
+			assert.Equal(t, tc.rule, decision.Rule)
+			assert.Equal(t, Classify(args), decision.Risk)
+		})
+	}
+}
+
+func TestCheckPreviewable(t *testing.T) {
+	testCases := []struct {
+		command string
+		err     string
+	}{
+		{command: "commit -am fix"},
+		{command: "rebase main"},
+		{command: "--no-pager checkout -b feature/login"},
+		{command: "config user.name Alice"},
+		{command: "worktree list"},
+		{command: "push /path/to/real/repo HEAD:main", err: "network commands are not previewed"},
+		{command: "fetch https://example.com/repo.git", err: "network commands are not previewed"},
+		{command: "reset --hard HEAD~1"},
+		{command: "checkout -- ."},
+		{command: "restore main.go"},
+		{command: "clean -fdx"},
+		{command: "branch -D feature/login"},
+		{command: "stash drop"},
+		{command: "gc --prune=now"},
+		{command: "push --force-with-lease", err: "network commands are not previewed"},
+		{command: "push origin :old-branch", err: "network commands are not previewed"},
+		{command: "remote update", err: "network commands are not previewed"},
+		{command: "-c core.fsmonitor=./evil status", err: "the global option -c is not previewed"},
+		{command: "submodule foreach rm -rf /", err: "commands that run other programs are not previewed"},
+		{command: "filter-branch --tree-filter true HEAD", err: "commands that run other programs are not previewed"},
+		{command: "bisect run ./test.sh", err: "commands that run other programs are not previewed"},
+		{command: "grep -O./evil TODO", err: "commands that run other programs are not previewed"},
+		{command: "rebase -x ./evil main", err: "commands that run other programs are not previewed"},
+		{command: "rebase --exec=./evil main", err: "commands that run other programs are not previewed"},
+		{command: "archive --exec=./evil --remote=origin HEAD", err: "commands that run other programs are not previewed"},
+		{command: "-C .. commit -m fix", err: "the global option -C is not previewed"},
+		{command: "--git-dir=../.git commit -m fix", err: "the global option --git-dir=../.git is not previewed"},
+		{command: "--work-tree .. checkout main", err: "the global option --work-tree is not previewed"},
+		{command: "config --global user.name Alice", err: "configuration outside the repository is not previewed"},
+		{command: "config --file=../.git/config core.bare true", err: "configuration outside the repository is not previewed"},
+		{command: "worktree add ../x", err: "worktree add is not previewed"},
+		{command: "format-patch -3 --output-directory=../patches", err: "commands that write output files are not previewed"},
+		{command: "init ../other", err: "init is not previewed"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.command, func(t *testing.T) {
+			err := checkPreviewable(strings.Fields(tc.command))
+			if tc.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	dir := t.TempDir()
+
+	policy, err := LoadPolicy(filepath.Join(dir, "missing.json"))
+	require.NoError(t, err)
+	assert.Equal(t, DefaultPolicy(), policy)
+
+	path := filepath.Join(dir, "policy.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"allow": ["fetch"], "deny": ["push --force"]}`), 0600))
+	policy, err = LoadPolicy(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fetch"}, policy.Allow)
+	assert.Equal(t, []string{"push --force"}, policy.Deny)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"allow": "fetch"}`), 0600))
+	_, err = LoadPolicy(path)
+	assert.ErrorContains(t, err, "invalid policy file format")
+}
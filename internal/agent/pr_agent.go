@@ -0,0 +1,229 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-coders/git_gpt/internal/common"
+	"github.com/go-coders/git_gpt/pkg/apierrors"
+)
+
+// prTemplatePaths are the places GitHub and GitLab look for a pull request
+// template, relative to the top of the repository
+var prTemplatePaths = []string{
+	".github/pull_request_template.md",
+	".github/PULL_REQUEST_TEMPLATE.md",
+	"docs/pull_request_template.md",
+	"pull_request_template.md",
+	".gitlab/merge_request_templates/Default.md",
+}
+
+// PRAgent writes pull request descriptions for the current branch
+type PRAgent struct {
+	*BaseAgent
+	// diffs filters, redacts and summarizes the branch diff the way the
+	// commit prompt does
+	diffs *CommitAgent
+}
+
+func NewPRAgent(config AgentConfig) (*PRAgent, error) {
+	diffs, err := NewCommitAgent(config)
+	if err != nil {
+		return nil, err
+	}
+	return &PRAgent{BaseAgent: diffs.BaseAgent, diffs: diffs}, nil
+}
+
+// Describe writes a Markdown description of the commits on HEAD since it
+// left opts.Base
+func (a *PRAgent) Describe(ctx context.Context, opts PROptions) (string, error) {
+	base := opts.Base
+	if base == "" {
+		var err error
+		if base, err = a.git.DefaultBranch(ctx); err != nil {
+			return "", fmt.Errorf("%w, name the target branch", err)
+		}
+	}
+
+	mergeBase, err := a.git.MergeBase(ctx, base, "HEAD")
+	if err != nil {
+		return "", err
+	}
+	commits, err := a.git.CommitLog(ctx, mergeBase, "HEAD")
+	if err != nil {
+		return "", err
+	}
+	if len(commits) == 0 {
+		return "", apierrors.New(apierrors.ErrNothingToCommit, fmt.Sprintf("HEAD has no commits that are not on %s", base))
+	}
+	files, err := a.git.GetRangeChanges(ctx, mergeBase, "HEAD")
+	if err != nil {
+		return "", err
+	}
+
+	a.display.StartSpinner("Writing the pull request description...")
+	defer a.display.StopSpinner()
+
+	prompt, err := a.prPrompt(ctx, base, mergeBase, commits, files)
+	if err != nil {
+		return "", err
+	}
+	response, err := a.llm.Chat(ctx, prompt)
+	if err != nil {
+		return "", fmt.Errorf("failed to get LLM response: %w", err)
+	}
+
+	cleanedResponse := cleanJSONResponse(response)
+	a.logger.Debug("Cleaned LLM response: %s", cleanedResponse)
+
+	var pr PRResponse
+	if err := json.Unmarshal([]byte(cleanedResponse), &pr); err != nil {
+		return "", fmt.Errorf("failed to parse the pull request description: %w", err)
+	}
+	if strings.TrimSpace(pr.Title) == "" {
+		// The first commit usually names what the branch set out to do
+		pr.Title = commits[len(commits)-1].Subject
+	}
+	return pr.Markdown(), nil
+}
+
+// prPrompt renders the pull request prompt with the diff since mergeBase, or
+// with summaries of its parts when the diff does not fit the model's context
+func (a *PRAgent) prPrompt(ctx context.Context, base, mergeBase string, commits []common.Commit, files []common.FileChange) (string, error) {
+	omitted := a.diffs.markOmittedDiffs(ctx, files)
+	diff, err := a.git.GetRangeDiff(ctx, mergeBase, "HEAD", omitted...)
+	if err != nil {
+		return "", err
+	}
+
+	diff, secrets, _ := a.diffs.redactDiff(diff, "")
+	commits, found := a.redactCommits(commits)
+	if locations := append(secrets, found...); len(locations) > 0 {
+		a.display.ShowWarning(fmt.Sprintf("Possible secrets in the branch were redacted before sending: %s",
+			strings.Join(uniqueStrings(locations), ", ")))
+	}
+
+	template := a.loadPRTemplate(ctx)
+	prompt, err := a.prompts.GetPRPrompt(base, commits, files, diff, "", nil, template)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate pull request prompt: %w", err)
+	}
+
+	budget := a.llm.AvailableTokens()
+	if budget <= 0 || a.llm.CountTokens(prompt) <= budget {
+		return prompt, nil
+	}
+	a.logger.Debug("Branch diff exceeds %d tokens, summarizing it in parts", budget)
+
+	stat, err := a.git.GetRangeDiffStat(ctx, mergeBase, "HEAD")
+	if err != nil {
+		return "", err
+	}
+	summaries, err := a.diffs.summarizeDiff(ctx, diff, budget)
+	if err != nil {
+		return "", err
+	}
+
+	prompt, err = a.prompts.GetPRPrompt(base, commits, files, "", stat, summaries, template)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate pull request prompt: %w", err)
+	}
+	return prompt, nil
+}
+
+// redactCommits removes secrets from commit messages and returns the
+// redacted copies with "hash (rule)" for each finding
+func (a *PRAgent) redactCommits(commits []common.Commit) ([]common.Commit, []string) {
+	redacted := make([]common.Commit, len(commits))
+	var locations []string
+	for i, commit := range commits {
+		text, findings := a.secrets.Redact(commit.Subject + "\n" + commit.Body)
+		subject, body, _ := strings.Cut(text, "\n")
+		redacted[i] = common.Commit{Hash: commit.Hash, Subject: subject, Body: body}
+		for _, f := range findings {
+			locations = append(locations, fmt.Sprintf("commit %.7s (%s)", commit.Hash, f.Rule))
+		}
+	}
+	return redacted, locations
+}
+
+// loadPRTemplate returns the repository's pull request template, or an empty
+// string when it has none
+func (a *PRAgent) loadPRTemplate(ctx context.Context) string {
+	root, err := a.git.Root(ctx)
+	if err != nil {
+		return ""
+	}
+	for _, name := range prTemplatePaths {
+		data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(name)))
+		if err == nil && strings.TrimSpace(string(data)) != "" {
+			a.logger.Debug("Using the pull request template %s", name)
+			return strings.TrimSpace(string(data))
+		}
+	}
+	return ""
+}
+
+// HandlePR shows the description of the current branch against base in the
+// REPL, or writes it to output when that is set
+func (a *PRAgent) HandlePR(ctx context.Context, base, output string) error {
+	markdown, err := a.Describe(ctx, PROptions{Base: base})
+	if err != nil {
+		var appErr *apierrors.AppError
+		if errors.As(err, &appErr) && appErr.Type == apierrors.ErrNothingToCommit {
+			a.display.ShowInfo(appErr.Message)
+			return nil
+		}
+		return err
+	}
+
+	if output != "" {
+		if err := os.WriteFile(output, []byte(markdown), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", output, err)
+		}
+		a.display.ShowSuccess(fmt.Sprintf("Wrote the pull request description to %s", output))
+		return nil
+	}
+	a.display.ShowSection("Pull Request", markdown, map[string]string{"icon": "📝"})
+	return nil
+}
+
+// Markdown renders the description with the title as a heading. A filled in
+// template is used as the body; otherwise the sections are laid out in turn.
+func (r PRResponse) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", strings.TrimSpace(r.Title))
+	if body := strings.TrimSpace(r.Body); body != "" {
+		b.WriteString(body + "\n")
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "## Summary\n\n%s\n", strings.TrimSpace(r.Summary))
+	writeMarkdownList(&b, "Changes", r.Changes, "")
+	writeMarkdownList(&b, "Testing", r.Testing, "No testing notes.")
+	writeMarkdownList(&b, "Risks", r.Risks, "No notable risks.")
+	return b.String()
+}
+
+// writeMarkdownList writes a section with one bullet per non-empty item, or
+// with none when there are no items
+func writeMarkdownList(b *strings.Builder, title string, items []string, none string) {
+	var lines []string
+	for _, item := range items {
+		if item = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(item), "- ")); item != "" {
+			lines = append(lines, "- "+item)
+		}
+	}
+	if len(lines) == 0 {
+		if none == "" {
+			return
+		}
+		lines = []string{none}
+	}
+	fmt.Fprintf(b, "\n## %s\n\n%s\n", title, strings.Join(lines, "\n"))
+}
@@ -17,6 +17,49 @@ type TemplateData struct {
 	Changes        []common.FileChange
 	Diff           string
 	CommandResults string
+	Step           int
+	MaxSteps       int
+	UseTools       bool
+	Units          []PromptUnit
+	DiffStat       string
+	Summaries      []string
+	Part           int
+	Parts          int
+	Rules          []string
+	Repairs        []RepairItem
+	Style          *StyleProfile
+	Ticket         *TicketRef
+	Base           string
+	Commits        []common.Commit
+	PRTemplate     string
+	Entries        []ChangelogItem
+	Version        string
+	Previous       string
+}
+
+// RepairItem is a commit message that broke the repository's commit rules
+type RepairItem struct {
+	Message    string
+	Violations []string
+}
+
+// PromptUnit is a piece of a staged change offered to the model when
+// splitting it into several commits
+type PromptUnit struct {
+	ID     string
+	Status string
+	Path   string
+	Text   string
+}
+
+// ChangelogItem is a changelog entry offered to the model for rewording
+type ChangelogItem struct {
+	ID      int
+	Section string
+	Scope   string
+	Text    string
+	Note    string
+	Body    string
 }
 
 type TimeContext struct {
@@ -60,7 +103,12 @@ Command Types:
    - Operations that change the repository state
    - Examples: git commit, git reset, git revert, git checkout, git merge
    - These require user confirmation before execution
-
+{{if .UseTools}}
+Respond by calling exactly one tool:
+1. answer: if you can answer using existing information
+2. run_git_query: if you need to execute query commands
+3. propose_git_modification: if suggesting modification commands
+{{else}}
 Return a JSON response in one of these formats:
 
 1. If you can answer using existing information:
@@ -97,9 +145,47 @@ Return a JSON response in one of these formats:
     ],
     "reason": "Explain why these modifications are suggested"
 }
+{{end}}
+The output of executed commands is sent back to you, so you can ask for
+follow-up commands that depend on it (for example, find a commit hash first
+and then show that commit).
 
 Query: {{.Query}}`
 
+	nextStepTpl = `You are working on this query step by step:
+
+Query: {{.Query}}
+
+Results of step {{.Step}} of at most {{.MaxSteps}}:
+{{.CommandResults}}
+{{if .UseTools}}
+Decide what to do next and call exactly one tool:
+1. answer: if you have enough information, answer the query from all results so far
+2. run_git_query or propose_git_modification: if you need more information or further changes
+
+Rules:
+1. Do not repeat commands that were already executed
+2. Use the results above, such as hashes or branch names, in the next commands
+3. If a command failed, try a different command or answer with what you know
+{{- else}}
+Decide what to do next and return a JSON response in one of these formats:
+
+1. If you have enough information to answer the query:
+{
+    "type": "done",
+    "reason": "Explain why no more commands are needed"
+}
+
+2. If you need more information or further changes, return another "execute"
+response in the same format as before, with "commandType" set to "query" or
+"modify".
+
+Rules:
+1. Do not repeat commands that were already executed
+2. Use the results above, such as hashes or branch names, in the next commands
+3. If a command failed, try a different command or finish with "done"
+{{- end}}`
+
 	summarizeResultsTpl = `Answer this Git repository question based on the command results:
 
 Question: {{.Query}}
@@ -121,41 +207,245 @@ Return a JSON response in this exact format:
     "summary": "A brief summary of the changes in markdown format",
     "suggestions": [
         {
-            "message": "type(scope): subject"
+            "message": "{{if .Style}}the subject line{{else}}type(scope): subject{{end}}",
+            "body": "why the change was made and what it does, or empty",
+            "trailers": [
+                {"key": "Refs", "value": "#123"}
+            ]
         }
     ]
 }
 
 Changes:
-{{range .Changes}}- {{.Status}}: {{.Path}} ({{.Additions}}+/{{.Deletions}}-)
+{{range .Changes}}- {{.Status}}: {{.DisplayPath}} {{if .Binary}}(binary){{else}}({{.Additions}}+/{{.Deletions}}-){{end}}{{if .DiffOmitted}} [diff omitted: {{.DiffOmitted}}]{{end}}
 {{end}}
 
+{{if .Summaries}}
+Diff overview:
+{{.DiffStat}}
+
+The diff is too large to show in full. Summaries of its parts:
+{{range .Summaries}}
+- {{.}}
+{{end}}
+{{else}}
 Detailed diff:
 {{.Diff}}
-
+{{end}}
 Guidelines for commit messages:
-1. Use conventional commits format: type(scope): description
+{{if .Style}}Match the style of this repository's recent commits:
+{{range .Style.Guidelines}}- {{.}}
+{{end}}
+Recent commit subjects:
+{{range .Style.Examples}}- {{.}}
+{{end}}
+Also:
+1. Focus on what changes accomplish, not how
+2. Generate exactly 3 different suggestions
+3. Each suggestion should focus on a different aspect
+{{else}}1. Use conventional commits format: type(scope): description
 2. Available types: feat, fix, docs, style, refactor, test, chore
 4. Focus on what changes accomplish, not how
 5. No period at the end
 6. Use imperative mood ("add" not "added")
 7. Generate exactly 3 different suggestions
 8. Each suggestion should focus on a different aspect
-
+{{end}}
+Guidelines for body and trailers:
+1. Add a body when the subject alone does not explain the change; leave it empty for small changes
+2. Write the body as plain sentences or "- " list items; it is wrapped automatically
+3. Use "Refs" only for an issue the changes mention, and "BREAKING CHANGE" only for an incompatible change to a public interface, saying what users must do
+4. Never invent issue numbers or co-authors; leave trailers empty when none apply
+{{with .Ticket}}
+Every suggestion must reference ticket {{.Key}}, which the branch works on:
+{{if eq .Position "prefix"}}- Start the subject with "{{.Key}} ", for example "{{.Key}} handle login retries"
+{{else if eq .Position "scope"}}- Use it as the scope, for example "fix({{.Key}}): handle login retries"
+{{else}}- Add the trailer {"key": "{{.Trailer}}", "value": "{{.Key}}"} and leave the subject without it
+{{end}}{{end}}
+{{if .Rules}}
+The repository enforces these commit rules, which take precedence over the guidelines above:
+{{range .Rules}}- {{.}}
+{{end}}{{end}}
 Guidelines for summary:
 1. Brief but comprehensive summary of changes
 2. Focus on the overall impact
 3. Keep it under 3-4 sentences
 4. Include key changes and their purposes
 5. Use technical but clear language`
+
+	chunkSummaryTpl = `Summarize part {{.Part}} of {{.Parts}} of a large staged git diff. The summaries of all parts
+will be used to write the commit message.
+
+Diff part:
+{{.Diff}}
+
+Guidelines:
+1. Say what changed in each file and what the change is for
+2. Mention renamed, added or deleted files and public API changes
+3. Keep it under 5 sentences
+4. Use plain text, no formatting`
+
+	repairCommitTpl = `These commit messages break the repository's commit rules. Fix each one
+while keeping its meaning.
+Return a JSON response in this exact format, with one suggestion per message in the same order:
+{
+    "suggestions": [
+        {
+            "message": "the fixed subject line",
+            "body": "the fixed body, or empty",
+            "trailers": [
+                {"key": "Refs", "value": "#123"}
+            ]
+        }
+    ]
+}
+
+Commit rules:
+{{range .Rules}}- {{.}}
+{{end}}
+Messages, each after a "---" line:
+{{range $i, $r := .Repairs}}---
+{{$r.Message}}
+Violations:
+{{range $r.Violations}}- {{.}}
+{{end}}{{end}}`
+
+	prPromptTpl = `Write a pull request description for the changes of this branch, which will be merged into {{.Base}}.
+Return a JSON response in this exact format:
+{
+    "title": "a short title for the pull request",
+    "summary": "what the pull request does and why, in a few sentences",
+    "changes": ["one notable change per item"],
+    "testing": ["how the changes are tested or can be checked"],
+    "risks": ["what could break or needs a careful review"]{{if .PRTemplate}},
+    "body": "the pull request template below, filled in"{{end}}
+}
+
+Commits, newest first:
+{{range .Commits}}- {{.Subject}}
+{{with .Body}}{{.}}
+{{end}}{{end}}
+Changed files:
+{{range .Changes}}- {{.Status}}: {{.DisplayPath}} {{if .Binary}}(binary){{else}}({{.Additions}}+/{{.Deletions}}-){{end}}{{if .DiffOmitted}} [diff omitted: {{.DiffOmitted}}]{{end}}
+{{end}}
+{{if .Summaries}}
+Diff overview:
+{{.DiffStat}}
+
+The diff is too large to show in full. Summaries of its parts:
+{{range .Summaries}}
+- {{.}}
+{{end}}
+{{else}}
+Detailed diff:
+{{.Diff}}
+{{end}}
+{{if .PRTemplate}}
+The repository has this pull request template:
+<template>
+{{.PRTemplate}}
+</template>
+
+Fill it in as "body": keep its headings, checklists and order, replace placeholder text and
+HTML comments with content from the changes, and leave checkboxes unchecked unless the changes
+show that they apply.
+{{end}}
+Guidelines:
+1. Write for reviewers: explain the purpose before the details
+2. Describe what the branch does as a whole, not each commit in turn
+3. Base the testing notes on the tests in the changes; never claim that tests were run
+4. List migrations, configuration changes, removed APIs and behavior changes as risks; leave risks empty when there are none
+5. Keep the title under 72 characters, without a trailing period
+6. Use Markdown inside the text, but no headings outside the template`
+
+	changelogPromptTpl = `Rewrite these changelog entries, taken from commit messages, as release notes for users.
+Return a JSON response in this exact format, with one entry per id:
+{
+    "entries": [
+        {
+            "id": 1,
+            "text": "the entry as a user-facing sentence",
+            "note": "for breaking changes, what users must do; otherwise empty"
+        }
+    ]
+}
+
+Entries:
+{{range .Entries}}
+[{{.ID}}] {{.Section}}{{with .Scope}} ({{.}}){{end}}: {{.Text}}
+{{with .Note}}Breaking change: {{.}}
+{{end}}{{with .Body}}Commit body:
+{{.}}
+{{end}}{{end}}
+Guidelines:
+1. Describe what changed for users of the project, not how the code changed
+2. Keep each entry to one sentence in the present or past tense, ending with a period
+3. Keep names of commands, options, files and APIs as written, in backticks
+4. Do not invent details that the entry and its commit body do not state
+5. Do not repeat the scope; it is shown next to the entry`
+
+	releasePromptTpl = `Write the release notes for {{.Version}}{{if .Previous}}, the release after {{.Previous}}{{else}}, the first release{{end}}.
+They become the message of the release tag.
+Return a JSON response in this exact format:
+{
+    "title": "a short headline for the release",
+    "summary": "what the release brings to users, in a few sentences",
+    "highlights": ["one notable change per item"]
+}
+
+Changes:
+{{range .Entries}}
+- {{.Section}}{{with .Scope}} ({{.}}){{end}}: {{.Text}}
+{{with .Note}}Breaking change: {{.}}
+{{end}}{{with .Body}}Commit body:
+{{.}}
+{{end}}{{end}}
+Guidelines:
+1. Write for users deciding whether to upgrade
+2. Mention breaking changes first and say what users must do about them
+3. Do not invent changes that are not listed
+4. Keep the title under 60 characters, without the version number or a trailing period
+5. Use plain text without headings; list at most 5 highlights`
+
+	splitPromptTpl = `Split these staged git changes into a sequence of logical commits, for example a refactor, a bug fix and documentation.
+Return a JSON response in this exact format:
+{
+    "commits": [
+        {
+            "message": "type(scope): subject",
+            "units": ["1.1", "2"]
+        }
+    ]
+}
+
+Each unit is a hunk (id "file.hunk") or a whole file (id "file"):
+{{range .Units}}
+[{{.ID}}] {{.Status}}: {{.Path}}
+{{.Text}}
+{{end}}
+
+Guidelines:
+1. Every unit must appear in exactly one commit
+2. Order the commits so that each one builds on the ones before it
+3. Keep related changes together; propose a single commit if the changes belong together
+4. Use conventional commits format: type(scope): description
+5. Available types: feat, fix, docs, style, refactor, test, chore
+6. Use imperative mood and no period at the end`
 )
 
 // PromptManager handles template rendering for different prompts
 type PromptManager struct {
 	systemPrompt     *template.Template
 	generateCommands *template.Template
+	nextStep         *template.Template
 	summarizeResults *template.Template
 	commitPrompt     *template.Template
+	chunkSummary     *template.Template
+	repairCommit     *template.Template
+	splitPrompt      *template.Template
+	prPrompt         *template.Template
+	changelogPrompt  *template.Template
+	releasePrompt    *template.Template
 }
 
 func NewPromptManager() (*PromptManager, error) {
@@ -170,6 +460,10 @@ func NewPromptManager() (*PromptManager, error) {
 		return nil, fmt.Errorf("failed to parse commands template: %w", err)
 	}
 
+	if pm.nextStep, err = template.New("nextStep").Parse(nextStepTpl); err != nil {
+		return nil, fmt.Errorf("failed to parse next step template: %w", err)
+	}
+
 	if pm.summarizeResults, err = template.New("summarize").Parse(summarizeResultsTpl); err != nil {
 		return nil, fmt.Errorf("failed to parse summarize template: %w", err)
 	}
@@ -178,6 +472,30 @@ func NewPromptManager() (*PromptManager, error) {
 		return nil, fmt.Errorf("failed to parse commit template: %w", err)
 	}
 
+	if pm.chunkSummary, err = template.New("chunkSummary").Parse(chunkSummaryTpl); err != nil {
+		return nil, fmt.Errorf("failed to parse chunk summary template: %w", err)
+	}
+
+	if pm.repairCommit, err = template.New("repairCommit").Parse(repairCommitTpl); err != nil {
+		return nil, fmt.Errorf("failed to parse repair template: %w", err)
+	}
+
+	if pm.splitPrompt, err = template.New("split").Parse(splitPromptTpl); err != nil {
+		return nil, fmt.Errorf("failed to parse split template: %w", err)
+	}
+
+	if pm.prPrompt, err = template.New("pr").Parse(prPromptTpl); err != nil {
+		return nil, fmt.Errorf("failed to parse pull request template: %w", err)
+	}
+
+	if pm.changelogPrompt, err = template.New("changelog").Parse(changelogPromptTpl); err != nil {
+		return nil, fmt.Errorf("failed to parse changelog template: %w", err)
+	}
+
+	if pm.releasePrompt, err = template.New("release").Parse(releasePromptTpl); err != nil {
+		return nil, fmt.Errorf("failed to parse release template: %w", err)
+	}
+
 	return pm, nil
 }
 
@@ -188,13 +506,27 @@ func (pm *PromptManager) GetSystemPrompt() (string, error) {
 	return pm.renderTemplate(pm.systemPrompt, data)
 }
 
-func (pm *PromptManager) GetGenerateCommandsPrompt(query string) (string, error) {
+// GetGenerateCommandsPrompt renders the first prompt of a query. With useTools
+// the model is told to call a tool instead of writing JSON.
+func (pm *PromptManager) GetGenerateCommandsPrompt(query string, useTools bool) (string, error) {
 	data := TemplateData{
-		Query: query,
+		Query:    query,
+		UseTools: useTools,
 	}
 	return pm.renderTemplate(pm.generateCommands, data)
 }
 
+func (pm *PromptManager) GetNextStepPrompt(query string, step, maxSteps int, results string, useTools bool) (string, error) {
+	data := TemplateData{
+		Query:          query,
+		Step:           step,
+		MaxSteps:       maxSteps,
+		CommandResults: results,
+		UseTools:       useTools,
+	}
+	return pm.renderTemplate(pm.nextStep, data)
+}
+
 func (pm *PromptManager) GetSummarizeResultsPrompt(query, results string) (string, error) {
 	data := TemplateData{
 		Query:          query,
@@ -203,14 +535,97 @@ func (pm *PromptManager) GetSummarizeResultsPrompt(query, results string) (strin
 	return pm.renderTemplate(pm.summarizeResults, data)
 }
 
-func (pm *PromptManager) GetCommitPrompt(changes []common.FileChange, diff string) (string, error) {
+// GetCommitPrompt renders the commit prompt. rules are the repository's commit
+// rules, if any, in plain sentences; style is the repository's learned commit
+// style, or nil for the conventional commits guidelines; ticket, if not nil,
+// is the ticket every suggestion must reference.
+func (pm *PromptManager) GetCommitPrompt(changes []common.FileChange, diff string, rules []string, style *StyleProfile, ticket *TicketRef) (string, error) {
 	data := TemplateData{
 		Changes: changes,
 		Diff:    diff,
+		Rules:   rules,
+		Style:   style,
+		Ticket:  ticket,
 	}
 	return pm.renderTemplate(pm.commitPrompt, data)
 }
 
+// GetSummarizedCommitPrompt renders the commit prompt for a diff that is too
+// large to send, using a diffstat and summaries of its parts instead
+func (pm *PromptManager) GetSummarizedCommitPrompt(changes []common.FileChange, diffStat string, summaries, rules []string, style *StyleProfile, ticket *TicketRef) (string, error) {
+	data := TemplateData{
+		Changes:   changes,
+		DiffStat:  diffStat,
+		Summaries: summaries,
+		Rules:     rules,
+		Style:     style,
+		Ticket:    ticket,
+	}
+	return pm.renderTemplate(pm.commitPrompt, data)
+}
+
+// GetChunkSummaryPrompt asks for a summary of one part of a large diff
+func (pm *PromptManager) GetChunkSummaryPrompt(diff string, part, parts int) (string, error) {
+	data := TemplateData{
+		Diff:  diff,
+		Part:  part,
+		Parts: parts,
+	}
+	return pm.renderTemplate(pm.chunkSummary, data)
+}
+
+// GetRepairCommitPrompt asks the model to fix messages that broke the rules
+func (pm *PromptManager) GetRepairCommitPrompt(repairs []RepairItem, rules []string) (string, error) {
+	data := TemplateData{
+		Repairs: repairs,
+		Rules:   rules,
+	}
+	return pm.renderTemplate(pm.repairCommit, data)
+}
+
+func (pm *PromptManager) GetSplitPrompt(units []PromptUnit) (string, error) {
+	data := TemplateData{
+		Units: units,
+	}
+	return pm.renderTemplate(pm.splitPrompt, data)
+}
+
+// GetPRPrompt renders the pull request prompt for the commits and changes of
+// a branch since it left base. With summaries the diff is replaced by the
+// diffstat and summaries of its parts. prTemplate is the repository's pull
+// request template, if any.
+func (pm *PromptManager) GetPRPrompt(base string, commits []common.Commit, changes []common.FileChange, diff, diffStat string, summaries []string, prTemplate string) (string, error) {
+	data := TemplateData{
+		Base:       base,
+		Commits:    commits,
+		Changes:    changes,
+		Diff:       diff,
+		DiffStat:   diffStat,
+		Summaries:  summaries,
+		PRTemplate: prTemplate,
+	}
+	return pm.renderTemplate(pm.prPrompt, data)
+}
+
+// GetChangelogPrompt asks the model to reword changelog entries for users
+func (pm *PromptManager) GetChangelogPrompt(entries []ChangelogItem) (string, error) {
+	data := TemplateData{
+		Entries: entries,
+	}
+	return pm.renderTemplate(pm.changelogPrompt, data)
+}
+
+// GetReleasePrompt asks the model for the summary of the release version,
+// which follows previous; previous is empty for the first release
+func (pm *PromptManager) GetReleasePrompt(version, previous string, entries []ChangelogItem) (string, error) {
+	data := TemplateData{
+		Version:  version,
+		Previous: previous,
+		Entries:  entries,
+	}
+	return pm.renderTemplate(pm.releasePrompt, data)
+}
+
 func (pm *PromptManager) renderTemplate(tmpl *template.Template, data TemplateData) (string, error) {
 	var buf bytes.Buffer
 	if err := tmpl.Execute(&buf, data); err != nil {
@@ -0,0 +1,296 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-coders/git_gpt/internal/changelog"
+	"github.com/go-coders/git_gpt/internal/common"
+	"github.com/go-coders/git_gpt/internal/version"
+	"github.com/go-coders/git_gpt/pkg/apierrors"
+)
+
+// releaseTagPattern matches the tags releases are made from
+const releaseTagPattern = "v[0-9]*"
+
+// ReleaseAgent tags releases with the next semantic version and a summary of
+// the commits since the last one
+type ReleaseAgent struct {
+	*BaseAgent
+}
+
+func NewReleaseAgent(config AgentConfig) (*ReleaseAgent, error) {
+	base, err := NewBaseAgent(config)
+	if err != nil {
+		return nil, err
+	}
+	return &ReleaseAgent{BaseAgent: base}, nil
+}
+
+// ReleasePlan is the next release and the commits that call for it
+type ReleasePlan struct {
+	// Previous is the last release tag, empty before the first release
+	Previous string
+	Tag      string
+	Level    version.Level
+	Reasons  []changelog.BumpReason
+	// Notes explain choices that no single commit made, such as a bump
+	// asked for with ReleaseOptions.Bump
+	Notes   []string
+	Commits []common.Commit
+}
+
+// Release works out the next version from the commits since the last release
+// tag, shows why, and after confirmation creates an annotated tag whose
+// message summarizes the release. It returns the tag name, which is not
+// created with opts.DryRun.
+func (a *ReleaseAgent) Release(ctx context.Context, opts ReleaseOptions) (string, error) {
+	plan, err := a.Plan(ctx, opts)
+	if err != nil {
+		return "", err
+	}
+	a.display.ShowSection("Release", plan.Describe(), map[string]string{"icon": "🏷️"})
+
+	message := a.tagMessage(ctx, plan)
+	a.display.ShowSection("Tag Message", message, map[string]string{"icon": "📝"})
+	if opts.DryRun {
+		return plan.Tag, nil
+	}
+
+	if !opts.Yes {
+		if message, err = a.confirmTag(ctx, plan.Tag, message); err != nil {
+			return "", err
+		}
+	}
+	if err := a.git.CreateTag(ctx, plan.Tag, message); err != nil {
+		return "", err
+	}
+	a.display.ShowSuccess(fmt.Sprintf("Created tag %s. Push it with: git push origin %s", plan.Tag, plan.Tag))
+	return plan.Tag, nil
+}
+
+// Plan works out the next release without creating anything
+func (a *ReleaseAgent) Plan(ctx context.Context, opts ReleaseOptions) (*ReleasePlan, error) {
+	previous, current, err := a.lastRelease(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	commits, err := a.git.CommitLog(ctx, previous, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	if len(commits) == 0 {
+		return nil, apierrors.New(apierrors.ErrNothingToCommit, fmt.Sprintf("HEAD has no commits since %s", previous))
+	}
+
+	plan := &ReleasePlan{Previous: previous, Commits: commits}
+	plan.Level, plan.Reasons = changelog.Bump(commits)
+
+	if opts.Bump != "" {
+		level, err := version.ParseLevel(opts.Bump)
+		if err != nil {
+			return nil, err
+		}
+		if level != plan.Level {
+			plan.Notes = append(plan.Notes, fmt.Sprintf("A %s release was asked for; the commits call for %s", level, plan.Level))
+		}
+		plan.Level = level
+	} else if plan.Level == version.Major && current.Major == 0 {
+		plan.Level = version.Minor
+		plan.Notes = append(plan.Notes, "Breaking changes bump the minor version before 1.0.0")
+	}
+	if plan.Level == version.None {
+		since := ""
+		if previous != "" {
+			since = " since " + previous
+		}
+		return nil, apierrors.New(apierrors.ErrNothingToCommit,
+			fmt.Sprintf("None of the %d commits%s call for a release; choose a bump to release anyway", len(commits), since))
+	}
+
+	next := current.Next(plan.Level)
+	if opts.Prerelease != "" {
+		if next, err = current.NextPrerelease(plan.Level, opts.Prerelease); err != nil {
+			return nil, err
+		}
+	}
+	plan.Tag = "v" + next.String()
+	if _, err := a.git.TagDate(ctx, plan.Tag); err == nil {
+		return nil, fmt.Errorf("tag %s already exists", plan.Tag)
+	}
+	return plan, nil
+}
+
+// lastRelease returns the newest release tag reachable from HEAD and its
+// version, or an empty tag when there is none. Tags that match the pattern but
+// are not semantic versions, such as v2-archive, are passed over for the ones
+// before them.
+func (a *ReleaseAgent) lastRelease(ctx context.Context) (string, version.Semver, error) {
+	rev := "HEAD"
+	for {
+		tag, err := a.git.LastTag(ctx, rev, releaseTagPattern)
+		if err != nil {
+			if rev != "HEAD" {
+				// A tagged root commit has no parent and so no earlier tag
+				return "", version.Semver{}, nil
+			}
+			return "", version.Semver{}, err
+		}
+		if tag == "" {
+			return "", version.Semver{}, nil
+		}
+		if current, err := version.ParseSemver(tag); err == nil {
+			return tag, current, nil
+		}
+		a.logger.Debug("Skipping the tag %s, which is not a semantic version", tag)
+		rev = tag + "^"
+	}
+}
+
+// Describe explains the next version: the bump, the commits that asked for a
+// release and any notes
+func (p *ReleasePlan) Describe() string {
+	var b strings.Builder
+	if p.Previous == "" {
+		fmt.Fprintf(&b, "%s (%s, first release)\n", p.Tag, p.Level)
+	} else {
+		fmt.Fprintf(&b, "%s → %s (%s)\n", p.Previous, p.Tag, p.Level)
+	}
+
+	if len(p.Reasons) > 0 {
+		b.WriteString("\n")
+	}
+	for _, reason := range p.Reasons {
+		fmt.Fprintf(&b, "%-5s  %.7s  %s (%s)\n", reason.Level, reason.Change.Hash, reason.Change.Subject, reason.Why)
+	}
+	if other := len(p.Commits) - len(p.Reasons); other > 0 {
+		fmt.Fprintf(&b, "\n%d other commit(s), such as docs or chores, do not change the version\n", other)
+	}
+	for _, note := range p.Notes {
+		fmt.Fprintf(&b, "\n%s\n", note)
+	}
+	return b.String()
+}
+
+// tagMessage asks the model to summarize the release. When that fails the
+// message lists the changelog entries instead, with a warning.
+func (a *ReleaseAgent) tagMessage(ctx context.Context, plan *ReleasePlan) string {
+	sections := changelog.Build(plan.Commits)
+	summary, err := a.summarize(ctx, plan, sections)
+	if err == nil {
+		return summary.Message(plan.Tag)
+	}
+	a.logger.Debug("Summarizing the release failed: %v", err)
+	a.display.ShowWarning(fmt.Sprintf("Could not summarize the release, listing its changes instead: %v", err))
+	return plainReleaseMessage(plan.Tag, sections)
+}
+
+func (a *ReleaseAgent) summarize(ctx context.Context, plan *ReleasePlan, sections []changelog.Section) (*ReleaseResponse, error) {
+	prompt, err := a.prompts.GetReleasePrompt(plan.Tag, plan.Previous, a.changelogItems(sections))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate release prompt: %w", err)
+	}
+
+	a.display.StartSpinner("Summarizing the release...")
+	response, err := a.llm.Chat(ctx, prompt)
+	a.display.StopSpinner()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get LLM response: %w", err)
+	}
+
+	cleanedResponse := cleanJSONResponse(response)
+	a.logger.Debug("Cleaned LLM response: %s", cleanedResponse)
+
+	var summary ReleaseResponse
+	if err := json.Unmarshal([]byte(cleanedResponse), &summary); err != nil {
+		return nil, fmt.Errorf("failed to parse the release summary: %w", err)
+	}
+	if strings.TrimSpace(summary.Summary) == "" {
+		return nil, fmt.Errorf("the model returned an empty summary")
+	}
+	return &summary, nil
+}
+
+// confirmTag asks before tag is created. "e" opens message in the editor
+// first; it returns the message to tag with.
+func (a *ReleaseAgent) confirmTag(ctx context.Context, tag, message string) (string, error) {
+	a.display.ShowQuestion(fmt.Sprintf("Create the annotated tag %s? (y/n/e to edit the message): ", tag))
+	input, err := a.reader.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+
+	switch strings.TrimSpace(strings.ToLower(input)) {
+	case "y":
+		return message, nil
+	case "e":
+		edited, err := a.git.EditMessage(ctx, message)
+		if err != nil {
+			return "", err
+		}
+		if strings.TrimSpace(edited) == "" {
+			return "", apierrors.NewCancelledError()
+		}
+		return edited, nil
+	default:
+		return "", apierrors.NewCancelledError()
+	}
+}
+
+// HandleRelease runs a release from the REPL
+func (a *ReleaseAgent) HandleRelease(ctx context.Context, opts ReleaseOptions) error {
+	if _, err := a.Release(ctx, opts); err != nil {
+		var appErr *apierrors.AppError
+		if errors.As(err, &appErr) && appErr.Type == apierrors.ErrNothingToCommit {
+			a.display.ShowInfo(appErr.Message)
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// Message renders the summary as a tag message headed by tag
+func (r ReleaseResponse) Message(tag string) string {
+	var b strings.Builder
+	b.WriteString(tag)
+	if title := strings.TrimSpace(r.Title); title != "" {
+		fmt.Fprintf(&b, ": %s", strings.TrimSuffix(title, "."))
+	}
+	fmt.Fprintf(&b, "\n\n%s\n", strings.TrimSpace(r.Summary))
+
+	var highlights []string
+	for _, item := range r.Highlights {
+		if item = cleanEntryText(item); item != "" {
+			highlights = append(highlights, "- "+item)
+		}
+	}
+	if len(highlights) > 0 {
+		fmt.Fprintf(&b, "\n%s\n", strings.Join(highlights, "\n"))
+	}
+	return b.String()
+}
+
+// plainReleaseMessage lists the changelog entries of a release under tag
+func plainReleaseMessage(tag string, sections []changelog.Section) string {
+	var b strings.Builder
+	b.WriteString(tag + "\n")
+	for _, section := range sections {
+		fmt.Fprintf(&b, "\n%s:\n", section.Title)
+		for _, entry := range section.Entries {
+			b.WriteString("- ")
+			if entry.Scope != "" {
+				b.WriteString(entry.Scope + ": ")
+			}
+			b.WriteString(entry.Text + "\n")
+			if entry.Note != "" {
+				fmt.Fprintf(&b, "  %s\n", entry.Note)
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,241 @@
+package agent
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-coders/git_gpt/internal/common"
+	"github.com/go-coders/git_gpt/internal/version"
+	"github.com/go-coders/git_gpt/pkg/apierrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type ReleaseAgentTestSuite struct {
+	BaseAgentTestSuite
+	agent *ReleaseAgent
+}
+
+func (s *ReleaseAgentTestSuite) SetupTest() {
+	s.BaseAgentTestSuite.SetupTest()
+
+	s.display.On("StartSpinner", mock.Anything).Return().Maybe()
+	s.display.On("StopSpinner").Return().Maybe()
+	s.logger.On("Debug", mock.Anything, mock.Anything).Return()
+
+	agent, err := NewReleaseAgent(AgentConfig{
+		Git:     s.git,
+		LLM:     s.llm,
+		Display: s.display,
+		Logger:  s.logger,
+		Reader:  s.input,
+	})
+	s.Require().NoError(err)
+	s.agent = agent
+}
+
+func TestReleaseAgent(t *testing.T) {
+	suite.Run(t, new(ReleaseAgentTestSuite))
+}
+
+// expectHistory sets up the commits since the last release tag
+func (s *ReleaseAgentTestSuite) expectHistory(previous string, subjects ...string) {
+	s.git.On("LastTag", s.ctx, "HEAD", "v[0-9]*").Return(previous, nil).Once()
+	var commits []common.Commit
+	for i, subject := range subjects {
+		commits = append(commits, common.Commit{Hash: string(rune('a'+i)) + "000000000", Subject: subject})
+	}
+	s.git.On("CommitLog", s.ctx, previous, "HEAD").Return(commits, nil).Once()
+}
+
+func (s *ReleaseAgentTestSuite) notTagged(tag string) {
+	s.git.On("TagDate", s.ctx, tag).Return("", errors.New(tag+" is not a tag")).Once()
+}
+
+func (s *ReleaseAgentTestSuite) TestPlan() {
+	tests := []struct {
+		name     string
+		previous string
+		subjects []string
+		opts     ReleaseOptions
+		tag      string
+		level    version.Level
+		notes    []string
+	}{
+		{
+			name:     "feature",
+			previous: "v1.2.3",
+			subjects: []string{"docs: usage", "feat: export", "fix: crash"},
+			tag:      "v1.3.0",
+			level:    version.Minor,
+		},
+		{
+			name:     "breaking",
+			previous: "v1.2.3",
+			subjects: []string{"feat(api)!: drop v1"},
+			tag:      "v2.0.0",
+			level:    version.Major,
+		},
+		{
+			name:     "breaking before 1.0",
+			previous: "v0.4.1",
+			subjects: []string{"feat(api)!: drop v1"},
+			tag:      "v0.5.0",
+			level:    version.Minor,
+			notes:    []string{"Breaking changes bump the minor version before 1.0.0"},
+		},
+		{
+			name:     "first release",
+			subjects: []string{"fix: crash", "Initial commit"},
+			tag:      "v0.0.1",
+			level:    version.Patch,
+		},
+		{
+			name:     "prerelease",
+			previous: "v2.0.0-rc.1",
+			subjects: []string{"fix: crash"},
+			opts:     ReleaseOptions{Prerelease: "rc"},
+			tag:      "v2.0.0-rc.2",
+			level:    version.Patch,
+		},
+		{
+			name:     "forced bump",
+			previous: "v1.2.3",
+			subjects: []string{"docs: usage"},
+			opts:     ReleaseOptions{Bump: "major"},
+			tag:      "v2.0.0",
+			level:    version.Major,
+			notes:    []string{"A major release was asked for; the commits call for none"},
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			s.expectHistory(tt.previous, tt.subjects...)
+			s.notTagged(tt.tag)
+
+			plan, err := s.agent.Plan(s.ctx, tt.opts)
+			s.Require().NoError(err)
+			s.Assert().Equal(tt.tag, plan.Tag)
+			s.Assert().Equal(tt.level, plan.Level)
+			s.Assert().Equal(tt.notes, plan.Notes)
+		})
+	}
+}
+
+func (s *ReleaseAgentTestSuite) TestPlan_SkipsTagsThatAreNotVersions() {
+	s.Run("earlier version", func() {
+		s.git.On("LastTag", s.ctx, "HEAD", "v[0-9]*").Return("v2-archive", nil).Once()
+		s.git.On("LastTag", s.ctx, "v2-archive^", "v[0-9]*").Return("v1.4.0", nil).Once()
+		s.git.On("CommitLog", s.ctx, "v1.4.0", "HEAD").Return([]common.Commit{{Hash: "a000000000", Subject: "fix: crash"}}, nil).Once()
+		s.notTagged("v1.4.1")
+
+		plan, err := s.agent.Plan(s.ctx, ReleaseOptions{})
+		s.Require().NoError(err)
+		s.Assert().Equal("v1.4.0", plan.Previous)
+		s.Assert().Equal("v1.4.1", plan.Tag)
+	})
+
+	s.Run("only tag is on the root commit", func() {
+		s.git.On("LastTag", s.ctx, "HEAD", "v[0-9]*").Return("v1-import", nil).Once()
+		s.git.On("LastTag", s.ctx, "v1-import^", "v[0-9]*").Return("", errors.New("unknown revision v1-import^")).Once()
+		s.git.On("CommitLog", s.ctx, "", "HEAD").Return([]common.Commit{{Hash: "a000000000", Subject: "feat: export"}}, nil).Once()
+		s.notTagged("v0.1.0")
+
+		plan, err := s.agent.Plan(s.ctx, ReleaseOptions{})
+		s.Require().NoError(err)
+		s.Assert().Equal("", plan.Previous)
+		s.Assert().Equal("v0.1.0", plan.Tag)
+	})
+}
+
+func (s *ReleaseAgentTestSuite) TestPlan_Errors() {
+	s.Run("nothing to release", func() {
+		s.expectHistory("v1.0.0", "docs: usage", "chore: bump deps")
+		_, err := s.agent.Plan(s.ctx, ReleaseOptions{})
+		var appErr *apierrors.AppError
+		s.Require().ErrorAs(err, &appErr)
+		s.Assert().Equal(apierrors.ErrNothingToCommit, appErr.Type)
+		s.Assert().Equal("None of the 2 commits since v1.0.0 call for a release; choose a bump to release anyway", appErr.Message)
+	})
+
+	s.Run("no commits", func() {
+		s.expectHistory("v1.0.0")
+		_, err := s.agent.Plan(s.ctx, ReleaseOptions{})
+		s.Assert().EqualError(err, "HEAD has no commits since v1.0.0")
+	})
+
+
+	s.Run("tag exists", func() {
+		s.expectHistory("v1.0.0", "fix: crash")
+		s.git.On("TagDate", s.ctx, "v1.0.1").Return("2024-05-01", nil).Once()
+		_, err := s.agent.Plan(s.ctx, ReleaseOptions{})
+		s.Assert().EqualError(err, "tag v1.0.1 already exists")
+	})
+}
+
+func (s *ReleaseAgentTestSuite) TestRelease_CreatesTag() {
+	s.expectHistory("v1.2.3", "feat(auth): retry logins", "fix: keep sessions")
+	s.notTagged("v1.3.0")
+	s.llm.On("Chat", s.ctx, mock.MatchedBy(func(prompt string) bool {
+		return s.Contains(prompt, "Write the release notes for v1.3.0, the release after v1.2.3.") &&
+			s.Contains(prompt, "- Added (auth): Retry logins\n") &&
+			s.Contains(prompt, "- Fixed: Keep sessions\n")
+	})).Return(`{"title": "More reliable logins.", "summary": "Logins are retried and sessions survive a refresh.",
+		"highlights": ["- Failed logins are retried", ""]}`, nil).Once()
+
+	message := "v1.3.0: More reliable logins\n\nLogins are retried and sessions survive a refresh.\n\n- Failed logins are retried\n"
+	s.display.On("ShowSection", "Release", "v1.2.3 → v1.3.0 (minor)\n\n"+
+		"minor  a000000  feat(auth): retry logins (new feature)\n"+
+		"patch  b000000  fix: keep sessions (bug fix)\n", mock.Anything).Return().Once()
+	s.display.On("ShowSection", "Tag Message", message, mock.Anything).Return().Once()
+	s.git.On("CreateTag", s.ctx, "v1.3.0", message).Return(nil).Once()
+	s.display.On("ShowSuccess", "Created tag v1.3.0. Push it with: git push origin v1.3.0").Return().Once()
+	s.input.WriteString("y\n")
+
+	tag, err := s.agent.Release(s.ctx, ReleaseOptions{})
+	s.Require().NoError(err)
+	s.Assert().Equal("v1.3.0", tag)
+	s.git.AssertExpectations(s.T())
+	s.display.AssertExpectations(s.T())
+	s.display.AssertCalled(s.T(), "ShowQuestion", "Create the annotated tag v1.3.0? (y/n/e to edit the message): ")
+}
+
+func (s *ReleaseAgentTestSuite) TestRelease_FallbackAndDryRun() {
+	s.expectHistory("", "feat(api)!: drop v1", "docs: usage")
+	s.notTagged("v0.1.0")
+	s.llm.On("Chat", s.ctx, mock.Anything).Return("", errors.New("timeout")).Once()
+	s.logger.On("Debug", mock.Anything, mock.Anything, mock.Anything).Return().Maybe()
+	s.display.On("ShowWarning", "Could not summarize the release, listing its changes instead: failed to get LLM response: timeout").Return().Once()
+	s.display.On("ShowSection", "Release", "v0.1.0 (minor, first release)\n\n"+
+		"major  a000000  feat(api)!: drop v1 (breaking change)\n\n"+
+		"1 other commit(s), such as docs or chores, do not change the version\n\n"+
+		"Breaking changes bump the minor version before 1.0.0\n", mock.Anything).Return().Once()
+	s.display.On("ShowSection", "Tag Message", "v0.1.0\n\nBreaking Changes:\n- api: Drop v1\n", mock.Anything).Return().Once()
+
+	tag, err := s.agent.Release(s.ctx, ReleaseOptions{DryRun: true})
+	s.Require().NoError(err)
+	s.Assert().Equal("v0.1.0", tag)
+	s.git.AssertNotCalled(s.T(), "CreateTag", mock.Anything, mock.Anything, mock.Anything)
+	s.display.AssertExpectations(s.T())
+}
+
+func (s *ReleaseAgentTestSuite) TestRelease_Declined() {
+	s.expectHistory("v1.0.0", "fix: crash")
+	s.notTagged("v1.0.1")
+	s.llm.On("Chat", s.ctx, mock.Anything).Return(`{"title": "Fixes", "summary": "A crash is fixed."}`, nil).Once()
+	s.display.On("ShowSection", mock.Anything, mock.Anything, mock.Anything).Return()
+	s.input.WriteString("n\n")
+
+	_, err := s.agent.Release(s.ctx, ReleaseOptions{})
+	var appErr *apierrors.AppError
+	s.Require().ErrorAs(err, &appErr)
+	s.Assert().Equal(apierrors.ErrCancelled, appErr.Type)
+	s.git.AssertNotCalled(s.T(), "CreateTag", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestReleaseResponse_Message(t *testing.T) {
+	r := ReleaseResponse{Summary: "  Fixes a crash.  "}
+	assert.Equal(t, "v1.0.1\n\nFixes a crash.\n", r.Message("v1.0.1"))
+}
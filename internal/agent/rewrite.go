@@ -0,0 +1,133 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-coders/git_gpt/pkg/apierrors"
+)
+
+// diffSource selects the changes a message is written for. The zero value
+// is the staged changes; amending and rewording compare revisions instead.
+type diffSource struct {
+	from string // base revision, empty for the staged changes
+	to   string // target revision, empty for the index
+}
+
+func (s diffSource) diff(ctx context.Context, git GitExecutor, exclude ...string) (string, error) {
+	if s.from == "" {
+		return git.GetDiff(ctx, true, exclude...)
+	}
+	return git.GetRangeDiff(ctx, s.from, s.to, exclude...)
+}
+
+func (s diffSource) stat(ctx context.Context, git GitExecutor) (string, error) {
+	if s.from == "" {
+		return git.GetDiffStat(ctx, true)
+	}
+	return git.GetRangeDiffStat(ctx, s.from, s.to)
+}
+
+// AmendCommit replaces the last commit. The message is written for the
+// commit's changes together with the staged ones, and the chosen message is
+// returned.
+func (a *CommitAgent) AmendCommit(ctx context.Context, opts CommitOptions) (string, error) {
+	if err := a.stageForOptions(ctx, opts.Stage); err != nil {
+		return "", err
+	}
+
+	base, err := a.git.ParentOf(ctx, "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("there is no commit to amend")
+	}
+	files, err := a.git.GetRangeChanges(ctx, base, "")
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "", apierrors.NewNothingToCommitError()
+	}
+
+	if published, err := a.git.RemoteBranchesContaining(ctx, "HEAD"); err == nil && len(published) > 0 {
+		a.display.ShowWarning(fmt.Sprintf("HEAD is already on %s; amending it rewrites published history", strings.Join(published, ", ")))
+	}
+
+	message, err := a.chooseMessage(ctx, files, diffSource{from: base}, opts)
+	if err != nil {
+		return "", err
+	}
+	if opts.DryRun {
+		return message, nil
+	}
+
+	a.recordUndoPoint(ctx, "commit --amend")
+	if err := a.git.Amend(ctx, message); err != nil {
+		return "", err
+	}
+	return message, nil
+}
+
+// RewordCommit writes a new message for rev, an unpushed commit on the
+// current branch, from that commit's own diff. The commits after it are
+// rebased onto the reworded one. A commit that is already on a remote
+// branch is refused.
+func (a *CommitAgent) RewordCommit(ctx context.Context, rev string, opts CommitOptions) (string, error) {
+	published, err := a.git.RemoteBranchesContaining(ctx, rev)
+	if err != nil {
+		return "", err
+	}
+	if len(published) > 0 {
+		return "", apierrors.NewPublishedCommitError(rev, published)
+	}
+
+	base, err := a.git.ParentOf(ctx, rev)
+	if err != nil {
+		return "", err
+	}
+	files, err := a.git.GetRangeChanges(ctx, base, rev)
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("%s changes no files, there is nothing to describe", rev)
+	}
+
+	message, err := a.chooseMessage(ctx, files, diffSource{from: base, to: rev}, opts)
+	if err != nil {
+		return "", err
+	}
+	if opts.DryRun {
+		return message, nil
+	}
+
+	a.recordUndoPoint(ctx, "reword "+rev)
+	if err := a.git.Reword(ctx, rev, message); err != nil {
+		return "", fmt.Errorf("failed to reword %s: %w", rev, err)
+	}
+	return message, nil
+}
+
+// HandleAmendCommit runs the interactive amend from the REPL
+func (a *CommitAgent) HandleAmendCommit(ctx context.Context) error {
+	message, err := a.AmendCommit(ctx, CommitOptions{})
+	if err != nil {
+		return a.showFlowError(err, "Nothing to amend")
+	}
+
+	subject, _, _ := strings.Cut(message, "\n")
+	a.display.ShowSuccess(fmt.Sprintf("Amended the last commit: %s", subject))
+	return nil
+}
+
+// HandleRewordCommit runs the interactive reword from the REPL
+func (a *CommitAgent) HandleRewordCommit(ctx context.Context, rev string) error {
+	message, err := a.RewordCommit(ctx, rev, CommitOptions{})
+	if err != nil {
+		return a.showFlowError(err, "Nothing to reword")
+	}
+
+	subject, _, _ := strings.Cut(message, "\n")
+	a.display.ShowSuccess(fmt.Sprintf("Reworded %s: %s", rev, subject))
+	return nil
+}
@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"errors"
+
+	"github.com/go-coders/git_gpt/internal/common"
+	"github.com/go-coders/git_gpt/pkg/apierrors"
+	"github.com/stretchr/testify/mock"
+)
+
+func (s *CommitAgentTestSuite) TestAmendCommit_DescribesCommitAndStagedChanges() {
+	files := []common.FileChange{{Path: "a.go", Status: "modified"}, {Path: "b.go", Status: "added"}}
+
+	s.git.On("ParentOf", s.ctx, "HEAD").Return("base", nil).Once()
+	s.git.On("GetRangeChanges", s.ctx, "base", "").Return(files, nil).Once()
+	s.git.On("RemoteBranchesContaining", s.ctx, "HEAD").Return([]string{"origin/main"}, nil).Once()
+	s.git.On("GetRangeDiff", s.ctx, "base", "").Return("amend diff", nil).Once()
+	s.llm.On("Chat", s.ctx, mock.MatchedBy(func(prompt string) bool {
+		return s.Contains(prompt, "amend diff")
+	})).Return(`{"summary": "Test", "suggestions": [{"message": "feat: add b"}]}`, nil).Once()
+	s.display.On("ShowWarning", "HEAD is already on origin/main; amending it rewrites published history").Return().Once()
+	s.git.On("CreateSnapshot", s.ctx, "commit --amend").Return(&common.Snapshot{}, nil).Once()
+	s.git.On("Amend", s.ctx, "feat: add b").Return(nil).Once()
+
+	message, err := s.agent.CommitWithOptions(s.ctx, CommitOptions{Amend: true, Yes: true})
+	s.Require().NoError(err)
+	s.Assert().Equal("feat: add b", message)
+	s.git.AssertExpectations(s.T())
+	s.git.AssertNotCalled(s.T(), "Commit", mock.Anything, mock.Anything)
+}
+
+func (s *CommitAgentTestSuite) TestAmendCommit_NoCommit() {
+	s.git.On("ParentOf", s.ctx, "HEAD").Return("", errors.New("unknown commit HEAD")).Once()
+
+	_, err := s.agent.AmendCommit(s.ctx, CommitOptions{Yes: true})
+	s.Assert().ErrorContains(err, "there is no commit to amend")
+}
+
+func (s *CommitAgentTestSuite) TestRewordCommit_UsesTheCommitDiff() {
+	files := []common.FileChange{{Path: "a.go", Status: "modified"}}
+
+	s.git.On("RemoteBranchesContaining", s.ctx, "HEAD~1").Return([]string{}, nil).Once()
+	s.git.On("ParentOf", s.ctx, "HEAD~1").Return("base", nil).Once()
+	s.git.On("GetRangeChanges", s.ctx, "base", "HEAD~1").Return(files, nil).Once()
+	s.git.On("GetRangeDiff", s.ctx, "base", "HEAD~1").Return("old diff", nil).Once()
+	s.llm.On("Chat", s.ctx, mock.MatchedBy(func(prompt string) bool {
+		return s.Contains(prompt, "old diff")
+	})).Return(`{"summary": "Test", "suggestions": [{"message": "fix: one"}, {"message": "fix: two"}]}`, nil).Once()
+	s.git.On("CreateSnapshot", s.ctx, "reword HEAD~1").Return(&common.Snapshot{}, nil).Once()
+	s.git.On("Reword", s.ctx, "HEAD~1", "fix: two").Return(nil).Once()
+
+	message, err := s.agent.RewordCommit(s.ctx, "HEAD~1", CommitOptions{Pick: 2})
+	s.Require().NoError(err)
+	s.Assert().Equal("fix: two", message)
+	s.git.AssertExpectations(s.T())
+}
+
+func (s *CommitAgentTestSuite) TestRewordCommit_DryRun() {
+	s.git.On("RemoteBranchesContaining", s.ctx, "abc123").Return([]string{}, nil).Once()
+	s.git.On("ParentOf", s.ctx, "abc123").Return("base", nil).Once()
+	s.git.On("GetRangeChanges", s.ctx, "base", "abc123").Return([]common.FileChange{{Path: "a.go", Status: "added"}}, nil).Once()
+	s.git.On("GetRangeDiff", s.ctx, "base", "abc123").Return("diff", nil).Once()
+	s.llm.On("Chat", s.ctx, mock.Anything).
+		Return(`{"summary": "Test", "suggestions": [{"message": "feat: add a"}]}`, nil).Once()
+
+	message, err := s.agent.RewordCommit(s.ctx, "abc123", CommitOptions{Yes: true, DryRun: true})
+	s.Require().NoError(err)
+	s.Assert().Equal("feat: add a", message)
+	s.git.AssertNotCalled(s.T(), "Reword", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *CommitAgentTestSuite) TestRewordCommit_Refused() {
+	s.Run("published commit", func() {
+		s.git.On("RemoteBranchesContaining", s.ctx, "HEAD~3").Return([]string{"origin/main", "origin/dev"}, nil).Once()
+
+		_, err := s.agent.RewordCommit(s.ctx, "HEAD~3", CommitOptions{Yes: true})
+		var appErr *apierrors.AppError
+		s.Require().ErrorAs(err, &appErr)
+		s.Assert().Equal(apierrors.ErrPublishedCommit, appErr.Type)
+		s.Assert().Contains(err.Error(), "origin/main, origin/dev")
+	})
+
+	s.Run("empty commit", func() {
+		s.git.On("RemoteBranchesContaining", s.ctx, "HEAD").Return([]string{}, nil).Once()
+		s.git.On("ParentOf", s.ctx, "HEAD").Return("base", nil).Once()
+		s.git.On("GetRangeChanges", s.ctx, "base", "HEAD").Return([]common.FileChange{}, nil).Once()
+
+		_, err := s.agent.RewordCommit(s.ctx, "HEAD", CommitOptions{Yes: true})
+		s.Assert().ErrorContains(err, "changes no files")
+	})
+
+	s.git.AssertNotCalled(s.T(), "Reword", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,193 @@
+package agent
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// SecretFinding is a possible secret or piece of personal data that was
+// redacted from text before it was sent to the model
+type SecretFinding struct {
+	Rule string
+	Line int // 1-based line in the scanned text
+}
+
+// secretRule finds one kind of secret. When the pattern has a capture group
+// only the group is redacted, so "password = ..." keeps its name.
+type secretRule struct {
+	name    string
+	pattern *regexp.Regexp
+	valid   func(match string) bool
+}
+
+var defaultSecretRules = []secretRule{
+	{name: "aws-access-key", pattern: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{name: "github-token", pattern: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{name: "slack-token", pattern: regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{name: "api-key", pattern: regexp.MustCompile(`\bsk-(?:[A-Za-z0-9]+-)*[A-Za-z0-9_-]{20,}`)},
+	{name: "google-api-key", pattern: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}`)},
+	{name: "stripe-key", pattern: regexp.MustCompile(`\b[rs]k_live_[0-9A-Za-z]{24,}`)},
+	{name: "jwt", pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+	{name: "password", pattern: regexp.MustCompile(`(?i)\b(?:password|passwd|secret|token|api[_-]?key|access[_-]?key)["']?\s*[:=]\s*["']([^"'\s]{8,})["']`)},
+	{name: "credit-card", pattern: regexp.MustCompile(`\b[3-6]\d{3}(?:[ -]?\d){9,15}\b`), valid: luhnValid},
+	{name: "high-entropy", pattern: regexp.MustCompile(`[A-Za-z0-9+/_=-]{32,}`), valid: highEntropy},
+}
+
+var (
+	privateKeyBegin = regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----`)
+	privateKeyEnd   = regexp.MustCompile(`-----END [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----`)
+	privateKeyBody  = regexp.MustCompile(`[A-Za-z0-9/=][A-Za-z0-9+/=]{15,}`)
+)
+
+// SecretScanner redacts API keys, tokens, private keys, high-entropy strings
+// and card numbers from text. It runs locally, before a diff or git output
+// is put into a prompt.
+type SecretScanner struct {
+	rules []secretRule
+}
+
+// NewSecretScanner returns a scanner with the built-in rules and the extra
+// regular expressions in patterns
+func NewSecretScanner(patterns []string) (*SecretScanner, error) {
+	rules := append([]secretRule(nil), defaultSecretRules...)
+	for _, p := range patterns {
+		pattern, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret pattern %q: %w", p, err)
+		}
+		rules = append(rules, secretRule{name: "custom", pattern: pattern})
+	}
+	return &SecretScanner{rules: rules}, nil
+}
+
+// DefaultSecretScanner returns a scanner with the built-in rules only
+func DefaultSecretScanner() *SecretScanner {
+	return &SecretScanner{rules: defaultSecretRules}
+}
+
+// Redact replaces each secret in text with [REDACTED:<rule>] and reports
+// where it was found
+func (s *SecretScanner) Redact(text string) (string, []SecretFinding) {
+	var findings []SecretFinding
+	lines := strings.Split(text, "\n")
+	inKey := false
+
+	for i, line := range lines {
+		// Private keys span lines, so the whole block is redacted
+		switch {
+		case privateKeyBegin.MatchString(line):
+			inKey = !privateKeyEnd.MatchString(line)
+			lines[i] = privateKeyBody.ReplaceAllString(privateKeyBegin.ReplaceAllString(line, "[REDACTED:private-key]"), "[REDACTED:private-key]")
+			findings = append(findings, SecretFinding{Rule: "private-key", Line: i + 1})
+			continue
+		case inKey:
+			inKey = !privateKeyEnd.MatchString(line)
+			lines[i] = privateKeyBody.ReplaceAllString(line, "[REDACTED:private-key]")
+			continue
+		}
+
+		for _, rule := range s.rules {
+			line = rule.redact(line, func() {
+				findings = append(findings, SecretFinding{Rule: rule.name, Line: i + 1})
+			})
+		}
+		lines[i] = line
+	}
+
+	if len(findings) == 0 {
+		return text, nil
+	}
+	return strings.Join(lines, "\n"), findings
+}
+
+// redact replaces the matches of the rule in line, calling found for each
+func (r secretRule) redact(line string, found func()) string {
+	matches := r.pattern.FindAllStringSubmatchIndex(line, -1)
+	if len(matches) == 0 {
+		return line
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if len(m) >= 4 && m[2] >= 0 {
+			start, end = m[2], m[3]
+		}
+		if r.valid != nil && !r.valid(line[start:end]) {
+			continue
+		}
+		// A rule that ran earlier may have redacted this part already
+		if strings.Contains(line[start:end], "[REDACTED:") {
+			continue
+		}
+		b.WriteString(line[last:start])
+		b.WriteString("[REDACTED:" + r.name + "]")
+		last = end
+		found()
+	}
+	b.WriteString(line[last:])
+	return b.String()
+}
+
+// luhnValid reports whether the digits of s pass the Luhn checksum used by
+// card numbers
+func luhnValid(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// highEntropy reports whether s looks like a random key rather than a word,
+// path or hash. Hex strings such as commit ids are never flagged.
+func highEntropy(s string) bool {
+	var upper, lower, digit bool
+	hex := true
+	for _, r := range s {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+		if !strings.ContainsRune("0123456789abcdefABCDEF-", r) {
+			hex = false
+		}
+	}
+	if hex || !upper || !lower || !digit {
+		return false
+	}
+	return shannonEntropy(s) >= 4.5
+}
+
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	entropy := 0.0
+	n := float64(len(s))
+	for _, c := range counts {
+		p := float64(c) / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
@@ -0,0 +1,378 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-coders/git_gpt/internal/common"
+	"github.com/go-coders/git_gpt/pkg/apierrors"
+)
+
+// splitUnit is the smallest piece of a staged change a split can move: one
+// hunk, or a whole file when the file cannot be divided
+type splitUnit struct {
+	ID     string
+	Change common.FileChange
+	Diff   *common.FileDiff
+	Hunk   int // -1 for the whole file
+}
+
+// label names the unit for the grouping editor
+func (u splitUnit) label() string {
+	if u.Hunk < 0 {
+		return u.Change.DisplayPath()
+	}
+	return fmt.Sprintf("%s %s", u.Change.Path, u.Diff.Hunks[u.Hunk].Header)
+}
+
+// splitGroup is one proposed commit, with indexes into the unit list
+type splitGroup struct {
+	Message string
+	Units   []int
+}
+
+// SplitCommit records the staged changes as several logical commits proposed
+// by the model. Unless opts.Yes is set the user can edit the grouping first.
+// It returns the commit messages in order; with opts.DryRun nothing is
+// committed.
+func (a *CommitAgent) SplitCommit(ctx context.Context, opts CommitOptions) ([]string, error) {
+	if err := a.stageForOptions(ctx, opts.Stage); err != nil {
+		return nil, err
+	}
+
+	staged, _, err := a.git.GetStatus(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status: %w", err)
+	}
+	if len(staged) == 0 {
+		return nil, apierrors.NewNothingToCommitError()
+	}
+
+	units, err := a.collectSplitUnits(ctx, staged)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := a.proposeSplit(ctx, units)
+	if err != nil {
+		return nil, err
+	}
+
+	if !opts.Yes {
+		if groups, err = a.editSplit(units, groups); err != nil {
+			return nil, err
+		}
+	}
+
+	// The ticket reference is added last so that editing shows plain subjects
+	ticket := a.branchTicket(ctx)
+	messages := make([]string, len(groups))
+	for i, group := range groups {
+		groups[i].Message = ticket.Apply(CommitSuggestion{Message: group.Message}).Text()
+		messages[i], _, _ = strings.Cut(groups[i].Message, "\n")
+	}
+	if opts.DryRun {
+		return messages, nil
+	}
+
+	a.recordUndoPoint(ctx, "commit --split")
+	if err := a.git.CommitSplit(ctx, buildCommitSteps(units, groups)); err != nil {
+		return nil, fmt.Errorf("failed to split the commit, the original index was restored: %w", err)
+	}
+	return messages, nil
+}
+
+// HandleSplitCommit runs the interactive split from the REPL
+func (a *CommitAgent) HandleSplitCommit(ctx context.Context) error {
+	messages, err := a.SplitCommit(ctx, CommitOptions{})
+	if err != nil {
+		return a.showFlowError(err, "No staged changes to split")
+	}
+
+	for _, message := range messages {
+		a.display.ShowSuccess(fmt.Sprintf("Committed: %s", message))
+	}
+	return nil
+}
+
+// showFlowError reports the expected ends of an interactive flow, nothing to
+// do or cancelled, as information. Other errors are returned.
+func (a *CommitAgent) showFlowError(err error, nothing string) error {
+	var appErr *apierrors.AppError
+	if !errors.As(err, &appErr) {
+		return err
+	}
+	switch appErr.Type {
+	case apierrors.ErrNothingToCommit:
+		a.display.ShowInfo(nothing)
+		return nil
+	case apierrors.ErrCancelled:
+		a.display.ShowInfo("Commit cancelled")
+		return nil
+	}
+	return err
+}
+
+// collectSplitUnits divides each staged file into hunks. Renames, copies,
+// submodules, binary files and single-hunk files stay whole.
+func (a *CommitAgent) collectSplitUnits(ctx context.Context, staged []common.FileChange) ([]splitUnit, error) {
+	var units []splitUnit
+	for i, change := range staged {
+		diff, err := a.git.GetFileDiff(ctx, change.Path, true)
+		if err != nil {
+			return nil, err
+		}
+
+		whole := change.OrigPath != "" || change.Submodule != nil || diff.Binary || len(diff.Hunks) <= 1
+		if whole {
+			units = append(units, splitUnit{ID: strconv.Itoa(i + 1), Change: change, Diff: diff, Hunk: -1})
+			continue
+		}
+		for j := range diff.Hunks {
+			units = append(units, splitUnit{ID: fmt.Sprintf("%d.%d", i+1, j+1), Change: change, Diff: diff, Hunk: j})
+		}
+	}
+	return units, nil
+}
+
+func (a *CommitAgent) proposeSplit(ctx context.Context, units []splitUnit) ([]splitGroup, error) {
+	a.display.StartSpinner("Grouping changes into commits...")
+	defer a.display.StopSpinner()
+
+	var (
+		promptUnits = make([]PromptUnit, len(units))
+		secrets     []string
+		added       bool
+	)
+	for i, unit := range units {
+		text := "(binary)"
+		switch {
+		case unit.Hunk >= 0:
+			text = unit.Diff.Hunks[unit.Hunk].Text
+		case !unit.Diff.Binary:
+			var b strings.Builder
+			for _, hunk := range unit.Diff.Hunks {
+				b.WriteString(hunk.Text)
+			}
+			text = b.String()
+		}
+		text, found, add := a.redactDiff(text, unit.Change.Path)
+		secrets, added = append(secrets, found...), added || add
+		promptUnits[i] = PromptUnit{
+			ID:     unit.ID,
+			Status: unit.Change.Status,
+			Path:   unit.Change.DisplayPath(),
+			Text:   strings.TrimRight(text, "\n"),
+		}
+	}
+	if err := a.reportSecrets(secrets, added); err != nil {
+		return nil, err
+	}
+
+	prompt, err := a.prompts.GetSplitPrompt(promptUnits)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate split prompt: %w", err)
+	}
+
+	response, err := a.llm.Chat(ctx, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get LLM response: %w", err)
+	}
+
+	cleanedResponse := cleanJSONResponse(response)
+	a.logger.Debug("Cleaned LLM response: %s", cleanedResponse)
+
+	var result SplitResponse
+	if err := json.Unmarshal([]byte(cleanedResponse), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse split: %w", err)
+	}
+
+	groups := normalizeSplit(units, result)
+	if len(groups) == 0 {
+		return nil, fmt.Errorf("no commits proposed")
+	}
+	return groups, nil
+}
+
+// normalizeSplit turns the model's grouping into groups that use every unit
+// exactly once. Unknown and repeated ids are dropped, groups without a
+// message or units are skipped, and units left out join the last group.
+func normalizeSplit(units []splitUnit, response SplitResponse) []splitGroup {
+	index := make(map[string]int, len(units))
+	for i, unit := range units {
+		index[unit.ID] = i
+	}
+
+	used := make([]bool, len(units))
+	var groups []splitGroup
+	for _, suggestion := range response.Commits {
+		message := strings.TrimSpace(suggestion.Message)
+		if message == "" {
+			continue
+		}
+
+		group := splitGroup{Message: message}
+		for _, id := range suggestion.Units {
+			i, ok := index[strings.TrimSpace(id)]
+			if !ok || used[i] {
+				continue
+			}
+			used[i] = true
+			group.Units = append(group.Units, i)
+		}
+		if len(group.Units) > 0 {
+			sort.Ints(group.Units)
+			groups = append(groups, group)
+		}
+	}
+
+	if len(groups) == 0 {
+		return nil
+	}
+	last := &groups[len(groups)-1]
+	for i := range units {
+		if !used[i] {
+			last.Units = append(last.Units, i)
+		}
+	}
+	sort.Ints(last.Units)
+	return groups
+}
+
+// editSplit shows the proposed commits and lets the user move units between
+// them and reword messages until the grouping is accepted
+func (a *CommitAgent) editSplit(units []splitUnit, groups []splitGroup) ([]splitGroup, error) {
+	for {
+		a.displaySplit(units, groups)
+
+		a.display.ShowQuestion("\nEnter to create these commits, \"m ID N\" to move a unit to commit N, \"e N\" to edit message N, \"c\" to cancel: ")
+		input, err := a.reader.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("failed to read input: %w", err)
+		}
+
+		fields := strings.Fields(input)
+		switch {
+		case len(fields) == 0:
+			return groups, nil
+
+		case fields[0] == "c":
+			return nil, apierrors.NewCancelledError()
+
+		case fields[0] == "m" && len(fields) == 3:
+			unit := -1
+			for i := range units {
+				if units[i].ID == fields[1] {
+					unit = i
+				}
+			}
+			target, err := strconv.Atoi(fields[2])
+			if unit < 0 || err != nil || target < 1 || target > len(groups)+1 {
+				a.display.ShowWarning("Invalid move, use m ID N with a unit id and a commit number")
+				continue
+			}
+
+			if target == len(groups)+1 {
+				message, err := a.readSplitMessage("Message for the new commit: ")
+				if err != nil {
+					return nil, err
+				}
+				if message == "" {
+					continue
+				}
+				groups = append(groups, splitGroup{Message: message})
+			}
+			groups = moveSplitUnit(groups, unit, target-1)
+
+		case fields[0] == "e" && len(fields) == 2:
+			n, err := strconv.Atoi(fields[1])
+			if err != nil || n < 1 || n > len(groups) {
+				a.display.ShowWarning(fmt.Sprintf("Invalid commit number: %s", fields[1]))
+				continue
+			}
+			message, err := a.readSplitMessage("New message: ")
+			if err != nil {
+				return nil, err
+			}
+			if message != "" {
+				groups[n-1].Message = message
+			}
+
+		default:
+			a.display.ShowWarning(fmt.Sprintf("Unknown command: %s", strings.TrimSpace(input)))
+		}
+	}
+}
+
+func (a *CommitAgent) readSplitMessage(prompt string) (string, error) {
+	a.display.ShowQuestion(prompt)
+	input, err := a.reader.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+	return strings.TrimSpace(input), nil
+}
+
+// moveSplitUnit moves unit into groups[target] and drops groups left empty
+func moveSplitUnit(groups []splitGroup, unit, target int) []splitGroup {
+	for i := range groups {
+		kept := groups[i].Units[:0]
+		for _, u := range groups[i].Units {
+			if u != unit {
+				kept = append(kept, u)
+			}
+		}
+		groups[i].Units = kept
+	}
+	groups[target].Units = append(groups[target].Units, unit)
+	sort.Ints(groups[target].Units)
+
+	result := groups[:0]
+	for _, group := range groups {
+		if len(group.Units) > 0 {
+			result = append(result, group)
+		}
+	}
+	return result
+}
+
+func (a *CommitAgent) displaySplit(units []splitUnit, groups []splitGroup) {
+	a.display.ShowSection("Proposed Commits", "", map[string]string{"icon": "✂️"})
+	for i, group := range groups {
+		a.display.ShowInfo(fmt.Sprintf("%d) %s", i+1, group.Message))
+		for _, u := range group.Units {
+			a.display.ShowInfo(fmt.Sprintf("     [%s] %s", units[u].ID, units[u].label()))
+		}
+	}
+}
+
+// buildCommitSteps turns the groups into commit steps with one part per file
+func buildCommitSteps(units []splitUnit, groups []splitGroup) []common.CommitStep {
+	steps := make([]common.CommitStep, len(groups))
+	for i, group := range groups {
+		steps[i].Message = group.Message
+
+		parts := make(map[*common.FileDiff]int)
+		for _, u := range group.Units {
+			unit := units[u]
+			p, ok := parts[unit.Diff]
+			if !ok {
+				part := common.CommitPart{Diff: unit.Diff}
+				if unit.Change.Status == "renamed" {
+					part.OrigPath = unit.Change.OrigPath
+				}
+				steps[i].Parts = append(steps[i].Parts, part)
+				p = len(steps[i].Parts) - 1
+				parts[unit.Diff] = p
+			}
+			if unit.Hunk >= 0 {
+				steps[i].Parts[p].Hunks = append(steps[i].Parts[p].Hunks, unit.Hunk)
+			}
+		}
+	}
+	return steps
+}
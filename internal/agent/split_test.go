@@ -0,0 +1,202 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/go-coders/git_gpt/internal/common"
+	"github.com/go-coders/git_gpt/pkg/apierrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func testSplitUnits() []splitUnit {
+	code := &common.FileDiff{Path: "code.go", Hunks: []common.Hunk{
+		{Header: "@@ -1 +1 @@", Text: "@@ -1 +1 @@\n-a\n+b\n"},
+		{Header: "@@ -9 +9 @@", Text: "@@ -9 +9 @@\n-c\n+d\n"},
+	}}
+	readme := &common.FileDiff{Path: "README.md", Hunks: []common.Hunk{{Header: "@@ -1 +1 @@"}}}
+	moved := &common.FileDiff{Path: "new.go"}
+
+	return []splitUnit{
+		{ID: "1.1", Change: common.FileChange{Path: "code.go", Status: "modified"}, Diff: code, Hunk: 0},
+		{ID: "1.2", Change: common.FileChange{Path: "code.go", Status: "modified"}, Diff: code, Hunk: 1},
+		{ID: "2", Change: common.FileChange{Path: "README.md", Status: "modified"}, Diff: readme, Hunk: -1},
+		{ID: "3", Change: common.FileChange{Path: "new.go", OrigPath: "old.go", Status: "renamed"}, Diff: moved, Hunk: -1},
+	}
+}
+
+func TestNormalizeSplit(t *testing.T) {
+	units := testSplitUnits()
+
+	testCases := []struct {
+		name     string
+		response SplitResponse
+		expected []splitGroup
+	}{
+		{
+			name: "complete grouping",
+			response: SplitResponse{Commits: []SplitSuggestion{
+				{Message: "refactor: rename", Units: []string{"3", "1.1"}},
+				{Message: "fix: bug", Units: []string{"1.2"}},
+				{Message: "docs: readme", Units: []string{"2"}},
+			}},
+			expected: []splitGroup{
+				{Message: "refactor: rename", Units: []int{0, 3}},
+				{Message: "fix: bug", Units: []int{1}},
+				{Message: "docs: readme", Units: []int{2}},
+			},
+		},
+		{
+			name: "unknown, repeated and missing units",
+			response: SplitResponse{Commits: []SplitSuggestion{
+				{Message: "fix: bug", Units: []string{"1.2", "9", " 1.2"}},
+				{Message: "", Units: []string{"2"}},
+				{Message: "chore: nothing", Units: []string{"1.2"}},
+				{Message: "docs: readme", Units: []string{"2"}},
+			}},
+			expected: []splitGroup{
+				{Message: "fix: bug", Units: []int{1}},
+				{Message: "docs: readme", Units: []int{0, 2, 3}},
+			},
+		},
+		{
+			name:     "nothing usable",
+			response: SplitResponse{Commits: []SplitSuggestion{{Message: "fix", Units: []string{"7"}}}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, normalizeSplit(units, tc.response))
+		})
+	}
+}
+
+func TestBuildCommitSteps(t *testing.T) {
+	units := testSplitUnits()
+	steps := buildCommitSteps(units, []splitGroup{
+		{Message: "refactor: rename", Units: []int{0, 3}},
+		{Message: "fix and docs", Units: []int{1, 2}},
+	})
+
+	assert.Equal(t, []common.CommitStep{
+		{Message: "refactor: rename", Parts: []common.CommitPart{
+			{Diff: units[0].Diff, Hunks: []int{0}},
+			{Diff: units[3].Diff, OrigPath: "old.go"},
+		}},
+		{Message: "fix and docs", Parts: []common.CommitPart{
+			{Diff: units[1].Diff, Hunks: []int{1}},
+			{Diff: units[2].Diff},
+		}},
+	}, steps)
+}
+
+func TestMoveSplitUnit(t *testing.T) {
+	groups := []splitGroup{
+		{Message: "one", Units: []int{0, 1}},
+		{Message: "two", Units: []int{2}},
+	}
+
+	groups = moveSplitUnit(groups, 2, 0)
+	assert.Equal(t, []splitGroup{{Message: "one", Units: []int{0, 1, 2}}}, groups)
+}
+
+func (s *CommitAgentTestSuite) expectSplitProposal(response SplitResponse) []common.FileChange {
+	staged := []common.FileChange{
+		{Path: "code.go", Status: "modified"},
+		{Path: "README.md", Status: "added"},
+	}
+	code := &common.FileDiff{Path: "code.go", Hunks: []common.Hunk{
+		{Header: "@@ -1 +1 @@", Text: "@@ -1 +1 @@\n-a\n+b\n"},
+		{Header: "@@ -9 +9 @@", Text: "@@ -9 +9 @@\n-c\n+d\n"},
+	}}
+	readme := &common.FileDiff{Path: "README.md", Hunks: []common.Hunk{{Header: "@@ -0,0 +1 @@", Text: "@@ -0,0 +1 @@\n+docs\n"}}}
+
+	s.git.On("GetStatus", s.ctx).Return(staged, []common.FileChange{}, nil).Once()
+	s.git.On("GetFileDiff", s.ctx, "code.go", true).Return(code, nil).Once()
+	s.git.On("GetFileDiff", s.ctx, "README.md", true).Return(readme, nil).Once()
+
+	body, _ := json.Marshal(response)
+	s.llm.On("Chat", s.ctx, mock.MatchedBy(func(prompt string) bool {
+		return assert.Contains(s.T(), prompt, "[1.2] modified: code.go") &&
+			assert.Contains(s.T(), prompt, "[2] added: README.md\n@@ -0,0 +1 @@\n+docs")
+	})).Return(string(body), nil).Once()
+	return staged
+}
+
+func (s *CommitAgentTestSuite) TestSplitCommit_EditAndCommit() {
+	s.expectSplitProposal(SplitResponse{Commits: []SplitSuggestion{
+		{Message: "refactor: tidy", Units: []string{"1.1"}},
+		{Message: "fix: bug", Units: []string{"1.2", "2"}},
+	}})
+
+	s.git.On("CreateSnapshot", s.ctx, "commit --split").Return(&common.Snapshot{}, nil).Once()
+	s.git.On("CommitSplit", s.ctx, mock.MatchedBy(func(steps []common.CommitStep) bool {
+		return len(steps) == 3 &&
+			steps[0].Message == "refactor: tidy" && assert.Equal(s.T(), []int{0}, steps[0].Parts[0].Hunks) &&
+			steps[1].Message == "fix: off by one" && assert.Equal(s.T(), []int{1}, steps[1].Parts[0].Hunks) &&
+			steps[2].Message == "docs: add readme" && steps[2].Parts[0].Diff.Path == "README.md" && steps[2].Parts[0].Hunks == nil
+	})).Return(nil).Once()
+
+	s.display.On("ShowSection", "Proposed Commits", "", mock.Anything).Return()
+	s.display.On("ShowInfo", mock.Anything).Return()
+	s.display.On("ShowWarning", mock.Anything).Return()
+
+	// Move the readme into a new commit, reword commit 2, try a bad move, accept
+	s.input.WriteString("m 2 3\ndocs: add readme\ne 2\nfix: off by one\nm 9 1\n\n")
+
+	messages, err := s.agent.SplitCommit(s.ctx, CommitOptions{})
+	s.Require().NoError(err)
+	s.Assert().Equal([]string{"refactor: tidy", "fix: off by one", "docs: add readme"}, messages)
+	s.display.AssertCalled(s.T(), "ShowWarning", "Invalid move, use m ID N with a unit id and a commit number")
+	s.git.AssertExpectations(s.T())
+}
+
+func (s *CommitAgentTestSuite) TestSplitCommit_DryRunDoesNotCommit() {
+	s.expectSplitProposal(SplitResponse{Commits: []SplitSuggestion{
+		{Message: "feat: everything", Units: []string{"1.1", "1.2", "2"}},
+	}})
+
+	messages, err := s.agent.SplitCommit(s.ctx, CommitOptions{Yes: true, DryRun: true})
+	s.Require().NoError(err)
+	s.Assert().Equal([]string{"feat: everything"}, messages)
+	s.git.AssertNotCalled(s.T(), "CommitSplit", mock.Anything, mock.Anything)
+}
+
+func (s *CommitAgentTestSuite) TestSplitCommit_Cancelled() {
+	s.expectSplitProposal(SplitResponse{Commits: []SplitSuggestion{
+		{Message: "feat: everything", Units: []string{"1.1", "1.2", "2"}},
+	}})
+	s.display.On("ShowSection", "Proposed Commits", "", mock.Anything).Return()
+	s.display.On("ShowInfo", mock.Anything).Return()
+
+	s.input.WriteString("c\n")
+
+	err := s.agent.HandleSplitCommit(s.ctx)
+	s.Require().NoError(err)
+	s.display.AssertCalled(s.T(), "ShowInfo", "Commit cancelled")
+	s.git.AssertNotCalled(s.T(), "CommitSplit", mock.Anything, mock.Anything)
+}
+
+func (s *CommitAgentTestSuite) TestSplitCommit_FailureIsReported() {
+	s.expectSplitProposal(SplitResponse{Commits: []SplitSuggestion{
+		{Message: "feat: everything", Units: []string{"1.1", "1.2", "2"}},
+	}})
+	s.git.On("CreateSnapshot", s.ctx, "commit --split").Return(&common.Snapshot{}, nil).Once()
+	s.git.On("CommitSplit", s.ctx, mock.Anything).Return(errors.New("commit 1: hook failed")).Once()
+
+	_, err := s.agent.SplitCommit(s.ctx, CommitOptions{Yes: true})
+	s.Require().Error(err)
+	s.Assert().Contains(err.Error(), "original index was restored")
+}
+
+func (s *CommitAgentTestSuite) TestSplitCommit_NothingStaged() {
+	s.git.On("GetStatus", s.ctx).Return([]common.FileChange{}, []common.FileChange{{Path: "a.go", Status: "modified"}}, nil).Once()
+
+	_, err := s.agent.SplitCommit(s.ctx, CommitOptions{Split: true})
+	var appErr *apierrors.AppError
+	s.Require().ErrorAs(err, &appErr)
+	s.Assert().Equal(apierrors.ErrNothingToCommit, appErr.Type)
+}
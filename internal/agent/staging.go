@@ -0,0 +1,222 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-coders/git_gpt/internal/common"
+)
+
+// stagingSelection tracks the files and hunks picked in the staging UI. A
+// file with an entry in hunks is staged partially.
+type stagingSelection struct {
+	files    []common.FileChange
+	selected []bool
+	diffs    map[int]*common.FileDiff
+	hunks    map[int][]bool
+}
+
+func newStagingSelection(files []common.FileChange) *stagingSelection {
+	return &stagingSelection{
+		files:    files,
+		selected: make([]bool, len(files)),
+		diffs:    make(map[int]*common.FileDiff),
+		hunks:    make(map[int][]bool),
+	}
+}
+
+func (s *stagingSelection) toggle(index int) {
+	s.selected[index] = !s.selected[index]
+	delete(s.hunks, index)
+}
+
+func (s *stagingSelection) setAll(selected bool) {
+	for i := range s.selected {
+		s.selected[i] = selected
+	}
+	s.hunks = make(map[int][]bool)
+}
+
+// parseIndex turns a 1-based file number into an index
+func (s *stagingSelection) parseIndex(field string) (int, error) {
+	n, err := strconv.Atoi(field)
+	if err != nil || n < 1 || n > len(s.files) {
+		return 0, fmt.Errorf("invalid selection: %s", field)
+	}
+	return n - 1, nil
+}
+
+// selectChanges lets the user pick files, and hunks within modified files,
+// to stage. It returns true when something was staged.
+func (a *CommitAgent) selectChanges(ctx context.Context, changes []common.FileChange) (bool, error) {
+	selection := newStagingSelection(changes)
+
+	for {
+		a.displaySelection(selection)
+
+		a.display.ShowQuestion("\nToggle files by number, \"h N\" to pick hunks of file N, \"a\" all, \"n\" none, Enter to stage, \"q\" to cancel: ")
+		input, err := a.reader.ReadString('\n')
+		if err != nil {
+			return false, fmt.Errorf("failed to read input: %w", err)
+		}
+
+		fields := strings.Fields(input)
+		switch {
+		case len(fields) == 0:
+			return a.stageSelection(ctx, selection)
+		case fields[0] == "q":
+			a.display.ShowInfo("Commit cancelled")
+			return false, nil
+		case fields[0] == "a":
+			selection.setAll(true)
+		case fields[0] == "n":
+			selection.setAll(false)
+		case fields[0] == "h":
+			if len(fields) != 2 {
+				a.display.ShowWarning("Usage: h N")
+				continue
+			}
+			index, err := selection.parseIndex(fields[1])
+			if err != nil {
+				a.display.ShowWarning(err.Error())
+				continue
+			}
+			if err := a.selectHunks(ctx, selection, index); err != nil {
+				return false, err
+			}
+		default:
+			for _, field := range fields {
+				index, err := selection.parseIndex(field)
+				if err != nil {
+					a.display.ShowWarning(err.Error())
+					continue
+				}
+				selection.toggle(index)
+			}
+		}
+	}
+}
+
+// selectHunks asks about each hunk of a modified file
+func (a *CommitAgent) selectHunks(ctx context.Context, selection *stagingSelection, index int) error {
+	change := selection.files[index]
+	if change.Status != "modified" || change.Submodule != nil {
+		a.display.ShowWarning(fmt.Sprintf("%s can only be staged as a whole", change.Path))
+		return nil
+	}
+
+	diff, ok := selection.diffs[index]
+	if !ok {
+		var err error
+		if diff, err = a.git.GetFileDiff(ctx, change.Path, false); err != nil {
+			return err
+		}
+		selection.diffs[index] = diff
+	}
+	if diff.Binary || len(diff.Hunks) == 0 {
+		a.display.ShowWarning(fmt.Sprintf("%s can only be staged as a whole", change.Path))
+		return nil
+	}
+
+	chosen := make([]bool, len(diff.Hunks))
+	for i, hunk := range diff.Hunks {
+		title := fmt.Sprintf("Hunk %d/%d: %s", i+1, len(diff.Hunks), change.Path)
+		a.display.ShowSection(title, strings.TrimRight(hunk.Text, "\n"), nil)
+
+		a.display.ShowQuestion("Stage this hunk? (y/n, q to stop): ")
+		input, err := a.reader.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("failed to read input: %w", err)
+		}
+		answer := strings.TrimSpace(input)
+		if answer == "q" {
+			break
+		}
+		chosen[i] = answer == "y"
+	}
+
+	count := 0
+	for _, c := range chosen {
+		if c {
+			count++
+		}
+	}
+
+	// All or nothing is the same as picking the whole file
+	selection.selected[index] = count > 0
+	delete(selection.hunks, index)
+	if count > 0 && count < len(chosen) {
+		selection.hunks[index] = chosen
+	}
+	return nil
+}
+
+func (a *CommitAgent) displaySelection(selection *stagingSelection) {
+	items := make([][2]string, len(selection.files))
+	for i, change := range selection.files {
+		mark := "[ ]"
+		description := ""
+		if selection.selected[i] {
+			mark = "[x]"
+		}
+		if chosen, ok := selection.hunks[i]; ok {
+			mark = "[~]"
+			count := 0
+			for _, c := range chosen {
+				if c {
+					count++
+				}
+			}
+			description = fmt.Sprintf("%d/%d hunks", count, len(chosen))
+		}
+		items[i] = [2]string{
+			fmt.Sprintf("%s %s %s", mark, getStatusSymbol(change.Status), change.DisplayPath()),
+			description,
+		}
+	}
+
+	a.display.ShowSection("Select Changes", "", map[string]string{"icon": "☑️"})
+	a.display.ShowNumberedList(items)
+}
+
+// stageSelection stages whole files with git add and partial files hunk by hunk
+func (a *CommitAgent) stageSelection(ctx context.Context, selection *stagingSelection) (bool, error) {
+	var files []string
+	for i, change := range selection.files {
+		if selection.selected[i] {
+			if _, partial := selection.hunks[i]; !partial {
+				files = append(files, change.Path)
+			}
+		}
+	}
+	if len(files) == 0 && len(selection.hunks) == 0 {
+		a.display.ShowInfo("Nothing selected, commit cancelled")
+		return false, nil
+	}
+
+	if len(files) > 0 {
+		if err := a.git.StageFiles(ctx, files); err != nil {
+			return false, fmt.Errorf("failed to stage changes: %w", err)
+		}
+	}
+	for i := range selection.files {
+		chosen, ok := selection.hunks[i]
+		if !ok {
+			continue
+		}
+		var hunks []int
+		for j, c := range chosen {
+			if c {
+				hunks = append(hunks, j)
+			}
+		}
+		if err := a.git.StageHunks(ctx, selection.diffs[i], hunks); err != nil {
+			return false, err
+		}
+	}
+
+	a.display.ShowSuccess("Selected changes staged successfully")
+	return true, nil
+}
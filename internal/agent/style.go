@@ -0,0 +1,370 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Commit conventions a StyleProfile can detect
+const (
+	StyleConventional = "conventional" // "feat(scope): subject"
+	StyleGitmoji      = "gitmoji"      // "✨ subject"
+	StyleTicket       = "ticket"       // "PROJ-123 subject"
+	StyleBracket      = "bracket"      // "[area] subject"
+	StyleComponent    = "component"    // "area: subject"
+	StyleFreeForm     = "free-form"
+)
+
+const (
+	// styleSampleSize is how many recent subjects the profile is built from
+	styleSampleSize = 100
+	// minStyleSamples is the shortest history worth learning from
+	minStyleSamples = 5
+	// styleExamples is how many subjects are shown to the model
+	styleExamples = 5
+	// styleCacheTTL is how long a cached profile is reused
+	styleCacheTTL = 7 * 24 * time.Hour
+	// styleCacheFile is the cache location inside the git directory
+	styleCacheFile = "ggpt/style.json"
+)
+
+var (
+	conventionalTypes  = []string{"feat", "fix", "docs", "style", "refactor", "perf", "test", "build", "ci", "chore", "revert"}
+	conventionalHeader = regexp.MustCompile(`^([a-z]+)(\([^)]*\))?!?: `)
+	gitmojiCode        = regexp.MustCompile(`^:[a-z0-9_+-]+:\s*`)
+	ticketRef          = regexp.MustCompile(`\b([A-Z][A-Z0-9]+)-\d+\b|#\d+\b`)
+	ticketPrefix       = regexp.MustCompile(`^[\[(]?(?:[A-Z][A-Z0-9]+-\d+|#\d+)[\])]?:?\s+`)
+	bracketPrefix      = regexp.MustCompile(`^\[[^\]]+\]\s+`)
+	componentPrefix    = regexp.MustCompile(`^[\w./-]+(?:, ?[\w./-]+)*: `)
+)
+
+// StyleProfile is the commit message style a repository uses, learned from
+// the subjects of its recent commits
+type StyleProfile struct {
+	Convention string   `json:"convention"`
+	Types      []string `json:"types,omitempty"`  // most used conventional types
+	Ticket     string   `json:"ticket,omitempty"` // a typical ticket reference, such as "PROJ-123"
+	Language   string   `json:"language"`
+	// Case is "upper" or "lower" for the first letter of the description,
+	// or empty when the history is mixed
+	Case       string    `json:"case,omitempty"`
+	Period     bool      `json:"period"`      // subjects end with a period
+	PastTense  bool      `json:"past_tense"`  // "added" rather than "add"
+	TypicalLen int       `json:"typical_len"` // median subject length in characters
+	MaxLen     int       `json:"max_len"`     // 90th percentile subject length
+	Examples   []string  `json:"examples"`
+	Sampled    int       `json:"sampled"`
+	Created    time.Time `json:"created"`
+}
+
+// LoadStyleProfile returns the commit style of the repository. A profile is
+// cached in the git directory and reused for a week. It returns nil when
+// the history is too short to learn from.
+func LoadStyleProfile(ctx context.Context, git GitExecutor) (*StyleProfile, error) {
+	cache, err := git.GitPath(ctx, styleCacheFile)
+	if err == nil {
+		if profile := readStyleCache(cache); profile != nil {
+			return profile, nil
+		}
+	}
+
+	subjects, err := git.RecentSubjects(ctx, styleSampleSize)
+	if err != nil {
+		return nil, err
+	}
+	profile := DetectStyle(subjects)
+	if profile != nil && cache != "" {
+		writeStyleCache(cache, profile)
+	}
+	return profile, nil
+}
+
+func readStyleCache(path string) *StyleProfile {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var profile StyleProfile
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return nil
+	}
+	if time.Since(profile.Created) > styleCacheTTL {
+		return nil
+	}
+	return &profile
+}
+
+// writeStyleCache saves the profile; the cache is an optimization, so
+// failures are ignored
+func writeStyleCache(path string, profile *StyleProfile) {
+	data, err := json.MarshalIndent(profile, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	_ = os.WriteFile(path, data, 0644)
+}
+
+// DetectStyle learns the commit style from subjects, newest first. It
+// returns nil when there are too few subjects to tell.
+func DetectStyle(subjects []string) *StyleProfile {
+	if len(subjects) < minStyleSamples {
+		return nil
+	}
+
+	profile := &StyleProfile{
+		Convention: detectConvention(subjects),
+		Language:   detectLanguage(subjects),
+		Sampled:    len(subjects),
+		Created:    time.Now(),
+	}
+	if profile.Convention == StyleConventional {
+		profile.Types = topConventionalTypes(subjects, 5)
+	}
+	profile.Ticket = typicalTicket(subjects)
+
+	var upper, lower, period, past int
+	lengths := make([]int, 0, len(subjects))
+	for _, subject := range subjects {
+		lengths = append(lengths, utf8.RuneCountInString(subject))
+		if strings.HasSuffix(subject, ".") {
+			period++
+		}
+
+		description := stripStylePrefix(subject)
+		first, _ := utf8.DecodeRuneInString(description)
+		switch {
+		case unicode.IsUpper(first):
+			upper++
+		case unicode.IsLower(first):
+			lower++
+		}
+		if word, _, _ := strings.Cut(description, " "); len(word) > 3 && strings.HasSuffix(strings.ToLower(word), "ed") {
+			past++
+		}
+	}
+
+	n := len(subjects)
+	switch {
+	case upper*10 >= n*7:
+		profile.Case = "upper"
+	case lower*10 >= n*7:
+		profile.Case = "lower"
+	}
+	profile.Period = period*2 > n
+	profile.PastTense = profile.Language == "English" && past*2 > n
+
+	sort.Ints(lengths)
+	profile.TypicalLen = lengths[n/2]
+	profile.MaxLen = lengths[(n*9)/10]
+	if profile.MaxLen > lengths[n-1] {
+		profile.MaxLen = lengths[n-1]
+	}
+
+	for _, subject := range subjects {
+		if len(profile.Examples) == styleExamples {
+			break
+		}
+		if conventionOf(subject) == profile.Convention || profile.Convention == StyleFreeForm {
+			profile.Examples = append(profile.Examples, subject)
+		}
+	}
+	return profile
+}
+
+// detectConvention returns the convention at least half of the subjects use
+func detectConvention(subjects []string) string {
+	counts := make(map[string]int)
+	for _, subject := range subjects {
+		counts[conventionOf(subject)]++
+	}
+	for _, convention := range []string{StyleConventional, StyleGitmoji, StyleTicket, StyleBracket, StyleComponent} {
+		if counts[convention]*2 >= len(subjects) {
+			return convention
+		}
+	}
+	return StyleFreeForm
+}
+
+func conventionOf(subject string) string {
+	if m := conventionalHeader.FindStringSubmatch(subject); m != nil && isConventionalType(m[1]) {
+		return StyleConventional
+	}
+	if gitmojiCode.MatchString(subject) {
+		return StyleGitmoji
+	}
+	if first, _ := utf8.DecodeRuneInString(subject); first >= 0x2190 && unicode.Is(unicode.So, first) {
+		return StyleGitmoji
+	}
+	if ticketPrefix.MatchString(subject) {
+		return StyleTicket
+	}
+	if bracketPrefix.MatchString(subject) {
+		return StyleBracket
+	}
+	if componentPrefix.MatchString(subject) {
+		return StyleComponent
+	}
+	return StyleFreeForm
+}
+
+func isConventionalType(t string) bool {
+	for _, known := range conventionalTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// stripStylePrefix returns the description of subject without its type,
+// emoji, ticket or area prefix
+func stripStylePrefix(subject string) string {
+	for _, prefix := range []*regexp.Regexp{conventionalHeader, gitmojiCode, ticketPrefix, bracketPrefix} {
+		subject = prefix.ReplaceAllString(subject, "")
+	}
+	if first, size := utf8.DecodeRuneInString(subject); first >= 0x2190 && unicode.Is(unicode.So, first) {
+		subject = strings.TrimLeftFunc(subject[size:], func(r rune) bool {
+			return unicode.IsSpace(r) || unicode.Is(unicode.Mn, r) || r == 0xFE0F
+		})
+	}
+	return componentPrefix.ReplaceAllString(subject, "")
+}
+
+func topConventionalTypes(subjects []string, n int) []string {
+	counts := make(map[string]int)
+	for _, subject := range subjects {
+		if m := conventionalHeader.FindStringSubmatch(subject); m != nil && isConventionalType(m[1]) {
+			counts[m[1]]++
+		}
+	}
+	types := make([]string, 0, len(counts))
+	for t := range counts {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if counts[types[i]] != counts[types[j]] {
+			return counts[types[i]] > counts[types[j]]
+		}
+		return types[i] < types[j]
+	})
+	if len(types) > n {
+		types = types[:n]
+	}
+	return types
+}
+
+// typicalTicket returns the most recent ticket reference when at least a
+// third of the subjects mention one
+func typicalTicket(subjects []string) string {
+	var first string
+	found := 0
+	for _, subject := range subjects {
+		if ref := ticketRef.FindString(subject); ref != "" {
+			if first == "" {
+				first = ref
+			}
+			found++
+		}
+	}
+	if found*3 < len(subjects) {
+		return ""
+	}
+	return first
+}
+
+// detectLanguage returns the language most subjects are written in, judged
+// by their script
+func detectLanguage(subjects []string) string {
+	counts := make(map[string]int)
+	for _, subject := range subjects {
+		counts[languageOf(subject)]++
+	}
+	best := "English"
+	for language, count := range counts {
+		if count > counts[best] || (count == counts[best] && language < best) {
+			best = language
+		}
+	}
+	return best
+}
+
+func languageOf(text string) string {
+	var han, kana, hangul, cyrillic bool
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana = true
+		case unicode.Is(unicode.Hangul, r):
+			hangul = true
+		case unicode.Is(unicode.Han, r):
+			han = true
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic = true
+		}
+	}
+	switch {
+	case kana:
+		return "Japanese"
+	case hangul:
+		return "Korean"
+	case han:
+		return "Chinese"
+	case cyrillic:
+		return "Russian"
+	}
+	return "English"
+}
+
+// Guidelines describes the style as instructions for the model
+func (p *StyleProfile) Guidelines() []string {
+	var lines []string
+	switch p.Convention {
+	case StyleConventional:
+		lines = append(lines, fmt.Sprintf("Use the conventional commits format type(scope): description, with types such as %s", strings.Join(p.Types, ", ")))
+	case StyleGitmoji:
+		lines = append(lines, "Start the subject with a gitmoji that fits the change, such as ✨ for a feature or 🐛 for a bug fix")
+	case StyleTicket:
+		lines = append(lines, "Start the subject with the ticket reference, written the way the examples write it")
+	case StyleBracket:
+		lines = append(lines, "Start the subject with the affected area in square brackets, as the examples do")
+	case StyleComponent:
+		lines = append(lines, `Start the subject with the affected package or area and a colon, such as "parser: "`)
+	default:
+		lines = append(lines, "Write a plain subject without a type or area prefix")
+	}
+	if p.Ticket != "" {
+		lines = append(lines, fmt.Sprintf("Reference tickets the way the examples do (like %s) when the changes name one; never invent a ticket number", p.Ticket))
+	}
+
+	lines = append(lines, fmt.Sprintf("Write the message in %s", p.Language))
+	switch p.Case {
+	case "upper":
+		lines = append(lines, "Start the description with a capital letter")
+	case "lower":
+		lines = append(lines, "Start the description with a lower-case letter")
+	}
+	if p.PastTense {
+		lines = append(lines, `Use the past tense ("added" not "add")`)
+	} else if p.Language == "English" {
+		lines = append(lines, `Use imperative mood ("add" not "added")`)
+	}
+	if p.Period {
+		lines = append(lines, "End the subject with a period")
+	} else {
+		lines = append(lines, "No period at the end")
+	}
+	lines = append(lines, fmt.Sprintf("Keep the subject around %d characters and under %d", p.TypicalLen, p.MaxLen+1))
+	return lines
+}
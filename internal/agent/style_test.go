@@ -0,0 +1,196 @@
+package agent
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-coders/git_gpt/internal/agent/mocks"
+	"github.com/go-coders/git_gpt/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDetectStyle(t *testing.T) {
+	tests := []struct {
+		name       string
+		subjects   []string
+		convention string
+		types      []string
+		ticket     string
+		language   string
+		letterCase string
+		period     bool
+		pastTense  bool
+		examples   []string
+	}{
+		{
+			name: "conventional",
+			subjects: []string{
+				"feat(agent): add style profile",
+				"fix: handle empty history",
+				"docs: describe commit styles",
+				"fix(git): skip merge commits",
+				"Update README",
+				"chore: bump deps",
+			},
+			convention: StyleConventional,
+			types:      []string{"fix", "chore", "docs", "feat"},
+			language:   "English",
+			letterCase: "lower",
+			examples: []string{
+				"feat(agent): add style profile",
+				"fix: handle empty history",
+				"docs: describe commit styles",
+				"fix(git): skip merge commits",
+				"chore: bump deps",
+			},
+		},
+		{
+			name: "jira ticket prefix",
+			subjects: []string{
+				"PROJ-42 Added retry to the client.",
+				"[PROJ-41] Fixed login redirect.",
+				"PROJ-40: Removed unused flags.",
+				"PROJ-39 Updated docs.",
+				"Merged hotfix.",
+			},
+			convention: StyleTicket,
+			ticket:     "PROJ-42",
+			language:   "English",
+			letterCase: "upper",
+			period:     true,
+			pastTense:  true,
+			examples: []string{
+				"PROJ-42 Added retry to the client.",
+				"[PROJ-41] Fixed login redirect.",
+				"PROJ-40: Removed unused flags.",
+				"PROJ-39 Updated docs.",
+			},
+		},
+		{
+			name: "go style component prefix",
+			subjects: []string{
+				"net/http: reject invalid headers",
+				"cmd/go, cmd/link: share the build cache",
+				"runtime: fix race in timers",
+				"all: remove unused code",
+				"spec: clarify conversions",
+			},
+			convention: StyleComponent,
+			language:   "English",
+			letterCase: "lower",
+		},
+		{
+			name: "gitmoji",
+			subjects: []string{
+				"✨ Add dark mode",
+				"🐛 Fix crash on start",
+				":memo: Update docs",
+				"♻️ Simplify parser",
+				"Bump version",
+			},
+			convention: StyleGitmoji,
+			language:   "English",
+			letterCase: "upper",
+		},
+		{
+			name: "chinese free form",
+			subjects: []string{
+				"修复登录问题",
+				"增加配置文件说明",
+				"优化提交流程",
+				"update readme",
+				"重构代码结构",
+			},
+			convention: StyleFreeForm,
+			language:   "Chinese",
+		},
+		{
+			name: "bracket prefix",
+			subjects: []string{
+				"[ui] Show spinner while loading",
+				"[api] Return 404 for unknown ids",
+				"[ui] Fix layout on small screens",
+				"[build] Cache dependencies",
+				"Release 1.2.0",
+			},
+			convention: StyleBracket,
+			language:   "English",
+			letterCase: "upper",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile := DetectStyle(tt.subjects)
+			if !assert.NotNil(t, profile) {
+				return
+			}
+			assert.Equal(t, tt.convention, profile.Convention)
+			assert.Equal(t, tt.types, profile.Types)
+			assert.Equal(t, tt.ticket, profile.Ticket)
+			assert.Equal(t, tt.language, profile.Language)
+			assert.Equal(t, tt.letterCase, profile.Case)
+			assert.Equal(t, tt.period, profile.Period)
+			assert.Equal(t, tt.pastTense, profile.PastTense)
+			if tt.examples != nil {
+				assert.Equal(t, tt.examples, profile.Examples)
+			}
+			assert.NotEmpty(t, profile.Guidelines())
+		})
+	}
+}
+
+func TestDetectStyle_ShortHistory(t *testing.T) {
+	assert.Nil(t, DetectStyle([]string{"Initial commit", "Add README"}))
+}
+
+func TestStyleProfile_Guidelines(t *testing.T) {
+	profile := &StyleProfile{
+		Convention: StyleConventional,
+		Types:      []string{"feat", "fix"},
+		Language:   "Chinese",
+		TypicalLen: 30,
+		MaxLen:     52,
+	}
+	assert.Equal(t, []string{
+		"Use the conventional commits format type(scope): description, with types such as feat, fix",
+		"Write the message in Chinese",
+		"No period at the end",
+		"Keep the subject around 30 characters and under 53",
+	}, profile.Guidelines())
+}
+
+func (s *CommitAgentTestSuite) TestGenerateCommitSuggestions_FollowsRepositoryStyle() {
+	git := new(mocks.GitExecutor)
+	s.agent.git = git
+	cache := filepath.Join(s.T().TempDir(), "ggpt", "style.json")
+
+	git.On("CheckAttributes", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(map[string]map[string]string{}, nil)
+	git.On("GitPath", s.ctx, "ggpt/style.json").Return(cache, nil)
+	git.On("RecentSubjects", s.ctx, styleSampleSize).Return([]string{
+		"[ui] Show spinner while loading",
+		"[api] Return 404 for unknown ids",
+		"[ui] Fix layout on small screens",
+		"[build] Cache dependencies",
+		"[api] Validate page size",
+	}, nil).Once()
+	git.On("GetDiff", s.ctx, true).Return("test diff", nil)
+	s.llm.On("Chat", s.ctx, mock.MatchedBy(func(prompt string) bool {
+		return strings.Contains(prompt, "- Start the subject with the affected area in square brackets") &&
+			strings.Contains(prompt, "- [api] Return 404 for unknown ids") &&
+			!strings.Contains(prompt, "Use conventional commits format")
+	})).Return(`{"suggestions": [{"message": "[api] Add paging"}]}`, nil).Twice()
+
+	files := []common.FileChange{{Path: "api.go", Status: "modified"}}
+	_, err := s.agent.generateCommitSuggestions(s.ctx, files)
+	s.Require().NoError(err)
+	s.FileExists(cache)
+
+	// The second run reads the cached profile instead of the history
+	_, err = s.agent.generateCommitSuggestions(s.ctx, files)
+	s.Require().NoError(err)
+	git.AssertExpectations(s.T())
+}
@@ -0,0 +1,269 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/go-coders/git_gpt/internal/common"
+)
+
+const (
+	// summaryWorkers bounds how many chunk summaries are requested at once
+	summaryWorkers = 4
+	// minChunkTokens keeps chunks useful when the prompt budget is tiny
+	minChunkTokens = 200
+)
+
+// commitPrompt renders the commit prompt with the diff of src, or with
+// summaries of its parts when the diff does not fit the model's context.
+// Noisy files such as lockfiles are listed without their diff. A non-nil
+// ticket must be referenced by the suggestions.
+func (a *CommitAgent) commitPrompt(ctx context.Context, files []common.FileChange, src diffSource, ticket *TicketRef) (string, error) {
+	omitted := a.markOmittedDiffs(ctx, files)
+	diff, err := src.diff(ctx, a.git, omitted...)
+	if err != nil {
+		return "", fmt.Errorf("failed to get diff: %w", err)
+	}
+
+	diff, secrets, added := a.redactDiff(diff, "")
+	if err := a.reportSecrets(secrets, added); err != nil {
+		return "", err
+	}
+
+	style := a.styleProfile(ctx)
+	prompt, err := a.prompts.GetCommitPrompt(files, diff, a.lintRules(), style, ticket)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate commit prompt: %w", err)
+	}
+
+	budget := a.llm.AvailableTokens()
+	if budget <= 0 || a.llm.CountTokens(prompt) <= budget {
+		return prompt, nil
+	}
+	a.logger.Debug("Staged diff exceeds %d tokens, summarizing it in parts", budget)
+
+	stat, err := src.stat(ctx, a.git)
+	if err != nil {
+		return "", err
+	}
+	summaries, err := a.summarizeDiff(ctx, diff, budget)
+	if err != nil {
+		return "", err
+	}
+
+	prompt, err = a.prompts.GetSummarizedCommitPrompt(files, stat, summaries, a.lintRules(), style, ticket)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate commit prompt: %w", err)
+	}
+	return prompt, nil
+}
+
+// summarizeDiff splits diff into chunks that fit budget tokens together with
+// the summary instructions, and summarizes each of them
+func (a *CommitAgent) summarizeDiff(ctx context.Context, diff string, budget int) ([]string, error) {
+	// Leave room for the summary instructions around each chunk
+	empty, err := a.prompts.GetChunkSummaryPrompt("", 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate summary prompt: %w", err)
+	}
+	chunkBudget := budget - a.llm.CountTokens(empty)
+	if chunkBudget < minChunkTokens {
+		chunkBudget = minChunkTokens
+	}
+	return a.summarizeChunks(ctx, chunkDiff(diff, chunkBudget, a.llm.CountTokens))
+}
+
+// summarizeChunks asks for a summary of every chunk, at most summaryWorkers
+// at a time. The summaries keep the order of the chunks; the first failure
+// cancels the requests that have not started.
+func (a *CommitAgent) summarizeChunks(ctx context.Context, chunks []string) ([]string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		summaries = make([]string, len(chunks))
+		jobs      = make(chan int)
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		firstErr  error
+	)
+
+	for w := 0; w < summaryWorkers && w < len(chunks); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+				summary, err := a.summarizeChunk(ctx, chunks[i], i+1, len(chunks))
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+						cancel()
+					}
+					mu.Unlock()
+					continue
+				}
+				summaries[i] = summary
+			}
+		}()
+	}
+
+	for i := range chunks {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return summaries, nil
+}
+
+func (a *CommitAgent) summarizeChunk(ctx context.Context, chunk string, part, parts int) (string, error) {
+	prompt, err := a.prompts.GetChunkSummaryPrompt(chunk, part, parts)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate summary prompt: %w", err)
+	}
+
+	summary, err := a.llm.Chat(ctx, prompt)
+	if err != nil {
+		return "", fmt.Errorf("failed to summarize part %d of the diff: %w", part, err)
+	}
+	return strings.TrimSpace(summary), nil
+}
+
+// chunkDiff splits a unified diff into chunks of at most budget tokens. Whole
+// files are packed together while they fit; larger files are split between
+// hunks, each chunk repeating the file header, and a single hunk that is
+// still too large is cut short.
+func chunkDiff(diff string, budget int, count func(string) int) []string {
+	var (
+		chunks  []string
+		current strings.Builder
+		used    int
+	)
+	add := func(piece string, tokens int) {
+		if used > 0 && used+tokens > budget {
+			chunks = append(chunks, current.String())
+			current.Reset()
+			used = 0
+		}
+		current.WriteString(piece)
+		used += tokens
+	}
+
+	for _, file := range splitDiffFiles(diff) {
+		if tokens := count(file); tokens <= budget {
+			add(file, tokens)
+			continue
+		}
+
+		header, hunks := splitDiffHunks(file)
+		headerTokens := count(header)
+		for len(hunks) > 0 {
+			// Start a new chunk for each run of hunks from this file
+			if used > 0 {
+				chunks = append(chunks, current.String())
+				current.Reset()
+				used = 0
+			}
+			add(header, headerTokens)
+
+			for len(hunks) > 0 {
+				tokens := count(hunks[0])
+				if used+tokens > budget {
+					if used == headerTokens {
+						add(truncateHunk(hunks[0], budget-used, count), 0)
+						hunks = hunks[1:]
+					}
+					break
+				}
+				add(hunks[0], tokens)
+				hunks = hunks[1:]
+			}
+		}
+	}
+
+	if used > 0 {
+		chunks = append(chunks, current.String())
+	}
+	return chunks
+}
+
+// splitDiffFiles splits a unified diff at each "diff --git" line
+func splitDiffFiles(diff string) []string {
+	var files []string
+	start := 0
+	for i := 0; i < len(diff); {
+		end := strings.IndexByte(diff[i:], '\n')
+		if end < 0 {
+			end = len(diff)
+		} else {
+			end += i + 1
+		}
+		if i > start && strings.HasPrefix(diff[i:], "diff --git ") {
+			files = append(files, diff[start:i])
+			start = i
+		}
+		i = end
+	}
+	if start < len(diff) {
+		files = append(files, diff[start:])
+	}
+	return files
+}
+
+// splitDiffHunks separates a file's diff into its header and its hunks
+func splitDiffHunks(file string) (string, []string) {
+	lines := strings.SplitAfter(file, "\n")
+
+	var (
+		header strings.Builder
+		hunks  []string
+		hunk   strings.Builder
+	)
+	for _, line := range lines {
+		if strings.HasPrefix(line, "@@") {
+			if hunk.Len() > 0 {
+				hunks = append(hunks, hunk.String())
+				hunk.Reset()
+			}
+			hunk.WriteString(line)
+			continue
+		}
+		if hunk.Len() > 0 {
+			hunk.WriteString(line)
+		} else {
+			header.WriteString(line)
+		}
+	}
+	if hunk.Len() > 0 {
+		hunks = append(hunks, hunk.String())
+	}
+	return header.String(), hunks
+}
+
+// truncateHunk keeps the leading lines of hunk that fit in budget tokens
+func truncateHunk(hunk string, budget int, count func(string) int) string {
+	const marker = "... (hunk truncated)\n"
+	budget -= count(marker)
+
+	var b strings.Builder
+	used := 0
+	for _, line := range strings.SplitAfter(hunk, "\n") {
+		tokens := count(line)
+		if used+tokens > budget {
+			break
+		}
+		b.WriteString(line)
+		used += tokens
+	}
+	b.WriteString(marker)
+	return b.String()
+}
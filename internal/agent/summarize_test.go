@@ -0,0 +1,174 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-coders/git_gpt/internal/agent/mocks"
+	"github.com/go-coders/git_gpt/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// byteCount counts one token per byte so budgets are easy to reason about
+func byteCount(s string) int {
+	return len(s)
+}
+
+func diffFile(name string, hunks ...string) string {
+	var b strings.Builder
+	b.WriteString("diff --git a/" + name + " b/" + name + "\n--- a/" + name + "\n+++ b/" + name + "\n")
+	for _, hunk := range hunks {
+		b.WriteString(hunk)
+	}
+	return b.String()
+}
+
+func TestChunkDiff(t *testing.T) {
+	small := diffFile("a.go", "@@ -1 +1 @@\n-a\n+b\n")
+	other := diffFile("b.go", "@@ -1 +1 @@\n-c\n+d\n")
+	header := "diff --git a/big.go b/big.go\n--- a/big.go\n+++ b/big.go\n"
+	hunk1 := "@@ -1,2 +1,2 @@\n-one\n+uno\n"
+	hunk2 := "@@ -9,2 +9,2 @@\n-two\n+dos\n"
+	huge := "@@ -20 +20 @@\n" + strings.Repeat("+line\n", 40)
+
+	testCases := []struct {
+		name     string
+		diff     string
+		budget   int
+		expected []string
+	}{
+		{
+			name:     "everything fits",
+			diff:     small + other,
+			budget:   1000,
+			expected: []string{small + other},
+		},
+		{
+			name:     "one file per chunk",
+			diff:     small + other,
+			budget:   len(small) + 10,
+			expected: []string{small, other},
+		},
+		{
+			name:     "large file split between hunks",
+			diff:     small + header + hunk1 + hunk2,
+			budget:   len(header) + len(hunk1) + 5,
+			expected: []string{small, header + hunk1, header + hunk2},
+		},
+		{
+			name:   "oversized hunk is cut short",
+			diff:   header + huge,
+			budget: len(header) + 60,
+			expected: []string{
+				header + "@@ -20 +20 @@\n" + strings.Repeat("+line\n", 4) + "... (hunk truncated)\n",
+			},
+		},
+		{
+			name:   "empty diff",
+			diff:   "",
+			budget: 100,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			chunks := chunkDiff(tc.diff, tc.budget, byteCount)
+			assert.Equal(t, tc.expected, chunks)
+		})
+	}
+}
+
+func (s *CommitAgentTestSuite) useBudget(budget int) *mocks.LLMClient {
+	llm := new(mocks.LLMClient)
+	llm.On("AvailableTokens").Return(budget)
+	llm.On("CountTokens", mock.Anything).Return(byteCount)
+	s.agent.llm = llm
+	return llm
+}
+
+func (s *CommitAgentTestSuite) TestGenerateCommitSuggestions_SummarizesLargeDiff() {
+	files := []common.FileChange{{Path: "a.go", Status: "modified"}, {Path: "b.go", Status: "modified"}}
+	diff := diffFile("a.go", "@@ -1 +1 @@\n-"+strings.Repeat("a", 300)+"\n") +
+		diffFile("b.go", "@@ -1 +1 @@\n-"+strings.Repeat("b", 300)+"\n")
+
+	prompt, err := s.agent.prompts.GetChunkSummaryPrompt("", 0, 0)
+	s.Require().NoError(err)
+	llm := s.useBudget(len(prompt) + 400)
+
+	s.git.On("GetDiff", s.ctx, true).Return(diff, nil).Once()
+	s.git.On("GetDiffStat", s.ctx, true).Return(" a.go | 1 -\n b.go | 1 -\n", nil).Once()
+
+	llm.On("Chat", mock.Anything, mock.MatchedBy(func(prompt string) bool {
+		return strings.Contains(prompt, "part 1 of 2") && strings.Contains(prompt, "aaaa")
+	})).Return("Drops the a line", nil).Once()
+	llm.On("Chat", mock.Anything, mock.MatchedBy(func(prompt string) bool {
+		return strings.Contains(prompt, "part 2 of 2") && strings.Contains(prompt, "bbbb")
+	})).Return("Drops the b line\n", nil).Once()
+	llm.On("Chat", s.ctx, mock.MatchedBy(func(prompt string) bool {
+		return strings.Contains(prompt, " a.go | 1 -") &&
+			strings.Contains(prompt, "- Drops the a line\n\n- Drops the b line") &&
+			!strings.Contains(prompt, "aaaa")
+	})).Return(`{"summary": "s", "suggestions": [{"message": "chore: drop lines"}]}`, nil).Once()
+
+	response, err := s.agent.generateCommitSuggestions(s.ctx, files)
+	s.Require().NoError(err)
+	s.Assert().Equal("chore: drop lines", response.Suggestions[0].Message)
+	llm.AssertExpectations(s.T())
+}
+
+func (s *CommitAgentTestSuite) TestGenerateCommitSuggestions_SmallDiffIsSentWhole() {
+	llm := s.useBudget(100000)
+	s.git.On("GetDiff", s.ctx, true).Return("test diff", nil).Once()
+	llm.On("Chat", s.ctx, mock.MatchedBy(func(prompt string) bool {
+		return strings.Contains(prompt, "Detailed diff:\ntest diff")
+	})).Return(`{"suggestions": [{"message": "fix: test"}]}`, nil).Once()
+
+	_, err := s.agent.generateCommitSuggestions(s.ctx, []common.FileChange{{Path: "a.go", Status: "modified"}})
+	s.Require().NoError(err)
+	s.git.AssertNotCalled(s.T(), "GetDiffStat", mock.Anything, mock.Anything)
+}
+
+func (s *CommitAgentTestSuite) TestSummarizeChunks_BoundedAndOrdered() {
+	llm := s.useBudget(0)
+
+	var running, peak int32
+	llm.On("Chat", mock.Anything, mock.Anything).Return(func(_ context.Context, prompt string) string {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		return "summary of " + prompt[strings.Index(prompt, "chunk"):strings.Index(prompt, "chunk")+len("chunk 0")]
+	}, nil)
+
+	chunks := make([]string, 10)
+	for i := range chunks {
+		chunks[i] = fmt.Sprintf("chunk %d", i)
+	}
+
+	summaries, err := s.agent.summarizeChunks(s.ctx, chunks)
+	s.Require().NoError(err)
+	for i, summary := range summaries {
+		s.Assert().Equal(fmt.Sprintf("summary of chunk %d", i), summary)
+	}
+	s.Assert().LessOrEqual(int(peak), summaryWorkers)
+}
+
+func (s *CommitAgentTestSuite) TestSummarizeChunks_Failure() {
+	llm := s.useBudget(0)
+	llm.On("Chat", mock.Anything, mock.Anything).Return("", errors.New("rate limited"))
+
+	_, err := s.agent.summarizeChunks(s.ctx, []string{"one", "two", "three"})
+	s.Require().Error(err)
+	s.Assert().Contains(err.Error(), "rate limited")
+}
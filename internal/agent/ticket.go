@@ -0,0 +1,203 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Positions of the ticket reference in a commit message
+const (
+	TicketPrefix  = "prefix"  // "PROJ-1234 fix login retry"
+	TicketScope   = "scope"   // "fix(PROJ-1234): login retry"
+	TicketTrailer = "trailer" // a "Refs: PROJ-1234" trailer
+)
+
+// DefaultTicketTrailer is the trailer key used when none is configured
+const DefaultTicketTrailer = "Refs"
+
+var (
+	scopedHeader = regexp.MustCompile(`^(\w+)(?:\(([^)]*)\))?(!?): (.*)$`)
+	issueNumber  = regexp.MustCompile(`^[0-9]+$`)
+)
+
+// TicketRule finds the ticket a branch works on from its name
+type TicketRule struct {
+	patterns []*regexp.Regexp
+	position string
+	trailer  string
+}
+
+// TicketRef is the ticket a commit must reference and where the reference goes
+type TicketRef struct {
+	Key      string
+	Position string
+	// Trailer is the trailer key when Position is TicketTrailer
+	Trailer string
+}
+
+// NewTicketRule compiles the branch name patterns. The first capture group of
+// a pattern is the ticket key, or the whole match when it has none. An empty
+// position puts the reference in a trailer named trailer, "Refs" by default.
+func NewTicketRule(patterns []string, position, trailer string) (*TicketRule, error) {
+	rule := &TicketRule{position: position, trailer: trailer}
+	switch position {
+	case "":
+		rule.position = TicketTrailer
+	case TicketPrefix, TicketScope, TicketTrailer:
+	default:
+		return nil, fmt.Errorf("invalid ticket position %q: expected prefix, scope or trailer", position)
+	}
+	if rule.trailer == "" {
+		rule.trailer = DefaultTicketTrailer
+	}
+	if !trailerKey.MatchString(rule.trailer) {
+		return nil, fmt.Errorf("invalid ticket trailer %q", trailer)
+	}
+
+	for _, p := range patterns {
+		pattern, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ticket pattern %q: %w", p, err)
+		}
+		rule.patterns = append(rule.patterns, pattern)
+	}
+	return rule, nil
+}
+
+// Find returns the ticket named by branch, trying the patterns in order, or
+// nil when none matches. A plain number is an issue and becomes "#123".
+func (r *TicketRule) Find(branch string) *TicketRef {
+	if r == nil {
+		return nil
+	}
+	for _, pattern := range r.patterns {
+		match := pattern.FindStringSubmatch(branch)
+		if match == nil {
+			continue
+		}
+		key := match[0]
+		if len(match) > 1 {
+			key = match[1]
+		}
+		if key == "" {
+			continue
+		}
+		if issueNumber.MatchString(key) {
+			key = "#" + key
+		}
+		return &TicketRef{Key: key, Position: r.position, Trailer: r.trailer}
+	}
+	return nil
+}
+
+// Apply adds the reference to a suggestion that does not have it in the
+// configured position. The scope of a conventional subject is replaced by the
+// ticket; other subjects get it as a prefix instead.
+func (t *TicketRef) Apply(s CommitSuggestion) CommitSuggestion {
+	if t == nil {
+		return s
+	}
+
+	subject := strings.TrimSpace(s.Message)
+	switch t.Position {
+	case TicketTrailer:
+		for _, trailer := range s.Trailers {
+			if mentionsTicket(trailer.Value, t.Key) {
+				return s
+			}
+		}
+		s.Trailers = append(append([]Trailer(nil), s.Trailers...), Trailer{Key: t.Trailer, Value: t.Key})
+	case TicketScope:
+		if m := scopedHeader.FindStringSubmatch(subject); m != nil {
+			if !mentionsTicket(m[2], t.Key) {
+				s.Message = fmt.Sprintf("%s(%s)%s: %s", m[1], t.Key, m[3], m[4])
+			}
+			return s
+		}
+		fallthrough
+	default:
+		if !strings.HasPrefix(subject, t.Key) || !ticketBoundary(subject, len(t.Key)) {
+			s.Message = t.Key + " " + subject
+		}
+	}
+	return s
+}
+
+// exempt removes the reference from where Apply puts it, so that the commit
+// rules check message as if it had none. Rules such as scope-case and
+// type-empty know nothing of ticket keys and would reject every message.
+func (t *TicketRef) exempt(message string) string {
+	if t == nil || t.Position == TicketTrailer {
+		return message
+	}
+
+	// The model may also have put the key in the other place
+	subject, rest, found := strings.Cut(message, "\n")
+	if strings.HasPrefix(subject, t.Key) && ticketBoundary(subject, len(t.Key)) {
+		subject = strings.TrimLeft(subject[len(t.Key):], " :")
+	}
+	if m := scopedHeader.FindStringSubmatch(subject); m != nil && mentionsTicket(m[2], t.Key) {
+		var scopes []string
+		for _, scope := range strings.Split(m[2], ",") {
+			if scope = strings.TrimSpace(scope); scope != t.Key {
+				scopes = append(scopes, scope)
+			}
+		}
+		scope := ""
+		if len(scopes) > 0 {
+			scope = "(" + strings.Join(scopes, ",") + ")"
+		}
+		subject = fmt.Sprintf("%s%s%s: %s", m[1], scope, m[3], m[4])
+	}
+
+	if found {
+		return subject + "\n" + rest
+	}
+	return subject
+}
+
+// mentionsTicket reports whether text contains key as a whole word, so that
+// PROJ-12 is not found in PROJ-123
+func mentionsTicket(text, key string) bool {
+	for offset := 0; ; {
+		i := strings.Index(text[offset:], key)
+		if i < 0 {
+			return false
+		}
+		start := offset + i
+		if (start == 0 || ticketBoundary(text, start-1)) && ticketBoundary(text, start+len(key)) {
+			return true
+		}
+		offset = start + 1
+	}
+}
+
+// ticketBoundary reports whether text ends at i or has a character there
+// that cannot continue a ticket key, such as a space or a colon
+func ticketBoundary(text string, i int) bool {
+	if i >= len(text) {
+		return true
+	}
+	c := text[i]
+	return !(c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_')
+}
+
+// branchTicket returns the ticket of the current branch, or nil when there is
+// no rule, no branch or no match
+func (a *CommitAgent) branchTicket(ctx context.Context) *TicketRef {
+	if a.ticket == nil {
+		return nil
+	}
+	branch, err := a.git.GetCurrentBranch(ctx)
+	if err != nil {
+		a.logger.Debug("Failed to get the current branch: %v", err)
+		return nil
+	}
+	ticket := a.ticket.Find(branch)
+	if ticket != nil {
+		a.logger.Debug("Branch %s references %s", branch, ticket.Key)
+	}
+	return ticket
+}
@@ -0,0 +1,239 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/go-coders/git_gpt/internal/commitlint"
+	"github.com/go-coders/git_gpt/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTicketRule(t *testing.T) {
+	rule, err := NewTicketRule([]string{`[A-Z]+-\d+`}, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, &TicketRef{Key: "PROJ-1", Position: TicketTrailer, Trailer: "Refs"}, rule.Find("PROJ-1"))
+
+	_, err = NewTicketRule([]string{`[A-Z`}, "", "")
+	assert.ErrorContains(t, err, "invalid ticket pattern")
+	_, err = NewTicketRule(nil, "suffix", "")
+	assert.ErrorContains(t, err, "invalid ticket position")
+	_, err = NewTicketRule(nil, TicketTrailer, "Fixes issue")
+	assert.ErrorContains(t, err, "invalid ticket trailer")
+}
+
+func TestTicketRule_Find(t *testing.T) {
+	rule, err := NewTicketRule([]string{`[A-Z][A-Z0-9]+-\d+`, `^(?:feature|fix)/(\d+)-`}, TicketPrefix, "")
+	require.NoError(t, err)
+
+	tests := []struct {
+		branch string
+		want   string
+	}{
+		{branch: "feature/PROJ-1234-login-retry", want: "PROJ-1234"},
+		{branch: "PROJ-7", want: "PROJ-7"},
+		{branch: "fix/42-crash", want: "#42"},
+		{branch: "main", want: ""},
+		{branch: "", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.branch, func(t *testing.T) {
+			ref := rule.Find(tt.branch)
+			if tt.want == "" {
+				assert.Nil(t, ref)
+				return
+			}
+			require.NotNil(t, ref)
+			assert.Equal(t, tt.want, ref.Key)
+		})
+	}
+
+	var none *TicketRule
+	assert.Nil(t, none.Find("feature/PROJ-1-x"))
+}
+
+func TestTicketRef_Apply(t *testing.T) {
+	tests := []struct {
+		name     string
+		ref      *TicketRef
+		in       CommitSuggestion
+		want     string
+		trailers int
+	}{
+		{
+			name: "prefix",
+			ref:  &TicketRef{Key: "PROJ-1", Position: TicketPrefix},
+			in:   CommitSuggestion{Message: "fix login retry"},
+			want: "PROJ-1 fix login retry",
+		},
+		{
+			name: "prefix already there",
+			ref:  &TicketRef{Key: "PROJ-1", Position: TicketPrefix},
+			in:   CommitSuggestion{Message: "PROJ-1 fix login retry"},
+			want: "PROJ-1 fix login retry",
+		},
+		{
+			name: "prefix of a longer key",
+			ref:  &TicketRef{Key: "PROJ-12", Position: TicketPrefix},
+			in:   CommitSuggestion{Message: "PROJ-123 fix login retry"},
+			want: "PROJ-12 PROJ-123 fix login retry",
+		},
+		{
+			name: "prefix with a colon",
+			ref:  &TicketRef{Key: "PROJ-12", Position: TicketPrefix},
+			in:   CommitSuggestion{Message: "PROJ-12: fix login retry"},
+			want: "PROJ-12: fix login retry",
+		},
+		{
+			name: "scope is added",
+			ref:  &TicketRef{Key: "PROJ-1", Position: TicketScope},
+			in:   CommitSuggestion{Message: "fix!: drop retries"},
+			want: "fix(PROJ-1)!: drop retries",
+		},
+		{
+			name: "scope is replaced",
+			ref:  &TicketRef{Key: "PROJ-1", Position: TicketScope},
+			in:   CommitSuggestion{Message: "feat(auth): retry logins"},
+			want: "feat(PROJ-1): retry logins",
+		},
+		{
+			name: "scope falls back to a prefix",
+			ref:  &TicketRef{Key: "PROJ-1", Position: TicketScope},
+			in:   CommitSuggestion{Message: "Retry logins"},
+			want: "PROJ-1 Retry logins",
+		},
+		{
+			name: "trailer",
+			ref:  &TicketRef{Key: "#42", Position: TicketTrailer, Trailer: "Refs"},
+			in:   CommitSuggestion{Message: "fix: crash", Body: "Guard the nil map."},
+			want: "fix: crash\n\nGuard the nil map.\n\nRefs: #42",
+		},
+		{
+			name:     "trailer already there",
+			ref:      &TicketRef{Key: "PROJ-1", Position: TicketTrailer, Trailer: "Refs"},
+			in:       CommitSuggestion{Message: "fix: crash", Trailers: []Trailer{{Key: "Closes", Value: "PROJ-1"}}},
+			want:     "fix: crash\n\nCloses: PROJ-1",
+			trailers: 1,
+		},
+		{
+			name: "scope of a longer key",
+			ref:  &TicketRef{Key: "PROJ-12", Position: TicketScope},
+			in:   CommitSuggestion{Message: "fix(PROJ-123): crash"},
+			want: "fix(PROJ-12): crash",
+		},
+		{
+			name:     "trailer of a longer key",
+			ref:      &TicketRef{Key: "PROJ-12", Position: TicketTrailer, Trailer: "Refs"},
+			in:       CommitSuggestion{Message: "fix: crash", Trailers: []Trailer{{Key: "Refs", Value: "PROJ-123"}}},
+			want:     "fix: crash\n\nRefs: PROJ-123\nRefs: PROJ-12",
+			trailers: 1,
+		},
+		{
+			name: "no ticket",
+			in:   CommitSuggestion{Message: "fix: crash"},
+			want: "fix: crash",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.ref.Apply(tt.in).Text())
+			assert.Len(t, tt.in.Trailers, tt.trailers, "the input is not modified")
+		})
+	}
+}
+
+func TestGetCommitPrompt_Ticket(t *testing.T) {
+	pm, err := NewPromptManager()
+	require.NoError(t, err)
+
+	prompt, err := pm.GetCommitPrompt(nil, "diff", nil, nil, &TicketRef{Key: "PROJ-1234", Position: TicketScope})
+	require.NoError(t, err)
+	assert.Contains(t, prompt, "Every suggestion must reference ticket PROJ-1234")
+	assert.Contains(t, prompt, `"fix(PROJ-1234): handle login retries"`)
+
+	prompt, err = pm.GetCommitPrompt(nil, "diff", nil, nil, &TicketRef{Key: "#42", Position: TicketTrailer, Trailer: "Closes"})
+	require.NoError(t, err)
+	assert.Contains(t, prompt, `{"key": "Closes", "value": "#42"}`)
+
+	prompt, err = pm.GetCommitPrompt(nil, "diff", nil, nil, nil)
+	require.NoError(t, err)
+	assert.NotContains(t, prompt, "must reference ticket")
+}
+
+func (s *CommitAgentTestSuite) TestCommitWithOptions_BranchTicket() {
+	rule, err := NewTicketRule([]string{`[A-Z]+-\d+`}, TicketPrefix, "")
+	s.Require().NoError(err)
+	s.agent.ticket = rule
+	s.logger.On("Debug", mock.Anything, mock.Anything, mock.Anything).Return()
+
+	staged := []common.FileChange{{Path: "login.go", Status: "modified"}}
+	s.git.On("GetCurrentBranch", s.ctx).Return("feature/PROJ-1234-login-retry", nil).Once()
+	s.git.On("GetStatus", s.ctx).Return(staged, []common.FileChange{}, nil).Once()
+	s.git.On("GetDiff", s.ctx, true).Return("test diff", nil).Once()
+	s.llm.On("Chat", s.ctx, mock.MatchedBy(func(prompt string) bool {
+		return s.Contains(prompt, `Start the subject with "PROJ-1234 "`)
+	})).Return(`{"summary": "Test", "suggestions": [{"message": "retry failed logins"}]}`, nil).Once()
+	s.git.On("Commit", s.ctx, "PROJ-1234 retry failed logins").Return(nil).Once()
+
+	message, err := s.agent.CommitWithOptions(s.ctx, CommitOptions{Yes: true})
+	s.Require().NoError(err)
+	s.Assert().Equal("PROJ-1234 retry failed logins", message)
+	s.git.AssertExpectations(s.T())
+}
+
+func TestTicketRef_Exempt(t *testing.T) {
+	tests := []struct {
+		position string
+		message  string
+		want     string
+	}{
+		{TicketScope, "fix(PROJ-1234): retry logins", "fix: retry logins"},
+		{TicketScope, "fix(PROJ-1234)!: drop v1 logins\n\nBody", "fix!: drop v1 logins\n\nBody"},
+		{TicketScope, "fix(auth, PROJ-1234): retry logins", "fix(auth): retry logins"},
+		{TicketScope, "fix(PROJ-12345): retry logins", "fix(PROJ-12345): retry logins"},
+		{TicketScope, "PROJ-1234 Retry logins", "Retry logins"},
+		{TicketPrefix, "PROJ-1234 fix: retry logins", "fix: retry logins"},
+		{TicketPrefix, "PROJ-1234: fix: retry logins", "fix: retry logins"},
+		{TicketPrefix, "PROJ-12345 fix: retry logins", "PROJ-12345 fix: retry logins"},
+		{TicketPrefix, "PROJ-1234 fix(PROJ-1234): retry logins", "fix: retry logins"},
+		{TicketTrailer, "fix: retry logins\n\nRefs: PROJ-1234", "fix: retry logins\n\nRefs: PROJ-1234"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			ref := &TicketRef{Key: "PROJ-1234", Position: tt.position, Trailer: DefaultTicketTrailer}
+			assert.Equal(t, tt.want, ref.exempt(tt.message))
+		})
+	}
+
+	var none *TicketRef
+	assert.Equal(t, "fix(PROJ-1): x", none.exempt("fix(PROJ-1): x"))
+}
+
+func (s *CommitAgentTestSuite) TestGenerateCommitSuggestions_TicketPassesRules() {
+	for _, position := range []string{TicketScope, TicketPrefix} {
+		s.Run(position, func() {
+			s.SetupTest()
+			rule, err := NewTicketRule([]string{`[A-Z]+-\d+`}, position, "")
+			s.Require().NoError(err)
+			s.agent.ticket = rule
+			s.agent.lint = commitlint.Conventional()
+			s.logger.On("Debug", mock.Anything, mock.Anything, mock.Anything).Return()
+
+			s.git.On("GetCurrentBranch", s.ctx).Return("feature/PROJ-1234-login-retry", nil).Once()
+			s.git.On("GetDiff", s.ctx, true).Return("test diff", nil).Once()
+			s.llm.On("Chat", s.ctx, mock.Anything).
+				Return(`{"suggestions": [{"message": "fix: retry failed logins"}, {"message": "fix(PROJ-1234): retry logins"}]}`, nil).Once()
+
+			// The suggestions pass the default rules, so no repair is requested
+			response, err := s.agent.generateCommitSuggestions(s.ctx, []common.FileChange{{Path: "login.go", Status: "modified"}})
+			s.Require().NoError(err)
+			s.Len(response.Suggestions, 2)
+			for _, suggestion := range response.Suggestions {
+				s.Contains(suggestion.Message, "PROJ-1234")
+			}
+			s.llm.AssertNumberOfCalls(s.T(), "Chat", 1)
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-coders/git_gpt/internal/common"
+)
+
+// Tools offered to models with native tool calling
+const (
+	ToolRunGitQuery            = "run_git_query"
+	ToolProposeGitModification = "propose_git_modification"
+	ToolAnswer                 = "answer"
+)
+
+// toolFieldDescriptions documents the struct fields exposed to the model,
+// keyed by their json name
+var toolFieldDescriptions = map[string]string{
+	"commands": "Git commands to run, in order",
+	"reason":   "Why these commands are needed",
+	"args":     "Arguments passed to git, without the leading \"git\"",
+	"purpose":  "What the command is for, in the language of the query",
+	"impact":   "What the command will change in the repository, in the language of the query",
+	"content":  "The answer, in the language of the query",
+}
+
+// commandTools describes the responses of the chat agent as tools. The
+// schemas are derived from Response and Command so that tool arguments
+// decode straight into a Response.
+var commandTools = []common.Tool{
+	{
+		Name:        ToolRunGitQuery,
+		Description: "Run read-only git commands such as log, status, diff or show. Their output is sent back to you.",
+		Parameters: structSchema(reflect.TypeOf(Response{}), map[reflect.Type][]string{
+			reflect.TypeOf(Response{}): {"commands", "reason"},
+			reflect.TypeOf(Command{}):  {"args", "purpose"},
+		}),
+	},
+	{
+		Name:        ToolProposeGitModification,
+		Description: "Propose git commands that change the repository. The user confirms them before they run.",
+		Parameters: structSchema(reflect.TypeOf(Response{}), map[reflect.Type][]string{
+			reflect.TypeOf(Response{}): {"commands", "reason"},
+			reflect.TypeOf(Command{}):  {"args", "purpose", "impact"},
+		}),
+	},
+	{
+		Name:        ToolAnswer,
+		Description: "Answer the query when no more commands are needed.",
+		Parameters: structSchema(reflect.TypeOf(Response{}), map[reflect.Type][]string{
+			reflect.TypeOf(Response{}): {"content"},
+		}),
+	},
+}
+
+// structSchema builds a JSON schema object from the json tags of t. Only the
+// fields listed for a struct type are included and fields tagged omitempty
+// are optional.
+func structSchema(t reflect.Type, fields map[reflect.Type][]string) map[string]interface{} {
+	byName := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		byName[name] = field
+	}
+
+	properties := make(map[string]interface{})
+	required := []string{}
+	for _, name := range fields[t] {
+		field, ok := byName[name]
+		if !ok {
+			panic(fmt.Sprintf("%s has no field with json name %q", t, name))
+		}
+
+		schema := typeSchema(field.Type, fields)
+		if description, ok := toolFieldDescriptions[name]; ok {
+			schema["description"] = description
+		}
+		properties[name] = schema
+
+		if !strings.Contains(field.Tag.Get("json"), ",omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+func typeSchema(t reflect.Type, fields map[reflect.Type][]string) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Slice:
+		return map[string]interface{}{
+			"type":  "array",
+			"items": typeSchema(t.Elem(), fields),
+		}
+	case reflect.Struct:
+		return structSchema(t, fields)
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	default:
+		return map[string]interface{}{"type": "string"}
+	}
+}
+
+// responseFromToolCall turns a tool call into the Response the text prompts
+// would have produced
+func responseFromToolCall(call *common.ToolCall) (Response, error) {
+	var response Response
+	if err := json.Unmarshal([]byte(call.Arguments), &response); err != nil {
+		return Response{}, fmt.Errorf("failed to parse %s arguments: %w", call.Name, err)
+	}
+
+	switch call.Name {
+	case ToolRunGitQuery:
+		response.Type = ResponseTypeExecute
+		response.CommandType = CommandTypeQuery
+	case ToolProposeGitModification:
+		response.Type = ResponseTypeExecute
+		response.CommandType = CommandTypeModify
+	case ToolAnswer:
+		response.Type = ResponseTypeAnswer
+		return response, nil
+	default:
+		return Response{}, fmt.Errorf("unknown tool: %s", call.Name)
+	}
+
+	for i := range response.Commands {
+		response.Commands[i].Type = response.CommandType
+	}
+	return response, nil
+}
@@ -2,8 +2,10 @@ package agent
 
 import (
 	"context"
+	"encoding/json"
 	"io"
 
+	"github.com/go-coders/git_gpt/internal/commitlint"
 	"github.com/go-coders/git_gpt/internal/common"
 )
 
@@ -21,13 +23,27 @@ type (
 		ShowWarning(message string)
 		StartSpinner(message string)
 		StopSpinner()
+		ShowStreamChunk(chunk string)
+		FinishStream()
 		ShowCommand(command string)
 		ShowSection(title, content string, opts map[string]string)
 		ShowNumberedList(items [][2]string)
+		// ShowQuestion asks for input; the answer is typed on the same line
+		ShowQuestion(question string)
+		// ShowOutput prints text as is, such as the output of a command
+		ShowOutput(output string)
 	}
 
 	LLMClient interface {
 		Chat(ctx context.Context, content string) (string, error)
+		ChatStream(ctx context.Context, content string, onToken func(token string)) (string, error)
+		// ChatWithTools makes the model answer by calling one of tools
+		ChatWithTools(ctx context.Context, content string, tools []common.Tool) (*common.ToolCall, error)
+		SupportsTools() bool
+		// CountTokens returns how many tokens content takes for the model
+		CountTokens(content string) int
+		// AvailableTokens is the prompt budget, or 0 when there is no limit
+		AvailableTokens() int
 		SetSystemMessage(message string)
 		ClearHistory()
 	}
@@ -37,9 +53,59 @@ type (
 		IsGitRepository(ctx context.Context) bool
 		GetStatus(ctx context.Context) (staged []common.FileChange, unstaged []common.FileChange, err error)
 		StageAll(ctx context.Context) error
+		StageTracked(ctx context.Context) error
 		StageFiles(ctx context.Context, files []string) error
 		Commit(ctx context.Context, message string) error
-		GetDiff(ctx context.Context, staged bool) (string, error)
+		// GetDiff returns the diff of the changes, leaving out the paths in exclude
+		GetDiff(ctx context.Context, staged bool, exclude ...string) (string, error)
+		// GetDiffStat returns the diffstat overview of the changes
+		GetDiffStat(ctx context.Context, staged bool) (string, error)
+		// CheckAttributes looks up gitattributes of paths as set in the index
+		CheckAttributes(ctx context.Context, paths []string, attrs ...string) (map[string]map[string]string, error)
+		GetFileDiff(ctx context.Context, path string, staged bool) (*common.FileDiff, error)
+		// StageHunks adds the hunks of diff with the given 0-based indexes to the index
+		StageHunks(ctx context.Context, diff *common.FileDiff, hunks []int) error
+		// CommitSplit records the staged changes as one commit per step
+		CommitSplit(ctx context.Context, steps []common.CommitStep) error
+		CreateSnapshot(ctx context.Context, query string) (*common.Snapshot, error)
+		// RecentSubjects returns the subjects of the last n non-merge commits
+		RecentSubjects(ctx context.Context, n int) ([]string, error)
+		// GitPath returns the absolute path of name inside the git directory
+		GitPath(ctx context.Context, name string) (string, error)
+		// EditMessage opens message in the user's editor and returns the result
+		EditMessage(ctx context.Context, message string) (string, error)
+		// ParentOf returns the first parent of rev, or the empty tree for a root commit
+		ParentOf(ctx context.Context, rev string) (string, error)
+		// GetRangeChanges lists the files changed between from and to; an empty to means the index
+		GetRangeChanges(ctx context.Context, from, to string) ([]common.FileChange, error)
+		// GetRangeDiff returns the diff between from and to, leaving out the paths in exclude
+		GetRangeDiff(ctx context.Context, from, to string, exclude ...string) (string, error)
+		// GetRangeDiffStat returns the diffstat overview between from and to
+		GetRangeDiffStat(ctx context.Context, from, to string) (string, error)
+		// RemoteBranchesContaining returns the remote branches that already have rev
+		RemoteBranchesContaining(ctx context.Context, rev string) ([]string, error)
+		// Amend replaces the last commit, adding the staged changes
+		Amend(ctx context.Context, message string) error
+		// Reword replaces the message of rev and rebases the commits after it
+		Reword(ctx context.Context, rev, message string) error
+		// GetCurrentBranch returns the checked out branch, empty when HEAD is detached
+		GetCurrentBranch(ctx context.Context) (string, error)
+		// Root returns the top directory of the working tree
+		Root(ctx context.Context) (string, error)
+		// MergeBase returns the best common ancestor of a and b
+		MergeBase(ctx context.Context, a, b string) (string, error)
+		// DefaultBranch guesses the branch pull requests target
+		DefaultBranch(ctx context.Context) (string, error)
+		// CommitLog returns the non-merge commits in from..to, newest first
+		CommitLog(ctx context.Context, from, to string) ([]common.Commit, error)
+		// LastTag returns the newest tag reachable from rev matching the glob, empty when there is none
+		LastTag(ctx context.Context, rev, match string) (string, error)
+		// TagDate returns the date of the commit tagged name as YYYY-MM-DD
+		TagDate(ctx context.Context, name string) (string, error)
+		// CreateTag adds the annotated tag name at HEAD
+		CreateTag(ctx context.Context, name, message string) error
+		// Preview runs commands in a throwaway copy of the repository
+		Preview(ctx context.Context, commands [][]string) (*common.Preview, error)
 	}
 
 	InputReader interface {
@@ -53,6 +119,20 @@ const (
 	CommandTypeModify = "modify"
 )
 
+// Response types returned by the model
+const (
+	ResponseTypeAnswer  = "answer"
+	ResponseTypeExecute = "execute"
+	ResponseTypeDone    = "done"
+)
+
+// Stage modes used by CommitOptions
+const (
+	StageModeNone    = "none"
+	StageModeAll     = "all"
+	StageModeTracked = "tracked"
+)
+
 // Core data structures
 type (
 	Command struct {
@@ -61,6 +141,7 @@ type (
 		Args    []string `json:"args"`
 		Purpose string   `json:"purpose"`
 		Impact  string   `json:"impact,omitempty"`
+		Risk    string   `json:"risk,omitempty"` // set by the local Policy
 	}
 
 	Response struct {
@@ -77,18 +158,119 @@ type (
 		Error   error
 	}
 
+	// AskOptions controls the one-shot query mode
+	AskOptions struct {
+		AllowModify bool
+	}
+
+	// AskResult is the machine-readable outcome of a one-shot query
+	AskResult struct {
+		Query       string          `json:"query"`
+		Type        string          `json:"type"`
+		CommandType string          `json:"commandType,omitempty"`
+		Commands    []Command       `json:"commands"`
+		Results     []CommandResult `json:"results"`
+		Summary     string          `json:"summary"`
+		Executed    bool            `json:"executed"`
+	}
+
 	CommitResponse struct {
 		Summary     string             `json:"summary"`
 		Suggestions []CommitSuggestion `json:"suggestions"`
 	}
 
+	// CommitSuggestion is a proposed commit message. Message is the subject
+	// line; Text renders it with the body and trailers.
 	CommitSuggestion struct {
-		Message     string `json:"message"`
-		Description string `json:"description,omitempty"`
+		Message  string    `json:"message"`
+		Body     string    `json:"body,omitempty"`
+		Trailers []Trailer `json:"trailers,omitempty"`
+	}
+
+	// Trailer is a "Key: value" line at the end of a commit message, such as
+	// "Refs: #123" or "Co-authored-by: Name <email>"
+	Trailer struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	}
+
+	// SplitResponse is the model's grouping of staged units into commits
+	SplitResponse struct {
+		Commits []SplitSuggestion `json:"commits"`
+	}
+
+	SplitSuggestion struct {
+		Message string   `json:"message"`
+		Units   []string `json:"units"`
 	}
 
+	// CommitOptions drives a commit without the interactive prompts
 	CommitOptions struct {
-		AutoStage bool
+		Stage  string // one of the StageMode constants
+		Pick   int    // 1-based suggestion index, 0 means ask
+		Yes    bool   // accept the first suggestion when Pick is not set
+		DryRun bool   // generate and select a message without committing
+		Split  bool   // commit the staged changes as several logical commits
+		Edit   bool   // open the chosen message in the editor before committing
+		Amend  bool   // replace the last commit, describing it together with the staged changes
+		// Trailers are added to the chosen message, after the suggested ones
+		Trailers []Trailer
+	}
+
+	// PROptions selects what a pull request description is written for
+	PROptions struct {
+		Base string // target branch, empty for the default branch
+	}
+
+	// PRResponse is the model's pull request description. Body is the
+	// repository's template filled in, when there is one.
+	PRResponse struct {
+		Title   string   `json:"title"`
+		Summary string   `json:"summary"`
+		Changes []string `json:"changes"`
+		Testing []string `json:"testing"`
+		Risks   []string `json:"risks"`
+		Body    string   `json:"body,omitempty"`
+	}
+
+	// ChangelogOptions selects the commits and heading of a changelog section
+	ChangelogOptions struct {
+		// Range is FROM..TO. FROM defaults to the last tag before TO and TO
+		// to HEAD; a single revision is FROM.
+		Range string
+		// Version names the section, empty for Unreleased or the tag at TO
+		Version string
+		// Date is shown after a version, empty for today or the date of the tag
+		Date string
+		// NoLLM keeps the entries as parsed from the commits
+		NoLLM bool
+	}
+
+	// ChangelogResponse is the model's rewording of changelog entries
+	ChangelogResponse struct {
+		Entries []ChangelogEntry `json:"entries"`
+	}
+
+	ChangelogEntry struct {
+		ID   int    `json:"id"`
+		Text string `json:"text"`
+		Note string `json:"note,omitempty"`
+	}
+
+	// ReleaseOptions drives the release command
+	ReleaseOptions struct {
+		Bump       string // major, minor or patch; empty works it out from the commits
+		Prerelease string // release as a prerelease with this name, such as rc
+		Yes        bool   // create the tag without asking
+		DryRun     bool   // show the next version and tag message without tagging
+	}
+
+	// ReleaseResponse is the model's summary of a release, used as the tag
+	// message
+	ReleaseResponse struct {
+		Title      string   `json:"title"`
+		Summary    string   `json:"summary"`
+		Highlights []string `json:"highlights"`
 	}
 
 	// Agent configuration
@@ -98,7 +280,39 @@ type (
 		Display DisplayManager
 		Logger  Logger
 		Reader  io.Reader
+		// MaxSteps limits the chat agent's plan and execute loop, 0 uses the default
+		MaxSteps int
+		// Policy classifies generated commands, nil uses DefaultPolicy
+		Policy *Policy
+		// DiffIgnore lists globs of files whose diffs are left out of the
+		// commit prompt
+		DiffIgnore []string
+		// Secrets redacts secrets from prompts, nil uses DefaultSecretScanner
+		Secrets *SecretScanner
+		// AbortOnSecrets stops a commit whose staged changes add a secret
+		AbortOnSecrets bool
+		// Lint checks commit messages, nil skips the checks
+		Lint *commitlint.Linter
+		// Ticket finds the ticket commits must reference from the branch
+		// name, nil leaves references to the model
+		Ticket *TicketRule
 	}
 )
 
+// MarshalJSON renders the command error as a string so results can be printed as JSON
+func (r CommandResult) MarshalJSON() ([]byte, error) {
+	out := struct {
+		Command Command `json:"command"`
+		Output  string  `json:"output"`
+		Error   string  `json:"error,omitempty"`
+	}{
+		Command: r.Command,
+		Output:  r.Output,
+	}
+	if r.Error != nil {
+		out.Error = r.Error.Error()
+	}
+	return json.Marshal(out)
+}
+
 // Validator interface for command validation
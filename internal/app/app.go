@@ -11,6 +11,7 @@ import (
 	"github.com/go-coders/git_gpt/internal/display"
 	"github.com/go-coders/git_gpt/internal/git"
 	"github.com/go-coders/git_gpt/internal/llm"
+	"github.com/go-coders/git_gpt/pkg/apierrors"
 	"github.com/go-coders/git_gpt/pkg/utils"
 )
 
@@ -20,10 +21,11 @@ type Application struct {
 	display     *display.DisplayImpl
 	logger      *utils.LoggerImpl
 	version     string
+	workDir     *git.GitExecutor
 	gitClient   *git.GitExecutor
-	chatAgent   *agent.ChatAgent
-	commitAgent *agent.CommitAgent
+	session     *Session
 	repl        *REPL
+	interactive bool
 	mu          sync.RWMutex
 }
 
@@ -32,6 +34,11 @@ type Options struct {
 	Config  *config.Config
 	Logger  *utils.LoggerImpl
 	Version string
+	// NonInteractive disables the configuration wizard for subcommands and
+	// writes the display to stderr, keeping stdout for their result
+	NonInteractive bool
+	// Dir is the directory git runs in, empty for the current directory
+	Dir string
 }
 
 // New creates a new Application instance
@@ -40,12 +47,24 @@ func New(opts Options) (*Application, error) {
 		return nil, err
 	}
 
+	workDir := git.NewExecutor()
+	if opts.Dir != "" {
+		workDir = git.NewExecutorInDir(opts.Dir)
+	}
+
+	output := os.Stdout
+	if opts.NonInteractive {
+		output = os.Stderr
+	}
+
 	app := &Application{
-		config:    opts.Config,
-		logger:    opts.Logger,
-		version:   opts.Version,
-		display:   display.NewManager(opts.Version),
-		gitClient: git.NewExecutor(),
+		config:      opts.Config,
+		logger:      opts.Logger,
+		version:     opts.Version,
+		display:     display.NewManagerTo(opts.Version, output),
+		workDir:     workDir,
+		gitClient:   openRepository(context.Background(), workDir),
+		interactive: !opts.NonInteractive,
 	}
 
 	if err := app.initialize(); err != nil {
@@ -60,17 +79,64 @@ func (a *Application) Run(ctx context.Context) error {
 	return a.repl.Start(ctx)
 }
 
+// Commit runs a single commit without the welcome screen or the REPL
+func (a *Application) Commit(ctx context.Context, opts agent.CommitOptions) (string, error) {
+	if !a.gitClient.IsGitRepository(ctx) {
+		return "", apierrors.NewNotGitRepoError()
+	}
+	return a.session.commitAgent.CommitWithOptions(ctx, opts)
+}
+
+// Reword writes a new message for the unpushed commit rev without the REPL
+func (a *Application) Reword(ctx context.Context, rev string, opts agent.CommitOptions) (string, error) {
+	if !a.gitClient.IsGitRepository(ctx) {
+		return "", apierrors.NewNotGitRepoError()
+	}
+	return a.session.commitAgent.RewordCommit(ctx, rev, opts)
+}
+
+// PR writes the pull request description of the current branch without the REPL
+func (a *Application) PR(ctx context.Context, opts agent.PROptions) (string, error) {
+	if !a.gitClient.IsGitRepository(ctx) {
+		return "", apierrors.NewNotGitRepoError()
+	}
+	return a.session.prAgent.Describe(ctx, opts)
+}
+
+// Changelog writes the changelog section of a revision range without the REPL
+func (a *Application) Changelog(ctx context.Context, opts agent.ChangelogOptions) (string, error) {
+	if !a.gitClient.IsGitRepository(ctx) {
+		return "", apierrors.NewNotGitRepoError()
+	}
+	return a.session.changelog.Changelog(ctx, opts)
+}
+
+// Release tags the next version without the REPL and returns the tag name
+func (a *Application) Release(ctx context.Context, opts agent.ReleaseOptions) (string, error) {
+	if !a.gitClient.IsGitRepository(ctx) {
+		return "", apierrors.NewNotGitRepoError()
+	}
+	return a.session.release.Release(ctx, opts)
+}
+
+// Ask answers a single natural-language query without the REPL
+func (a *Application) Ask(ctx context.Context, query string, opts agent.AskOptions) (*agent.AskResult, error) {
+	return a.session.chatAgent.Ask(ctx, query, opts)
+}
+
+// Reload reads the configuration file again. Sessions created afterwards use
+// the new configuration.
 func (a *Application) Reload() error {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
-	newConfig, err := config.Load()
+	newConfig, err := config.Load(a.config.ConfigPath)
 	if err != nil {
 		return fmt.Errorf("failed to reload configuration: %w", err)
 	}
 
 	a.config = newConfig
-	return a.initialize()
+	return nil
 }
 
 func (a *Application) HandleErr(err error) {
@@ -108,33 +174,47 @@ func (a *Application) initialize() error {
 }
 
 func (a *Application) initializeLLMServices() error {
-	if a.config.LLM.APIKey == "" {
+	if a.config.LLM.NeedsAPIKey() && a.config.LLM.APIKey == "" {
+		if !a.interactive {
+			return apierrors.NewApiKeyError()
+		}
 		if err := a.runConfigWizard(); err != nil {
 			return err
 		}
 	}
 
-	chatLLM, commitLLM, err := a.createLLMClients()
+	session, err := a.NewSession(a.workDir)
 	if err != nil {
 		return err
 	}
-
-	if err := a.createAgents(chatLLM, commitLLM); err != nil {
-		return err
-	}
+	a.session = session
 
 	return nil
 }
 
+// openRepository moves gitClient to the top level of its repository. Outside
+// a repository it is returned as is, so the REPL can start anywhere.
+func openRepository(ctx context.Context, gitClient *git.GitExecutor) *git.GitExecutor {
+	root, err := git.Open(ctx, gitClient.Dir())
+	if err != nil {
+		return gitClient
+	}
+	return root
+}
+
 func (a *Application) createLLMClients() (chatLLM, commitLLM *llm.Client, err error) {
 	// Create chat LLM client with history enabled
 	chatLLM, err = llm.NewClient(llm.Config{
-		APIKey:        a.config.LLM.APIKey,
-		BaseURL:       a.config.LLM.BaseURL,
-		Model:         a.config.LLM.Model,
-		MaxTokens:     a.config.LLM.MaxTokens,
-		Temperature:   a.config.LLM.ChatTemperture,
-		EnableHistory: true,
+		Provider:        a.config.LLM.Provider,
+		APIKey:          a.config.LLM.APIKey,
+		BaseURL:         a.config.LLM.BaseURL,
+		APIVersion:      a.config.LLM.APIVersion,
+		Deployment:      a.config.LLM.Deployment,
+		Model:           a.config.LLM.Model,
+		MaxTokens:       a.config.LLM.MaxTokens,
+		MaxOutputTokens: a.config.LLM.MaxOutputTokens,
+		Temperature:     a.config.LLM.ChatTemperture,
+		EnableHistory:   true,
 	})
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to initialize chat LLM client: %w", err)
@@ -142,11 +222,16 @@ func (a *Application) createLLMClients() (chatLLM, commitLLM *llm.Client, err er
 
 	// Create commit LLM client without history
 	commitLLM, err = llm.NewClient(llm.Config{
-		APIKey:        a.config.LLM.APIKey,
-		BaseURL:       a.config.LLM.BaseURL,
-		Model:         a.config.LLM.Model,
-		Temperature:   a.config.LLM.CommitTemperture,
-		EnableHistory: false,
+		Provider:        a.config.LLM.Provider,
+		APIKey:          a.config.LLM.APIKey,
+		BaseURL:         a.config.LLM.BaseURL,
+		APIVersion:      a.config.LLM.APIVersion,
+		Deployment:      a.config.LLM.Deployment,
+		Model:           a.config.LLM.Model,
+		MaxTokens:       a.config.LLM.MaxTokens,
+		MaxOutputTokens: a.config.LLM.MaxOutputTokens,
+		Temperature:     a.config.LLM.CommitTemperture,
+		EnableHistory:   false,
 	})
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to initialize commit LLM client: %w", err)
@@ -155,37 +240,6 @@ func (a *Application) createLLMClients() (chatLLM, commitLLM *llm.Client, err er
 	return chatLLM, commitLLM, nil
 }
 
-func (a *Application) createAgents(chatLLM, commitLLM *llm.Client) error {
-	// Create base config for agents
-	baseConfig := agent.AgentConfig{
-		Git:     a.gitClient,
-		Display: a.display,
-		Logger:  a.logger,
-		Reader:  os.Stdin,
-	}
-
-	// Create chat agent
-	chatConfig := baseConfig
-	chatConfig.LLM = chatLLM
-	chat, err := agent.NewChatAgent(chatConfig)
-	if err != nil {
-		return fmt.Errorf("failed to initialize chat agent: %w", err)
-	}
-
-	// Create commit agent
-	commitConfig := baseConfig
-	commitConfig.LLM = commitLLM
-	commit, err := agent.NewCommitAgent(commitConfig)
-	if err != nil {
-		return fmt.Errorf("failed to initialize commit agent: %w", err)
-	}
-
-	a.chatAgent = chat
-	a.commitAgent = commit
-
-	return nil
-}
-
 func (a *Application) runConfigWizard() error {
 	wizard := NewConfigWizard(a.config)
 	if err := wizard.Run(); err != nil {
@@ -29,6 +29,8 @@ type configPrompt struct {
 	current   string
 	validator func(string) error
 	setter    func(string) error
+	// when limits the prompt to some configurations, it is always shown if nil
+	when func() bool
 }
 
 func (w *ConfigWizard) Run() error {
@@ -43,9 +45,10 @@ func (w *ConfigWizard) Run() error {
 		}
 	}(*w.config)
 
-	prompts := w.getPrompts()
-	for _, p := range prompts {
-		if err := w.handlePrompt(p); err != nil {
+	for i := 0; i < len(w.getPrompts()); i++ {
+		// Rebuild the prompts so current values reflect earlier answers,
+		// e.g. the defaults of a newly selected provider
+		if err := w.handlePrompt(w.getPrompts()[i]); err != nil {
 			return err
 		}
 	}
@@ -61,11 +64,29 @@ func (w *ConfigWizard) Run() error {
 
 func (w *ConfigWizard) getPrompts() []configPrompt {
 	return []configPrompt{
+		{
+			label:   "LLM Provider (openai, azure, anthropic, ollama)",
+			current: w.config.LLM.Provider,
+			validator: func(s string) error {
+				switch s {
+				case "", config.ProviderOpenAI, config.ProviderAzure, config.ProviderAnthropic, config.ProviderOllama:
+					return nil
+				default:
+					return fmt.Errorf("unsupported provider: %s", s)
+				}
+			},
+			setter: func(s string) error {
+				if s != "" && s != w.config.LLM.Provider {
+					w.switchProvider(s)
+				}
+				return nil
+			},
+		},
 		{
 			label:   "LLM API Key",
 			current: maskAPIKey(w.config.LLM.APIKey),
 			validator: func(s string) error {
-				if s == "" && w.config.LLM.APIKey == "" {
+				if s == "" && w.config.LLM.APIKey == "" && w.config.LLM.NeedsAPIKey() {
 					return apierrors.NewApiKeyError()
 				}
 				return nil
@@ -97,6 +118,28 @@ func (w *ConfigWizard) getPrompts() []configPrompt {
 				return nil
 			},
 		},
+		{
+			label:   "Azure Deployment Name",
+			current: w.config.LLM.Deployment,
+			when:    w.isAzure,
+			setter: func(s string) error {
+				if s != "" {
+					w.config.LLM.Deployment = s
+				}
+				return nil
+			},
+		},
+		{
+			label:   "Azure API Version",
+			current: w.config.LLM.APIVersion,
+			when:    w.isAzure,
+			setter: func(s string) error {
+				if s != "" {
+					w.config.LLM.APIVersion = s
+				}
+				return nil
+			},
+		},
 		{
 			label:   "Max Tokens",
 			current: strconv.Itoa(w.config.LLM.MaxTokens),
@@ -119,7 +162,28 @@ func (w *ConfigWizard) getPrompts() []configPrompt {
 	}
 }
 
+// switchProvider changes the provider and replaces the model and base URL
+// when they still hold the previous provider's defaults
+func (w *ConfigWizard) switchProvider(provider string) {
+	llmConfig := &w.config.LLM
+	if llmConfig.Model == config.DefaultModelFor(llmConfig.Provider) {
+		llmConfig.Model = config.DefaultModelFor(provider)
+	}
+	if llmConfig.BaseURL == config.DefaultBaseURLFor(llmConfig.Provider) {
+		llmConfig.BaseURL = config.DefaultBaseURLFor(provider)
+	}
+	llmConfig.Provider = provider
+}
+
+func (w *ConfigWizard) isAzure() bool {
+	return w.config.LLM.Provider == config.ProviderAzure
+}
+
 func (w *ConfigWizard) handlePrompt(p configPrompt) error {
+	if p.when != nil && !p.when() {
+		return nil
+	}
+
 	var prompt string
 	if p.current != "" {
 		prompt = fmt.Sprintf("Enter %s (current: %s, press Enter to keep current): ", p.label, p.current)
@@ -142,9 +206,14 @@ func (w *ConfigWizard) handlePrompt(p configPrompt) error {
 
 func (w *ConfigWizard) showSummary() {
 	fmt.Println("\n📝 Configuration Summary:")
+	fmt.Printf("Provider: %s\n", w.config.LLM.Provider)
 	fmt.Printf("API Key: %s\n", maskAPIKey(w.config.LLM.APIKey))
 	fmt.Printf("Model: %s\n", w.config.LLM.Model)
 	fmt.Printf("API Base URL: %s\n", w.config.LLM.BaseURL)
+	if w.isAzure() {
+		fmt.Printf("Deployment: %s\n", w.config.LLM.Deployment)
+		fmt.Printf("API Version: %s\n", w.config.LLM.APIVersion)
+	}
 	fmt.Printf("Max Tokens: %d\n", w.config.LLM.MaxTokens)
 	fmt.Printf("\n✅ Configuration saved to: %s\n", w.config.ConfigPath)
 }
@@ -0,0 +1,113 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-coders/git_gpt/internal/git"
+	"github.com/go-coders/git_gpt/pkg/apierrors"
+)
+
+const (
+	hookName   = "prepare-commit-msg"
+	hookMarker = "# Installed by ggpt"
+)
+
+const hookScriptTpl = `#!/bin/sh
+%s: AI commit message suggestions
+GGPT=%s
+[ -x "$GGPT" ] || GGPT=ggpt
+command -v "$GGPT" >/dev/null 2>&1 || exit 0
+exec "$GGPT" hook run "$@"
+`
+
+// InstallHook writes the prepare-commit-msg hook into the repository's hooks
+// directory. An existing hook that was not installed by ggpt is only replaced
+// when force is set.
+func InstallHook(ctx context.Context, gitClient *git.GitExecutor, force bool) (string, error) {
+	path, err := hookPath(ctx, gitClient)
+	if err != nil {
+		return "", err
+	}
+
+	if existing, err := os.ReadFile(path); err == nil {
+		if !strings.Contains(string(existing), hookMarker) && !force {
+			return "", fmt.Errorf("%s already exists, use --force to replace it", path)
+		}
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		exe = "ggpt"
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+
+	script := fmt.Sprintf(hookScriptTpl, hookMarker, shellQuote(exe))
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		return "", fmt.Errorf("failed to write hook: %w", err)
+	}
+	return path, nil
+}
+
+// UninstallHook removes the prepare-commit-msg hook if it was installed by ggpt
+func UninstallHook(ctx context.Context, gitClient *git.GitExecutor) (string, error) {
+	path, err := hookPath(ctx, gitClient)
+	if err != nil {
+		return "", err
+	}
+
+	existing, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("no %s hook installed", hookName)
+		}
+		return "", fmt.Errorf("failed to read hook: %w", err)
+	}
+	if !strings.Contains(string(existing), hookMarker) {
+		return "", fmt.Errorf("%s was not installed by ggpt", path)
+	}
+
+	if err := os.Remove(path); err != nil {
+		return "", fmt.Errorf("failed to remove hook: %w", err)
+	}
+	return path, nil
+}
+
+// ShouldPrepareMessage reports whether the hook should generate suggestions
+// for the given commit message source. Merges, amends, squashes and messages
+// supplied with -m, -F or a template are left untouched.
+func ShouldPrepareMessage(source string) bool {
+	return source == ""
+}
+
+// PrepareCommitMessage fills the commit message file passed to the hook
+func (a *Application) PrepareCommitMessage(ctx context.Context, path string) error {
+	if !a.gitClient.IsGitRepository(ctx) {
+		return apierrors.NewNotGitRepoError()
+	}
+	return a.session.commitAgent.PrepareCommitMessage(ctx, path)
+}
+
+// shellQuote quotes s as one /bin/sh word. Nothing is expanded inside single
+// quotes, so only a single quote itself needs escaping.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func hookPath(ctx context.Context, gitClient *git.GitExecutor) (string, error) {
+	if !gitClient.IsGitRepository(ctx) {
+		return "", apierrors.NewNotGitRepoError()
+	}
+
+	dir, err := gitClient.HooksDir(ctx)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, hookName), nil
+}
@@ -0,0 +1,30 @@
+package app
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShellQuote(t *testing.T) {
+	paths := []string{
+		"/usr/local/bin/ggpt",
+		"/home/me/my tools/ggpt",
+		`/tmp/$HOME/ggpt`,
+		"/tmp/`id`/ggpt",
+		`C:\tools\ggpt`,
+		"/opt/it's/ggpt",
+		"/home/用户/ggpt",
+	}
+
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			// The hook assigns the path the same way
+			out, err := exec.Command("/bin/sh", "-c", "GGPT="+shellQuote(path)+"\nprintf %s \"$GGPT\"").Output()
+			require.NoError(t, err)
+			assert.Equal(t, path, string(out))
+		})
+	}
+}
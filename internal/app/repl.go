@@ -6,20 +6,24 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
+	"github.com/go-coders/git_gpt/internal/agent"
 	"github.com/go-coders/git_gpt/internal/version"
 )
 
 type REPL struct {
-	app    *Application
-	reader *bufio.Reader
+	app     *Application
+	session *Session
+	reader  *bufio.Reader
 }
 
 func NewREPL(app *Application) *REPL {
 	return &REPL{
-		app:    app,
-		reader: bufio.NewReader(os.Stdin),
+		app:     app,
+		session: app.session,
+		reader:  bufio.NewReader(os.Stdin),
 	}
 }
 
@@ -46,19 +50,14 @@ func (r *REPL) Start(ctx context.Context) error {
 }
 
 func (r *REPL) showPrompt(ctx context.Context) error {
-	pwd, err := os.Getwd()
-	if err != nil {
-		return err
-	}
-
 	branch := "no git"
-	if r.app.gitClient.IsGitRepository(ctx) {
-		if b, err := r.app.gitClient.GetCurrentBranch(ctx); err == nil {
+	if r.session.git.IsGitRepository(ctx) {
+		if b, err := r.session.git.GetCurrentBranch(ctx); err == nil {
 			branch = b
 		}
 	}
 
-	r.app.display.ShowPrompt(pwd, branch)
+	r.app.display.ShowPrompt(r.session.Dir(), branch)
 	return nil
 }
 
@@ -70,14 +69,96 @@ func (r *REPL) handleInput(ctx context.Context, input string) error {
 	case input == "config":
 		return r.handleConfig()
 	case input == "commit":
-		return r.app.commitAgent.HandleCommit(ctx)
+		return r.session.commitAgent.HandleCommit(ctx)
+	case input == "commit --split":
+		return r.session.commitAgent.HandleSplitCommit(ctx)
+	case input == "commit --amend":
+		return r.session.commitAgent.HandleAmendCommit(ctx)
+	case strings.HasPrefix(input, "reword "):
+		return r.session.commitAgent.HandleRewordCommit(ctx, strings.TrimSpace(strings.TrimPrefix(input, "reword ")))
+	case input == "pr" || strings.HasPrefix(input, "pr "):
+		return r.handlePR(ctx, input)
+	case input == "changelog" || strings.HasPrefix(input, "changelog "):
+		return r.handleChangelog(ctx, input)
+	case input == "release" || strings.HasPrefix(input, "release "):
+		return r.handleRelease(ctx, input)
+	case input == "undo" || strings.HasPrefix(input, "undo "):
+		return r.handleUndo(ctx, input)
 	case strings.HasPrefix(input, "cd"):
 		return r.handleChangeDirectory(input)
 	case strings.TrimSpace(input) == "":
 		return nil
 	default:
-		return r.app.chatAgent.Chat(ctx, input)
+		return r.session.chatAgent.Chat(ctx, input)
+	}
+}
+
+// handlePR runs "pr [base] [-o file]"
+func (r *REPL) handlePR(ctx context.Context, input string) error {
+	var base, output string
+	args := strings.Fields(input)[1:]
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "-o" && i+1 < len(args):
+			output = args[i+1]
+			i++
+		case base == "" && !strings.HasPrefix(args[i], "-"):
+			base = args[i]
+		default:
+			return fmt.Errorf("usage: pr [base] [-o file]")
+		}
+	}
+	return r.session.prAgent.HandlePR(ctx, base, r.resolvePath(output))
+}
+
+// resolvePath resolves a relative path against the session's directory, which
+// is not the directory the process runs in once the user has used cd
+func (r *REPL) resolvePath(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
 	}
+	return filepath.Join(r.session.Dir(), path)
+}
+
+// handleChangelog runs "changelog [range] [--no-llm] [-o file]"
+func (r *REPL) handleChangelog(ctx context.Context, input string) error {
+	var opts agent.ChangelogOptions
+	var output string
+	args := strings.Fields(input)[1:]
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "-o" && i+1 < len(args):
+			output = args[i+1]
+			i++
+		case args[i] == "--no-llm":
+			opts.NoLLM = true
+		case opts.Range == "" && !strings.HasPrefix(args[i], "-"):
+			opts.Range = args[i]
+		default:
+			return fmt.Errorf("usage: changelog [range] [--no-llm] [-o file]")
+		}
+	}
+	return r.session.changelog.HandleChangelog(ctx, opts, r.resolvePath(output))
+}
+
+// handleRelease runs "release [major|minor|patch] [--pre name] [--dry-run]"
+func (r *REPL) handleRelease(ctx context.Context, input string) error {
+	var opts agent.ReleaseOptions
+	args := strings.Fields(input)[1:]
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--pre" && i+1 < len(args):
+			opts.Prerelease = args[i+1]
+			i++
+		case args[i] == "--dry-run":
+			opts.DryRun = true
+		case opts.Bump == "" && !strings.HasPrefix(args[i], "-"):
+			opts.Bump = args[i]
+		default:
+			return fmt.Errorf("usage: release [major|minor|patch] [--pre name] [--dry-run]")
+		}
+	}
+	return r.session.release.HandleRelease(ctx, opts)
 }
 
 func (r *REPL) handleConfig() error {
@@ -88,6 +169,13 @@ func (r *REPL) handleConfig() error {
 	if err := r.app.Reload(); err != nil {
 		return fmt.Errorf("failed to reload application: %w", err)
 	}
+	// The new configuration takes effect in a new session for the same
+	// directory
+	session, err := r.app.NewSession(r.session.workDir)
+	if err != nil {
+		return fmt.Errorf("failed to reload application: %w", err)
+	}
+	r.session = session
 	r.app.display.ShowSuccess("Configuration updated and reloaded successfully")
 	return nil
 }
@@ -104,12 +192,19 @@ func (r *REPL) handleChangeDirectory(input string) error {
 		path = strings.Replace(path, "~", homeDir, 1)
 	}
 
-	if err := os.Chdir(path); err != nil {
-		return fmt.Errorf("failed to change directory: %w", err)
+	// Relative paths are resolved against where the user is, not the
+	// top level of the repository
+	workDir, err := r.session.workDir.WithDir(path)
+	if err != nil {
+		return err
 	}
 
-	pwd, _ := os.Getwd()
-	r.app.display.ShowInfo(fmt.Sprintf("Changed to: %s", pwd))
-	r.app.chatAgent.ResetChat()
+	// A new session starts with a fresh chat for the new directory
+	session, err := r.app.NewSession(workDir)
+	if err != nil {
+		return err
+	}
+	r.session = session
+	r.app.display.ShowInfo(fmt.Sprintf("Changed to: %s", session.Dir()))
 	return nil
 }
@@ -0,0 +1,150 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/go-coders/git_gpt/internal/agent"
+	"github.com/go-coders/git_gpt/internal/commitlint"
+	"github.com/go-coders/git_gpt/internal/git"
+)
+
+// Session binds the agents and their chat history to one directory, so that
+// several repositories can be served by one process
+type Session struct {
+	// workDir is the directory the user is in, git runs at its top level
+	workDir     *git.GitExecutor
+	git         *git.GitExecutor
+	chatAgent   *agent.ChatAgent
+	commitAgent *agent.CommitAgent
+	prAgent     *agent.PRAgent
+	changelog   *agent.ChangelogAgent
+	release     *agent.ReleaseAgent
+}
+
+// NewSession creates agents for the repository containing workDir. They run
+// git at its top level, like the subcommands do.
+func (a *Application) NewSession(workDir *git.GitExecutor) (*Session, error) {
+	gitClient := openRepository(context.Background(), workDir)
+
+	chatLLM, commitLLM, err := a.createLLMClients()
+	if err != nil {
+		return nil, err
+	}
+
+	secrets, err := agent.NewSecretScanner(a.config.Secrets.Patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	lint := a.loadCommitLint(gitClient)
+
+	ticketConfig := a.config.Commit.Ticket
+	ticket, err := agent.NewTicketRule(ticketConfig.Patterns, ticketConfig.Position, ticketConfig.Trailer)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create base config for agents
+	baseConfig := agent.AgentConfig{
+		Git:     gitClient,
+		Display: a.display,
+		Logger:  a.logger,
+		Reader:  os.Stdin,
+		Secrets: secrets,
+	}
+
+	// Create chat agent
+	chatConfig := baseConfig
+	chatConfig.LLM = chatLLM
+	chatConfig.MaxSteps = a.config.Agent.MaxSteps
+	policy, err := agent.LoadPolicy(a.config.PolicyPath())
+	if err != nil {
+		return nil, err
+	}
+	chatConfig.Policy = policy
+	chat, err := agent.NewChatAgent(chatConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize chat agent: %w", err)
+	}
+
+	// Create commit agent
+	commitConfig := baseConfig
+	commitConfig.LLM = commitLLM
+	commitConfig.DiffIgnore = a.config.Commit.DiffIgnore
+	commitConfig.AbortOnSecrets = a.config.Secrets.AbortCommit
+	commitConfig.Lint = lint
+	if len(ticketConfig.Patterns) > 0 {
+		commitConfig.Ticket = ticket
+	}
+	commit, err := agent.NewCommitAgent(commitConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize commit agent: %w", err)
+	}
+
+	// The pull request agent shares the commit agent's diff handling
+	pr, err := agent.NewPRAgent(commitConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize pull request agent: %w", err)
+	}
+
+	changelog, err := agent.NewChangelogAgent(commitConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize changelog agent: %w", err)
+	}
+
+	release, err := agent.NewReleaseAgent(commitConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize release agent: %w", err)
+	}
+
+	return &Session{
+		workDir:     workDir,
+		git:         gitClient,
+		chatAgent:   chat,
+		commitAgent: commit,
+		prAgent:     pr,
+		changelog:   changelog,
+		release:     release,
+	}, nil
+}
+
+// loadCommitLint picks the commit rules: the repository's .commitlintrc, then
+// the lint section of the config, then the conventional commits preset. The
+// preset is skipped when the history shows the repository uses another style.
+// A .commitlintrc that cannot be read is reported and skipped, so that a
+// broken file in one repository does not keep ggpt from starting.
+func (a *Application) loadCommitLint(gitClient *git.GitExecutor) *commitlint.Linter {
+	ctx := context.Background()
+	root, err := gitClient.Root(ctx)
+	if err == nil {
+		lint, path, err := commitlint.Load(root)
+		if err != nil {
+			a.display.ShowWarning(fmt.Sprintf("Ignoring the repository's commit rules: %v", err))
+		} else if lint != nil {
+			a.logger.Debug("Using commit rules from %s", path)
+			return lint
+		}
+	}
+
+	if a.config.Commit.Lint != nil {
+		return commitlint.New(*a.config.Commit.Lint)
+	}
+	if root != "" {
+		profile, err := agent.LoadStyleProfile(ctx, gitClient)
+		if err != nil {
+			a.logger.Debug("Failed to learn the commit style: %v", err)
+		} else if profile != nil && profile.Convention != agent.StyleConventional {
+			a.logger.Debug("Repository uses %s commit subjects, skipping the conventional commits rules", profile.Convention)
+			return nil
+		}
+	}
+	return commitlint.Conventional()
+}
+
+// Dir returns the directory the user is in, which can be below the top level
+// git runs in
+func (s *Session) Dir() string {
+	return s.workDir.Dir()
+}
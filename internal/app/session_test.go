@@ -0,0 +1,133 @@
+package app
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-coders/git_gpt/internal/commitlint"
+	"github.com/go-coders/git_gpt/internal/config"
+	"github.com/go-coders/git_gpt/internal/display"
+	"github.com/go-coders/git_gpt/internal/git"
+	"github.com/go-coders/git_gpt/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testApplication returns an application on a local model that is never
+// called, and the file its display writes to
+func testApplication(t *testing.T) (*Application, string) {
+	t.Helper()
+	cfg := &config.Config{ConfigPath: filepath.Join(t.TempDir(), "config.json")}
+	cfg.LLM.Provider = config.ProviderOllama
+	cfg.LLM.BaseURL = "http://localhost:1"
+	cfg.SetDefaultValue()
+
+	path := filepath.Join(t.TempDir(), "display")
+	out, err := os.Create(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { out.Close() })
+
+	return &Application{
+		config:  cfg,
+		logger:  utils.NewLogger(false),
+		display: display.NewManagerTo("test", out),
+	}, path
+}
+
+// testRepo creates an empty repository and returns its top level
+func testRepo(t *testing.T) string {
+	t.Helper()
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	out, err := exec.Command("git", "-C", root, "init", "-q").CombinedOutput()
+	require.NoError(t, err, string(out))
+	return root
+}
+
+func TestChangeDirectory_KeepsSubdirectory(t *testing.T) {
+	root := testRepo(t)
+	pkg := filepath.Join(root, "pkg")
+	require.NoError(t, os.MkdirAll(filepath.Join(pkg, "sub"), 0755))
+
+	app, _ := testApplication(t)
+	session, err := app.NewSession(git.NewExecutorInDir(root))
+	require.NoError(t, err)
+	repl := &REPL{app: app, session: session}
+
+	// Relative paths follow the user's directory, git stays at the top level
+	steps := []struct {
+		input string
+		dir   string
+	}{
+		{"cd pkg", pkg},
+		{"cd sub", filepath.Join(pkg, "sub")},
+		{"cd ..", pkg},
+		{"cd ../..", filepath.Dir(root)},
+	}
+	for _, step := range steps {
+		require.NoError(t, repl.handleChangeDirectory(step.input), step.input)
+		assert.Equal(t, step.dir, repl.session.Dir(), step.input)
+		if step.dir != filepath.Dir(root) {
+			assert.Equal(t, root, repl.session.git.Dir(), step.input)
+		}
+	}
+}
+
+func TestNewSession_MalformedCommitLint(t *testing.T) {
+	root := testRepo(t)
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".commitlintrc"), []byte("rules: [\n"), 0644))
+
+	app, output := testApplication(t)
+	session, err := app.NewSession(git.NewExecutorInDir(root))
+	require.NoError(t, err)
+	require.NotNil(t, session)
+
+	// The session falls back to the conventional commits preset
+	assert.Equal(t, commitlint.Conventional(), app.loadCommitLint(session.git))
+	shown, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Contains(t, string(shown), "Ignoring the repository's commit rules: invalid .commitlintrc")
+}
+
+func TestResolvePath(t *testing.T) {
+	root := testRepo(t)
+	pkg := filepath.Join(root, "pkg")
+	require.NoError(t, os.Mkdir(pkg, 0755))
+
+	app, _ := testApplication(t)
+	session, err := app.NewSession(git.NewExecutorInDir(pkg))
+	require.NoError(t, err)
+	repl := &REPL{app: app, session: session}
+
+	assert.Equal(t, "", repl.resolvePath(""))
+	assert.Equal(t, filepath.Join(pkg, "PR.md"), repl.resolvePath("PR.md"))
+	assert.Equal(t, filepath.Join(root, "docs", "PR.md"), repl.resolvePath("../docs/PR.md"))
+	assert.Equal(t, "/tmp/PR.md", repl.resolvePath("/tmp/PR.md"))
+}
+
+func TestHandleChangelog_WritesRelativeToSession(t *testing.T) {
+	root := testRepo(t)
+	pkg := filepath.Join(root, "pkg")
+	require.NoError(t, os.Mkdir(pkg, 0755))
+	for _, args := range [][]string{
+		{"config", "user.name", "Test"},
+		{"config", "user.email", "test@example.com"},
+		{"commit", "-q", "--allow-empty", "-m", "feat: add login"},
+	} {
+		out, err := exec.Command("git", append([]string{"-C", root}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	app, _ := testApplication(t)
+	session, err := app.NewSession(git.NewExecutorInDir(pkg))
+	require.NoError(t, err)
+	repl := &REPL{app: app, session: session}
+
+	require.NoError(t, repl.handleChangelog(context.Background(), "changelog --no-llm -o CHANGES.md"))
+	data, err := os.ReadFile(filepath.Join(pkg, "CHANGES.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "- Add login")
+}
@@ -0,0 +1,82 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-coders/git_gpt/internal/common"
+	"github.com/go-coders/git_gpt/internal/git"
+	"github.com/go-coders/git_gpt/pkg/apierrors"
+)
+
+// DefaultUndoListSize is how many undo points are listed when no count is given
+const DefaultUndoListSize = 10
+
+// Undo restores the repository to the state recorded before the last
+// confirmed modification. It returns the restored undo point and the ref
+// that keeps the replaced state.
+func Undo(ctx context.Context, gitClient *git.GitExecutor) (*common.Snapshot, string, error) {
+	if !gitClient.IsGitRepository(ctx) {
+		return nil, "", apierrors.NewNotGitRepoError()
+	}
+	return gitClient.Undo(ctx)
+}
+
+// UndoHistory returns the last limit undo points, newest first
+func UndoHistory(ctx context.Context, gitClient *git.GitExecutor, limit int) ([]common.Snapshot, error) {
+	if !gitClient.IsGitRepository(ctx) {
+		return nil, apierrors.NewNotGitRepoError()
+	}
+	return gitClient.ListSnapshots(ctx, limit)
+}
+
+// FormatUndoPoint describes an undo point on a single line
+func FormatUndoPoint(snapshot common.Snapshot) string {
+	return fmt.Sprintf("%s  %s", snapshot.Time.Local().Format("2006-01-02 15:04:05"), snapshot.Query)
+}
+
+// handleUndo runs "undo" and "undo list [N]"
+func (r *REPL) handleUndo(ctx context.Context, input string) error {
+	args := strings.Fields(input)[1:]
+	if len(args) == 0 {
+		snapshot, backup, err := Undo(ctx, r.session.git)
+		if err != nil {
+			return err
+		}
+		r.app.display.ShowSuccess(fmt.Sprintf("Restored the state before: %s", snapshot.Query))
+		r.app.display.ShowInfo(fmt.Sprintf("The replaced state is kept in %s", backup))
+		return nil
+	}
+
+	if args[0] != "list" || len(args) > 2 {
+		return fmt.Errorf("usage: undo | undo list [N]")
+	}
+
+	limit := DefaultUndoListSize
+	if len(args) == 2 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid count: %s", args[1])
+		}
+		limit = n
+	}
+
+	snapshots, err := UndoHistory(ctx, r.session.git, limit)
+	if err != nil {
+		return err
+	}
+	if len(snapshots) == 0 {
+		r.app.display.ShowInfo("No recorded operations")
+		return nil
+	}
+
+	items := make([][2]string, len(snapshots))
+	for i, snapshot := range snapshots {
+		items[i] = [2]string{FormatUndoPoint(snapshot), ""}
+	}
+	r.app.display.ShowSection("Recorded Operations", "", map[string]string{"icon": "⏪"})
+	r.app.display.ShowNumberedList(items)
+	return nil
+}
@@ -0,0 +1,56 @@
+package changelog
+
+import (
+	"github.com/go-coders/git_gpt/internal/common"
+	"github.com/go-coders/git_gpt/internal/version"
+)
+
+// patchTypes are the conventional types that ask for a patch release, with
+// what they are
+var patchTypes = map[string]string{
+	"fix":        "bug fix",
+	"perf":       "performance improvement",
+	"revert":     "revert",
+	"security":   "security fix",
+	"sec":        "security fix",
+	"deprecate":  "deprecation",
+	"deprecated": "deprecation",
+}
+
+// BumpReason is a commit that asks for a release, and why
+type BumpReason struct {
+	Level  version.Level
+	Why    string
+	Change Change
+}
+
+// Bump returns the version bump commits ask for together with the commits
+// behind it, in the order of commits. Breaking changes ask for a major
+// release, features for a minor one, and fixes, performance work and
+// commits that are not conventional for a patch. Other types such as docs or
+// chore do not ask for a release.
+func Bump(commits []common.Commit) (version.Level, []BumpReason) {
+	level := version.None
+	var reasons []BumpReason
+	for _, commit := range commits {
+		change := Parse(commit)
+		reason := BumpReason{Change: change}
+		switch {
+		case change.Breaking:
+			reason.Level, reason.Why = version.Major, "breaking change"
+		case change.Type == "feat":
+			reason.Level, reason.Why = version.Minor, "new feature"
+		case change.Type == "":
+			reason.Level, reason.Why = version.Patch, "not a conventional commit"
+		case patchTypes[change.Type] != "":
+			reason.Level, reason.Why = version.Patch, patchTypes[change.Type]
+		default:
+			continue
+		}
+		reasons = append(reasons, reason)
+		if reason.Level > level {
+			level = reason.Level
+		}
+	}
+	return level, reasons
+}
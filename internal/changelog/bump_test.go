@@ -0,0 +1,69 @@
+package changelog
+
+import (
+	"testing"
+
+	"github.com/go-coders/git_gpt/internal/common"
+	"github.com/go-coders/git_gpt/internal/version"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBump(t *testing.T) {
+	tests := []struct {
+		name     string
+		subjects []string
+		want     version.Level
+		whys     []string
+	}{
+		{
+			name:     "feature",
+			subjects: []string{"fix: crash", "feat: export", "docs: usage"},
+			want:     version.Minor,
+			whys:     []string{"bug fix", "new feature"},
+		},
+		{
+			name:     "breaking",
+			subjects: []string{"perf: cache lookups", "feat!: new config format"},
+			want:     version.Major,
+			whys:     []string{"performance improvement", "breaking change"},
+		},
+		{
+			name:     "not conventional",
+			subjects: []string{"Update the README", "chore: bump deps"},
+			want:     version.Patch,
+			whys:     []string{"not a conventional commit"},
+		},
+		{
+			name:     "component prefix",
+			subjects: []string{"auth: fix token refresh", "docs: usage"},
+			want:     version.Patch,
+			whys:     []string{"not a conventional commit"},
+		},
+		{
+			name:     "nothing to release",
+			subjects: []string{"docs: usage", "test: cover parser", "ci: cache modules"},
+			want:     version.None,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var commits []common.Commit
+			for _, subject := range tt.subjects {
+				commits = append(commits, common.Commit{Subject: subject})
+			}
+			level, reasons := Bump(commits)
+			assert.Equal(t, tt.want, level)
+
+			var whys []string
+			for _, reason := range reasons {
+				whys = append(whys, reason.Why)
+			}
+			assert.Equal(t, tt.whys, whys)
+		})
+	}
+
+	level, reasons := Bump([]common.Commit{{Subject: "docs: usage", Body: "BREAKING CHANGE: the docs site moved"}})
+	assert.Equal(t, version.Major, level)
+	assert.Equal(t, "docs: usage", reasons[0].Change.Subject)
+}
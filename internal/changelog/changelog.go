@@ -0,0 +1,326 @@
+// Package changelog turns conventional commits into Keep a Changelog
+// sections
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-coders/git_gpt/internal/common"
+)
+
+// Section titles, in the order they are rendered. Breaking changes come
+// first; the rest follow Keep a Changelog.
+const (
+	SectionBreaking   = "Breaking Changes"
+	SectionAdded      = "Added"
+	SectionChanged    = "Changed"
+	SectionDeprecated = "Deprecated"
+	SectionRemoved    = "Removed"
+	SectionFixed      = "Fixed"
+	SectionSecurity   = "Security"
+)
+
+var sectionOrder = []string{
+	SectionBreaking,
+	SectionAdded,
+	SectionChanged,
+	SectionDeprecated,
+	SectionRemoved,
+	SectionFixed,
+	SectionSecurity,
+}
+
+// sectionOf maps conventional types to sections. Types that are missing,
+// such as docs, test or chore, are left out of the changelog unless the
+// commit is breaking.
+var sectionOf = map[string]string{
+	"feat":       SectionAdded,
+	"fix":        SectionFixed,
+	"perf":       SectionChanged,
+	"refactor":   SectionChanged,
+	"revert":     SectionChanged,
+	"deprecate":  SectionDeprecated,
+	"deprecated": SectionDeprecated,
+	"remove":     SectionRemoved,
+	"removed":    SectionRemoved,
+	"security":   SectionSecurity,
+	"sec":        SectionSecurity,
+}
+
+// leftOutTypes are conventional types with no section of their own
+var leftOutTypes = map[string]bool{
+	"build": true, "chore": true, "ci": true, "docs": true, "style": true, "test": true,
+}
+
+var (
+	headerPattern   = regexp.MustCompile(`^(\w+)(?:\(([^()]*)\))?(!?): (.+)$`)
+	breakingPattern = regexp.MustCompile(`^BREAKING[ -]CHANGE: *(.*)$`)
+)
+
+// Change is a commit parsed as a conventional commit
+type Change struct {
+	Hash        string
+	Subject     string
+	Type        string // empty when the subject is not conventional
+	Scope       string
+	Description string
+	Breaking    bool
+	// BreakingNote is the text of the BREAKING CHANGE footer
+	BreakingNote string
+	Body         string
+}
+
+// Parse reads the type, scope and breaking markers of a commit. A subject
+// that is not conventional is kept whole as the description, and so is one
+// whose prefix is not a known type, such as "parser: handle EOF".
+func Parse(commit common.Commit) Change {
+	subject := strings.TrimSpace(commit.Subject)
+	change := Change{Hash: commit.Hash, Subject: subject, Description: subject, Body: commit.Body}
+	if m := headerPattern.FindStringSubmatch(change.Description); m != nil && isConventionalType(strings.ToLower(m[1])) {
+		change.Type = strings.ToLower(m[1])
+		change.Scope = strings.TrimSpace(m[2])
+		change.Breaking = m[3] == "!"
+		change.Description = strings.TrimSpace(m[4])
+	}
+
+	// The note runs from the footer to the next blank line
+	lines := strings.Split(commit.Body, "\n")
+	for i, line := range lines {
+		m := breakingPattern.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		change.Breaking = true
+		note := []string{m[1]}
+		for _, next := range lines[i+1:] {
+			if strings.TrimSpace(next) == "" {
+				break
+			}
+			note = append(note, strings.TrimSpace(next))
+		}
+		change.BreakingNote = strings.TrimSpace(strings.Join(note, " "))
+		break
+	}
+	return change
+}
+
+func isConventionalType(t string) bool {
+	return sectionOf[t] != "" || leftOutTypes[t]
+}
+
+// Section returns the section the change is listed in, or an empty string
+// when it is left out
+func (c Change) Section() string {
+	if c.Breaking {
+		return SectionBreaking
+	}
+	if c.Type == "" {
+		return SectionChanged
+	}
+	if c.Type == "fix" && strings.EqualFold(c.Scope, "security") {
+		return SectionSecurity
+	}
+	return sectionOf[c.Type]
+}
+
+// Entry is one line of a section
+type Entry struct {
+	Scope string
+	Text  string
+	// Note is shown under the text, such as what users of a breaking change
+	// must do
+	Note string
+	// Change is the commit the entry was made from
+	Change Change
+}
+
+// Section is a group of entries under one heading
+type Section struct {
+	Title   string
+	Entries []Entry
+}
+
+// Build parses commits and groups them into sections in the order of
+// sectionOrder. Entries keep the order of commits; commits whose type is not
+// user-facing are dropped.
+func Build(commits []common.Commit) []Section {
+	entries := make(map[string][]Entry)
+	for _, commit := range commits {
+		change := Parse(commit)
+		section := change.Section()
+		if section == "" || change.Description == "" {
+			continue
+		}
+		entry := Entry{
+			Scope:  change.Scope,
+			Text:   capitalize(change.Description),
+			Note:   capitalize(change.BreakingNote),
+			Change: change,
+		}
+		if change.Type == "revert" {
+			entry.Text = "Revert " + change.Description
+		}
+		if section == SectionSecurity && strings.EqualFold(entry.Scope, "security") {
+			entry.Scope = ""
+		}
+		entries[section] = append(entries[section], entry)
+	}
+
+	var sections []Section
+	for _, title := range sectionOrder {
+		if len(entries[title]) > 0 {
+			sections = append(sections, Section{Title: title, Entries: entries[title]})
+		}
+	}
+	return sections
+}
+
+// History reads the commits and tags of a repository
+type History interface {
+	// LastTag returns the newest tag reachable from rev matching the glob,
+	// empty when there is none
+	LastTag(ctx context.Context, rev, match string) (string, error)
+	// TagDate returns the date of the commit tagged name as YYYY-MM-DD
+	TagDate(ctx context.Context, name string) (string, error)
+	// CommitLog returns the non-merge commits in from..to, newest first
+	CommitLog(ctx context.Context, from, to string) ([]common.Commit, error)
+}
+
+// Release is a changelog section before it is rendered
+type Release struct {
+	// From and To are the resolved range; an empty From is the whole history
+	From, To string
+	// Version is empty for Unreleased
+	Version  string
+	Date     string
+	Sections []Section
+}
+
+// Collect reads the commits of spec and groups them. spec is FROM..TO: TO
+// defaults to HEAD and FROM to the last tag before TO, and a single revision
+// is FROM. A range that ends at a tag is named after it.
+func Collect(ctx context.Context, h History, spec string) (*Release, error) {
+	from, to, err := resolveRange(ctx, h, spec)
+	if err != nil {
+		return nil, err
+	}
+	commits, err := h.CommitLog(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	release := &Release{From: from, To: to, Sections: Build(commits)}
+	if date, err := h.TagDate(ctx, to); err == nil {
+		release.Version = strings.TrimPrefix(to, "v")
+		release.Date = date
+	}
+	return release, nil
+}
+
+func resolveRange(ctx context.Context, h History, spec string) (string, string, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.Contains(spec, "...") {
+		return "", "", fmt.Errorf("symmetric ranges are not supported, use FROM..TO")
+	}
+	from, to, found := strings.Cut(spec, "..")
+	if !found {
+		from, to = spec, ""
+	}
+	if to == "" {
+		to = "HEAD"
+	}
+	if from != "" {
+		return from, to, nil
+	}
+
+	tag, err := h.LastTag(ctx, to, "")
+	if err != nil {
+		return "", "", err
+	}
+	if tag == to {
+		// TO is a release; its changes start at the tag before it. A tagged
+		// root commit has no parent and so no earlier tag.
+		if tag, err = h.LastTag(ctx, to+"^", ""); err != nil {
+			tag = ""
+		}
+	}
+	return tag, to, nil
+}
+
+// Render writes the release as a Keep a Changelog section. An empty version
+// is rendered as Unreleased; the date is left out when empty.
+func (r *Release) Render() string {
+	var b strings.Builder
+	heading := "Unreleased"
+	if r.Version != "" {
+		heading = r.Version
+	}
+	fmt.Fprintf(&b, "## [%s]", heading)
+	if r.Date != "" {
+		fmt.Fprintf(&b, " - %s", r.Date)
+	}
+	b.WriteString("\n")
+
+	if len(r.Sections) == 0 {
+		b.WriteString("\nNo user-facing changes.\n")
+	}
+	for _, section := range r.Sections {
+		fmt.Fprintf(&b, "\n### %s\n\n", section.Title)
+		for _, entry := range section.Entries {
+			b.WriteString("- ")
+			if entry.Scope != "" {
+				fmt.Fprintf(&b, "**%s:** ", entry.Scope)
+			}
+			b.WriteString(oneLine(entry.Text))
+			b.WriteString("\n")
+			if entry.Note != "" {
+				fmt.Fprintf(&b, "  %s\n", oneLine(entry.Note))
+			}
+		}
+	}
+	return b.String()
+}
+
+// fileHeader starts a new CHANGELOG.md
+const fileHeader = `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.1.0/).
+`
+
+// Prepend adds release above the newest release in a changelog file, keeping
+// the title and introduction on top. An empty file gets the standard header.
+func Prepend(existing, release string) string {
+	release = strings.TrimRight(release, "\n") + "\n"
+	if strings.TrimSpace(existing) == "" {
+		return fileHeader + "\n" + release
+	}
+
+	lines := strings.SplitAfter(existing, "\n")
+	offset := 0
+	for _, line := range lines {
+		if strings.HasPrefix(line, "## ") {
+			return existing[:offset] + release + "\n" + existing[offset:]
+		}
+		offset += len(line)
+	}
+	return strings.TrimRight(existing, "\n") + "\n\n" + release
+}
+
+func capitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
+	}
+	return string(unicode.ToUpper(r)) + s[size:]
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,245 @@
+package changelog
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-coders/git_gpt/internal/common"
+	"github.com/go-coders/git_gpt/internal/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		commit  common.Commit
+		want    Change
+		section string
+	}{
+		{
+			name:    "feature with scope",
+			commit:  common.Commit{Subject: "feat(auth): retry failed logins"},
+			want:    Change{Type: "feat", Scope: "auth", Description: "retry failed logins"},
+			section: SectionAdded,
+		},
+		{
+			name:    "breaking marker",
+			commit:  common.Commit{Subject: "refactor!: drop the v1 API"},
+			want:    Change{Type: "refactor", Description: "drop the v1 API", Breaking: true},
+			section: SectionBreaking,
+		},
+		{
+			name: "breaking footer",
+			commit: common.Commit{
+				Subject: "Fix: rename the config file",
+				Body:    "Config moves to one place.\n\nBREAKING CHANGE: the config file is now\nconfig.json.\nRefs: #12",
+			},
+			want: Change{
+				Type:         "fix",
+				Description:  "rename the config file",
+				Breaking:     true,
+				BreakingNote: "the config file is now config.json. Refs: #12",
+			},
+			section: SectionBreaking,
+		},
+		{
+			name:    "security fix",
+			commit:  common.Commit{Subject: "fix(security): escape user names"},
+			want:    Change{Type: "fix", Scope: "security", Description: "escape user names"},
+			section: SectionSecurity,
+		},
+		{
+			name:   "chore is left out",
+			commit: common.Commit{Subject: "chore: bump deps"},
+			want:   Change{Type: "chore", Description: "bump deps"},
+		},
+		{
+			name:    "not conventional",
+			commit:  common.Commit{Subject: "Update README: add badges"},
+			want:    Change{Description: "Update README: add badges"},
+			section: SectionChanged,
+		},
+		{
+			name:    "component prefix",
+			commit:  common.Commit{Subject: "parser: handle EOF"},
+			want:    Change{Description: "parser: handle EOF"},
+			section: SectionChanged,
+		},
+		{
+			name:    "component prefix with breaking marker",
+			commit:  common.Commit{Subject: "api(v2)!: drop the v1 endpoints"},
+			want:    Change{Description: "api(v2)!: drop the v1 endpoints"},
+			section: SectionChanged,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			change := Parse(tt.commit)
+			change.Subject, change.Body = "", ""
+			assert.Equal(t, tt.want, change)
+			assert.Equal(t, tt.section, change.Section())
+		})
+	}
+}
+
+func TestPrepend(t *testing.T) {
+	release := "## [1.1.0] - 2024-05-01\n\n### Added\n\n- Retry\n"
+
+	t.Run("new file", func(t *testing.T) {
+		got := Prepend("", release)
+		assert.Equal(t, fileHeader+"\n"+release, got)
+	})
+
+	t.Run("above the newest release", func(t *testing.T) {
+		existing := "# Changelog\n\nIntro.\n\n## [1.0.0] - 2024-01-01\n\n### Added\n\n- First\n"
+		got := Prepend(existing, release)
+		assert.Equal(t, "# Changelog\n\nIntro.\n\n"+release+"\n## [1.0.0] - 2024-01-01\n\n### Added\n\n- First\n", got)
+	})
+
+	t.Run("no releases yet", func(t *testing.T) {
+		got := Prepend("# Changelog\n", release)
+		assert.Equal(t, "# Changelog\n\n"+release, got)
+	})
+}
+
+// fixture runs git commands in a test repository
+type fixture struct {
+	t *testing.T
+	e *git.GitExecutor
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	// Fixed dates keep tag dates and hashes stable
+	t.Setenv("GIT_AUTHOR_DATE", "2024-05-01T12:00:00Z")
+	t.Setenv("GIT_COMMITTER_DATE", "2024-05-01T12:00:00Z")
+
+	f := &fixture{t: t, e: git.NewExecutorInDir(t.TempDir())}
+	f.git("init", "-q", "-b", "main")
+	f.git("config", "user.name", "Test")
+	f.git("config", "user.email", "test@example.com")
+	return f
+}
+
+func (f *fixture) git(args ...string) {
+	f.t.Helper()
+	_, err := f.e.Execute(context.Background(), args...)
+	require.NoError(f.t, err, "git %v", args)
+}
+
+// commit records empty commits with the given messages, oldest first
+func (f *fixture) commit(messages ...string) {
+	f.t.Helper()
+	for _, message := range messages {
+		f.git("commit", "-q", "--allow-empty", "-m", message)
+	}
+}
+
+func TestCollect_Golden(t *testing.T) {
+	testCases := []struct {
+		name  string
+		spec  string
+		setup func(f *fixture)
+	}{
+		{
+			name: "unreleased",
+			setup: func(f *fixture) {
+				f.commit("feat: first release")
+				f.git("tag", "v1.0.0")
+				f.commit(
+					"feat(auth): retry failed logins",
+					"fix: keep the session after a refresh",
+					"docs: explain retries",
+					"feat(api)!: drop the v1 endpoints\n\nBREAKING CHANGE: clients must call /v2 instead.",
+					"perf: cache the user lookup",
+					"chore: bump dependencies",
+					"fix(security): escape user names in the audit log",
+				)
+			},
+		},
+		{
+			name: "between-tags",
+			spec: "..v1.1.0",
+			setup: func(f *fixture) {
+				f.commit("feat: first release")
+				f.git("tag", "-a", "-m", "Release 1.0.0", "v1.0.0")
+				f.commit("feat: export to CSV", "fix(export): quote commas")
+				f.git("tag", "-a", "-m", "Release 1.1.0", "v1.1.0")
+				f.commit("feat: not released yet")
+			},
+		},
+		{
+			name: "explicit-range",
+			spec: "v1.0.0..main",
+			setup: func(f *fixture) {
+				f.commit("feat: first release")
+				f.git("tag", "v1.0.0")
+				f.commit("deprecate: the --legacy flag", "remove: the old importer", "revert: \"feat: beta search\"")
+			},
+		},
+		{
+			name: "no-tags",
+			setup: func(f *fixture) {
+				f.commit("Initial import", "Add a README", "test: cover the parser")
+			},
+		},
+		{
+			name: "component-subjects",
+			setup: func(f *fixture) {
+				f.commit("feat: first release")
+				f.git("tag", "v1.0.0")
+				f.commit(
+					"parser: handle EOF",
+					"auth: fix token refresh",
+					"docs: explain the parser",
+					"fix(parser): keep trailing comments",
+				)
+			},
+		},
+		{
+			name: "nothing-new",
+			setup: func(f *fixture) {
+				f.commit("feat: first release", "docs: usage")
+				f.git("tag", "v2.0.0")
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFixture(t)
+			tc.setup(f)
+
+			release, err := Collect(context.Background(), f.e, tc.spec)
+			require.NoError(t, err)
+			got := release.Render()
+
+			golden := filepath.Join("testdata", tc.name+".golden.md")
+			if *updateGolden {
+				require.NoError(t, os.MkdirAll(filepath.Dir(golden), 0755))
+				require.NoError(t, os.WriteFile(golden, []byte(got), 0644))
+			}
+			want, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			assert.Equal(t, string(want), got)
+		})
+	}
+}
+
+func TestCollect_Errors(t *testing.T) {
+	f := newFixture(t)
+	f.commit("feat: first")
+
+	_, err := Collect(context.Background(), f.e, "main...HEAD")
+	assert.EqualError(t, err, "symmetric ranges are not supported, use FROM..TO")
+
+	_, err = Collect(context.Background(), f.e, "..no-such-tag")
+	assert.EqualError(t, err, "unknown revision no-such-tag")
+}
@@ -0,0 +1,531 @@
+// Package commitlint checks commit messages against commitlint style rules,
+// read from a repository's .commitlintrc or from the ggpt config
+package commitlint
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Rule levels, as in commitlint
+const (
+	LevelDisabled = 0
+	LevelWarning  = 1
+	LevelError    = 2
+)
+
+// ConventionalPreset is the shared config whose rules are used when a
+// .commitlintrc extends it, or when no rules are configured at all
+const ConventionalPreset = "@commitlint/config-conventional"
+
+// ConfigFiles are the file names searched for at the top of a repository
+var ConfigFiles = []string{".commitlintrc", ".commitlintrc.json", ".commitlintrc.yaml", ".commitlintrc.yml"}
+
+// Rule is a commitlint rule, written as [level, "always" | "never", value]
+type Rule struct {
+	Level int
+	When  string
+	Value interface{}
+}
+
+// UnmarshalJSON reads the array form used by commitlint
+func (r *Rule) UnmarshalJSON(data []byte) error {
+	var parts []interface{}
+	if err := json.Unmarshal(data, &parts); err != nil {
+		return fmt.Errorf("rule must be an array: %w", err)
+	}
+	if len(parts) == 0 {
+		return fmt.Errorf("rule must have a level")
+	}
+
+	level, ok := parts[0].(float64)
+	if !ok {
+		return fmt.Errorf("rule level must be a number")
+	}
+	r.Level = int(level)
+	r.When = "always"
+	if len(parts) > 1 {
+		if r.When, ok = parts[1].(string); !ok {
+			return fmt.Errorf("rule applicability must be \"always\" or \"never\"")
+		}
+	}
+	if len(parts) > 2 {
+		r.Value = parts[2]
+	}
+	return nil
+}
+
+// MarshalJSON writes the rule back in the array form
+func (r Rule) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{r.Level, r.When, r.Value})
+}
+
+// Config is the part of a .commitlintrc that ggpt understands
+type Config struct {
+	Extends []string        `json:"extends"`
+	Rules   map[string]Rule `json:"rules"`
+}
+
+// Violation is a rule a message breaks
+type Violation struct {
+	Rule    string
+	Level   int
+	Message string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s", v.Rule, v.Message)
+}
+
+// Linter checks messages against a set of rules
+type Linter struct {
+	rules map[string]Rule
+}
+
+// New returns a linter for cfg, with the conventional rules underneath when
+// cfg extends them
+func New(cfg Config) *Linter {
+	rules := make(map[string]Rule)
+	for _, preset := range cfg.Extends {
+		if preset == ConventionalPreset || preset == "conventional" {
+			for name, rule := range conventionalRules() {
+				rules[name] = rule
+			}
+		}
+	}
+	for name, rule := range cfg.Rules {
+		rules[name] = rule
+	}
+	return &Linter{rules: rules}
+}
+
+// Conventional returns a linter with the rules of the conventional preset
+func Conventional() *Linter {
+	return New(Config{Extends: []string{ConventionalPreset}})
+}
+
+// Load reads the first of ConfigFiles found in dir. It returns nil and no
+// error when the repository has none.
+func Load(dir string) (*Linter, string, error) {
+	for _, name := range ConfigFiles {
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, "", fmt.Errorf("failed to read %s: %w", name, err)
+		}
+
+		cfg, err := Parse(data)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid %s: %w", name, err)
+		}
+		return New(cfg), path, nil
+	}
+	return nil, "", nil
+}
+
+// Parse reads a .commitlintrc in JSON or YAML
+func Parse(data []byte) (Config, error) {
+	// YAML is a superset of JSON, so one parser reads both. The result is
+	// passed through JSON to reuse the rule decoding.
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return Config{}, err
+	}
+	encoded, err := json.Marshal(raw)
+	if err != nil {
+		return Config{}, err
+	}
+
+	var cfg Config
+	if raw == nil {
+		return cfg, nil
+	}
+	if err := json.Unmarshal(encoded, &cfg); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+func conventionalRules() map[string]Rule {
+	return map[string]Rule{
+		"type-enum": {LevelError, "always", []interface{}{
+			"build", "chore", "ci", "docs", "feat", "fix", "perf", "refactor", "revert", "style", "test",
+		}},
+		"type-case":              {LevelError, "always", "lower-case"},
+		"type-empty":             {LevelError, "never", nil},
+		"scope-case":             {LevelError, "always", "lower-case"},
+		"subject-case":           {LevelError, "never", []interface{}{"sentence-case", "start-case", "pascal-case", "upper-case"}},
+		"subject-empty":          {LevelError, "never", nil},
+		"subject-full-stop":      {LevelError, "never", "."},
+		"header-max-length":      {LevelError, "always", float64(100)},
+		"body-leading-blank":     {LevelWarning, "always", nil},
+		"body-max-line-length":   {LevelError, "always", float64(100)},
+		"footer-leading-blank":   {LevelWarning, "always", nil},
+		"footer-max-line-length": {LevelError, "always", float64(100)},
+	}
+}
+
+// message is a commit message split into the parts the rules look at
+type message struct {
+	header  string
+	typ     string
+	scopes  []string
+	subject string
+	body    []string // lines after the header, starting with the separator
+	footer  []string
+}
+
+var headerPattern = regexp.MustCompile(`^(\w+)(?:\(([^()]*)\))?!?: (.*)$`)
+
+// footerPattern matches trailers such as "Refs: #12" and "BREAKING CHANGE: x"
+var footerPattern = regexp.MustCompile(`^(?:BREAKING CHANGE|[\w-]+)(?:: | #)`)
+
+func parse(text string) message {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	m := message{header: lines[0], body: lines[1:]}
+
+	if parts := headerPattern.FindStringSubmatch(m.header); parts != nil {
+		m.typ = parts[1]
+		if parts[2] != "" {
+			for _, scope := range strings.Split(parts[2], ",") {
+				m.scopes = append(m.scopes, strings.TrimSpace(scope))
+			}
+		}
+		m.subject = parts[3]
+	}
+
+	// The footer is the last paragraph when it is made of trailers
+	start := len(m.body)
+	for i := len(m.body) - 1; i >= 0 && m.body[i] != ""; i-- {
+		start = i
+	}
+	if start < len(m.body) && start > 0 && footerPattern.MatchString(m.body[start]) {
+		m.footer = m.body[start:]
+		m.body = m.body[:start]
+	}
+	return m
+}
+
+// Lint returns the rules text breaks, errors first
+func (l *Linter) Lint(text string) []Violation {
+	m := parse(text)
+
+	var violations []Violation
+	names := make([]string, 0, len(l.rules))
+	for name := range l.rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		rule := l.rules[name]
+		if rule.Level == LevelDisabled {
+			continue
+		}
+		check, ok := checks[name]
+		if !ok {
+			continue
+		}
+		if problem := check(m, rule); problem != "" {
+			violations = append(violations, Violation{Rule: name, Level: rule.Level, Message: problem})
+		}
+	}
+
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].Level > violations[j].Level
+	})
+	return violations
+}
+
+// Errors keeps the violations that fail a message
+func Errors(violations []Violation) []Violation {
+	var errs []Violation
+	for _, v := range violations {
+		if v.Level >= LevelError {
+			errs = append(errs, v)
+		}
+	}
+	return errs
+}
+
+// Describe lists the enabled rules as short sentences for a prompt
+func (l *Linter) Describe() []string {
+	var lines []string
+	add := func(name, format string, args ...interface{}) {
+		if rule, ok := l.rules[name]; ok && rule.Level == LevelError {
+			lines = append(lines, fmt.Sprintf(format, args...))
+		}
+	}
+
+	if rule, ok := l.rules["type-enum"]; ok && rule.Level == LevelError {
+		verb := "must be one of"
+		if rule.When == "never" {
+			verb = "must not be"
+		}
+		add("type-enum", "The type %s: %s", verb, strings.Join(stringList(rule.Value), ", "))
+	}
+	if rule, ok := l.rules["scope-enum"]; ok && len(stringList(rule.Value)) > 0 {
+		verb := "must be one of"
+		if rule.When == "never" {
+			verb = "must not be"
+		}
+		add("scope-enum", "The scope %s: %s", verb, strings.Join(stringList(rule.Value), ", "))
+	}
+	if rule, ok := l.rules["scope-empty"]; ok {
+		if rule.When == "never" {
+			add("scope-empty", "A scope is required")
+		} else {
+			add("scope-empty", "Do not use a scope")
+		}
+	}
+	if rule, ok := l.rules["subject-case"]; ok {
+		if rule.When == "never" {
+			add("subject-case", "The subject must not be in %s", strings.Join(stringList(rule.Value), ", "))
+		} else {
+			add("subject-case", "The subject must be in %s", strings.Join(stringList(rule.Value), " or "))
+		}
+	}
+	if rule, ok := l.rules["header-max-length"]; ok && rule.When == "always" {
+		add("header-max-length", "The first line must be at most %d characters", number(rule.Value))
+	}
+	if rule, ok := l.rules["subject-max-length"]; ok && rule.When == "always" {
+		add("subject-max-length", "The subject must be at most %d characters", number(rule.Value))
+	}
+	if rule, ok := l.rules["subject-full-stop"]; ok && rule.When == "never" {
+		add("subject-full-stop", "The subject must not end with %q", rule.Value)
+	}
+	if rule, ok := l.rules["body-max-line-length"]; ok && rule.When == "always" {
+		add("body-max-line-length", "Wrap body lines at %d characters", number(rule.Value))
+	}
+	return lines
+}
+
+type checkFunc func(m message, rule Rule) string
+
+var checks = map[string]checkFunc{
+	"type-enum": func(m message, rule Rule) string {
+		return checkEnum("type", []string{m.typ}, rule)
+	},
+	"type-case": func(m message, rule Rule) string {
+		return checkCaseRule("type", m.typ, rule)
+	},
+	"type-empty": func(m message, rule Rule) string {
+		return checkEmpty("type", m.typ, rule)
+	},
+	"scope-enum": func(m message, rule Rule) string {
+		return checkEnum("scope", m.scopes, rule)
+	},
+	"scope-case": func(m message, rule Rule) string {
+		return checkCaseRule("scope", strings.Join(m.scopes, ","), rule)
+	},
+	"scope-empty": func(m message, rule Rule) string {
+		return checkEmpty("scope", strings.Join(m.scopes, ","), rule)
+	},
+	"subject-case": func(m message, rule Rule) string {
+		return checkCaseRule("subject", m.subject, rule)
+	},
+	"subject-empty": func(m message, rule Rule) string {
+		return checkEmpty("subject", m.subject, rule)
+	},
+	"subject-full-stop": func(m message, rule Rule) string {
+		stop, _ := rule.Value.(string)
+		if stop == "" {
+			stop = "."
+		}
+		ends := m.subject != "" && strings.HasSuffix(m.subject, stop)
+		if ends && rule.When == "never" {
+			return fmt.Sprintf("subject may not end with %q", stop)
+		}
+		if !ends && rule.When == "always" {
+			return fmt.Sprintf("subject must end with %q", stop)
+		}
+		return ""
+	},
+	"subject-max-length": func(m message, rule Rule) string {
+		return checkMaxLength("subject", []string{m.subject}, rule)
+	},
+	"header-max-length": func(m message, rule Rule) string {
+		return checkMaxLength("header", []string{m.header}, rule)
+	},
+	"body-max-line-length": func(m message, rule Rule) string {
+		return checkMaxLength("body line", m.body, rule)
+	},
+	"footer-max-line-length": func(m message, rule Rule) string {
+		return checkMaxLength("footer line", m.footer, rule)
+	},
+	"body-leading-blank": func(m message, rule Rule) string {
+		return checkLeadingBlank("body", m.body, rule)
+	},
+	"footer-leading-blank": func(m message, rule Rule) string {
+		if len(m.footer) == 0 {
+			return ""
+		}
+		if len(m.body) == 0 || m.body[len(m.body)-1] != "" {
+			if rule.When == "always" {
+				return "footer must have a leading blank line"
+			}
+		}
+		return ""
+	},
+}
+
+func checkEnum(field string, values []string, rule Rule) string {
+	allowed := stringList(rule.Value)
+	if len(allowed) == 0 {
+		return ""
+	}
+	for _, v := range values {
+		if v == "" {
+			continue
+		}
+		in := contains(allowed, v)
+		if rule.When == "never" && in {
+			return fmt.Sprintf("%s may not be %s", field, v)
+		}
+		if rule.When != "never" && !in {
+			return fmt.Sprintf("%s must be one of [%s]", field, strings.Join(allowed, ", "))
+		}
+	}
+	return ""
+}
+
+func checkEmpty(field, value string, rule Rule) string {
+	if rule.When == "never" && value == "" {
+		return fmt.Sprintf("%s may not be empty", field)
+	}
+	if rule.When == "always" && value != "" {
+		return fmt.Sprintf("%s must be empty", field)
+	}
+	return ""
+}
+
+func checkMaxLength(field string, values []string, rule Rule) string {
+	limit := number(rule.Value)
+	if limit <= 0 {
+		return ""
+	}
+	for _, v := range values {
+		if n := len([]rune(v)); n > limit {
+			return fmt.Sprintf("%s must not be longer than %d characters, current length is %d", field, limit, n)
+		}
+	}
+	return ""
+}
+
+func checkLeadingBlank(field string, lines []string, rule Rule) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	blank := lines[0] == ""
+	if rule.When == "always" && !blank {
+		return fmt.Sprintf("%s must have a leading blank line", field)
+	}
+	if rule.When == "never" && blank {
+		return fmt.Sprintf("%s may not have a leading blank line", field)
+	}
+	return ""
+}
+
+func checkCaseRule(field, value string, rule Rule) string {
+	cases := stringList(rule.Value)
+	if value == "" || len(cases) == 0 {
+		return ""
+	}
+
+	matched := false
+	for _, c := range cases {
+		if matchesCase(value, c) {
+			matched = true
+			break
+		}
+	}
+	if rule.When == "never" && matched {
+		return fmt.Sprintf("%s must not be %s", field, strings.Join(cases, ", "))
+	}
+	if rule.When != "never" && !matched {
+		return fmt.Sprintf("%s must be %s", field, strings.Join(cases, " or "))
+	}
+	return ""
+}
+
+// matchesCase reports whether s is written in the named commitlint case
+func matchesCase(s, name string) bool {
+	words := strings.Fields(s)
+	switch name {
+	case "lower-case", "lowercase":
+		return s == strings.ToLower(s)
+	case "upper-case", "uppercase":
+		return s == strings.ToUpper(s)
+	case "sentence-case", "sentencecase":
+		return startsUpper(s) && s[1:] == strings.ToLower(s[1:])
+	case "start-case", "startcase":
+		for _, w := range words {
+			if !startsUpper(w) {
+				return false
+			}
+		}
+		return len(words) > 0
+	case "pascal-case", "pascalcase":
+		return startsUpper(s) && !strings.ContainsAny(s, " -_")
+	case "camel-case", "camelcase":
+		return !startsUpper(s) && !strings.ContainsAny(s, " -_")
+	case "kebab-case", "kebabcase":
+		return s == strings.ToLower(s) && !strings.ContainsAny(s, " _")
+	case "snake-case", "snakecase":
+		return s == strings.ToLower(s) && !strings.ContainsAny(s, " -")
+	}
+	return false
+}
+
+func startsUpper(s string) bool {
+	return s != "" && strings.ToUpper(s[:1]) == s[:1] && strings.ToLower(s[:1]) != s[:1]
+}
+
+// stringList reads a rule value that is a string or a list of strings
+func stringList(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []interface{}:
+		var list []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+// number reads a numeric rule value
+func number(value interface{}) int {
+	switch v := value.(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	}
+	return 0
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
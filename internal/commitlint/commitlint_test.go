@@ -0,0 +1,124 @@
+package commitlint
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rulesOf(violations []Violation) []string {
+	var names []string
+	for _, v := range violations {
+		names = append(names, v.Rule)
+	}
+	return names
+}
+
+func TestLint_Conventional(t *testing.T) {
+	lint := Conventional()
+
+	testCases := []struct {
+		name     string
+		message  string
+		expected []string
+	}{
+		{name: "valid", message: "feat(agent): add commit rules"},
+		{name: "valid with breaking marker", message: "fix!: drop the old flag"},
+		{name: "no type", message: "add commit rules", expected: []string{"subject-empty", "type-empty"}},
+		{name: "unknown type", message: "feature: add commit rules", expected: []string{"type-enum"}},
+		{name: "upper case type", message: "Feat: add commit rules", expected: []string{"type-case", "type-enum"}},
+		{name: "sentence case subject", message: "feat: Add commit rules", expected: []string{"subject-case"}},
+		{name: "full stop", message: "docs: explain rules.", expected: []string{"subject-full-stop"}},
+		{name: "upper case scope", message: "fix(API): handle errors", expected: []string{"scope-case"}},
+		{
+			name:     "long header",
+			message:  "feat: " + strings.Repeat("x", 101),
+			expected: []string{"header-max-length"},
+		},
+		{
+			name:     "body without blank line is a warning",
+			message:  "fix: handle errors\nmore detail",
+			expected: []string{"body-leading-blank"},
+		},
+		{
+			name:     "long body line",
+			message:  "fix: handle errors\n\n" + strings.Repeat("word ", 21),
+			expected: []string{"body-max-line-length"},
+		},
+		{
+			name:    "footer",
+			message: "fix: handle errors\n\nExplain the fix.\n\nRefs: #12\nBREAKING CHANGE: the flag is gone",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			violations := lint.Lint(tc.message)
+			assert.ElementsMatch(t, tc.expected, rulesOf(violations))
+		})
+	}
+}
+
+func TestLint_CustomRules(t *testing.T) {
+	cfg, err := Parse([]byte(`
+extends:
+  - "@commitlint/config-conventional"
+rules:
+  type-enum: [2, always, [feat, fix]]
+  scope-enum: [2, always, [api, cli]]
+  scope-empty: [2, never]
+  subject-case: [0]
+  subject-max-length: [1, always, 20]
+`))
+	require.NoError(t, err)
+	lint := New(cfg)
+
+	assert.Empty(t, lint.Lint("feat(api): Add Things"))
+	assert.Equal(t, []string{"scope-enum"}, rulesOf(lint.Lint("fix(db): repair index")))
+	assert.Equal(t, []string{"scope-empty"}, rulesOf(lint.Lint("fix: repair index")))
+	assert.Equal(t, []string{"type-enum"}, rulesOf(lint.Lint("docs(cli): usage")))
+
+	violations := lint.Lint("fix(cli,api): a subject that is too long")
+	require.Len(t, violations, 1)
+	assert.Equal(t, LevelWarning, violations[0].Level)
+	assert.Empty(t, Errors(violations))
+
+	assert.Contains(t, lint.Describe(), "The type must be one of: feat, fix")
+	assert.Contains(t, lint.Describe(), "The scope must be one of: api, cli")
+	assert.Contains(t, lint.Describe(), "A scope is required")
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	lint, _, err := Load(dir)
+	require.NoError(t, err)
+	assert.Nil(t, lint)
+
+	path := filepath.Join(dir, ".commitlintrc.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"rules": {"header-max-length": [2, "always", 10]}}`), 0644))
+	lint, found, err := Load(dir)
+	require.NoError(t, err)
+	assert.Equal(t, path, found)
+	assert.Equal(t, []string{"header-max-length"}, rulesOf(lint.Lint("fix: a long header")))
+	// Without extends only the listed rules apply
+	assert.Empty(t, lint.Lint("whatever"))
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"rules": {"type-enum": "feat"}}`), 0644))
+	_, _, err = Load(dir)
+	assert.ErrorContains(t, err, ".commitlintrc.json")
+}
+
+func TestRule_JSONRoundTrip(t *testing.T) {
+	cfg, err := Parse([]byte(`{"rules": {"type-enum": [2, "always", ["feat"]], "body-leading-blank": [1]}}`))
+	require.NoError(t, err)
+	assert.Equal(t, Rule{Level: LevelWarning, When: "always"}, cfg.Rules["body-leading-blank"])
+
+	data, err := cfg.Rules["type-enum"].MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `[2, "always", ["feat"]]`, string(data))
+}
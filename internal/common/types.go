@@ -1,9 +1,123 @@
 package common
 
+import (
+	"fmt"
+	"time"
+)
+
 // FileChange represents a change in git repository
 type FileChange struct {
-	Path      string `json:"path"`
-	Status    string `json:"status"`
-	Additions int    `json:"additions"`
-	Deletions int    `json:"deletions"`
+	Path      string          `json:"path"`
+	OrigPath  string          `json:"orig_path,omitempty"` // source of a rename or copy
+	Status    string          `json:"status"`
+	Additions int             `json:"additions"`
+	Deletions int             `json:"deletions"`
+	Binary    bool            `json:"binary,omitempty"` // no line counts for binary files
+	Submodule *SubmoduleState `json:"submodule,omitempty"`
+	Conflict  *ConflictStages `json:"conflict,omitempty"`
+	// DiffOmitted says why the diff is left out of the commit prompt, such as
+	// "generated" or "binary"; only the line counts are sent then
+	DiffOmitted string `json:"diff_omitted,omitempty"`
+}
+
+// SubmoduleState describes how a changed submodule differs
+type SubmoduleState struct {
+	CommitChanged bool `json:"commit_changed"`
+	Modified      bool `json:"modified"`  // tracked changes inside the submodule
+	Untracked     bool `json:"untracked"` // untracked files inside the submodule
+}
+
+// ConflictStages holds the index stages of an unmerged path. A stage that
+// does not exist, such as the base of a path added on both sides, is empty.
+type ConflictStages struct {
+	Code   string `json:"code"` // two letter status such as UU, AA or DU
+	Base   string `json:"base,omitempty"`
+	Ours   string `json:"ours,omitempty"`
+	Theirs string `json:"theirs,omitempty"`
+}
+
+// DisplayPath returns the path, prefixed with its origin for renames and copies
+func (c FileChange) DisplayPath() string {
+	if c.OrigPath == "" {
+		return c.Path
+	}
+	return fmt.Sprintf("%s -> %s", c.OrigPath, c.Path)
+}
+
+// Tool describes a function the model can call. Parameters is a JSON schema
+// for the arguments.
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  map[string]interface{}
+}
+
+// ToolCall is the tool the model chose and its JSON encoded arguments
+type ToolCall struct {
+	Name      string
+	Arguments string
+}
+
+// Snapshot records the repository state before a modification so that it can
+// be restored later
+type Snapshot struct {
+	Ref       string            `json:"-"`
+	Query     string            `json:"query"`
+	Time      time.Time         `json:"time"`
+	Head      string            `json:"head,omitempty"`
+	HeadRef   string            `json:"head_ref,omitempty"` // empty when HEAD is detached
+	Branches  map[string]string `json:"branches"`
+	IndexTree string            `json:"index_tree"`
+	Worktree  string            `json:"worktree,omitempty"` // stash-like commit of local changes
+}
+
+// Preview is the outcome of running modification commands in a throwaway copy
+// of the repository
+type Preview struct {
+	Steps    []PreviewStep
+	Log      string // git log --oneline of the copy
+	Status   string // git status --short of the copy
+	DiffStat string // diffstat of the copy against the real working tree
+}
+
+// PreviewStep is one command run during a preview. Steps after a failing
+// command are not run.
+type PreviewStep struct {
+	Args   []string
+	Output string
+	Error  string
+}
+
+// FileDiff is the diff of one file split into hunks
+type FileDiff struct {
+	Path   string
+	Header string // everything before the first hunk, starting with "diff --git"
+	Hunks  []Hunk
+	Binary bool
+}
+
+// Hunk is one @@ section of a diff
+type Hunk struct {
+	Header string // the @@ line
+	Text   string // the raw hunk including the @@ line, ready to be applied
+}
+
+// CommitStep is one commit of a staged change split into several commits
+type CommitStep struct {
+	Message string
+	Parts   []CommitPart
+}
+
+// CommitPart selects the staged change of one file for a CommitStep
+type CommitPart struct {
+	Diff     *FileDiff // staged diff of the file
+	OrigPath string    // source of a staged rename, removed with the file
+	Hunks    []int     // 0-based indexes into Diff.Hunks, nil for the whole file
+}
+
+// Commit is a commit read from the history
+type Commit struct {
+	Hash    string
+	Subject string
+	Body    string
 }
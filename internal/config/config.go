@@ -7,27 +7,109 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+
+	"github.com/go-coders/git_gpt/internal/commitlint"
 )
 
 const (
+	ProviderOpenAI    = "openai"
+	ProviderAzure     = "azure"
+	ProviderAnthropic = "anthropic"
+	ProviderOllama    = "ollama"
+)
+
+const (
+	DefaultProvider         = ProviderOpenAI
 	DefaultModel            = "gpt-4o"
+	DefaultAnthropicModel   = "claude-3-5-sonnet-latest"
+	DefaultOllamaModel      = "llama3.1"
 	DefaultMaxTokens        = 4000
 	DefaultBaseURL          = "https://api.openai.com/v1"
+	DefaultAnthropicBaseURL = "https://api.anthropic.com/v1"
+	DefaultOllamaBaseURL    = "http://localhost:11434"
 	ConfigFileName          = "config.json"
+	PolicyFileName          = "policy.json"
 	DefaultChatTemperture   = 0.2
 	DefaultCommitTemperture = 0.5
+	DefaultMaxSteps         = 5
 )
 
+// DefaultDiffIgnore lists lockfiles, minified assets and vendored code whose
+// diffs are left out of the commit prompt
+var DefaultDiffIgnore = []string{
+	"go.sum",
+	"package-lock.json",
+	"yarn.lock",
+	"pnpm-lock.yaml",
+	"Cargo.lock",
+	"Gemfile.lock",
+	"poetry.lock",
+	"composer.lock",
+	"*.min.js",
+	"*.min.css",
+	"*.map",
+	"vendor/**",
+	"node_modules/**",
+}
+
 type Config struct {
-	LLM        LLMConfig `json:"llm"`
-	ConfigPath string    `json:"config_path"`
+	LLM        LLMConfig     `json:"llm"`
+	Agent      AgentConfig   `json:"agent"`
+	Commit     CommitConfig  `json:"commit"`
+	Secrets    SecretsConfig `json:"secrets"`
+	ConfigPath string        `json:"config_path"`
+}
+
+type CommitConfig struct {
+	// DiffIgnore lists globs of files that are sent to the model as a line
+	// with their stats instead of a full diff. A glob without a slash
+	// matches the file name in any directory.
+	DiffIgnore []string `json:"diff_ignore"`
+	// Lint holds commitlint rules, in the format of a .commitlintrc, used
+	// when the repository has no .commitlintrc of its own
+	Lint *commitlint.Config `json:"lint,omitempty"`
+	// Ticket takes the ticket every commit must reference from the branch
+	// name
+	Ticket TicketConfig `json:"ticket"`
+}
+
+type TicketConfig struct {
+	// Patterns are regular expressions matched against the branch name in
+	// order. The first capture group, or the whole match, is the ticket key.
+	// Without patterns no reference is added.
+	Patterns []string `json:"patterns"`
+	// Position is where the reference goes: prefix, scope or trailer
+	// (default)
+	Position string `json:"position,omitempty"`
+	// Trailer is the trailer key for the trailer position, Refs by default
+	Trailer string `json:"trailer,omitempty"`
+}
+
+type SecretsConfig struct {
+	// Patterns are extra regular expressions for secrets, on top of the
+	// built-in rules. With a capture group only the group is redacted.
+	Patterns []string `json:"patterns"`
+	// AbortCommit stops the commit when the staged changes add a secret,
+	// instead of redacting it and warning
+	AbortCommit bool `json:"abort_commit"`
+}
+
+type AgentConfig struct {
+	// MaxSteps limits how many rounds of commands a chat query may run
+	MaxSteps int `json:"max_steps"`
 }
 
 type LLMConfig struct {
-	APIKey           string  `json:"api_key"`
-	Model            string  `json:"model"`
-	BaseURL          string  `json:"base_url"`
-	MaxTokens        int     `json:"max_tokens"`
+	Provider   string `json:"provider"`
+	APIKey     string `json:"api_key"`
+	Model      string `json:"model"`
+	BaseURL    string `json:"base_url"`
+	APIVersion string `json:"api_version,omitempty"`
+	Deployment string `json:"deployment,omitempty"`
+	MaxTokens  int    `json:"max_tokens"`
+	// MaxOutputTokens limits the length of responses where the API needs a
+	// limit (Anthropic); 0 uses the provider default
+	MaxOutputTokens  int     `json:"max_output_tokens,omitempty"`
 	ChatTemperture   float32 `json:"chat_temperture"`
 	CommitTemperture float32 `json:"commit_temperture"`
 }
@@ -38,26 +120,26 @@ func Load(path ...string) (*Config, error) {
 	configPath := getConfigPath(path...)
 	cfg := new(Config)
 	cfg.ConfigPath = configPath
-	cfg.SetDefaultValue()
 
 	if err := cfg.loadFromFile(configPath); err != nil {
 		if !os.IsNotExist(err) {
 			return nil, fmt.Errorf("failed to load config: %w", err)
 		}
-		cfg.SetDefaultValue()
-
-		return cfg, nil
 	}
 
+	cfg.SetDefaultValue()
 	return cfg, nil
 }
 
 func (c *Config) SetDefaultValue() {
+	if c.LLM.Provider == "" {
+		c.LLM.Provider = DefaultProvider
+	}
 	if c.LLM.Model == "" {
-		c.LLM.Model = DefaultModel
+		c.LLM.Model = DefaultModelFor(c.LLM.Provider)
 	}
 	if c.LLM.BaseURL == "" {
-		c.LLM.BaseURL = DefaultBaseURL
+		c.LLM.BaseURL = DefaultBaseURLFor(c.LLM.Provider)
 	}
 	if c.LLM.MaxTokens == 0 {
 		c.LLM.MaxTokens = DefaultMaxTokens
@@ -70,7 +152,49 @@ func (c *Config) SetDefaultValue() {
 	if c.LLM.CommitTemperture == 0 {
 		c.LLM.CommitTemperture = DefaultCommitTemperture
 	}
+
+	if c.Agent.MaxSteps <= 0 {
+		c.Agent.MaxSteps = DefaultMaxSteps
+	}
+
+	// An empty list in the file turns the filter off, so only nil gets defaults
+	if c.Commit.DiffIgnore == nil {
+		c.Commit.DiffIgnore = append([]string(nil), DefaultDiffIgnore...)
+	}
 }
+
+// DefaultModelFor returns the model used when none is configured
+func DefaultModelFor(provider string) string {
+	switch provider {
+	case ProviderAnthropic:
+		return DefaultAnthropicModel
+	case ProviderOllama:
+		return DefaultOllamaModel
+	default:
+		return DefaultModel
+	}
+}
+
+// DefaultBaseURLFor returns the API base URL used when none is configured.
+// Azure has no default because the URL names the user's resource.
+func DefaultBaseURLFor(provider string) string {
+	switch provider {
+	case ProviderAnthropic:
+		return DefaultAnthropicBaseURL
+	case ProviderOllama:
+		return DefaultOllamaBaseURL
+	case ProviderAzure:
+		return ""
+	default:
+		return DefaultBaseURL
+	}
+}
+
+// NeedsAPIKey reports whether the configured provider requires an API key
+func (c LLMConfig) NeedsAPIKey() bool {
+	return c.Provider != ProviderOllama
+}
+
 func (c *Config) loadFromFile(path string) error {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -102,6 +226,11 @@ func (c *Config) Save() error {
 	return nil
 }
 
+// PolicyPath returns the command policy file kept next to the config file
+func (c *Config) PolicyPath() string {
+	return filepath.Join(filepath.Dir(c.ConfigPath), PolicyFileName)
+}
+
 // getConfigPath determines the configuration file path
 func getConfigPath(customPath ...string) string {
 	if len(customPath) > 0 && customPath[0] != "" {
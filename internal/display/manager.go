@@ -3,6 +3,8 @@ package display
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"strings"
 	"sync"
 	"time"
@@ -20,15 +22,24 @@ type Section struct {
 }
 
 type DisplayImpl struct {
+	out       io.Writer
 	spinner   *spinner.Spinner
 	formatter *ColorFormatter
+	streaming bool       // A streamed response is being written
 	mu        sync.Mutex // Protect spinner operations
 }
 
 func NewManager(version string) *DisplayImpl {
-	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
+	return NewManagerTo(version, os.Stdout)
+}
+
+// NewManagerTo creates a display that writes to out. Commands whose stdout is
+// their result write the display to stderr instead.
+func NewManagerTo(version string, out *os.File) *DisplayImpl {
+	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond, spinner.WithWriterFile(out))
 	s.Color("cyan")
 	return &DisplayImpl{
+		out:       out,
 		spinner:   s,
 		formatter: NewColorFormatter(version),
 	}
@@ -45,55 +56,69 @@ func (m *DisplayImpl) ShowSection(title, content string, opts map[string]string)
 		displayTitle = fmt.Sprintf("%s %s", icon, title)
 	}
 
-	fmt.Printf("\n%s\n%s\n", m.formatter.FormatSectionTitle(displayTitle), divider)
+	fmt.Fprintf(m.out, "\n%s\n%s\n", m.formatter.FormatSectionTitle(displayTitle), divider)
 	if content != "" {
-		fmt.Println(m.formatter.FormatSectionContent(content))
+		fmt.Fprintln(m.out, m.formatter.FormatSectionContent(content))
 	}
 }
 
 func (m *DisplayImpl) ShowNumberedList(items [][2]string) {
 	for i, item := range items {
 		// Pass both index and content to FormatListItem
-		fmt.Printf("%s\n", m.formatter.FormatListItem(i+1, item[0]))
+		fmt.Fprintf(m.out, "%s\n", m.formatter.FormatListItem(i+1, item[0]))
 		if item[1] != "" {
-			fmt.Printf("   %s\n", m.formatter.FormatListDescription(item[1]))
+			// Keep every line of a multi-line description under the item
+			description := strings.ReplaceAll(item[1], "\n", "\n      ")
+			fmt.Fprintf(m.out, "   %s\n", m.formatter.FormatListDescription(description))
 		}
 	}
 }
 
 // ShowWelcome displays the welcome message
 func (m *DisplayImpl) ShowWelcome() {
-	fmt.Println(m.formatter.FormatWelcome())
+	fmt.Fprintln(m.out, m.formatter.FormatWelcome())
 }
 
 // Required methods for agent.DisplayManager interface
 func (m *DisplayImpl) ShowPrompt(pwd, branch string) {
-	fmt.Print(m.formatter.FormatPrompt(pwd, branch))
+	fmt.Fprint(m.out, m.formatter.FormatPrompt(pwd, branch))
 }
 
 func (m *DisplayImpl) ShowSuccess(message string) {
 	m.stopSpinnerIfActive()
-	fmt.Println(m.formatter.FormatSuccess(message))
+	fmt.Fprintln(m.out, m.formatter.FormatSuccess(message))
 }
 
 func (m *DisplayImpl) ShowError(message string) {
 	m.stopSpinnerIfActive()
-	fmt.Println(m.formatter.FormatError(message))
+	fmt.Fprintln(m.out, m.formatter.FormatError(message))
 }
 
 func (m *DisplayImpl) ShowInfo(message string) {
 	m.stopSpinnerIfActive()
-	fmt.Println(m.formatter.FormatInfo(message))
+	fmt.Fprintln(m.out, m.formatter.FormatInfo(message))
 }
 
 func (m *DisplayImpl) ShowWarning(message string) {
 	m.stopSpinnerIfActive()
-	fmt.Println(m.formatter.FormatWarning(message))
+	fmt.Fprintln(m.out, m.formatter.FormatWarning(message))
 }
 
 func (m *DisplayImpl) ShowCommand(command string) {
 	m.stopSpinnerIfActive()
-	fmt.Println(m.formatter.FormatCommand(command))
+	fmt.Fprintln(m.out, m.formatter.FormatCommand(command))
+}
+
+// ShowQuestion asks for input without ending the line
+func (m *DisplayImpl) ShowQuestion(question string) {
+	m.stopSpinnerIfActive()
+	fmt.Fprint(m.out, question)
+}
+
+// ShowOutput prints text as is, such as the output of a command
+func (m *DisplayImpl) ShowOutput(output string) {
+	m.stopSpinnerIfActive()
+	fmt.Fprintln(m.out, output)
 }
 
 func (m *DisplayImpl) StartSpinner(message string) {
@@ -115,6 +140,33 @@ func (m *DisplayImpl) StopSpinner() {
 	}
 }
 
+// ShowStreamChunk writes part of a streamed response. The spinner stops and
+// the success marker is printed when the first chunk arrives.
+func (m *DisplayImpl) ShowStreamChunk(chunk string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.streaming {
+		if m.spinner.Active() {
+			m.spinner.Stop()
+		}
+		m.streaming = true
+		fmt.Fprint(m.out, m.formatter.FormatSuccess(""))
+	}
+	fmt.Fprint(m.out, m.formatter.FormatStreamChunk(chunk))
+}
+
+// FinishStream ends the line of a streamed response
+func (m *DisplayImpl) FinishStream() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.streaming {
+		fmt.Fprintln(m.out)
+		m.streaming = false
+	}
+}
+
 func (m *DisplayImpl) stopSpinnerIfActive() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -160,6 +212,10 @@ func (f *ColorFormatter) FormatSuccess(message string) string {
 	return f.success.Sprintf("✅ %s", message)
 }
 
+func (f *ColorFormatter) FormatStreamChunk(chunk string) string {
+	return f.success.Sprint(chunk)
+}
+
 func (f *ColorFormatter) FormatError(message string) string {
 	return f.error.Sprintf("❌ %s", message)
 }
@@ -193,6 +249,41 @@ func (f *ColorFormatter) FormatWelcome() string {
 			descEn: "Generate commit message and commit changes",
 			descZh: "生成提交消息并提交更改",
 		},
+		{
+			cmd:    "commit --split",
+			descEn: "Split the staged changes into several logical commits",
+			descZh: "将已暂存的更改拆分为多个逻辑提交",
+		},
+		{
+			cmd:    "commit --amend",
+			descEn: "Amend the last commit with a message for all of its changes",
+			descZh: "修正最后一次提交，并为其全部更改重新生成提交消息",
+		},
+		{
+			cmd:    "reword <rev>",
+			descEn: "Write a new message for an unpushed commit",
+			descZh: "为尚未推送的提交重新生成提交消息",
+		},
+		{
+			cmd:    "pr [base] [-o file]",
+			descEn: "Write a pull request description for the current branch",
+			descZh: "为当前分支生成合并请求（PR）描述",
+		},
+		{
+			cmd:    "changelog [range] [-o file]",
+			descEn: "Write the changelog section for a range of commits",
+			descZh: "为一段提交范围生成更新日志（changelog）章节",
+		},
+		{
+			cmd:    "release [major|minor|patch]",
+			descEn: "Tag the next semantic version with a release summary",
+			descZh: "计算下一个语义化版本号，并创建附带发布摘要的标签",
+		},
+		{
+			cmd:    "undo [list [N]]",
+			descEn: "Undo the last confirmed change or list recorded changes",
+			descZh: "撤销最近一次确认的修改，或列出已记录的修改",
+		},
 		{
 			cmd:    "config",
 			descEn: "Run configuration wizard",
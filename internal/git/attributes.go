@@ -0,0 +1,47 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// CheckAttributes looks up attrs for paths in the .gitattributes files of the
+// index. The result maps each path to its attributes, with the values "set",
+// "unset", "unspecified" or the attribute's value.
+func (e *GitExecutor) CheckAttributes(ctx context.Context, paths []string, attrs ...string) (map[string]map[string]string, error) {
+	result := make(map[string]map[string]string, len(paths))
+	if len(paths) == 0 || len(attrs) == 0 {
+		return result, nil
+	}
+
+	root, err := e.atRoot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	args := append([]string{"check-attr", "--cached", "-z", "--stdin"}, attrs...)
+	cmd := root.command(ctx, args...)
+	cmd.Stdin = strings.NewReader(strings.Join(paths, "\x00") + "\x00")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check attributes: %w: %s", err, stderr.String())
+	}
+
+	records := strings.Split(strings.TrimSuffix(string(output), "\x00"), "\x00")
+	if len(records)%3 != 0 {
+		return nil, fmt.Errorf("unexpected check-attr output: %q", output)
+	}
+	for i := 0; i < len(records); i += 3 {
+		path, attr, value := records[i], records[i+1], records[i+2]
+		if result[path] == nil {
+			result[path] = make(map[string]string, len(attrs))
+		}
+		result[path][attr] = value
+	}
+	return result, nil
+}
@@ -0,0 +1,63 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckAttributes_UsesIndex(t *testing.T) {
+	e, dir := newTestRepo(t)
+	ctx := context.Background()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sub"), 0755))
+	writeFile(t, dir, ".gitattributes", "*.pb.go linguist-generated\n*.bin -diff\nthird_party/** linguist-vendored=true\n")
+	mustGit(t, e, "add", ".gitattributes")
+
+	// Attributes changed in the working tree only do not count
+	writeFile(t, dir, ".gitattributes", "")
+
+	sub, err := e.WithDir("sub")
+	require.NoError(t, err)
+
+	attrs, err := sub.CheckAttributes(ctx, []string{"api/v1 x.pb.go", "data.bin", "third_party/lib.go", "a.txt"},
+		"linguist-generated", "linguist-vendored", "diff")
+	require.NoError(t, err)
+
+	assert.Equal(t, "set", attrs["api/v1 x.pb.go"]["linguist-generated"])
+	assert.Equal(t, "unset", attrs["data.bin"]["diff"])
+	assert.Equal(t, "true", attrs["third_party/lib.go"]["linguist-vendored"])
+	assert.Equal(t, map[string]string{
+		"linguist-generated": "unspecified",
+		"linguist-vendored":  "unspecified",
+		"diff":               "unspecified",
+	}, attrs["a.txt"])
+}
+
+func TestGetDiff_Exclude(t *testing.T) {
+	e, dir := newTestRepo(t)
+	ctx := context.Background()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sub"), 0755))
+	writeFile(t, dir, "a.txt", "two\n")
+	writeFile(t, dir, "go.sum", "hash\n")
+	writeFile(t, dir, "sub/[x].txt", "literal\n")
+	mustGit(t, e, "add", "-A")
+
+	sub, err := e.WithDir("sub")
+	require.NoError(t, err)
+
+	diff, err := sub.GetDiff(ctx, true, "go.sum", "sub/[x].txt")
+	require.NoError(t, err)
+	assert.Contains(t, diff, "+two")
+	assert.NotContains(t, diff, "go.sum")
+	assert.NotContains(t, diff, "[x].txt")
+
+	diff, err = sub.GetDiff(ctx, true)
+	require.NoError(t, err)
+	assert.Contains(t, diff, "go.sum")
+}
@@ -0,0 +1,119 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-coders/git_gpt/internal/common"
+)
+
+// GetFileDiff returns the diff of path split into hunks, between the index and
+// the working tree or, when staged, between HEAD and the index. Like status
+// paths, path is relative to the top level.
+func (e *GitExecutor) GetFileDiff(ctx context.Context, path string, staged bool) (*common.FileDiff, error) {
+	root, err := e.atRoot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fixed prefixes and no color or external tools, whatever the user config
+	// says, so the hunks can be applied again
+	args := []string{"diff", "--no-color", "--no-ext-diff", "--src-prefix=a/", "--dst-prefix=b/"}
+	if staged {
+		args = append(args, "--cached")
+	}
+	args = append(args, "--", path)
+
+	output, err := root.executeRaw(ctx, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get diff of %s: %w", path, err)
+	}
+
+	diff := parseFileDiff(string(output))
+	diff.Path = path
+	return diff, nil
+}
+
+// StageHunks adds the selected hunks of diff to the index with git apply
+// --cached. Hunks are 0-based indexes into diff.Hunks.
+func (e *GitExecutor) StageHunks(ctx context.Context, diff *common.FileDiff, hunks []int) error {
+	patch, err := buildPatch(diff, hunks)
+	if err != nil {
+		return err
+	}
+	root, err := e.atRoot(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := root.executeWithInput(ctx, patch, "apply", "--cached", "--whitespace=nowarn", "-"); err != nil {
+		return fmt.Errorf("failed to stage hunks of %s: %w", diff.Path, err)
+	}
+	return nil
+}
+
+// parseFileDiff splits the diff of a single file into its header and hunks
+func parseFileDiff(output string) *common.FileDiff {
+	diff := &common.FileDiff{}
+	var header strings.Builder
+	var hunk *strings.Builder
+
+	flush := func() {
+		if hunk != nil {
+			text := hunk.String()
+			line, _, _ := strings.Cut(text, "\n")
+			diff.Hunks = append(diff.Hunks, common.Hunk{Header: line, Text: text})
+		}
+	}
+
+	for _, line := range strings.SplitAfter(output, "\n") {
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "@@ "):
+			flush()
+			hunk = &strings.Builder{}
+			hunk.WriteString(line)
+		case hunk != nil:
+			hunk.WriteString(line)
+		default:
+			if strings.HasPrefix(line, "Binary files ") || strings.HasPrefix(line, "GIT binary patch") {
+				diff.Binary = true
+			}
+			header.WriteString(line)
+		}
+	}
+	flush()
+
+	diff.Header = header.String()
+	return diff
+}
+
+// buildPatch joins the file header with the selected hunks. The hunk headers
+// keep their original line numbers; git apply finds the shifted positions
+// when earlier hunks are left out.
+func buildPatch(diff *common.FileDiff, hunks []int) (string, error) {
+	if diff.Binary {
+		return "", fmt.Errorf("cannot stage parts of binary file %s", diff.Path)
+	}
+	if len(hunks) == 0 {
+		return "", fmt.Errorf("no hunks selected for %s", diff.Path)
+	}
+
+	selected := append([]int(nil), hunks...)
+	sort.Ints(selected)
+
+	var b strings.Builder
+	b.WriteString(diff.Header)
+	for i, index := range selected {
+		if index < 0 || index >= len(diff.Hunks) {
+			return "", fmt.Errorf("hunk %d out of range for %s", index+1, diff.Path)
+		}
+		if i > 0 && selected[i-1] == index {
+			continue
+		}
+		b.WriteString(diff.Hunks[index].Text)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,96 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// numberedLines writes "line N" for each line, or the replacement when given
+func numberedLines(from, to int, replace map[int]string) string {
+	var b strings.Builder
+	for i := from; i <= to; i++ {
+		if line, ok := replace[i]; ok {
+			b.WriteString(line + "\n")
+			continue
+		}
+		fmt.Fprintf(&b, "line %d\n", i)
+	}
+	return b.String()
+}
+
+func TestStageHunks_StagesOnlySelectedHunks(t *testing.T) {
+	e, dir := newTestRepo(t)
+	ctx := context.Background()
+
+	writeFile(t, dir, "a.txt", numberedLines(1, 30, nil))
+	mustGit(t, e, "add", "a.txt")
+	mustGit(t, e, "commit", "-q", "-m", "thirty lines")
+
+	// Three changes far enough apart to form separate hunks, one with
+	// trailing whitespace that must survive
+	writeFile(t, dir, "a.txt", numberedLines(1, 30, map[int]string{
+		2:  "first change",
+		15: "second change  ",
+		28: "third change",
+	}))
+
+	diff, err := e.GetFileDiff(ctx, "a.txt", false)
+	require.NoError(t, err)
+	require.Len(t, diff.Hunks, 3)
+	assert.False(t, diff.Binary)
+	assert.True(t, strings.HasPrefix(diff.Header, "diff --git a/a.txt b/a.txt\n"))
+	assert.True(t, strings.HasPrefix(diff.Hunks[1].Header, "@@ "))
+
+	require.NoError(t, e.StageHunks(ctx, diff, []int{2, 1}))
+
+	staged, err := e.GetDiff(ctx, true)
+	require.NoError(t, err)
+	assert.NotContains(t, staged, "first change")
+	assert.Contains(t, staged, "+second change")
+	assert.Contains(t, staged, "+third change")
+
+	index, err := e.executeRaw(ctx, "show", ":a.txt")
+	require.NoError(t, err)
+	assert.Contains(t, string(index), "second change  \n")
+
+	// The unstaged remainder is exactly the skipped hunk
+	rest, err := e.GetFileDiff(ctx, "a.txt", false)
+	require.NoError(t, err)
+	require.Len(t, rest.Hunks, 1)
+	assert.Contains(t, rest.Hunks[0].Text, "+first change")
+}
+
+func TestGetFileDiff_Binary(t *testing.T) {
+	e, dir := newTestRepo(t)
+	ctx := context.Background()
+
+	writeFile(t, dir, "image.bin", "\x00\x01")
+	mustGit(t, e, "add", "image.bin")
+	mustGit(t, e, "commit", "-q", "-m", "binary")
+	writeFile(t, dir, "image.bin", "\x00\x02")
+
+	diff, err := e.GetFileDiff(ctx, "image.bin", false)
+	require.NoError(t, err)
+	assert.True(t, diff.Binary)
+	assert.Empty(t, diff.Hunks)
+	assert.ErrorContains(t, e.StageHunks(ctx, diff, []int{0}), "binary")
+}
+
+func TestBuildPatch_Errors(t *testing.T) {
+	diff := parseFileDiff("diff --git a/a b/a\n--- a/a\n+++ b/a\n@@ -1 +1 @@\n-a\n+b\n")
+	require.Len(t, diff.Hunks, 1)
+
+	_, err := buildPatch(diff, nil)
+	assert.Error(t, err)
+	_, err = buildPatch(diff, []int{1})
+	assert.Error(t, err)
+
+	patch, err := buildPatch(diff, []int{0, 0})
+	require.NoError(t, err)
+	assert.Equal(t, "diff --git a/a b/a\n--- a/a\n+++ b/a\n@@ -1 +1 @@\n-a\n+b\n", patch)
+}
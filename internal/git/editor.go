@@ -0,0 +1,58 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+const editHelp = `Edit the commit message. Lines starting with the comment character are
+removed, and an empty message cancels the commit.`
+
+// EditMessage opens message in the editor git commit would use (GIT_EDITOR,
+// core.editor, VISUAL or EDITOR) and returns the saved text without comment
+// lines. An empty result means the user cleared the message.
+func (e *GitExecutor) EditMessage(ctx context.Context, message string) (string, error) {
+	editor, err := e.Execute(ctx, "var", "GIT_EDITOR")
+	if err != nil {
+		return "", fmt.Errorf("failed to find an editor: %w", err)
+	}
+
+	path, err := e.GitPath(ctx, "COMMIT_EDITMSG")
+	if err != nil {
+		return "", fmt.Errorf("failed to find the git directory: %w", err)
+	}
+	help, err := e.executeWithInput(ctx, editHelp, "stripspace", "--comment-lines")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(message+"\n\n"+help+"\n"), 0644); err != nil {
+		return "", fmt.Errorf("failed to write commit message: %w", err)
+	}
+
+	cmd := editorCommand(ctx, editor, path)
+	cmd.Dir = e.dir
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("editor %q failed: %w", editor, err)
+	}
+
+	edited, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read commit message: %w", err)
+	}
+	return e.executeWithInput(ctx, string(edited), "stripspace", "--strip-comments")
+}
+
+// editorCommand runs editor on path. Like git, an editor with arguments or
+// shell syntax is run through the shell.
+func editorCommand(ctx context.Context, editor, path string) *exec.Cmd {
+	if strings.ContainsAny(editor, " \t\"'$|&;<>()\\") {
+		return exec.CommandContext(ctx, "sh", "-c", editor+` "$@"`, editor, path)
+	}
+	return exec.CommandContext(ctx, editor, path)
+}
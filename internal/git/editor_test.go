@@ -0,0 +1,37 @@
+package git
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditMessage(t *testing.T) {
+	e, _ := newTestRepo(t)
+	ctx := context.Background()
+
+	t.Setenv("GIT_EDITOR", `sed -i.bak -e 's/subject/edited subject/'`)
+	edited, err := e.EditMessage(ctx, "fix: subject\n\nBody text.")
+	require.NoError(t, err)
+	assert.Equal(t, "fix: edited subject\n\nBody text.", edited)
+}
+
+func TestEditMessage_Cleared(t *testing.T) {
+	e, _ := newTestRepo(t)
+
+	// Deleting everything but the help comments cancels
+	t.Setenv("GIT_EDITOR", `sed -i.bak -e '/^[^#]/d'`)
+	edited, err := e.EditMessage(context.Background(), "fix: subject")
+	require.NoError(t, err)
+	assert.Empty(t, edited)
+}
+
+func TestEditMessage_EditorFails(t *testing.T) {
+	e, _ := newTestRepo(t)
+
+	t.Setenv("GIT_EDITOR", "false")
+	_, err := e.EditMessage(context.Background(), "fix: subject")
+	assert.ErrorContains(t, err, `editor "false" failed`)
+}
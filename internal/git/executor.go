@@ -1,35 +1,110 @@
 package git
 
 import (
-	"bufio"
+	"bytes"
 	"context"
 	"fmt"
 	"os"
 	"os/exec"
-	"regexp"
+	"path/filepath"
 	"strconv"
 	"strings"
 
 	"github.com/go-coders/git_gpt/internal/common"
+	"github.com/go-coders/git_gpt/pkg/apierrors"
 )
 
-// GitExecutor implements the Executor interface
+// GitExecutor implements the Executor interface. Every command runs in the
+// executor's directory, independent of the process working directory.
 type GitExecutor struct {
+	dir string
+	// isolated drops the variables that point git at another repository, for
+	// executors that must not touch the repository ggpt was started in
+	isolated bool
 }
 
-// NewExecutor creates a new GitExecutor instance
+// repositoryEnv are the variables that locate or configure a repository, as
+// listed by git rev-parse --local-env-vars. Git sets them for hooks.
+var repositoryEnv = map[string]bool{
+	"GIT_ALTERNATE_OBJECT_DIRECTORIES": true, "GIT_CONFIG": true,
+	"GIT_CONFIG_PARAMETERS": true, "GIT_CONFIG_COUNT": true,
+	"GIT_OBJECT_DIRECTORY": true, "GIT_DIR": true, "GIT_WORK_TREE": true,
+	"GIT_IMPLICIT_WORK_TREE": true, "GIT_GRAFT_FILE": true, "GIT_INDEX_FILE": true,
+	"GIT_NO_REPLACE_OBJECTS": true, "GIT_REPLACE_REF_BASE": true, "GIT_PREFIX": true,
+	"GIT_INTERNAL_SUPER_PREFIX": true, "GIT_SHALLOW_FILE": true, "GIT_COMMON_DIR": true,
+	"GIT_NAMESPACE": true, "GIT_CEILING_DIRECTORIES": true,
+}
+
+// NewExecutor creates a new GitExecutor instance for the current working directory
 func NewExecutor() *GitExecutor {
-	return &GitExecutor{}
+	dir, err := os.Getwd()
+	if err != nil {
+		// git falls back to the process working directory
+		dir = ""
+	}
+	return &GitExecutor{dir: dir}
 }
 
-func (e *GitExecutor) Execute(ctx context.Context, args ...string) (string, error) {
-	cmd := exec.CommandContext(ctx, "git", args...)
+// NewExecutorInDir creates a GitExecutor that runs git in dir, like git -C dir
+func NewExecutorInDir(dir string) *GitExecutor {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	return &GitExecutor{dir: dir}
+}
 
-	// env git use page mode when output is too large
+// Open creates a GitExecutor for the top level of the repository containing dir
+func Open(ctx context.Context, dir string) (*GitExecutor, error) {
+	root, err := NewExecutorInDir(dir).Root(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &GitExecutor{dir: root}, nil
+}
 
-	env := append(cmd.Env, "GIT_PAGER=cat", "PAGER=cat", "GIT_TERMINAL_PROMPT=0")
-	cmd.Env = env
-	cmd.Stdin = os.Stdin
+// Dir returns the directory git runs in
+func (e *GitExecutor) Dir() string {
+	return e.dir
+}
+
+// WithDir returns an executor for path, resolved against the executor's
+// directory the way cd would
+func (e *GitExecutor) WithDir(path string) (*GitExecutor, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(e.dir, path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to change directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("failed to change directory: %s is not a directory", path)
+	}
+	return &GitExecutor{dir: filepath.Clean(path)}, nil
+}
+
+// atRoot returns an executor for the top level of the repository. Status
+// paths are relative to it, and git apply ignores paths outside the directory
+// it runs in.
+func (e *GitExecutor) atRoot(ctx context.Context) (*GitExecutor, error) {
+	root, err := e.Root(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &GitExecutor{dir: root}, nil
+}
+
+// Root returns the top level of the repository the executor runs in
+func (e *GitExecutor) Root(ctx context.Context) (string, error) {
+	root, err := e.Execute(ctx, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", apierrors.NewNotGitRepoError()
+	}
+	return root, nil
+}
+
+func (e *GitExecutor) Execute(ctx context.Context, args ...string) (string, error) {
+	cmd := e.command(ctx, args...)
 
 	output, err := cmd.CombinedOutput()
 	if err != nil {
@@ -40,6 +115,61 @@ func (e *GitExecutor) Execute(ctx context.Context, args ...string) (string, erro
 	return result, nil
 }
 
+// executeRaw returns stdout untouched, for machine-readable formats such as
+// -z output where whitespace and line breaks belong to the paths
+func (e *GitExecutor) executeRaw(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := e.command(ctx, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git command failed: %w: %s", err, stderr.String())
+	}
+	return output, nil
+}
+
+// executeWithInput runs git with input on stdin, for commands such as
+// git apply that read a patch
+func (e *GitExecutor) executeWithInput(ctx context.Context, input string, args ...string) (string, error) {
+	cmd := e.command(ctx, args...)
+	cmd.Stdin = strings.NewReader(input)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git command failed: %w: %s", err, string(output))
+	}
+	return cleanOutput(string(output)), nil
+}
+
+func (e *GitExecutor) command(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = e.dir
+
+	// env git use page mode when output is too large
+	// keep the user's environment so HOME and the global config are found
+	env := os.Environ()
+	if e.isolated {
+		env = isolatedEnv(env)
+	}
+	cmd.Env = append(env, "GIT_PAGER=cat", "PAGER=cat", "GIT_TERMINAL_PROMPT=0")
+	cmd.Stdin = os.Stdin
+	return cmd
+}
+
+// isolatedEnv removes repositoryEnv from env
+func isolatedEnv(env []string) []string {
+	var result []string
+	for _, kv := range env {
+		name, _, _ := strings.Cut(kv, "=")
+		if repositoryEnv[name] || strings.HasPrefix(name, "GIT_CONFIG_KEY_") || strings.HasPrefix(name, "GIT_CONFIG_VALUE_") {
+			continue
+		}
+		result = append(result, kv)
+	}
+	return result
+}
+
 func cleanOutput(output string) string {
 	// Remove common terminal control sequences
 	output = strings.ReplaceAll(output, "\r", "")
@@ -69,6 +199,45 @@ func (e *GitExecutor) IsGitRepository(ctx context.Context) bool {
 	return err == nil
 }
 
+// HooksDir returns the absolute directory git runs hooks from, honoring core.hooksPath
+func (e *GitExecutor) HooksDir(ctx context.Context) (string, error) {
+	dir, err := e.GitPath(ctx, "hooks")
+	if err != nil {
+		return "", fmt.Errorf("failed to get hooks directory: %w", err)
+	}
+	return dir, nil
+}
+
+// GitPath returns the absolute path of name inside the git directory, such
+// as "hooks" or "info/exclude"
+func (e *GitExecutor) GitPath(ctx context.Context, name string) (string, error) {
+	path, err := e.Execute(ctx, "rev-parse", "--git-path", name)
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(e.dir, path)
+	}
+	return filepath.Abs(path)
+}
+
+// RecentSubjects returns the subject lines of the last n non-merge commits,
+// newest first
+func (e *GitExecutor) RecentSubjects(ctx context.Context, n int) ([]string, error) {
+	output, err := e.executeRaw(ctx, "log", "-n", strconv.Itoa(n), "--no-merges", "--format=%s%x00")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit subjects: %w", err)
+	}
+
+	var subjects []string
+	for _, record := range strings.Split(string(output), "\x00") {
+		if subject := strings.TrimSpace(record); subject != "" {
+			subjects = append(subjects, subject)
+		}
+	}
+	return subjects, nil
+}
+
 func (e *GitExecutor) GetCurrentBranch(ctx context.Context) (string, error) {
 	return e.Execute(ctx, "branch", "--show-current")
 }
@@ -81,28 +250,64 @@ func (e *GitExecutor) StageAll(ctx context.Context) error {
 	return nil
 }
 
+func (e *GitExecutor) StageTracked(ctx context.Context) error {
+	_, err := e.Execute(ctx, "add", "-u")
+	if err != nil {
+		return fmt.Errorf("failed to stage tracked changes: %w", err)
+	}
+	return nil
+}
+
+// StageFiles adds files, given relative to the top level like status paths
 func (e *GitExecutor) StageFiles(ctx context.Context, files []string) error {
-	args := append([]string{"add"}, files...)
-	_, err := e.Execute(ctx, args...)
+	root, err := e.atRoot(ctx)
 	if err != nil {
+		return err
+	}
+	args := append([]string{"add", "--"}, files...)
+	if _, err := root.Execute(ctx, args...); err != nil {
 		return fmt.Errorf("failed to stage files: %w", err)
 	}
 	return nil
 }
 
+// Commit records the staged changes. The message is passed with -F from a
+// temporary file, so a body and trailers are kept as written.
 func (e *GitExecutor) Commit(ctx context.Context, message string) error {
-	_, err := e.Execute(ctx, "commit", "-m", message)
+	return withMessageFile(message, func(path string) error {
+		if _, err := e.Execute(ctx, "commit", "-F", path); err != nil {
+			return fmt.Errorf("failed to commit: %w", err)
+		}
+		return nil
+	})
+}
+
+// withMessageFile writes message to a temporary file for the duration of fn
+func withMessageFile(message string, fn func(path string) error) error {
+	file, err := os.CreateTemp("", "ggpt-commit-*.txt")
 	if err != nil {
-		return fmt.Errorf("failed to commit: %w", err)
+		return fmt.Errorf("failed to write commit message: %w", err)
 	}
-	return nil
+	defer os.Remove(file.Name())
+
+	if _, err := file.WriteString(message + "\n"); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write commit message: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write commit message: %w", err)
+	}
+	return fn(file.Name())
 }
 
-func (e *GitExecutor) GetDiff(ctx context.Context, staged bool) (string, error) {
+// GetDiff returns the diff of the changes. Paths in exclude, relative to the
+// top of the repository, are left out.
+func (e *GitExecutor) GetDiff(ctx context.Context, staged bool, exclude ...string) (string, error) {
 	args := []string{"diff"}
 	if staged {
 		args = append(args, "--cached")
 	}
+	args = append(args, excludePathspecs(exclude)...)
 
 	output, err := e.Execute(ctx, args...)
 	if err != nil {
@@ -111,91 +316,74 @@ func (e *GitExecutor) GetDiff(ctx context.Context, staged bool) (string, error)
 	return output, nil
 }
 
-func (e *GitExecutor) GetStatus(ctx context.Context) (staged, unstaged []common.FileChange, err error) {
-	statusResult, err := e.Execute(ctx, "status", "--porcelain")
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get status: %w", err)
+// excludePathspecs returns the pathspecs that leave out paths, given relative
+// to the top of the repository
+func excludePathspecs(paths []string) []string {
+	if len(paths) == 0 {
+		return nil
 	}
+	args := []string{"--", ":(top)"}
+	for _, path := range paths {
+		args = append(args, ":(top,literal,exclude)"+path)
+	}
+	return args
+}
 
-	scanner := bufio.NewScanner(strings.NewReader(statusResult))
-	// Regular expression to match status lines, including untracked files
-	re := regexp.MustCompile(`^(?P<status>[ MADRCU?!]{2})\s+(?P<path>.+)$`)
-
-	for scanner.Scan() {
-		rawLine := scanner.Text()
-
-		if len(rawLine) < 3 {
-			continue
-		}
-
-		matches := re.FindStringSubmatch(rawLine)
-		if matches == nil {
-			continue
-		}
+// GetDiffStat returns the --stat overview of the changes
+func (e *GitExecutor) GetDiffStat(ctx context.Context, staged bool) (string, error) {
+	args := []string{"diff", "--stat"}
+	if staged {
+		args = append(args, "--cached")
+	}
 
-		statusCode := matches[1]
-		path := matches[2]
+	output, err := e.Execute(ctx, args...)
+	if err != nil {
+		return "", fmt.Errorf("failed to get diff stat: %w", err)
+	}
+	return output, nil
+}
 
-		indexStatus := statusCode[0]
-		workingStatus := statusCode[1]
+// GetStatus lists staged and unstaged changes, including untracked files,
+// with line counts for tracked files
+func (e *GitExecutor) GetStatus(ctx context.Context) (staged, unstaged []common.FileChange, err error) {
+	statusResult, err := e.executeRaw(ctx, "status", "--porcelain=v2", "-z")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get status: %w", err)
+	}
 
-		// Determine if the change is staged based on indexStatus.
-		if indexStatus != ' ' && indexStatus != '?' {
-			change := common.FileChange{
-				Path:   path,
-				Status: getReadableStatus(indexStatus),
-			}
-			staged = append(staged, change)
-		}
+	staged, unstaged, err = parseStatusV2(statusResult)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse status: %w", err)
+	}
 
-		// Determine if the change is unstaged based on workingStatus.
-		if workingStatus != ' ' && workingStatus != '?' {
-			change := common.FileChange{
-				Path:   path,
-				Status: getReadableStatus(workingStatus),
-			}
-			unstaged = append(unstaged, change)
-		}
+	// Line counts are best effort; a change without them is still a change
+	if len(staged) > 0 {
+		e.applyNumstat(ctx, staged, "diff", "--cached", "--numstat", "-z")
+	}
+	if len(unstaged) > 0 {
+		e.applyNumstat(ctx, unstaged, "diff", "--numstat", "-z")
+	}
 
-		// Handle untracked files
-		if indexStatus == '?' && workingStatus == '?' {
-			change := common.FileChange{
-				Path:   path,
-				Status: "untracked",
-			}
+	return staged, unstaged, nil
+}
 
-			unstaged = append(unstaged, change)
-		}
+func (e *GitExecutor) applyNumstat(ctx context.Context, changes []common.FileChange, args ...string) {
+	output, err := e.executeRaw(ctx, args...)
+	if err != nil {
+		return
+	}
+	stats, err := parseNumstat(output)
+	if err != nil {
+		return
 	}
 
-	// Get stats for staged files if any.
-	if len(staged) > 0 {
-		statsResult, err := e.Execute(ctx, "diff", "--cached", "--numstat")
-		if err == nil {
-			stats := make(map[string]struct{ add, del int })
-			scanner := bufio.NewScanner(strings.NewReader(statsResult))
-			for scanner.Scan() {
-				line := scanner.Text()
-				parts := strings.Fields(line)
-				if len(parts) >= 3 {
-					add, _ := strconv.Atoi(parts[0])
-					del, _ := strconv.Atoi(parts[1])
-					path := strings.Join(parts[2:], " ")
-					stats[path] = struct{ add, del int }{add, del}
-				}
-			}
-
-			// Update stats for staged files.
-			for i := range staged {
-				if stat, ok := stats[staged[i].Path]; ok {
-					staged[i].Additions = stat.add
-					staged[i].Deletions = stat.del
-				}
-			}
+	for i := range changes {
+		if stat, ok := stats[changes[i].Path]; ok {
+			changes[i].Additions = stat.additions
+			changes[i].Deletions = stat.deletions
+			changes[i].Binary = stat.binary
 		}
 	}
-
-	return staged, unstaged, nil
 }
 
 func getReadableStatus(code byte) string {
@@ -216,6 +404,8 @@ func getReadableStatus(code byte) string {
 		return "ignored"
 	case 'U':
 		return "unmerged"
+	case 'T':
+		return "typechange"
 	default:
 		return "unknown"
 	}
@@ -0,0 +1,136 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-coders/git_gpt/pkg/apierrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpen_FindsTopLevel(t *testing.T) {
+	_, dir := newTestRepo(t)
+	sub := filepath.Join(dir, "pkg", "api")
+	require.NoError(t, os.MkdirAll(sub, 0755))
+
+	e, err := Open(context.Background(), sub)
+	require.NoError(t, err)
+
+	root, err := filepath.EvalSymlinks(dir)
+	require.NoError(t, err)
+	assert.Equal(t, root, e.Dir())
+}
+
+func TestOpen_NotARepository(t *testing.T) {
+	_, err := Open(context.Background(), t.TempDir())
+
+	var appErr *apierrors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, apierrors.ErrGitNotInitialized, appErr.Type)
+}
+
+func TestExecutor_SeparateRepositories(t *testing.T) {
+	first, _ := newTestRepo(t)
+	second, dir := newTestRepo(t)
+	ctx := context.Background()
+
+	writeFile(t, dir, "b.txt", "two\n")
+	_, err := second.Execute(ctx, "add", "b.txt")
+	require.NoError(t, err)
+	_, err = second.Execute(ctx, "commit", "-q", "-m", "second")
+	require.NoError(t, err)
+
+	log, err := first.Execute(ctx, "log", "--format=%s")
+	require.NoError(t, err)
+	assert.Equal(t, "first", log)
+
+	log, err = second.Execute(ctx, "log", "--format=%s")
+	require.NoError(t, err)
+	assert.Equal(t, "second\nfirst", log)
+}
+
+func TestWithDir(t *testing.T) {
+	e, dir := newTestRepo(t)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "docs"), 0755))
+	writeFile(t, dir, "docs/readme.md", "docs\n")
+
+	docs, err := e.WithDir("docs")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "docs"), docs.Dir())
+
+	// Commands run relative to the new directory
+	files, err := docs.Execute(context.Background(), "ls-files", "--others")
+	require.NoError(t, err)
+	assert.Equal(t, "readme.md", files)
+
+	back, err := docs.WithDir("..")
+	require.NoError(t, err)
+	assert.Equal(t, dir, back.Dir())
+
+	_, err = e.WithDir("missing")
+	assert.Error(t, err)
+	_, err = e.WithDir("a.txt")
+	assert.ErrorContains(t, err, "not a directory")
+}
+
+func TestHooksDir_RelativeToExecutor(t *testing.T) {
+	e, dir := newTestRepo(t)
+
+	hooks, err := e.HooksDir(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, ".git", "hooks"), hooks)
+}
+
+func TestRecentSubjects_SkipsMerges(t *testing.T) {
+	e, _ := newTestRepo(t)
+	ctx := context.Background()
+
+	mustGit(t, e, "checkout", "-q", "-b", "topic")
+	mustGit(t, e, "commit", "-q", "--allow-empty", "-m", "topic work\n\nWith a body.")
+	mustGit(t, e, "checkout", "-q", "main")
+	mustGit(t, e, "commit", "-q", "--allow-empty", "-m", "main work")
+	mustGit(t, e, "merge", "-q", "--no-ff", "-m", "Merge branch 'topic'", "topic")
+
+	subjects, err := e.RecentSubjects(ctx, 10)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"main work", "topic work", "first"}, subjects)
+	assert.Equal(t, "first", subjects[2])
+
+	subjects, err = e.RecentSubjects(ctx, 1)
+	require.NoError(t, err)
+	assert.Len(t, subjects, 1)
+}
+
+func TestGitPath(t *testing.T) {
+	e, dir := newTestRepo(t)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sub"), 0755))
+	sub, err := e.WithDir("sub")
+	require.NoError(t, err)
+
+	path, err := sub.GitPath(context.Background(), "ggpt/style.json")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, ".git", "ggpt", "style.json"), path)
+}
+
+func TestCommit_KeepsBodyAndTrailers(t *testing.T) {
+	e, dir := newTestRepo(t)
+	ctx := context.Background()
+
+	writeFile(t, dir, "a.txt", "two\n")
+	mustGit(t, e, "add", "a.txt")
+
+	message := "fix: keep the body\n\nThe body explains why.\n#42 stays, it is not a comment.\n\nRefs: #42"
+	require.NoError(t, e.Commit(ctx, message))
+
+	logged, err := e.Execute(ctx, "log", "-1", "--format=%B")
+	require.NoError(t, err)
+	assert.Equal(t, message, logged)
+
+	trailers, err := e.Execute(ctx, "log", "-1", "--format=%(trailers:key=Refs,valueonly)")
+	require.NoError(t, err)
+	assert.Equal(t, "#42", trailers)
+}
@@ -0,0 +1,101 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-coders/git_gpt/internal/common"
+)
+
+// MergeBase returns the best common ancestor of a and b
+func (e *GitExecutor) MergeBase(ctx context.Context, a, b string) (string, error) {
+	base, err := e.Execute(ctx, "merge-base", a, b)
+	if err != nil {
+		return "", fmt.Errorf("no common ancestor of %s and %s", a, b)
+	}
+	return base, nil
+}
+
+// DefaultBranch guesses the branch pull requests target: the remote's HEAD,
+// else a local main or master
+func (e *GitExecutor) DefaultBranch(ctx context.Context) (string, error) {
+	if ref, err := e.Execute(ctx, "symbolic-ref", "-q", "--short", "refs/remotes/origin/HEAD"); err == nil && ref != "" {
+		return ref, nil
+	}
+	for _, name := range []string{"main", "master"} {
+		if _, err := e.Execute(ctx, "rev-parse", "--verify", "-q", "refs/heads/"+name); err == nil {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("could not find the default branch")
+}
+
+// CommitLog returns the non-merge commits reachable from to but not from
+// from, newest first. An empty from lists the whole history of to.
+func (e *GitExecutor) CommitLog(ctx context.Context, from, to string) ([]common.Commit, error) {
+	rev := to
+	if from != "" {
+		rev = from + ".." + to
+	}
+	output, err := e.executeRaw(ctx, "log", "--no-merges", "--format=%H%x00%s%x00%b%x1e", rev, "--")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the commits of %s: %w", rev, err)
+	}
+
+	var commits []common.Commit
+	for _, record := range strings.Split(string(output), "\x1e") {
+		fields := strings.SplitN(strings.TrimLeft(record, "\n"), "\x00", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		commits = append(commits, common.Commit{
+			Hash:    fields[0],
+			Subject: fields[1],
+			Body:    strings.TrimSpace(fields[2]),
+		})
+	}
+	return commits, nil
+}
+
+// LastTag returns the newest tag reachable from rev whose name matches the
+// glob match, or an empty string when there is none. An empty match accepts
+// every tag.
+func (e *GitExecutor) LastTag(ctx context.Context, rev, match string) (string, error) {
+	args := []string{"describe", "--tags", "--abbrev=0"}
+	if match != "" {
+		args = append(args, "--match", match)
+	}
+	tag, err := e.Execute(ctx, append(args, rev)...)
+	if err == nil {
+		return tag, nil
+	}
+	if _, verr := e.Execute(ctx, "rev-parse", "--verify", "-q", rev+"^{commit}"); verr != nil {
+		return "", fmt.Errorf("unknown revision %s", rev)
+	}
+	return "", nil
+}
+
+// TagDate returns the date of the commit tagged name as YYYY-MM-DD, or an
+// error when name is not a tag
+func (e *GitExecutor) TagDate(ctx context.Context, name string) (string, error) {
+	if _, err := e.Execute(ctx, "rev-parse", "--verify", "-q", "refs/tags/"+name); err != nil {
+		return "", fmt.Errorf("%s is not a tag", name)
+	}
+	date, err := e.Execute(ctx, "log", "-1", "--format=%cs", "refs/tags/"+name)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the date of %s: %w", name, err)
+	}
+	return date, nil
+}
+
+// CreateTag adds the annotated tag name at HEAD. The message is kept as
+// written, so Markdown headings are not taken for comments.
+func (e *GitExecutor) CreateTag(ctx context.Context, name, message string) error {
+	return withMessageFile(message, func(path string) error {
+		if _, err := e.Execute(ctx, "tag", "-a", "--cleanup=whitespace", "-F", path, name, "HEAD"); err != nil {
+			return fmt.Errorf("failed to create tag %s: %w", name, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,121 @@
+package git
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-coders/git_gpt/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeBaseAndCommitLog(t *testing.T) {
+	e, dir := newTestRepo(t)
+	ctx := context.Background()
+
+	mustGit(t, e, "checkout", "-q", "-b", "topic")
+	commitFile(t, e, dir, "b.txt", "b\n", "feat: add b")
+	writeFile(t, dir, "c.txt", "c\n")
+	mustGit(t, e, "add", "c.txt")
+	mustGit(t, e, "commit", "-q", "-m", "fix: add c\n\nWith a body.\n\nRefs: #1")
+	mustGit(t, e, "checkout", "-q", "main")
+	commitFile(t, e, dir, "d.txt", "d\n", "main moves on")
+	mustGit(t, e, "checkout", "-q", "topic")
+	mustGit(t, e, "merge", "-q", "--no-edit", "main")
+
+	base, err := e.MergeBase(ctx, "main", "HEAD")
+	require.NoError(t, err)
+	mainHead, err := e.Execute(ctx, "rev-parse", "main")
+	require.NoError(t, err)
+	assert.Equal(t, mainHead, base)
+
+	first, err := e.Execute(ctx, "rev-list", "--max-parents=0", "HEAD")
+	require.NoError(t, err)
+	commits, err := e.CommitLog(ctx, first, "topic")
+	require.NoError(t, err)
+
+	// The merge is left out; commits made in the same second have no fixed order
+	subjects := make(map[string]common.Commit)
+	for _, commit := range commits {
+		assert.Len(t, commit.Hash, 40)
+		subjects[commit.Subject] = commit
+	}
+	assert.Len(t, subjects, 3)
+	assert.Contains(t, subjects, "main moves on")
+	assert.Contains(t, subjects, "feat: add b")
+	assert.Equal(t, "With a body.\n\nRefs: #1", subjects["fix: add c"].Body)
+	assert.Empty(t, subjects["feat: add b"].Body)
+
+	all, err := e.CommitLog(ctx, "", "main")
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	_, err = e.MergeBase(ctx, "main", "no-such-branch")
+	assert.Error(t, err)
+}
+
+func TestDefaultBranch(t *testing.T) {
+	e, _ := newTestRepo(t)
+	ctx := context.Background()
+
+	branch, err := e.DefaultBranch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "main", branch)
+
+	mustGit(t, e, "update-ref", "refs/remotes/origin/develop", "HEAD")
+	mustGit(t, e, "symbolic-ref", "refs/remotes/origin/HEAD", "refs/remotes/origin/develop")
+	branch, err = e.DefaultBranch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "origin/develop", branch)
+
+	mustGit(t, e, "symbolic-ref", "--delete", "refs/remotes/origin/HEAD")
+	mustGit(t, e, "branch", "-q", "-m", "main", "trunk")
+	_, err = e.DefaultBranch(ctx)
+	assert.Error(t, err)
+}
+
+func TestLastTagAndTagDate(t *testing.T) {
+	e, dir := newTestRepo(t)
+	ctx := context.Background()
+
+	tag, err := e.LastTag(ctx, "HEAD", "")
+	require.NoError(t, err)
+	assert.Empty(t, tag)
+
+	mustGit(t, e, "tag", "v1.0.0")
+	commitFile(t, e, dir, "b.txt", "b\n", "feat: add b")
+	mustGit(t, e, "tag", "-a", "-m", "nightly", "nightly-1")
+
+	tag, err = e.LastTag(ctx, "HEAD", "")
+	require.NoError(t, err)
+	assert.Equal(t, "nightly-1", tag)
+	tag, err = e.LastTag(ctx, "HEAD", "v*")
+	require.NoError(t, err)
+	assert.Equal(t, "v1.0.0", tag)
+	tag, err = e.LastTag(ctx, "HEAD^", "")
+	require.NoError(t, err)
+	assert.Equal(t, "v1.0.0", tag)
+
+	_, err = e.LastTag(ctx, "no-such-rev", "")
+	assert.EqualError(t, err, "unknown revision no-such-rev")
+
+	date, err := e.TagDate(ctx, "nightly-1")
+	require.NoError(t, err)
+	assert.Regexp(t, `^\d{4}-\d{2}-\d{2}$`, date)
+	_, err = e.TagDate(ctx, "HEAD")
+	assert.EqualError(t, err, "HEAD is not a tag")
+}
+
+func TestCreateTag(t *testing.T) {
+	e, _ := newTestRepo(t)
+	ctx := context.Background()
+
+	message := "v1.0.0: First release\n\n# Not a comment\n\n- Logins are retried"
+	require.NoError(t, e.CreateTag(ctx, "v1.0.0", message))
+
+	got, err := e.Execute(ctx, "tag", "-l", "--format=%(objecttype) %(contents)", "v1.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, "tag "+message, got)
+
+	assert.ErrorContains(t, e.CreateTag(ctx, "v1.0.0", "again"), "failed to create tag v1.0.0")
+}
@@ -0,0 +1,131 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/go-coders/git_gpt/internal/common"
+)
+
+const (
+	// previewRemote names the real repository inside the copy while its refs
+	// are fetched. It is removed before any command runs, so nothing done in
+	// the copy can be pushed back.
+	previewRemote = "ggpt-preview"
+
+	previewLogSize = 10
+)
+
+// Preview runs commands in a throwaway clone of the repository and reports
+// what they would do. The clone shares the object store, starts from the
+// current branches, HEAD, index and local changes, and is removed afterwards.
+// Untracked files are not copied, and the clone has no remotes. Callers must
+// check that the commands stay inside the clone: options such as -C or
+// --git-dir and commands such as push to a path still reach other repositories.
+func (e *GitExecutor) Preview(ctx context.Context, commands [][]string) (*common.Preview, error) {
+	root, err := e.Execute(ctx, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find repository root: %w", err)
+	}
+	head, err := e.Execute(ctx, "rev-parse", "--verify", "-q", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("preview needs a repository with at least one commit")
+	}
+	local, err := e.Execute(ctx, withSnapshotIdentity("stash", "create")...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record local changes: %w", err)
+	}
+
+	dir, err := os.MkdirTemp("", "ggpt-preview-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create preview directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The copy ignores GIT_DIR, GIT_INDEX_FILE and the like, which git sets
+	// for hooks and which would lead the commands back to the real repository
+	clone := &GitExecutor{dir: dir, isolated: true}
+	if _, err := clone.Execute(ctx, "clone", "-q", "--shared", "--no-checkout", "--origin", previewRemote, root, dir); err != nil {
+		return nil, fmt.Errorf("failed to create preview copy: %w", err)
+	}
+	if err := e.preparePreview(ctx, clone, head, local); err != nil {
+		return nil, err
+	}
+
+	preview := &common.Preview{}
+	for _, args := range commands {
+		output, err := clone.Execute(ctx, withPreviewEditor(args...)...)
+		step := common.PreviewStep{Args: args, Output: output}
+		if err != nil {
+			step.Error = err.Error()
+		}
+		preview.Steps = append(preview.Steps, step)
+		if err != nil {
+			break
+		}
+	}
+
+	// The commands may leave the copy without a HEAD, so these are best effort
+	preview.Log, _ = clone.Execute(ctx, "log", "--oneline", fmt.Sprintf("-n%d", previewLogSize))
+	preview.Status, _ = clone.Execute(ctx, "status", "--short", "--branch")
+	base := head
+	if local != "" {
+		base = local
+	}
+	preview.DiffStat, _ = clone.Execute(ctx, "diff", "--stat", base)
+
+	return preview, nil
+}
+
+// preparePreview gives clone the refs, identity, HEAD, index and working tree
+// of the real repository
+func (e *GitExecutor) preparePreview(ctx context.Context, clone *GitExecutor, head, local string) error {
+	if _, err := clone.Execute(ctx, "fetch", "-q", "--update-head-ok", previewRemote,
+		"+refs/heads/*:refs/heads/*",
+		"+refs/tags/*:refs/tags/*",
+		"+refs/remotes/*:refs/remotes/*",
+	); err != nil {
+		return fmt.Errorf("failed to copy refs for preview: %w", err)
+	}
+	if _, err := clone.Execute(ctx, "remote", "remove", previewRemote); err != nil {
+		return fmt.Errorf("failed to detach preview copy: %w", err)
+	}
+
+	// Commits made in the copy need the identity the real repository would use
+	for _, key := range []string{"user.name", "user.email"} {
+		value, err := e.Execute(ctx, "config", "--get", key)
+		if err != nil || value == "" {
+			continue
+		}
+		if _, err := clone.Execute(ctx, "config", key, value); err != nil {
+			return fmt.Errorf("failed to configure preview copy: %w", err)
+		}
+	}
+
+	checkout := []string{"checkout", "-q", "-f", "--detach", head}
+	if branch, err := e.Execute(ctx, "symbolic-ref", "-q", "--short", "HEAD"); err == nil {
+		checkout = []string{"checkout", "-q", "-f", branch}
+	}
+	if _, err := clone.Execute(ctx, checkout...); err != nil {
+		return fmt.Errorf("failed to check out preview copy: %w", err)
+	}
+
+	if local == "" {
+		return nil
+	}
+	// A stash commit holds the working tree, its second parent the index
+	if _, err := clone.Execute(ctx, "read-tree", "--reset", "-u", local+"^{tree}"); err != nil {
+		return fmt.Errorf("failed to copy local changes for preview: %w", err)
+	}
+	if _, err := clone.Execute(ctx, "read-tree", local+"^2^{tree}"); err != nil {
+		return fmt.Errorf("failed to copy staged changes for preview: %w", err)
+	}
+	return nil
+}
+
+// withPreviewEditor replaces the editors with a no-op, since nobody answers an
+// editor opened for a preview
+func withPreviewEditor(args ...string) []string {
+	return append([]string{"-c", "core.editor=true", "-c", "sequence.editor=true"}, args...)
+}
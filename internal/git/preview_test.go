@@ -0,0 +1,112 @@
+package git
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreview_LeavesRepositoryAlone(t *testing.T) {
+	e, dir := newTestRepo(t)
+	ctx := context.Background()
+
+	writeFile(t, dir, "a.txt", "changed\n")
+	head, err := e.Execute(ctx, "rev-parse", "HEAD")
+	require.NoError(t, err)
+
+	preview, err := e.Preview(ctx, [][]string{
+		{"checkout", "-q", "-b", "feature/login"},
+		{"commit", "-q", "-am", "login"},
+		{"branch", "-q", "-D", "main"},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, preview.Steps, 3)
+	for _, step := range preview.Steps {
+		assert.Empty(t, step.Error)
+	}
+	assert.Contains(t, preview.Log, "login")
+	assert.Contains(t, preview.Status, "feature/login")
+	// The local change is committed in the copy, so it matches the real tree
+	assert.Empty(t, preview.DiffStat)
+
+	// The real repository is untouched
+	current, err := e.Execute(ctx, "rev-parse", "HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, head, current)
+	branches, err := e.Execute(ctx, "branch", "--format=%(refname:short)")
+	require.NoError(t, err)
+	assert.Equal(t, "main", branches)
+	status, err := e.Execute(ctx, "status", "--porcelain")
+	require.NoError(t, err)
+	assert.Equal(t, " M a.txt", status)
+}
+
+func TestPreview_ShowsChangesAgainstWorkingTree(t *testing.T) {
+	e, dir := newTestRepo(t)
+	ctx := context.Background()
+
+	writeFile(t, dir, "a.txt", "changed\n")
+
+	preview, err := e.Preview(ctx, [][]string{{"reset", "-q", "--hard"}})
+	require.NoError(t, err)
+	assert.Contains(t, preview.DiffStat, "a.txt")
+	assert.Equal(t, "## main", preview.Status)
+}
+
+func TestPreview_StopsAtFailingCommand(t *testing.T) {
+	e, _ := newTestRepo(t)
+
+	preview, err := e.Preview(context.Background(), [][]string{
+		{"checkout", "-q", "missing"},
+		{"commit", "--allow-empty", "-q", "-m", "never"},
+	})
+	require.NoError(t, err)
+	require.Len(t, preview.Steps, 1)
+	assert.NotEmpty(t, preview.Steps[0].Error)
+	assert.NotContains(t, preview.Log, "never")
+}
+
+func TestPreview_CannotPush(t *testing.T) {
+	e, _ := newTestRepo(t)
+
+	preview, err := e.Preview(context.Background(), [][]string{{"push", "origin", "main"}})
+	require.NoError(t, err)
+	require.Len(t, preview.Steps, 1)
+	assert.NotEmpty(t, preview.Steps[0].Error)
+}
+
+func TestPreview_IgnoresRepositoryEnvironment(t *testing.T) {
+	e, dir := newTestRepo(t)
+	ctx := context.Background()
+
+	// Git sets these when ggpt runs from a hook
+	t.Setenv("GIT_DIR", filepath.Join(dir, ".git"))
+	t.Setenv("GIT_WORK_TREE", dir)
+	t.Setenv("GIT_INDEX_FILE", filepath.Join(dir, ".git", "index"))
+	writeFile(t, dir, "a.txt", "changed\n")
+	head, err := e.Execute(ctx, "rev-parse", "HEAD")
+	require.NoError(t, err)
+
+	preview, err := e.Preview(ctx, [][]string{
+		{"commit", "-q", "-am", "in the copy"},
+		{"checkout", "-q", "-b", "feature/login"},
+	})
+	require.NoError(t, err)
+	require.Len(t, preview.Steps, 2)
+	assert.Empty(t, preview.Steps[1].Error)
+	assert.Contains(t, preview.Log, "in the copy")
+
+	current, err := e.Execute(ctx, "rev-parse", "HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, head, current)
+	branches, err := e.Execute(ctx, "branch", "--format=%(refname:short)")
+	require.NoError(t, err)
+	assert.Equal(t, "main", branches)
+	status, err := e.Execute(ctx, "status", "--porcelain")
+	require.NoError(t, err)
+	assert.Equal(t, " M a.txt", status)
+}
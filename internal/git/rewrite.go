@@ -0,0 +1,214 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-coders/git_gpt/internal/common"
+)
+
+// emptyTree is the tree with no entries, the base a root commit is
+// compared with
+const emptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// ParentOf returns the first parent of rev, or the empty tree when rev is a
+// root commit, so that diffs against it show everything rev added
+func (e *GitExecutor) ParentOf(ctx context.Context, rev string) (string, error) {
+	commit, err := e.Execute(ctx, "rev-parse", "--verify", "-q", rev+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("unknown commit %s", rev)
+	}
+	parent, err := e.Execute(ctx, "rev-parse", "--verify", "-q", commit+"^1")
+	if err != nil {
+		return emptyTree, nil
+	}
+	return parent, nil
+}
+
+// rangeArgs compares from with to, or with the index when to is empty
+func rangeArgs(from, to string) []string {
+	if to == "" {
+		return []string{"--cached", from}
+	}
+	return []string{from, to}
+}
+
+// GetRangeChanges lists the files that differ between from and to, with
+// their line counts. An empty to compares with the index.
+func (e *GitExecutor) GetRangeChanges(ctx context.Context, from, to string) ([]common.FileChange, error) {
+	output, err := e.executeRaw(ctx, append([]string{"diff", "--name-status", "-z", "-M"}, rangeArgs(from, to)...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list changes: %w", err)
+	}
+	changes, err := parseNameStatus(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse changes: %w", err)
+	}
+
+	if len(changes) > 0 {
+		e.applyNumstat(ctx, changes, append([]string{"diff", "--numstat", "-z", "-M"}, rangeArgs(from, to)...)...)
+	}
+	return changes, nil
+}
+
+// GetRangeDiff returns the diff between from and to, or between from and the
+// index when to is empty. Paths in exclude, relative to the top of the
+// repository, are left out.
+func (e *GitExecutor) GetRangeDiff(ctx context.Context, from, to string, exclude ...string) (string, error) {
+	args := append([]string{"diff", "-M"}, rangeArgs(from, to)...)
+	output, err := e.Execute(ctx, append(args, excludePathspecs(exclude)...)...)
+	if err != nil {
+		return "", fmt.Errorf("failed to get diff: %w", err)
+	}
+	return output, nil
+}
+
+// GetRangeDiffStat returns the --stat overview of GetRangeDiff
+func (e *GitExecutor) GetRangeDiffStat(ctx context.Context, from, to string) (string, error) {
+	output, err := e.Execute(ctx, append([]string{"diff", "--stat", "-M"}, rangeArgs(from, to)...)...)
+	if err != nil {
+		return "", fmt.Errorf("failed to get diff stat: %w", err)
+	}
+	return output, nil
+}
+
+// RemoteBranchesContaining returns the remote-tracking branches that already
+// have rev, meaning it has been pushed
+func (e *GitExecutor) RemoteBranchesContaining(ctx context.Context, rev string) ([]string, error) {
+	output, err := e.Execute(ctx, "for-each-ref", "--contains", rev, "--format=%(refname:short)", "refs/remotes")
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up remote branches: %w", err)
+	}
+
+	var branches []string
+	for _, branch := range strings.Split(output, "\n") {
+		// origin/HEAD is an alias of another remote branch
+		if branch != "" && !strings.HasSuffix(branch, "/HEAD") {
+			branches = append(branches, branch)
+		}
+	}
+	return branches, nil
+}
+
+// Amend replaces the last commit with one holding its changes plus the
+// staged ones, described by message
+func (e *GitExecutor) Amend(ctx context.Context, message string) error {
+	return withMessageFile(message, func(path string) error {
+		if _, err := e.Execute(ctx, "commit", "--amend", "-F", path); err != nil {
+			return fmt.Errorf("failed to amend the commit: %w", err)
+		}
+		return nil
+	})
+}
+
+// Reword replaces the message of rev, an ancestor of HEAD. The commit is
+// recreated with the same tree, parents and author, and the commits after it
+// are rebased onto the new one without prompting. Local changes to tracked
+// files are stashed for the rebase and restored afterwards.
+func (e *GitExecutor) Reword(ctx context.Context, rev, message string) error {
+	root, err := e.atRoot(ctx)
+	if err != nil {
+		return err
+	}
+
+	commit, err := root.Execute(ctx, "rev-parse", "--verify", "-q", rev+"^{commit}")
+	if err != nil {
+		return fmt.Errorf("unknown commit %s", rev)
+	}
+	head, err := root.Execute(ctx, "rev-parse", "--verify", "-q", "HEAD")
+	if err != nil {
+		return fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+	if _, err := root.Execute(ctx, "merge-base", "--is-ancestor", commit, head); err != nil {
+		return fmt.Errorf("%s is not on the current branch", rev)
+	}
+	for _, state := range []string{"rebase-merge", "rebase-apply", "MERGE_HEAD", "CHERRY_PICK_HEAD"} {
+		path, err := root.GitPath(ctx, state)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("a rebase, merge or cherry-pick is in progress; finish it first")
+		}
+	}
+
+	reworded, err := root.rewriteMessage(ctx, commit, message)
+	if err != nil {
+		return err
+	}
+
+	if commit == head {
+		if _, err := root.Execute(ctx, "update-ref", "-m", "ggpt: reword", "HEAD", reworded, head); err != nil {
+			return fmt.Errorf("failed to update HEAD: %w", err)
+		}
+		return nil
+	}
+
+	// --autostash would restore the staged changes as unstaged ones, so the
+	// stash is kept by hand and popped with its index
+	dirty, err := root.Execute(ctx, "status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return fmt.Errorf("failed to check for local changes: %w", err)
+	}
+	if dirty != "" {
+		if _, err := root.Execute(ctx, "stash", "push", "--quiet", "-m", "ggpt: reword "+rev); err != nil {
+			return fmt.Errorf("failed to stash local changes: %w", err)
+		}
+	}
+
+	rebaseErr := root.rebaseOnto(ctx, reworded, commit)
+	if dirty != "" {
+		if _, err := root.Execute(ctx, "stash", "pop", "--quiet", "--index"); err != nil {
+			return fmt.Errorf("failed to restore local changes, they are kept in the stash: %w", err)
+		}
+	}
+	return rebaseErr
+}
+
+// rebaseOnto moves the commits after upstream onto newBase, aborting the
+// rebase if it stops
+func (e *GitExecutor) rebaseOnto(ctx context.Context, newBase, upstream string) error {
+	if _, err := e.Execute(ctx, "rebase", "--quiet", "--rebase-merges", "--onto", newBase, upstream); err != nil {
+		if _, abortErr := e.Execute(ctx, "rebase", "--abort"); abortErr != nil {
+			return fmt.Errorf("failed to rebase onto the reworded commit: %w; aborting the rebase failed: %v", err, abortErr)
+		}
+		return fmt.Errorf("failed to rebase onto the reworded commit, nothing was changed: %w", err)
+	}
+	return nil
+}
+
+// rewriteMessage creates a copy of commit with a new message and returns its id
+func (e *GitExecutor) rewriteMessage(ctx context.Context, commit, message string) (string, error) {
+	info, err := e.executeRaw(ctx, "log", "-1", "--date=raw", "--format=%T%x00%P%x00%an%x00%ae%x00%ad", commit)
+	if err != nil {
+		return "", fmt.Errorf("failed to read commit %s: %w", commit, err)
+	}
+	fields := strings.Split(strings.TrimSuffix(string(info), "\n"), "\x00")
+	if len(fields) != 5 {
+		return "", fmt.Errorf("failed to read commit %s", commit)
+	}
+
+	args := []string{"commit-tree", fields[0]}
+	for _, parent := range strings.Fields(fields[1]) {
+		args = append(args, "-p", parent)
+	}
+
+	var id string
+	err = withMessageFile(message, func(path string) error {
+		cmd := e.command(ctx, append(args, "-F", path)...)
+		cmd.Env = append(cmd.Env,
+			"GIT_AUTHOR_NAME="+fields[2],
+			"GIT_AUTHOR_EMAIL="+fields[3],
+			"GIT_AUTHOR_DATE="+fields[4],
+		)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("failed to create the reworded commit: %w: %s", err, string(output))
+		}
+		id = strings.TrimSpace(string(output))
+		return nil
+	})
+	return id, err
+}
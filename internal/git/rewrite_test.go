@@ -0,0 +1,178 @@
+package git
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-coders/git_gpt/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func commitFile(t *testing.T, e *GitExecutor, dir, name, content, message string) {
+	t.Helper()
+	writeFile(t, dir, name, content)
+	mustGit(t, e, "add", name)
+	mustGit(t, e, "commit", "-q", "-m", message)
+}
+
+func TestParentOf(t *testing.T) {
+	e, dir := newTestRepo(t)
+	ctx := context.Background()
+
+	base, err := e.ParentOf(ctx, "HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, emptyTree, base)
+
+	first, err := e.Execute(ctx, "rev-parse", "HEAD")
+	require.NoError(t, err)
+	commitFile(t, e, dir, "b.txt", "b\n", "second")
+
+	base, err = e.ParentOf(ctx, "HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, first, base)
+
+	_, err = e.ParentOf(ctx, "no-such-rev")
+	assert.ErrorContains(t, err, "unknown commit")
+}
+
+func TestGetRangeChanges(t *testing.T) {
+	e, dir := newTestRepo(t)
+	ctx := context.Background()
+
+	writeFile(t, dir, "long.txt", "one\ntwo\nthree\nfour\nfive\n")
+	mustGit(t, e, "add", "long.txt")
+	mustGit(t, e, "commit", "-q", "-m", "second")
+	base, err := e.ParentOf(ctx, "HEAD")
+	require.NoError(t, err)
+
+	// The staged rename and edit are combined with what HEAD added
+	mustGit(t, e, "mv", "long.txt", "renamed.txt")
+	writeFile(t, dir, "a.txt", "one\nmore\n")
+	mustGit(t, e, "add", "a.txt")
+
+	changes, err := e.GetRangeChanges(ctx, base, "")
+	require.NoError(t, err)
+	assert.Equal(t, []common.FileChange{
+		{Path: "a.txt", Status: "modified", Additions: 1},
+		{Path: "renamed.txt", Status: "added", Additions: 5},
+	}, changes)
+
+	changes, err = e.GetRangeChanges(ctx, base, "HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, []common.FileChange{{Path: "long.txt", Status: "added", Additions: 5}}, changes)
+
+	diff, err := e.GetRangeDiff(ctx, base, "", "renamed.txt")
+	require.NoError(t, err)
+	assert.Contains(t, diff, "+more")
+	assert.NotContains(t, diff, "renamed.txt")
+
+	stat, err := e.GetRangeDiffStat(ctx, base, "HEAD")
+	require.NoError(t, err)
+	assert.Contains(t, stat, "long.txt")
+}
+
+func TestRemoteBranchesContaining(t *testing.T) {
+	e, dir := newTestRepo(t)
+	ctx := context.Background()
+
+	mustGit(t, e, "update-ref", "refs/remotes/origin/main", "HEAD")
+	mustGit(t, e, "symbolic-ref", "refs/remotes/origin/HEAD", "refs/remotes/origin/main")
+	commitFile(t, e, dir, "b.txt", "b\n", "local only")
+
+	branches, err := e.RemoteBranchesContaining(ctx, "HEAD~1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"origin/main"}, branches)
+
+	branches, err = e.RemoteBranchesContaining(ctx, "HEAD")
+	require.NoError(t, err)
+	assert.Empty(t, branches)
+}
+
+func TestAmend(t *testing.T) {
+	e, dir := newTestRepo(t)
+	ctx := context.Background()
+
+	writeFile(t, dir, "b.txt", "b\n")
+	mustGit(t, e, "add", "b.txt")
+	require.NoError(t, e.Amend(ctx, "feat: add a and b\n\nBoth files at once."))
+
+	count, err := e.Execute(ctx, "rev-list", "--count", "HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, "1", count)
+	message, err := e.Execute(ctx, "log", "-1", "--format=%B")
+	require.NoError(t, err)
+	assert.Equal(t, "feat: add a and b\n\nBoth files at once.", message)
+	files, err := e.Execute(ctx, "ls-tree", "--name-only", "HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, "a.txt\nb.txt", files)
+}
+
+func TestReword_RebasesLaterCommits(t *testing.T) {
+	e, dir := newTestRepo(t)
+	ctx := context.Background()
+
+	mustGit(t, e, "-c", "user.name=Original", "-c", "user.email=orig@example.com",
+		"commit", "-q", "--allow-empty", "-m", "wip")
+	commitFile(t, e, dir, "b.txt", "b\n", "third")
+	commitFile(t, e, dir, "c.txt", "c\n", "fourth")
+	tree, err := e.Execute(ctx, "rev-parse", "HEAD^{tree}")
+	require.NoError(t, err)
+
+	// Local changes survive the rebase
+	writeFile(t, dir, "a.txt", "dirty\n")
+	writeFile(t, dir, "b.txt", "staged\n")
+	mustGit(t, e, "add", "b.txt")
+
+	require.NoError(t, e.Reword(ctx, "HEAD~2", "chore: describe the work\n\nRefs: #1"))
+
+	subjects, err := e.RecentSubjects(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fourth", "third", "chore: describe the work", "first"}, subjects)
+
+	author, err := e.Execute(ctx, "log", "-1", "--format=%an <%ae>", "HEAD~2")
+	require.NoError(t, err)
+	assert.Equal(t, "Original <orig@example.com>", author)
+	message, err := e.Execute(ctx, "log", "-1", "--format=%B", "HEAD~2")
+	require.NoError(t, err)
+	assert.Equal(t, "chore: describe the work\n\nRefs: #1", message)
+
+	newTree, err := e.Execute(ctx, "rev-parse", "HEAD^{tree}")
+	require.NoError(t, err)
+	assert.Equal(t, tree, newTree)
+	assert.Equal(t, "dirty\n", readFile(t, dir, "a.txt"))
+	staged, err := e.Execute(ctx, "diff", "--cached", "--name-only")
+	require.NoError(t, err)
+	assert.Equal(t, "b.txt", staged)
+}
+
+func TestReword_Head(t *testing.T) {
+	e, dir := newTestRepo(t)
+	ctx := context.Background()
+
+	commitFile(t, e, dir, "b.txt", "b\n", "second")
+	writeFile(t, dir, "c.txt", "c\n")
+	mustGit(t, e, "add", "c.txt")
+
+	require.NoError(t, e.Reword(ctx, "HEAD", "feat: add b"))
+
+	subjects, err := e.RecentSubjects(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"feat: add b", "first"}, subjects)
+	// The staged file stays staged and out of the commit
+	staged, err := e.Execute(ctx, "diff", "--cached", "--name-only")
+	require.NoError(t, err)
+	assert.Equal(t, "c.txt", staged)
+}
+
+func TestReword_NotOnBranch(t *testing.T) {
+	e, dir := newTestRepo(t)
+	ctx := context.Background()
+
+	mustGit(t, e, "checkout", "-q", "-b", "topic")
+	commitFile(t, e, dir, "b.txt", "b\n", "topic work")
+	mustGit(t, e, "checkout", "-q", "main")
+
+	err := e.Reword(ctx, "topic", "feat: new message")
+	assert.ErrorContains(t, err, "not on the current branch")
+}
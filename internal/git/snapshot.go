@@ -0,0 +1,234 @@
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-coders/git_gpt/internal/common"
+	"github.com/go-coders/git_gpt/pkg/apierrors"
+)
+
+// Private ref namespaces for undo points. Backups hold the state replaced by
+// an undo, so that an undo never loses work.
+const (
+	SnapshotRefPrefix = "refs/ggpt/undo/"
+	BackupRefPrefix   = "refs/ggpt/backup/"
+
+	snapshotSubject = "ggpt undo point"
+)
+
+// withSnapshotIdentity prefixes args with a committer identity. Snapshot
+// commits are written by ggpt itself and must not depend on the user's
+// identity being configured.
+func withSnapshotIdentity(args ...string) []string {
+	return append([]string{"-c", "user.name=ggpt", "-c", "user.email=ggpt@localhost"}, args...)
+}
+
+// CreateSnapshot records HEAD, the branch refs, the index and the local
+// changes under SnapshotRefPrefix before query modifies the repository
+func (e *GitExecutor) CreateSnapshot(ctx context.Context, query string) (*common.Snapshot, error) {
+	return e.createSnapshot(ctx, SnapshotRefPrefix, query)
+}
+
+// ListSnapshots returns up to limit undo points, newest first. A limit of 0
+// returns all of them.
+func (e *GitExecutor) ListSnapshots(ctx context.Context, limit int) ([]common.Snapshot, error) {
+	args := []string{"for-each-ref", "--sort=-refname", "--format=%(refname)%09%(contents:body)"}
+	if limit > 0 {
+		args = append(args, fmt.Sprintf("--count=%d", limit))
+	}
+	args = append(args, SnapshotRefPrefix)
+
+	output, err := e.Execute(ctx, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list undo points: %w", err)
+	}
+
+	var snapshots []common.Snapshot
+	for _, line := range strings.Split(output, "\n") {
+		ref, body, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+
+		var snapshot common.Snapshot
+		if err := json.Unmarshal([]byte(body), &snapshot); err != nil {
+			return nil, fmt.Errorf("invalid undo point %s: %w", ref, err)
+		}
+		snapshot.Ref = ref
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, nil
+}
+
+// Undo restores the newest undo point and removes it. The replaced state is
+// kept under BackupRefPrefix; its ref is returned.
+func (e *GitExecutor) Undo(ctx context.Context) (*common.Snapshot, string, error) {
+	snapshots, err := e.ListSnapshots(ctx, 1)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(snapshots) == 0 {
+		return nil, "", apierrors.NewNothingToUndoError()
+	}
+
+	snapshot := snapshots[0]
+	backup, err := e.createSnapshot(ctx, BackupRefPrefix, fmt.Sprintf("before undoing: %s", snapshot.Query))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to back up the current state: %w", err)
+	}
+
+	if err := e.RestoreSnapshot(ctx, &snapshot); err != nil {
+		return nil, backup.Ref, err
+	}
+
+	if _, err := e.Execute(ctx, "update-ref", "-d", snapshot.Ref); err != nil {
+		return nil, backup.Ref, fmt.Errorf("failed to remove undo point: %w", err)
+	}
+	return &snapshot, backup.Ref, nil
+}
+
+// RestoreSnapshot puts the branches, HEAD, the index and the tracked files
+// back to the recorded state. Untracked files are left alone.
+func (e *GitExecutor) RestoreSnapshot(ctx context.Context, snapshot *common.Snapshot) error {
+	current, err := e.branchRefs(ctx)
+	if err != nil {
+		return err
+	}
+
+	for ref, commit := range snapshot.Branches {
+		if current[ref] == commit {
+			continue
+		}
+		if _, err := e.Execute(ctx, "update-ref", ref, commit); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", ref, err)
+		}
+	}
+	for ref := range current {
+		if _, ok := snapshot.Branches[ref]; ok {
+			continue
+		}
+		if _, err := e.Execute(ctx, "update-ref", "-d", ref); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", ref, err)
+		}
+	}
+
+	switch {
+	case snapshot.HeadRef != "":
+		_, err = e.Execute(ctx, "symbolic-ref", "HEAD", snapshot.HeadRef)
+	case snapshot.Head != "":
+		_, err = e.Execute(ctx, "update-ref", "--no-deref", "HEAD", snapshot.Head)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to restore HEAD: %w", err)
+	}
+
+	// Check out the recorded working tree, then put the index back on its own
+	worktree := snapshot.IndexTree
+	if snapshot.Worktree != "" {
+		worktree = snapshot.Worktree + "^{tree}"
+	} else if snapshot.Head != "" {
+		worktree = snapshot.Head + "^{tree}"
+	}
+	if _, err := e.Execute(ctx, "read-tree", "--reset", "-u", worktree); err != nil {
+		return fmt.Errorf("failed to restore working tree: %w", err)
+	}
+	if _, err := e.Execute(ctx, "read-tree", snapshot.IndexTree); err != nil {
+		return fmt.Errorf("failed to restore index: %w", err)
+	}
+	return nil
+}
+
+func (e *GitExecutor) createSnapshot(ctx context.Context, prefix, query string) (*common.Snapshot, error) {
+	now := time.Now()
+	snapshot := &common.Snapshot{
+		Ref:   fmt.Sprintf("%s%019d", prefix, now.UnixNano()),
+		Query: query,
+		Time:  now.UTC().Truncate(time.Second),
+	}
+
+	// A repository without commits has neither HEAD nor local changes to stash
+	if head, err := e.Execute(ctx, "rev-parse", "--verify", "-q", "HEAD"); err == nil {
+		snapshot.Head = head
+		if snapshot.Worktree, err = e.Execute(ctx, withSnapshotIdentity("stash", "create")...); err != nil {
+			return nil, fmt.Errorf("failed to record local changes: %w", err)
+		}
+	}
+	if headRef, err := e.Execute(ctx, "symbolic-ref", "-q", "HEAD"); err == nil {
+		snapshot.HeadRef = headRef
+	}
+
+	var err error
+	if snapshot.Branches, err = e.branchRefs(ctx); err != nil {
+		return nil, err
+	}
+	if snapshot.IndexTree, err = e.Execute(ctx, "write-tree"); err != nil {
+		return nil, fmt.Errorf("failed to record index: %w", err)
+	}
+
+	body, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode undo point: %w", err)
+	}
+
+	// Every recorded commit is a parent, so it stays reachable even after the
+	// operation moves or deletes the branches that pointed to it
+	args := withSnapshotIdentity("commit-tree", snapshot.IndexTree)
+	for _, parent := range snapshotParents(snapshot) {
+		args = append(args, "-p", parent)
+	}
+	args = append(args, "-m", snapshotSubject, "-m", string(body))
+
+	commit, err := e.Execute(ctx, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write undo point: %w", err)
+	}
+	if _, err := e.Execute(ctx, "update-ref", snapshot.Ref, commit); err != nil {
+		return nil, fmt.Errorf("failed to save undo point: %w", err)
+	}
+	return snapshot, nil
+}
+
+// branchRefs maps every local branch ref to the commit it points to
+func (e *GitExecutor) branchRefs(ctx context.Context) (map[string]string, error) {
+	output, err := e.Execute(ctx, "for-each-ref", "--format=%(refname) %(objectname)", "refs/heads/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+
+	branches := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		if ref, commit, ok := strings.Cut(line, " "); ok {
+			branches[ref] = commit
+		}
+	}
+	return branches, nil
+}
+
+func snapshotParents(snapshot *common.Snapshot) []string {
+	seen := make(map[string]bool)
+	var parents []string
+	add := func(commit string) {
+		if commit != "" && !seen[commit] {
+			seen[commit] = true
+			parents = append(parents, commit)
+		}
+	}
+
+	add(snapshot.Head)
+	add(snapshot.Worktree)
+
+	refs := make([]string, 0, len(snapshot.Branches))
+	for ref := range snapshot.Branches {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+	for _, ref := range refs {
+		add(snapshot.Branches[ref])
+	}
+	return parents
+}
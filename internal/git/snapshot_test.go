@@ -0,0 +1,163 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-coders/git_gpt/pkg/apierrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRepo creates a repository with one commit and an executor for it
+func newTestRepo(t *testing.T) (*GitExecutor, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	e := NewExecutorInDir(dir)
+	ctx := context.Background()
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"config", "user.name", "Test"},
+		{"config", "user.email", "test@example.com"},
+	} {
+		_, err := e.Execute(ctx, args...)
+		require.NoError(t, err)
+	}
+
+	writeFile(t, dir, "a.txt", "one\n")
+	_, err := e.Execute(ctx, "add", "a.txt")
+	require.NoError(t, err)
+	_, err = e.Execute(ctx, "commit", "-q", "-m", "first")
+	require.NoError(t, err)
+
+	return e, dir
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+}
+
+func readFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestUndo_RestoresRepositoryState(t *testing.T) {
+	e, dir := newTestRepo(t)
+	ctx := context.Background()
+
+	// Local changes: one staged, one only in the working tree
+	writeFile(t, dir, "a.txt", "staged\n")
+	_, err := e.Execute(ctx, "add", "a.txt")
+	require.NoError(t, err)
+	writeFile(t, dir, "a.txt", "worktree\n")
+
+	head, err := e.Execute(ctx, "rev-parse", "HEAD")
+	require.NoError(t, err)
+
+	snapshot, err := e.CreateSnapshot(ctx, "start a login feature")
+	require.NoError(t, err)
+	assert.Equal(t, head, snapshot.Head)
+	assert.Equal(t, "refs/heads/main", snapshot.HeadRef)
+	assert.NotEmpty(t, snapshot.Worktree)
+
+	// The operation that is undone later
+	for _, args := range [][]string{
+		{"checkout", "-q", "-b", "feature/login"},
+		{"add", "a.txt"},
+		{"commit", "-q", "-m", "login"},
+		{"branch", "-q", "-D", "main"},
+	} {
+		_, err := e.Execute(ctx, args...)
+		require.NoError(t, err)
+	}
+
+	snapshots, err := e.ListSnapshots(ctx, 5)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+	assert.Equal(t, "start a login feature", snapshots[0].Query)
+
+	restored, backup, err := e.Undo(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "start a login feature", restored.Query)
+	assert.Contains(t, backup, BackupRefPrefix)
+
+	branch, err := e.Execute(ctx, "symbolic-ref", "HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, "refs/heads/main", branch)
+
+	current, err := e.Execute(ctx, "rev-parse", "HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, head, current)
+
+	branches, err := e.Execute(ctx, "branch", "--format=%(refname:short)")
+	require.NoError(t, err)
+	assert.Equal(t, "main", branches)
+
+	staged, err := e.Execute(ctx, "show", ":a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "staged", staged)
+	assert.Equal(t, "worktree\n", readFile(t, dir, "a.txt"))
+
+	// The undo point is used up, the replaced state is kept as a backup
+	snapshots, err = e.ListSnapshots(ctx, 0)
+	require.NoError(t, err)
+	assert.Empty(t, snapshots)
+	_, err = e.Execute(ctx, "rev-parse", "--verify", backup)
+	assert.NoError(t, err)
+}
+
+func TestUndo_RemovesFilesAddedByTheOperation(t *testing.T) {
+	e, dir := newTestRepo(t)
+	ctx := context.Background()
+
+	_, err := e.CreateSnapshot(ctx, "add a second file")
+	require.NoError(t, err)
+
+	writeFile(t, dir, "b.txt", "two\n")
+	_, err = e.Execute(ctx, "add", "b.txt")
+	require.NoError(t, err)
+	_, err = e.Execute(ctx, "commit", "-q", "-m", "second")
+	require.NoError(t, err)
+
+	_, _, err = e.Undo(ctx)
+	require.NoError(t, err)
+
+	_, err = os.Stat(filepath.Join(dir, "b.txt"))
+	assert.True(t, os.IsNotExist(err))
+
+	status, err := e.Execute(ctx, "status", "--porcelain")
+	require.NoError(t, err)
+	assert.Empty(t, status)
+}
+
+func TestUndo_NothingRecorded(t *testing.T) {
+	e, _ := newTestRepo(t)
+
+	_, _, err := e.Undo(context.Background())
+	var appErr *apierrors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, apierrors.ErrNothingToUndo, appErr.Type)
+}
+
+func TestListSnapshots_NewestFirst(t *testing.T) {
+	e, _ := newTestRepo(t)
+	ctx := context.Background()
+
+	for _, query := range []string{"first", "second", "third"} {
+		_, err := e.CreateSnapshot(ctx, query)
+		require.NoError(t, err)
+	}
+
+	snapshots, err := e.ListSnapshots(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	assert.Equal(t, "third", snapshots[0].Query)
+	assert.Equal(t, "second", snapshots[1].Query)
+}
@@ -0,0 +1,101 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-coders/git_gpt/internal/common"
+)
+
+// CommitSplit turns the staged changes into one commit per step. The index is
+// reset to HEAD and each step stages its parts before committing. The last
+// step touching a file takes the file exactly as it was staged, so together
+// the commits record the original index. The working tree is never touched.
+// If a step fails, HEAD and the index are put back as they were.
+func (e *GitExecutor) CommitSplit(ctx context.Context, steps []common.CommitStep) (err error) {
+	root, err := e.atRoot(ctx)
+	if err != nil {
+		return err
+	}
+
+	head, err := root.Execute(ctx, "rev-parse", "--verify", "-q", "HEAD")
+	if err != nil {
+		return fmt.Errorf("splitting needs at least one existing commit")
+	}
+	tree, err := root.Execute(ctx, "write-tree")
+	if err != nil {
+		return fmt.Errorf("failed to record the index: %w", err)
+	}
+
+	last := make(map[string]int)
+	for i, step := range steps {
+		for _, part := range step.Parts {
+			last[part.Diff.Path] = i
+		}
+	}
+
+	defer func() {
+		if err == nil {
+			return
+		}
+		if _, resetErr := root.Execute(ctx, "reset", "-q", "--soft", head); resetErr != nil {
+			err = fmt.Errorf("%w; restoring HEAD failed: %v", err, resetErr)
+		}
+		if _, readErr := root.Execute(ctx, "read-tree", tree); readErr != nil {
+			err = fmt.Errorf("%w; restoring the index failed: %v", err, readErr)
+		}
+	}()
+
+	if _, err = root.Execute(ctx, "read-tree", head); err != nil {
+		return fmt.Errorf("failed to reset the index: %w", err)
+	}
+
+	for i, step := range steps {
+		for _, part := range step.Parts {
+			if part.Hunks == nil || last[part.Diff.Path] == i {
+				err = root.stageFromTree(ctx, tree, part)
+			} else {
+				err = root.StageHunks(ctx, part.Diff, part.Hunks)
+			}
+			if err != nil {
+				return fmt.Errorf("commit %d: %w", i+1, err)
+			}
+		}
+		if err = root.Commit(ctx, step.Message); err != nil {
+			return fmt.Errorf("commit %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// stageFromTree copies the index entries of part from tree, removing paths
+// the tree does not have
+func (e *GitExecutor) stageFromTree(ctx context.Context, tree string, part common.CommitPart) error {
+	paths := []string{part.Diff.Path}
+	if part.OrigPath != "" {
+		paths = append(paths, part.OrigPath)
+	}
+
+	for _, path := range paths {
+		entry, err := e.Execute(ctx, "ls-tree", "--full-tree", tree, "--", path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if entry == "" {
+			_, err = e.Execute(ctx, "update-index", "--force-remove", "--", path)
+		} else {
+			// <mode> SP <type> SP <object> TAB <path>
+			info, _, _ := strings.Cut(entry, "\t")
+			fields := strings.Fields(info)
+			if len(fields) != 3 {
+				return fmt.Errorf("unexpected tree entry for %s: %s", path, entry)
+			}
+			_, err = e.Execute(ctx, "update-index", "--add", "--cacheinfo", fmt.Sprintf("%s,%s,%s", fields[0], fields[2], path))
+		}
+		if err != nil {
+			return fmt.Errorf("failed to stage %s: %w", path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package git
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-coders/git_gpt/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stagedDiff returns the staged diff of path for building commit parts
+func stagedDiff(t *testing.T, e *GitExecutor, path string) *common.FileDiff {
+	t.Helper()
+	diff, err := e.GetFileDiff(context.Background(), path, true)
+	require.NoError(t, err)
+	return diff
+}
+
+func TestCommitSplit(t *testing.T) {
+	e, dir := newTestRepo(t)
+	ctx := context.Background()
+
+	writeFile(t, dir, "code.txt", numberedLines(1, 30, nil))
+	writeFile(t, dir, "old.txt", "moved\n")
+	mustGit(t, e, "add", ".")
+	mustGit(t, e, "commit", "-q", "-m", "base")
+
+	writeFile(t, dir, "code.txt", numberedLines(1, 30, map[int]string{2: "refactor", 28: "bugfix"}))
+	writeFile(t, dir, "README.md", "docs\n")
+	mustGit(t, e, "mv", "old.txt", "new.txt")
+	mustGit(t, e, "add", ".")
+	// Unstaged work must survive untouched
+	writeFile(t, dir, "a.txt", "local edit\n")
+
+	tree, err := e.Execute(ctx, "write-tree")
+	require.NoError(t, err)
+
+	code := stagedDiff(t, e, "code.txt")
+	require.Len(t, code.Hunks, 2)
+
+	err = e.CommitSplit(ctx, []common.CommitStep{
+		{Message: "refactor: rename things", Parts: []common.CommitPart{
+			{Diff: code, Hunks: []int{0}},
+			{Diff: stagedDiff(t, e, "new.txt"), OrigPath: "old.txt"},
+		}},
+		{Message: "fix: off by one", Parts: []common.CommitPart{{Diff: code, Hunks: []int{1}}}},
+		{Message: "docs: add readme", Parts: []common.CommitPart{{Diff: stagedDiff(t, e, "README.md")}}},
+	})
+	require.NoError(t, err)
+
+	log, err := e.Execute(ctx, "log", "--format=%s", "-n", "3")
+	require.NoError(t, err)
+	assert.Equal(t, "docs: add readme\nfix: off by one\nrefactor: rename things", log)
+
+	first, err := e.Execute(ctx, "show", "--format=", "--name-status", "-M", "HEAD~2")
+	require.NoError(t, err)
+	assert.Contains(t, first, "code.txt")
+	assert.Contains(t, first, "R100\told.txt\tnew.txt")
+	firstDiff, err := e.Execute(ctx, "show", "HEAD~2", "--", "code.txt")
+	require.NoError(t, err)
+	assert.Contains(t, firstDiff, "+refactor")
+	assert.NotContains(t, firstDiff, "+bugfix")
+
+	// Together the commits hold exactly what was staged
+	final, err := e.Execute(ctx, "rev-parse", "HEAD^{tree}")
+	require.NoError(t, err)
+	assert.Equal(t, tree, final)
+	status, err := e.Execute(ctx, "status", "--porcelain")
+	require.NoError(t, err)
+	assert.Equal(t, " M a.txt", status)
+}
+
+func TestCommitSplit_RestoresOnFailure(t *testing.T) {
+	e, dir := newTestRepo(t)
+	ctx := context.Background()
+
+	writeFile(t, dir, "b.txt", "two\n")
+	writeFile(t, dir, "c.txt", "three\n")
+	mustGit(t, e, "add", ".")
+
+	head, err := e.Execute(ctx, "rev-parse", "HEAD")
+	require.NoError(t, err)
+	tree, err := e.Execute(ctx, "write-tree")
+	require.NoError(t, err)
+
+	b := stagedDiff(t, e, "b.txt")
+	err = e.CommitSplit(ctx, []common.CommitStep{
+		{Message: "add b", Parts: []common.CommitPart{{Diff: b}}},
+		// b is already committed, so this step has nothing to commit
+		{Message: "broken", Parts: []common.CommitPart{{Diff: b, Hunks: []int{0}}}},
+		{Message: "add c", Parts: []common.CommitPart{{Diff: stagedDiff(t, e, "c.txt")}}},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "commit 2")
+
+	current, err := e.Execute(ctx, "rev-parse", "HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, head, current)
+	index, err := e.Execute(ctx, "write-tree")
+	require.NoError(t, err)
+	assert.Equal(t, tree, index)
+}
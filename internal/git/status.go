@@ -0,0 +1,204 @@
+package git
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-coders/git_gpt/internal/common"
+)
+
+// numstat holds the line counts git diff --numstat reports for one path
+type numstat struct {
+	additions int
+	deletions int
+	binary    bool
+}
+
+// parseStatusV2 parses git status --porcelain=v2 -z. Each tracked entry
+// becomes a staged change when the index differs from HEAD and an unstaged
+// change when the working tree differs from the index. Unmerged and untracked
+// paths are unstaged; ignored paths and headers are skipped.
+func parseStatusV2(data []byte) (staged, unstaged []common.FileChange, err error) {
+	records := strings.Split(string(data), "\x00")
+	for i := 0; i < len(records); i++ {
+		record := records[i]
+		if record == "" {
+			continue
+		}
+
+		switch record[0] {
+		case '#', '!':
+			continue
+
+		case '?':
+			if len(record) < 3 {
+				return nil, nil, fmt.Errorf("malformed untracked entry %q", record)
+			}
+			unstaged = append(unstaged, common.FileChange{Path: record[2:], Status: "untracked"})
+
+		case '1', '2':
+			// 1 XY sub mH mI mW hH hI path
+			// 2 XY sub mH mI mW hH hI Xscore path NUL origPath
+			count := 9
+			if record[0] == '2' {
+				count = 10
+			}
+			fields := strings.SplitN(record, " ", count)
+			if len(fields) != count || len(fields[1]) != 2 {
+				return nil, nil, fmt.Errorf("malformed changed entry %q", record)
+			}
+			submodule, err := parseSubmoduleState(fields[2])
+			if err != nil {
+				return nil, nil, err
+			}
+
+			change := common.FileChange{Path: fields[count-1], Submodule: submodule}
+			if record[0] == '2' {
+				if i+1 >= len(records) {
+					return nil, nil, fmt.Errorf("missing original path for %q", record)
+				}
+				i++
+				change.OrigPath = records[i]
+			}
+
+			if x := fields[1][0]; x != '.' {
+				entry := change
+				entry.Status = getReadableStatus(x)
+				if x != 'R' && x != 'C' {
+					entry.OrigPath = ""
+				}
+				staged = append(staged, entry)
+			}
+			if y := fields[1][1]; y != '.' {
+				entry := change
+				entry.Status = getReadableStatus(y)
+				if y != 'R' && y != 'C' {
+					entry.OrigPath = ""
+				}
+				unstaged = append(unstaged, entry)
+			}
+
+		case 'u':
+			// u XY sub m1 m2 m3 mW h1 h2 h3 path
+			fields := strings.SplitN(record, " ", 11)
+			if len(fields) != 11 || len(fields[1]) != 2 {
+				return nil, nil, fmt.Errorf("malformed unmerged entry %q", record)
+			}
+			submodule, err := parseSubmoduleState(fields[2])
+			if err != nil {
+				return nil, nil, err
+			}
+			unstaged = append(unstaged, common.FileChange{
+				Path:      fields[10],
+				Status:    "unmerged",
+				Submodule: submodule,
+				Conflict: &common.ConflictStages{
+					Code:   fields[1],
+					Base:   stageObject(fields[7]),
+					Ours:   stageObject(fields[8]),
+					Theirs: stageObject(fields[9]),
+				},
+			})
+
+		default:
+			return nil, nil, fmt.Errorf("unknown status entry %q", record)
+		}
+	}
+	return staged, unstaged, nil
+}
+
+// parseSubmoduleState parses the <sub> field, N... for a plain path or
+// S<c><m><u> for a submodule
+func parseSubmoduleState(field string) (*common.SubmoduleState, error) {
+	if len(field) != 4 || (field[0] != 'N' && field[0] != 'S') {
+		return nil, fmt.Errorf("malformed submodule state %q", field)
+	}
+	if field[0] == 'N' {
+		return nil, nil
+	}
+	return &common.SubmoduleState{
+		CommitChanged: field[1] == 'C',
+		Modified:      field[2] == 'M',
+		Untracked:     field[3] == 'U',
+	}, nil
+}
+
+// stageObject drops the all-zero id git prints for a missing stage
+func stageObject(id string) string {
+	if strings.Trim(id, "0") == "" {
+		return ""
+	}
+	return id
+}
+
+// parseNumstat parses git diff --numstat -z into counts by path. Renamed and
+// copied paths are keyed by their new name.
+func parseNumstat(data []byte) (map[string]numstat, error) {
+	stats := make(map[string]numstat)
+	records := strings.Split(string(data), "\x00")
+	for i := 0; i < len(records); i++ {
+		record := records[i]
+		if record == "" {
+			continue
+		}
+
+		// add TAB del TAB path, or add TAB del TAB NUL origPath NUL path
+		fields := strings.SplitN(record, "\t", 3)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("malformed numstat entry %q", record)
+		}
+		path := fields[2]
+		if path == "" {
+			if i+2 >= len(records) || records[i+2] == "" {
+				return nil, fmt.Errorf("missing paths for numstat entry %q", record)
+			}
+			path = records[i+2]
+			i += 2
+		}
+
+		var stat numstat
+		if fields[0] == "-" && fields[1] == "-" {
+			stat.binary = true
+		} else {
+			var err error
+			if stat.additions, err = strconv.Atoi(fields[0]); err != nil {
+				return nil, fmt.Errorf("malformed numstat entry %q: %w", record, err)
+			}
+			if stat.deletions, err = strconv.Atoi(fields[1]); err != nil {
+				return nil, fmt.Errorf("malformed numstat entry %q: %w", record, err)
+			}
+		}
+		stats[path] = stat
+	}
+	return stats, nil
+}
+
+// parseNameStatus parses git diff --name-status -z. Renames and copies
+// carry their source path in OrigPath.
+func parseNameStatus(data []byte) ([]common.FileChange, error) {
+	var changes []common.FileChange
+	records := strings.Split(string(data), "\x00")
+	for i := 0; i < len(records); i++ {
+		status := records[i]
+		if status == "" {
+			continue
+		}
+		if i+1 >= len(records) || records[i+1] == "" {
+			return nil, fmt.Errorf("missing path for name-status entry %q", status)
+		}
+
+		change := common.FileChange{Status: getReadableStatus(status[0])}
+		if status[0] == 'R' || status[0] == 'C' {
+			if i+2 >= len(records) || records[i+2] == "" {
+				return nil, fmt.Errorf("missing paths for name-status entry %q", status)
+			}
+			change.OrigPath = records[i+1]
+			i++
+		}
+		change.Path = records[i+1]
+		i++
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
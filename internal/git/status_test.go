@@ -0,0 +1,223 @@
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-coders/git_gpt/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// mustGit runs a git command in the test repository
+func mustGit(t *testing.T, e *GitExecutor, args ...string) {
+	t.Helper()
+	_, err := e.Execute(context.Background(), args...)
+	require.NoError(t, err, "git %v", args)
+}
+
+func TestGetStatus_Golden(t *testing.T) {
+	testCases := []struct {
+		name  string
+		setup func(t *testing.T, e *GitExecutor, dir string)
+	}{
+		{
+			name: "basic",
+			setup: func(t *testing.T, e *GitExecutor, dir string) {
+				writeFile(t, dir, "b.txt", "two\n")
+				mustGit(t, e, "add", "b.txt")
+				mustGit(t, e, "commit", "-q", "-m", "second")
+
+				writeFile(t, dir, "a.txt", "one\nstaged\n")
+				mustGit(t, e, "add", "a.txt")
+				writeFile(t, dir, "a.txt", "one\nstaged\nunstaged\n")
+				mustGit(t, e, "rm", "-q", "b.txt")
+				writeFile(t, dir, "c.txt", "three\n")
+				mustGit(t, e, "add", "c.txt")
+				writeFile(t, dir, "untracked.txt", "new\n")
+			},
+		},
+		{
+			name: "rename",
+			setup: func(t *testing.T, e *GitExecutor, dir string) {
+				writeFile(t, dir, "old name.txt", "line 1\nline 2\nline 3\nline 4\n")
+				mustGit(t, e, "add", "old name.txt")
+				mustGit(t, e, "commit", "-q", "-m", "second")
+
+				mustGit(t, e, "mv", "old name.txt", "new name.txt")
+				writeFile(t, dir, "new name.txt", "line 1\nline 2\nline 3\nline 4\nline 5\n")
+				mustGit(t, e, "add", "new name.txt")
+			},
+		},
+		{
+			name: "paths",
+			setup: func(t *testing.T, e *GitExecutor, dir string) {
+				writeFile(t, dir, "with space.txt", "space\n")
+				writeFile(t, dir, "ünïcödé.txt", "unicode\n")
+				writeFile(t, dir, "tab\tand \"quotes\".txt", "quoted\n")
+				writeFile(t, dir, "trailing space ", "space\n")
+				mustGit(t, e, "add", ".")
+				writeFile(t, dir, "-> arrow.txt", "arrow\n")
+			},
+		},
+		{
+			name: "binary",
+			setup: func(t *testing.T, e *GitExecutor, dir string) {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "image.bin"), []byte{0, 1, 2, 0, 255}, 0644))
+				mustGit(t, e, "add", "image.bin")
+			},
+		},
+		{
+			name: "conflict",
+			setup: func(t *testing.T, e *GitExecutor, dir string) {
+				mustGit(t, e, "checkout", "-q", "-b", "other")
+				writeFile(t, dir, "a.txt", "theirs\n")
+				writeFile(t, dir, "both.txt", "theirs\n")
+				mustGit(t, e, "add", ".")
+				mustGit(t, e, "commit", "-q", "-m", "theirs")
+
+				mustGit(t, e, "checkout", "-q", "main")
+				writeFile(t, dir, "a.txt", "ours\n")
+				writeFile(t, dir, "both.txt", "ours\n")
+				mustGit(t, e, "add", ".")
+				mustGit(t, e, "commit", "-q", "-m", "ours")
+
+				_, err := e.Execute(context.Background(), "merge", "-q", "other")
+				require.Error(t, err)
+			},
+		},
+		{
+			name: "submodule",
+			setup: func(t *testing.T, e *GitExecutor, dir string) {
+				_, subDir := newTestRepo(t)
+				mustGit(t, e, "-c", "protocol.file.allow=always", "submodule", "add", "-q", subDir, "lib")
+				mustGit(t, e, "commit", "-q", "-m", "add lib")
+
+				inner := NewExecutorInDir(filepath.Join(dir, "lib"))
+				mustGit(t, inner, "-c", "user.name=Test", "-c", "user.email=test@example.com",
+					"commit", "-q", "--allow-empty", "-m", "moved")
+				writeFile(t, filepath.Join(dir, "lib"), "a.txt", "changed\n")
+				writeFile(t, filepath.Join(dir, "lib"), "extra.txt", "extra\n")
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e, dir := newTestRepo(t)
+			tc.setup(t, e, dir)
+
+			staged, unstaged, err := e.GetStatus(context.Background())
+			require.NoError(t, err)
+
+			got, err := json.MarshalIndent(map[string][]common.FileChange{
+				"staged":   staged,
+				"unstaged": unstaged,
+			}, "", "  ")
+			require.NoError(t, err)
+
+			golden := filepath.Join("testdata", "status", tc.name+".golden.json")
+			if *updateGolden {
+				require.NoError(t, os.MkdirAll(filepath.Dir(golden), 0755))
+				require.NoError(t, os.WriteFile(golden, append(got, '\n'), 0644))
+			}
+			want, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			assert.JSONEq(t, string(want), string(got))
+		})
+	}
+}
+
+func TestParseStatusV2(t *testing.T) {
+	input := "1 .M N... 100644 100644 100644 aaa aaa a.txt\x00" +
+		"2 R. N... 100644 100644 100644 bbb bbb R100 new -> name\x00old\x00" +
+		"u UU N... 100644 100644 100644 100644 c1 c2 c3 both.txt\x00" +
+		"u AU N... 000000 100644 100644 100644 0000000000000000000000000000000000000000 d2 d3 added.txt\x00" +
+		"1 .M SC.U 160000 160000 160000 eee eee lib\x00" +
+		"? dir/\x00" +
+		"! ignored.log\x00" +
+		"# branch.head main\x00"
+
+	staged, unstaged, err := parseStatusV2([]byte(input))
+	require.NoError(t, err)
+
+	assert.Equal(t, []common.FileChange{
+		{Path: "new -> name", OrigPath: "old", Status: "renamed"},
+	}, staged)
+	assert.Equal(t, []common.FileChange{
+		{Path: "a.txt", Status: "modified"},
+		{Path: "both.txt", Status: "unmerged", Conflict: &common.ConflictStages{Code: "UU", Base: "c1", Ours: "c2", Theirs: "c3"}},
+		{Path: "added.txt", Status: "unmerged", Conflict: &common.ConflictStages{Code: "AU", Ours: "d2", Theirs: "d3"}},
+		{Path: "lib", Status: "modified", Submodule: &common.SubmoduleState{CommitChanged: true, Untracked: true}},
+		{Path: "dir/", Status: "untracked"},
+	}, unstaged)
+}
+
+func TestParseStatusV2_Malformed(t *testing.T) {
+	for _, input := range []string{
+		"1 .M N... a.txt\x00",
+		"2 R. N... 100644 100644 100644 bbb bbb R100 new",
+		"1 .M X... 100644 100644 100644 aaa aaa a.txt\x00",
+		"u UU N... 100644 100644 c1 c2 c3 both.txt\x00",
+		"?\x00",
+		"z what\x00",
+	} {
+		_, _, err := parseStatusV2([]byte(input))
+		assert.Error(t, err, "%q", input)
+	}
+}
+
+func TestParseNumstat(t *testing.T) {
+	input := "3\t1\ta.txt\x00" +
+		"-\t-\timage.bin\x00" +
+		"1\t0\t\x00old name.txt\x00new name.txt\x00" +
+		"2\t2\ttab\there.txt\x00"
+
+	stats, err := parseNumstat([]byte(input))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]numstat{
+		"a.txt":         {additions: 3, deletions: 1},
+		"image.bin":     {binary: true},
+		"new name.txt":  {additions: 1},
+		"tab\there.txt": {additions: 2, deletions: 2},
+	}, stats)
+
+	for _, input := range []string{"3\ta.txt\x00", "x\t1\ta.txt\x00", "1\t0\t\x00old\x00"} {
+		_, err := parseNumstat([]byte(input))
+		assert.Error(t, err, "%q", input)
+	}
+}
+
+func FuzzParseStatusV2(f *testing.F) {
+	f.Add([]byte("1 .M N... 100644 100644 100644 aaa aaa a.txt\x00? b.txt\x00"))
+	f.Add([]byte("2 R. N... 100644 100644 100644 bbb bbb R100 new\x00old\x00"))
+	f.Add([]byte("u UU SCMU 100644 100644 100644 100644 c1 c2 c3 both.txt\x00"))
+	f.Add([]byte("# branch.oid (initial)\x00! ignored\x00"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		staged, unstaged, err := parseStatusV2(data)
+		if err != nil {
+			return
+		}
+		for _, change := range append(staged, unstaged...) {
+			if change.Status == "" {
+				t.Fatalf("change without status: %+v", change)
+			}
+		}
+	})
+}
+
+func FuzzParseNumstat(f *testing.F) {
+	f.Add([]byte("3\t1\ta.txt\x00-\t-\timage.bin\x00"))
+	f.Add([]byte("1\t0\t\x00old\x00new\x00"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		_, _ = parseNumstat(data)
+	})
+}
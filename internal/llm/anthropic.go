@@ -0,0 +1,174 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	defaultAnthropicBaseURL = "https://api.anthropic.com/v1"
+	anthropicVersion        = "2023-06-01"
+	// defaultAnthropicMaxOutput is the response limit when Config.MaxOutputTokens is not set
+	defaultAnthropicMaxOutput = 4096
+)
+
+type (
+	anthropicProvider struct {
+		httpClient *http.Client
+		config     Config
+		counter    TokenCounter
+	}
+
+	anthropicMessage struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	}
+
+	anthropicRequest struct {
+		Model       string             `json:"model"`
+		System      string             `json:"system,omitempty"`
+		Messages    []anthropicMessage `json:"messages"`
+		MaxTokens   int                `json:"max_tokens"`
+		Temperature float32            `json:"temperature,omitempty"`
+		Stream      bool               `json:"stream,omitempty"`
+	}
+
+	// anthropicStreamEvent covers the fields of the stream events we read
+	anthropicStreamEvent struct {
+		Type  string `json:"type"`
+		Delta struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"delta"`
+		Error struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+
+	anthropicResponse struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+	}
+)
+
+func newAnthropicProvider(config Config) (Provider, error) {
+	if config.APIKey == "" {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if config.BaseURL == "" {
+		config.BaseURL = defaultAnthropicBaseURL
+	}
+
+	return &anthropicProvider{
+		httpClient: http.DefaultClient,
+		config:     config,
+		// Claude tokenizes slightly denser than cl100k, and no local
+		// tokenizer is published, so estimate from the text length.
+		counter: estimateCounter{charsPerToken: 3.5},
+	}, nil
+}
+
+func (p *anthropicProvider) Complete(ctx context.Context, messages []Message) (string, error) {
+	var resp anthropicResponse
+	if err := postJSON(ctx, p.httpClient, p.url(), p.headers(), p.buildRequest(messages), &resp); err != nil {
+		return "", err
+	}
+
+	var text strings.Builder
+	for _, block := range resp.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	if text.Len() == 0 {
+		return "", ErrNoResponse
+	}
+
+	return text.String(), nil
+}
+
+func (p *anthropicProvider) Stream(ctx context.Context, messages []Message, onToken func(token string)) (string, error) {
+	req := p.buildRequest(messages)
+	req.Stream = true
+
+	resp, err := sendJSON(ctx, p.httpClient, p.url(), p.headers(), req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var text strings.Builder
+	err = readSSE(resp.Body, func(_, data string) error {
+		var event anthropicStreamEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return fmt.Errorf("failed to decode stream event: %w", err)
+		}
+
+		switch event.Type {
+		case "content_block_delta":
+			if event.Delta.Type == "text_delta" && event.Delta.Text != "" {
+				text.WriteString(event.Delta.Text)
+				onToken(event.Delta.Text)
+			}
+		case "error":
+			return fmt.Errorf("stream error: %s: %s", event.Error.Type, event.Error.Message)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if text.Len() == 0 {
+		return "", ErrNoResponse
+	}
+	return text.String(), nil
+}
+
+func (p *anthropicProvider) buildRequest(messages []Message) anthropicRequest {
+	maxOutput := p.config.MaxOutputTokens
+	if maxOutput <= 0 {
+		maxOutput = defaultAnthropicMaxOutput
+	}
+	req := anthropicRequest{
+		Model:       p.config.Model,
+		MaxTokens:   maxOutput,
+		Temperature: p.config.Temperature,
+	}
+
+	// The Messages API takes the system prompt as a separate field
+	for _, msg := range messages {
+		if msg.Role == RoleSystem {
+			req.System = msg.Content
+			continue
+		}
+		req.Messages = append(req.Messages, anthropicMessage{
+			Role:    string(msg.Role),
+			Content: msg.Content,
+		})
+	}
+
+	return req
+}
+
+func (p *anthropicProvider) url() string {
+	return strings.TrimRight(p.config.BaseURL, "/") + "/messages"
+}
+
+func (p *anthropicProvider) headers() map[string]string {
+	return map[string]string{
+		"x-api-key":         p.config.APIKey,
+		"anthropic-version": anthropicVersion,
+	}
+}
+
+func (p *anthropicProvider) CountTokens(text string) int {
+	return p.counter.CountTokens(text)
+}
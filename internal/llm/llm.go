@@ -3,16 +3,22 @@ package llm
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/http"
 	"strings"
 
-	"github.com/pkoukk/tiktoken-go"
+	"github.com/go-coders/git_gpt/internal/common"
 	"github.com/sashabaranov/go-openai"
 )
 
 var (
-	ErrExceedMaxTokens = errors.New("message tokens exceed maximum limit")
-	ErrEmptyMessage    = errors.New("message content cannot be empty")
-	ErrInvalidAPIKey   = errors.New("invalid API key")
+	ErrExceedMaxTokens   = errors.New("message tokens exceed maximum limit")
+	ErrEmptyMessage      = errors.New("message content cannot be empty")
+	ErrInvalidAPIKey     = errors.New("invalid API key")
+	ErrUnknownProvider   = errors.New("unknown LLM provider")
+	ErrNoResponse        = errors.New("no response received")
+	ErrToolsNotSupported = errors.New("provider does not support tool calling")
+	ErrNoToolCall        = errors.New("model did not call a tool")
 )
 
 const (
@@ -37,58 +43,73 @@ type (
 	}
 
 	Config struct {
+		Provider      string // openai, azure, anthropic or ollama; empty means openai
 		APIKey        string
 		BaseURL       string
+		APIVersion    string // Azure OpenAI only
+		Deployment    string // Azure OpenAI only
 		EnableHistory bool
 		MaxMessages   int // 0 means no limit
 		MaxTokens     int // 0 means no limit
-		Model         string
-		Temperature   float32
+		// MaxOutputTokens limits the response where the API requires a
+		// limit, such as Anthropic; 0 uses the provider default
+		MaxOutputTokens int
+		Model           string
+		Temperature     float32
 	}
 
 	Client struct {
-		client         *openai.Client
+		provider       Provider
 		config         Config
 		messageHistory []Message
-		tokenizer      *tiktoken.Tiktoken
 		systemMessage  string
 	}
 )
 
 func NewClient(config Config) (*Client, error) {
-	if config.APIKey == "" {
-		return nil, ErrInvalidAPIKey
-	}
-
 	if config.Temperature == 0 {
 		config.Temperature = 0.1
 	}
 
-	cfg := openai.DefaultConfig(config.APIKey)
-	cfg.BaseURL = config.BaseURL
+	provider, err := newProvider(config)
+	if err != nil {
+		return nil, err
+	}
 
-	client := &Client{
-		client:         openai.NewClientWithConfig(cfg),
+	return &Client{
+		provider:       provider,
 		config:         config,
 		messageHistory: make([]Message, 0),
+	}, nil
+}
+
+func (c *Client) Chat(ctx context.Context, content string) (string, error) {
+	if content == "" {
+		return "", ErrEmptyMessage
 	}
 
-	tkm, err := client.getTokenEncoder()
+	messages := c.prepareMessages(Message{Role: RoleUser, Content: content})
+	response, err := c.sendRequest(ctx, messages)
 	if err != nil {
-		return nil, err
+		return "", err
+	}
+
+	if c.config.EnableHistory {
+		c.updateHistory(messages, response)
 	}
-	client.tokenizer = tkm
 
-	return client, nil
+	return response, nil
 }
 
-func (c *Client) Chat(ctx context.Context, content string) (string, error) {
+// ChatStream works like Chat but passes response tokens to onToken as they
+// arrive. Use Chat for responses that must be parsed as a whole, such as JSON.
+func (c *Client) ChatStream(ctx context.Context, content string, onToken func(token string)) (string, error) {
 	if content == "" {
 		return "", ErrEmptyMessage
 	}
 
 	messages := c.prepareMessages(Message{Role: RoleUser, Content: content})
-	response, err := c.sendRequest(ctx, messages)
+	response, err := c.provider.Stream(ctx, messages, onToken)
 	if err != nil {
 		return "", err
 	}
@@ -100,6 +121,43 @@ func (c *Client) Chat(ctx context.Context, content string) (string, error) {
 	return response, nil
 }
 
+// SupportsTools reports whether the provider can call tools natively
+func (c *Client) SupportsTools() bool {
+	_, ok := c.provider.(ToolProvider)
+	return ok
+}
+
+// ChatWithTools sends content and makes the model answer by calling one of
+// tools. It returns ErrToolsNotSupported when the provider has no tool calling
+// or the server rejects the request, as servers without tool support do, and
+// ErrNoToolCall when the model answers without calling a tool.
+func (c *Client) ChatWithTools(ctx context.Context, content string, tools []common.Tool) (*common.ToolCall, error) {
+	if content == "" {
+		return nil, ErrEmptyMessage
+	}
+
+	provider, ok := c.provider.(ToolProvider)
+	if !ok {
+		return nil, ErrToolsNotSupported
+	}
+
+	messages := c.prepareMessages(Message{Role: RoleUser, Content: content})
+	call, err := provider.CompleteWithTools(ctx, messages, tools)
+	if err != nil {
+		if rejectsTools(err) {
+			return nil, fmt.Errorf("%w: %v", ErrToolsNotSupported, err)
+		}
+		return nil, err
+	}
+
+	// History only holds plain messages, so the call is recorded as text
+	if c.config.EnableHistory {
+		c.updateHistory(messages, fmt.Sprintf("%s %s", call.Name, call.Arguments))
+	}
+
+	return call, nil
+}
+
 func (c *Client) prepareMessages(newMessage Message) []Message {
 	// Calculate available tokens
 	totalAvailable := c.calculateAvailableTokens()
@@ -177,16 +235,25 @@ func (c *Client) buildHistoryMessages(messages []Message, newMessage Message) []
 }
 
 func (c *Client) truncateMessage(msg Message, maxTokens int) Message {
-	tokens := c.tokenizer.Encode(msg.Content, nil, nil)
-	if len(tokens) <= maxTokens {
+	if c.provider.CountTokens(msg.Content) <= maxTokens {
 		return msg
 	}
 
 	const suffix = "..."
-	suffixTokens := len(c.tokenizer.Encode(suffix, nil, nil))
-	availableTokens := maxTokens - suffixTokens
-
-	truncated := c.tokenizer.Decode(tokens[:availableTokens])
+	availableTokens := maxTokens - c.provider.CountTokens(suffix)
+
+	// Find the longest prefix that fits; token counts grow with the prefix
+	runes := []rune(msg.Content)
+	low, high := 0, len(runes)
+	for low < high {
+		mid := (low + high + 1) / 2
+		if c.provider.CountTokens(string(runes[:mid])) <= availableTokens {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+	truncated := string(runes[:low])
 
 	// Find best breaking point
 	for _, breakPoint := range []struct {
@@ -211,11 +278,22 @@ func (c *Client) truncateMessage(msg Message, maxTokens int) Message {
 	}
 }
 
+// CountTokens returns how many tokens content takes for the configured model
+func (c *Client) CountTokens(content string) int {
+	return c.provider.CountTokens(content)
+}
+
+// AvailableTokens returns how many tokens a prompt may use, leaving room for
+// the response. It returns 0 when MaxTokens is not set.
+func (c *Client) AvailableTokens() int {
+	return c.calculateAvailableTokens()
+}
+
 // Helper methods...
 func (c *Client) countTokens(messages []Message) int {
 	tokens := 0
 	for _, msg := range messages {
-		tokens += len(c.tokenizer.Encode(msg.Content, nil, nil)) + 4
+		tokens += c.provider.CountTokens(msg.Content) + 4
 	}
 	return tokens
 }
@@ -256,41 +334,8 @@ func (c *Client) buildMessageCountLimited(messages []Message, newMessage Message
 	return result
 }
 
-func (c *Client) getTokenEncoder() (*tiktoken.Tiktoken, error) {
-	tkm, err := tiktoken.EncodingForModel(c.config.Model)
-	if err != nil {
-		// Fallback to cl100k_base encoding (used by GPT-3.5 and GPT-4)
-		tkm, err = tiktoken.GetEncoding("cl100k_base")
-		if err != nil {
-			return nil, err
-		}
-	}
-	return tkm, nil
-}
-
 func (c *Client) sendRequest(ctx context.Context, messages []Message) (string, error) {
-	openAIMessages := make([]openai.ChatCompletionMessage, len(messages))
-	for i, msg := range messages {
-		openAIMessages[i] = openai.ChatCompletionMessage{
-			Role:    string(msg.Role),
-			Content: msg.Content,
-		}
-	}
-
-	resp, err := c.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
-		Model:       c.config.Model,
-		Messages:    openAIMessages,
-		Temperature: c.config.Temperature,
-	})
-	if err != nil {
-		return "", err
-	}
-
-	if len(resp.Choices) == 0 {
-		return "", errors.New("no response received")
-	}
-
-	return resp.Choices[0].Message.Content, nil
+	return c.provider.Complete(ctx, messages)
 }
 
 func (c *Client) updateHistory(messages []Message, response string) {
@@ -318,3 +363,23 @@ func (c *Client) ClearHistory() {
 func (c *Client) SetSystemMessage(message string) {
 	c.systemMessage = message
 }
+
+// rejectsTools reports whether a failed tool request may succeed without the
+// tools: compatible servers answer tools and tool_choice they do not know
+// with a client error. Authentication and rate limits fail either way.
+func rejectsTools(err error) bool {
+	var status int
+	var apiErr *APIError
+	var openaiErr *openai.APIError
+	var requestErr *openai.RequestError
+	switch {
+	case errors.As(err, &apiErr):
+		status = apiErr.StatusCode
+	case errors.As(err, &openaiErr):
+		status = openaiErr.HTTPStatusCode
+	case errors.As(err, &requestErr):
+		status = requestErr.HTTPStatusCode
+	}
+	return status == http.StatusBadRequest || status == http.StatusNotFound ||
+		status == http.StatusUnprocessableEntity || status == http.StatusNotImplemented
+}
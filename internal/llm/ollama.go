@@ -0,0 +1,129 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const defaultOllamaBaseURL = "http://localhost:11434"
+
+type (
+	ollamaProvider struct {
+		httpClient *http.Client
+		config     Config
+		counter    TokenCounter
+	}
+
+	ollamaMessage struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	}
+
+	ollamaRequest struct {
+		Model    string          `json:"model"`
+		Messages []ollamaMessage `json:"messages"`
+		Stream   bool            `json:"stream"`
+		Options  ollamaOptions   `json:"options"`
+	}
+
+	ollamaOptions struct {
+		Temperature float32 `json:"temperature"`
+	}
+
+	ollamaResponse struct {
+		Message ollamaMessage `json:"message"`
+		Done    bool          `json:"done"`
+		Error   string        `json:"error,omitempty"`
+	}
+)
+
+func newOllamaProvider(config Config) (Provider, error) {
+	if config.BaseURL == "" {
+		config.BaseURL = defaultOllamaBaseURL
+	}
+
+	return &ollamaProvider{
+		httpClient: http.DefaultClient,
+		config:     config,
+		// Local models use many different tokenizers, so a length based
+		// estimate is the only portable option.
+		counter: estimateCounter{charsPerToken: 4},
+	}, nil
+}
+
+func (p *ollamaProvider) Complete(ctx context.Context, messages []Message) (string, error) {
+	var resp ollamaResponse
+	if err := postJSON(ctx, p.httpClient, p.url(), nil, p.buildRequest(messages, false), &resp); err != nil {
+		return "", err
+	}
+
+	if resp.Message.Content == "" {
+		return "", ErrNoResponse
+	}
+	return resp.Message.Content, nil
+}
+
+// Stream reads Ollama's newline-delimited JSON stream
+func (p *ollamaProvider) Stream(ctx context.Context, messages []Message, onToken func(token string)) (string, error) {
+	resp, err := sendJSON(ctx, p.httpClient, p.url(), nil, p.buildRequest(messages, true))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var text strings.Builder
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var chunk ollamaResponse
+		if err := decoder.Decode(&chunk); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return "", fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+
+		if chunk.Error != "" {
+			return "", fmt.Errorf("stream error: %s", chunk.Error)
+		}
+		if chunk.Message.Content != "" {
+			text.WriteString(chunk.Message.Content)
+			onToken(chunk.Message.Content)
+		}
+		if chunk.Done {
+			break
+		}
+	}
+
+	if text.Len() == 0 {
+		return "", ErrNoResponse
+	}
+	return text.String(), nil
+}
+
+func (p *ollamaProvider) buildRequest(messages []Message, stream bool) ollamaRequest {
+	req := ollamaRequest{
+		Model:   p.config.Model,
+		Stream:  stream,
+		Options: ollamaOptions{Temperature: p.config.Temperature},
+	}
+	for _, msg := range messages {
+		req.Messages = append(req.Messages, ollamaMessage{
+			Role:    string(msg.Role),
+			Content: msg.Content,
+		})
+	}
+	return req
+}
+
+func (p *ollamaProvider) url() string {
+	return strings.TrimRight(p.config.BaseURL, "/") + "/api/chat"
+}
+
+func (p *ollamaProvider) CountTokens(text string) int {
+	return p.counter.CountTokens(text)
+}
@@ -0,0 +1,198 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/go-coders/git_gpt/internal/common"
+	"github.com/pkoukk/tiktoken-go"
+	"github.com/sashabaranov/go-openai"
+)
+
+const defaultAzureAPIVersion = "2024-06-01"
+
+type (
+	openAIProvider struct {
+		client  *openai.Client
+		config  Config
+		counter TokenCounter
+	}
+
+	tiktokenCounter struct {
+		tokenizer *tiktoken.Tiktoken
+	}
+)
+
+func newOpenAIProvider(config Config) (Provider, error) {
+	if config.APIKey == "" {
+		return nil, ErrInvalidAPIKey
+	}
+
+	cfg := openai.DefaultConfig(config.APIKey)
+	if config.BaseURL != "" {
+		cfg.BaseURL = config.BaseURL
+	}
+
+	return &openAIProvider{
+		client:  openai.NewClientWithConfig(cfg),
+		config:  config,
+		counter: newTiktokenCounter(config.Model),
+	}, nil
+}
+
+func newAzureProvider(config Config) (Provider, error) {
+	if config.APIKey == "" {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if config.BaseURL == "" {
+		return nil, errors.New("azure provider requires the resource endpoint as base URL")
+	}
+
+	cfg := openai.DefaultAzureConfig(config.APIKey, config.BaseURL)
+	cfg.APIVersion = defaultAzureAPIVersion
+	if config.APIVersion != "" {
+		cfg.APIVersion = config.APIVersion
+	}
+	if config.Deployment != "" {
+		cfg.AzureModelMapperFunc = func(string) string {
+			return config.Deployment
+		}
+	}
+
+	return &openAIProvider{
+		client:  openai.NewClientWithConfig(cfg),
+		config:  config,
+		counter: newTiktokenCounter(config.Model),
+	}, nil
+}
+
+func (p *openAIProvider) Complete(ctx context.Context, messages []Message) (string, error) {
+	resp, err := p.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model:       p.config.Model,
+		Messages:    toOpenAIMessages(messages),
+		Temperature: p.config.Temperature,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if len(resp.Choices) == 0 {
+		return "", ErrNoResponse
+	}
+
+	return resp.Choices[0].Message.Content, nil
+}
+
+func (p *openAIProvider) Stream(ctx context.Context, messages []Message, onToken func(token string)) (string, error) {
+	stream, err := p.client.CreateChatCompletionStream(ctx, openai.ChatCompletionRequest{
+		Model:       p.config.Model,
+		Messages:    toOpenAIMessages(messages),
+		Temperature: p.config.Temperature,
+		Stream:      true,
+	})
+	if err != nil {
+		return "", err
+	}
+	defer stream.Close()
+
+	var response strings.Builder
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+		token := chunk.Choices[0].Delta.Content
+		response.WriteString(token)
+		onToken(token)
+	}
+
+	if response.Len() == 0 {
+		return "", ErrNoResponse
+	}
+	return response.String(), nil
+}
+
+func (p *openAIProvider) CompleteWithTools(ctx context.Context, messages []Message, tools []common.Tool) (*common.ToolCall, error) {
+	resp, err := p.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model:       p.config.Model,
+		Messages:    toOpenAIMessages(messages),
+		Temperature: p.config.Temperature,
+		Tools:       toOpenAITools(tools),
+		ToolChoice:  "required",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, ErrNoResponse
+	}
+
+	calls := resp.Choices[0].Message.ToolCalls
+	if len(calls) == 0 {
+		return nil, ErrNoToolCall
+	}
+
+	return &common.ToolCall{
+		Name:      calls[0].Function.Name,
+		Arguments: calls[0].Function.Arguments,
+	}, nil
+}
+
+func (p *openAIProvider) CountTokens(text string) int {
+	return p.counter.CountTokens(text)
+}
+
+func toOpenAIMessages(messages []Message) []openai.ChatCompletionMessage {
+	openAIMessages := make([]openai.ChatCompletionMessage, len(messages))
+	for i, msg := range messages {
+		openAIMessages[i] = openai.ChatCompletionMessage{
+			Role:    string(msg.Role),
+			Content: msg.Content,
+		}
+	}
+	return openAIMessages
+}
+
+func toOpenAITools(tools []common.Tool) []openai.Tool {
+	openAITools := make([]openai.Tool, len(tools))
+	for i, tool := range tools {
+		openAITools[i] = openai.Tool{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+			},
+		}
+	}
+	return openAITools
+}
+
+// newTiktokenCounter picks the model's encoding, falling back to cl100k_base
+// (used by GPT-3.5 and GPT-4) and then to a length based estimate when the
+// encoding cannot be loaded, for example when offline.
+func newTiktokenCounter(model string) TokenCounter {
+	tkm, err := tiktoken.EncodingForModel(model)
+	if err != nil {
+		tkm, err = tiktoken.GetEncoding("cl100k_base")
+		if err != nil {
+			return estimateCounter{charsPerToken: 4}
+		}
+	}
+	return tiktokenCounter{tokenizer: tkm}
+}
+
+func (c tiktokenCounter) CountTokens(text string) int {
+	return len(c.tokenizer.Encode(text, nil, nil))
+}
@@ -0,0 +1,162 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-coders/git_gpt/internal/common"
+)
+
+type (
+	// Provider sends a prepared conversation to a model backend. Each provider
+	// also knows how to estimate token usage for its models.
+	Provider interface {
+		Complete(ctx context.Context, messages []Message) (string, error)
+		// Stream passes response tokens to onToken as they arrive and
+		// returns the complete response
+		Stream(ctx context.Context, messages []Message, onToken func(token string)) (string, error)
+		TokenCounter
+	}
+
+	TokenCounter interface {
+		CountTokens(text string) int
+	}
+
+	// ToolProvider is implemented by providers with native tool calling. The
+	// model must answer by calling exactly one of the tools.
+	ToolProvider interface {
+		CompleteWithTools(ctx context.Context, messages []Message, tools []common.Tool) (*common.ToolCall, error)
+	}
+
+	providerFactory func(config Config) (Provider, error)
+
+	// estimateCounter approximates token usage from the text length. It is the
+	// fallback when no tokenizer is available for a model.
+	estimateCounter struct {
+		charsPerToken float64
+	}
+)
+
+var providers = map[string]providerFactory{
+	"openai":    newOpenAIProvider,
+	"azure":     newAzureProvider,
+	"anthropic": newAnthropicProvider,
+	"ollama":    newOllamaProvider,
+}
+
+func newProvider(config Config) (Provider, error) {
+	name := config.Provider
+	if name == "" {
+		name = "openai"
+	}
+
+	factory, ok := providers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+	}
+	return factory(config)
+}
+
+func (c estimateCounter) CountTokens(text string) int {
+	return int(math.Ceil(float64(utf8.RuneCountInString(text)) / c.charsPerToken))
+}
+
+// postJSON sends body as JSON and decodes a successful response into out
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body, out interface{}) error {
+	resp, err := sendJSON(ctx, client, url, headers, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// sendJSON posts body as JSON and returns the response when it succeeded.
+// The caller must close the response body.
+func sendJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body interface{}) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(data)}
+	}
+	return resp, nil
+}
+
+// readSSE calls handle for every event of a server-sent events stream
+func readSSE(r io.Reader, handle func(event, data string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var event string
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if len(data) > 0 {
+				if err := handle(event, strings.Join(data, "\n")); err != nil {
+					return err
+				}
+			}
+			event, data = "", nil
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if len(data) > 0 {
+		return handle(event, strings.Join(data, "\n"))
+	}
+	return nil
+}
+
+// APIError is returned when a provider answers with a non-success status
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("request failed with status %d: %s", e.StatusCode, e.Body)
+}
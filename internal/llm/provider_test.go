@@ -0,0 +1,360 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-coders/git_gpt/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStandIn starts a server that records the request and replies with reply
+func newStandIn(t *testing.T, status int, reply string, check func(r *http.Request, body map[string]interface{})) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		check(r, body)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(reply))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+var testMessages = []Message{
+	{Role: RoleSystem, Content: "be brief"},
+	{Role: RoleUser, Content: "hello"},
+}
+
+func TestOpenAIProvider(t *testing.T) {
+	server := newStandIn(t, http.StatusOK,
+		`{"choices":[{"index":0,"message":{"role":"assistant","content":"hi there"}}]}`,
+		func(r *http.Request, body map[string]interface{}) {
+			assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+			assert.Equal(t, "Bearer sk-test", r.Header.Get("Authorization"))
+			assert.Equal(t, "gpt-4o", body["model"])
+			assert.Len(t, body["messages"], 2)
+		})
+
+	provider, err := newProvider(Config{Provider: "openai", APIKey: "sk-test", BaseURL: server.URL + "/v1", Model: "gpt-4o"})
+	require.NoError(t, err)
+
+	reply, err := provider.Complete(context.Background(), testMessages)
+	require.NoError(t, err)
+	assert.Equal(t, "hi there", reply)
+}
+
+func TestAzureProvider(t *testing.T) {
+	server := newStandIn(t, http.StatusOK,
+		`{"choices":[{"index":0,"message":{"role":"assistant","content":"hi from azure"}}]}`,
+		func(r *http.Request, body map[string]interface{}) {
+			assert.Equal(t, "/openai/deployments/my-gpt4/chat/completions", r.URL.Path)
+			assert.Equal(t, "2024-02-01", r.URL.Query().Get("api-version"))
+			assert.Equal(t, "az-key", r.Header.Get("api-key"))
+		})
+
+	provider, err := newProvider(Config{
+		Provider:   "azure",
+		APIKey:     "az-key",
+		BaseURL:    server.URL,
+		Model:      "gpt-4o",
+		Deployment: "my-gpt4",
+		APIVersion: "2024-02-01",
+	})
+	require.NoError(t, err)
+
+	reply, err := provider.Complete(context.Background(), testMessages)
+	require.NoError(t, err)
+	assert.Equal(t, "hi from azure", reply)
+}
+
+func TestAnthropicProvider(t *testing.T) {
+	server := newStandIn(t, http.StatusOK,
+		`{"content":[{"type":"text","text":"hi "},{"type":"text","text":"from claude"}],"stop_reason":"end_turn"}`,
+		func(r *http.Request, body map[string]interface{}) {
+			assert.Equal(t, "/v1/messages", r.URL.Path)
+			assert.Equal(t, "ant-key", r.Header.Get("x-api-key"))
+			assert.Equal(t, anthropicVersion, r.Header.Get("anthropic-version"))
+			assert.Equal(t, "be brief", body["system"])
+			assert.EqualValues(t, defaultAnthropicMaxOutput, body["max_tokens"])
+
+			messages, _ := body["messages"].([]interface{})
+			if assert.Len(t, messages, 1) {
+				assert.Equal(t, "user", messages[0].(map[string]interface{})["role"])
+			}
+		})
+
+	provider, err := newProvider(Config{Provider: "anthropic", APIKey: "ant-key", BaseURL: server.URL + "/v1", Model: "claude"})
+	require.NoError(t, err)
+
+	reply, err := provider.Complete(context.Background(), testMessages)
+	require.NoError(t, err)
+	assert.Equal(t, "hi from claude", reply)
+}
+
+func TestAnthropicProvider_MaxOutputTokens(t *testing.T) {
+	server := newStandIn(t, http.StatusOK,
+		`{"content":[{"type":"text","text":"a long answer"}],"stop_reason":"end_turn"}`,
+		func(r *http.Request, body map[string]interface{}) {
+			assert.EqualValues(t, 8192, body["max_tokens"])
+		})
+
+	provider, err := newProvider(Config{Provider: "anthropic", APIKey: "ant-key", BaseURL: server.URL, Model: "claude", MaxOutputTokens: 8192})
+	require.NoError(t, err)
+
+	_, err = provider.Complete(context.Background(), testMessages)
+	require.NoError(t, err)
+}
+
+func TestAnthropicProvider_Error(t *testing.T) {
+	server := newStandIn(t, http.StatusUnauthorized,
+		`{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`,
+		func(r *http.Request, body map[string]interface{}) {})
+
+	provider, err := newProvider(Config{Provider: "anthropic", APIKey: "bad", BaseURL: server.URL, Model: "claude"})
+	require.NoError(t, err)
+
+	_, err = provider.Complete(context.Background(), testMessages)
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+	assert.Contains(t, apiErr.Error(), "invalid x-api-key")
+}
+
+func TestOllamaProvider(t *testing.T) {
+	server := newStandIn(t, http.StatusOK,
+		`{"model":"llama3.1","message":{"role":"assistant","content":"hi from llama"},"done":true}`,
+		func(r *http.Request, body map[string]interface{}) {
+			assert.Equal(t, "/api/chat", r.URL.Path)
+			assert.Equal(t, false, body["stream"])
+			assert.Equal(t, "llama3.1", body["model"])
+			assert.Len(t, body["messages"], 2)
+		})
+
+	// Ollama runs locally and needs no API key
+	provider, err := newProvider(Config{Provider: "ollama", BaseURL: server.URL, Model: "llama3.1"})
+	require.NoError(t, err)
+
+	reply, err := provider.Complete(context.Background(), testMessages)
+	require.NoError(t, err)
+	assert.Equal(t, "hi from llama", reply)
+}
+
+func TestNewProvider_Validation(t *testing.T) {
+	testCases := []struct {
+		name   string
+		config Config
+		err    error
+	}{
+		{name: "unknown provider", config: Config{Provider: "bard", APIKey: "k"}, err: ErrUnknownProvider},
+		{name: "openai without key", config: Config{Provider: "openai"}, err: ErrInvalidAPIKey},
+		{name: "anthropic without key", config: Config{Provider: "anthropic"}, err: ErrInvalidAPIKey},
+		{name: "azure without key", config: Config{Provider: "azure", BaseURL: "http://x"}, err: ErrInvalidAPIKey},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := newProvider(tc.config)
+			assert.ErrorIs(t, err, tc.err)
+		})
+	}
+}
+
+func TestClient_TruncatesWithEstimateCounter(t *testing.T) {
+	client, err := NewClient(Config{Provider: "ollama", Model: "llama3.1", MaxTokens: 400})
+	require.NoError(t, err)
+
+	long := Message{Role: RoleUser, Content: strings.Repeat("word ", 1000)}
+	truncated := client.truncateMessage(long, 100)
+
+	assert.LessOrEqual(t, client.provider.CountTokens(truncated.Content), 100)
+	assert.True(t, strings.HasSuffix(truncated.Content, "..."))
+}
+
+// newStreamStandIn starts a server that writes the given chunks, flushing after each
+func newStreamStandIn(t *testing.T, contentType string, chunks []string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, true, body["stream"])
+
+		w.Header().Set("Content-Type", contentType)
+		for _, chunk := range chunks {
+			_, _ = w.Write([]byte(chunk))
+			w.(http.Flusher).Flush()
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestProviders_Stream(t *testing.T) {
+	testCases := []struct {
+		name        string
+		provider    string
+		path        string
+		contentType string
+		chunks      []string
+	}{
+		{
+			name:        "openai",
+			provider:    "openai",
+			path:        "/v1",
+			contentType: "text/event-stream",
+			chunks: []string{
+				"data: {\"choices\":[{\"index\":0,\"delta\":{\"role\":\"assistant\",\"content\":\"\"}}]}\n\n",
+				"data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"hello \"}}]}\n\n",
+				"data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"world\"}}]}\n\n",
+				"data: [DONE]\n\n",
+			},
+		},
+		{
+			name:        "anthropic",
+			provider:    "anthropic",
+			path:        "/v1",
+			contentType: "text/event-stream",
+			chunks: []string{
+				"event: message_start\ndata: {\"type\":\"message_start\",\"message\":{}}\n\n",
+				"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"hello \"}}\n\n",
+				"event: ping\ndata: {\"type\":\"ping\"}\n\n",
+				"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"world\"}}\n\n",
+				"event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n",
+			},
+		},
+		{
+			name:        "ollama",
+			provider:    "ollama",
+			contentType: "application/x-ndjson",
+			chunks: []string{
+				"{\"message\":{\"role\":\"assistant\",\"content\":\"hello \"},\"done\":false}\n",
+				"{\"message\":{\"role\":\"assistant\",\"content\":\"world\"},\"done\":false}\n",
+				"{\"message\":{\"role\":\"assistant\",\"content\":\"\"},\"done\":true}\n",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newStreamStandIn(t, tc.contentType, tc.chunks)
+			provider, err := newProvider(Config{Provider: tc.provider, APIKey: "key", BaseURL: server.URL + tc.path, Model: "m"})
+			require.NoError(t, err)
+
+			var tokens []string
+			reply, err := provider.Stream(context.Background(), testMessages, func(token string) {
+				tokens = append(tokens, token)
+			})
+			require.NoError(t, err)
+			assert.Equal(t, "hello world", reply)
+			assert.Equal(t, []string{"hello ", "world"}, tokens)
+		})
+	}
+}
+
+func TestAnthropicProvider_StreamError(t *testing.T) {
+	server := newStreamStandIn(t, "text/event-stream", []string{
+		"event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n",
+	})
+	provider, err := newProvider(Config{Provider: "anthropic", APIKey: "key", BaseURL: server.URL, Model: "m"})
+	require.NoError(t, err)
+
+	_, err = provider.Stream(context.Background(), testMessages, func(string) {})
+	assert.ErrorContains(t, err, "overloaded_error")
+}
+
+func TestClient_ChatStreamKeepsHistory(t *testing.T) {
+	server := newStreamStandIn(t, "application/x-ndjson", []string{
+		"{\"message\":{\"role\":\"assistant\",\"content\":\"streamed\"},\"done\":true}\n",
+	})
+	client, err := NewClient(Config{Provider: "ollama", BaseURL: server.URL, Model: "m", EnableHistory: true})
+	require.NoError(t, err)
+
+	reply, err := client.ChatStream(context.Background(), "hello", func(string) {})
+	require.NoError(t, err)
+	assert.Equal(t, "streamed", reply)
+	assert.Equal(t, []Message{
+		{Role: RoleUser, Content: "hello"},
+		{Role: RoleAssistant, Content: "streamed"},
+	}, client.messageHistory)
+}
+
+func TestOpenAIProvider_ToolCall(t *testing.T) {
+	server := newStandIn(t, http.StatusOK,
+		`{"choices":[{"index":0,"message":{"role":"assistant","tool_calls":[{"id":"call_1","type":"function","function":{"name":"answer","arguments":"{\"content\":\"hi\"}"}}]}}]}`,
+		func(r *http.Request, body map[string]interface{}) {
+			assert.Equal(t, "required", body["tool_choice"])
+			tools, ok := body["tools"].([]interface{})
+			if assert.True(t, ok) && assert.Len(t, tools, 1) {
+				function := tools[0].(map[string]interface{})["function"].(map[string]interface{})
+				assert.Equal(t, "answer", function["name"])
+				assert.Equal(t, "object", function["parameters"].(map[string]interface{})["type"])
+			}
+		})
+
+	client, err := NewClient(Config{Provider: "openai", APIKey: "sk-test", BaseURL: server.URL + "/v1", Model: "gpt-4o", EnableHistory: true})
+	require.NoError(t, err)
+	require.True(t, client.SupportsTools())
+
+	call, err := client.ChatWithTools(context.Background(), "hello", []common.Tool{
+		{Name: "answer", Parameters: map[string]interface{}{"type": "object"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &common.ToolCall{Name: "answer", Arguments: `{"content":"hi"}`}, call)
+	assert.Len(t, client.messageHistory, 2)
+}
+
+func TestOpenAIProvider_NoToolCall(t *testing.T) {
+	server := newStandIn(t, http.StatusOK,
+		`{"choices":[{"index":0,"message":{"role":"assistant","content":"just text"}}]}`,
+		func(*http.Request, map[string]interface{}) {})
+
+	client, err := NewClient(Config{Provider: "openai", APIKey: "sk-test", BaseURL: server.URL + "/v1", Model: "gpt-4o"})
+	require.NoError(t, err)
+
+	_, err = client.ChatWithTools(context.Background(), "hello", []common.Tool{{Name: "answer"}})
+	assert.ErrorIs(t, err, ErrNoToolCall)
+}
+
+func TestClient_ToolsNotSupported(t *testing.T) {
+	client, err := NewClient(Config{Provider: "ollama", BaseURL: "http://localhost:1", Model: "m"})
+	require.NoError(t, err)
+
+	assert.False(t, client.SupportsTools())
+	_, err = client.ChatWithTools(context.Background(), "hello", nil)
+	assert.ErrorIs(t, err, ErrToolsNotSupported)
+}
+
+func TestOpenAIProvider_ToolsRejected(t *testing.T) {
+	tests := []struct {
+		status   int
+		rejected bool
+	}{
+		{http.StatusBadRequest, true},
+		{http.StatusUnprocessableEntity, true},
+		{http.StatusUnauthorized, false},
+		{http.StatusTooManyRequests, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(http.StatusText(tc.status), func(t *testing.T) {
+			server := newStandIn(t, tc.status, `{"error":{"message":"unknown field tools"}}`,
+				func(*http.Request, map[string]interface{}) {})
+
+			client, err := NewClient(Config{Provider: "openai", APIKey: "sk-test", BaseURL: server.URL + "/v1", Model: "local"})
+			require.NoError(t, err)
+
+			_, err = client.ChatWithTools(context.Background(), "hello", []common.Tool{{Name: "answer"}})
+			require.Error(t, err)
+			assert.Equal(t, tc.rejected, errors.Is(err, ErrToolsNotSupported), err.Error())
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package version
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Level is the part of a version a release bumps
+type Level int
+
+const (
+	None Level = iota
+	Patch
+	Minor
+	Major
+)
+
+func (l Level) String() string {
+	switch l {
+	case Patch:
+		return "patch"
+	case Minor:
+		return "minor"
+	case Major:
+		return "major"
+	default:
+		return "none"
+	}
+}
+
+// ParseLevel reads "major", "minor" or "patch"
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "major":
+		return Major, nil
+	case "minor":
+		return Minor, nil
+	case "patch":
+		return Patch, nil
+	default:
+		return None, fmt.Errorf("invalid bump %q, use major, minor or patch", s)
+	}
+}
+
+var (
+	semverPattern     = regexp.MustCompile(`^v?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(?:-([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?(?:\+[0-9A-Za-z-.]+)?$`)
+	prereleasePattern = regexp.MustCompile(`^[0-9A-Za-z-]+$`)
+)
+
+// Semver is a semantic version such as 1.4.0 or 2.0.0-rc.1. Build metadata
+// is dropped when parsing.
+type Semver struct {
+	Major, Minor, Patch int
+	Prerelease          string
+}
+
+// ParseSemver reads a version with or without a leading v, as used in tags
+func ParseSemver(s string) (Semver, error) {
+	m := semverPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return Semver{}, fmt.Errorf("%q is not a semantic version", s)
+	}
+	var v Semver
+	var err error
+	for i, part := range []*int{&v.Major, &v.Minor, &v.Patch} {
+		if *part, err = strconv.Atoi(m[i+1]); err != nil {
+			return Semver{}, fmt.Errorf("%q is not a semantic version: %w", s, err)
+		}
+	}
+	v.Prerelease = m[4]
+	return v, nil
+}
+
+func (v Semver) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	return s
+}
+
+// Next returns the release that bumps level. A prerelease becomes its own
+// release when that already covers the bump, so 2.0.0-rc.1 is followed by
+// 2.0.0 rather than 3.0.0.
+func (v Semver) Next(level Level) Semver {
+	pre := v.Prerelease != ""
+	switch level {
+	case Major:
+		if pre && v.Minor == 0 && v.Patch == 0 {
+			return Semver{Major: v.Major}
+		}
+		return Semver{Major: v.Major + 1}
+	case Minor:
+		if pre && v.Patch == 0 {
+			return Semver{Major: v.Major, Minor: v.Minor}
+		}
+		return Semver{Major: v.Major, Minor: v.Minor + 1}
+	case Patch:
+		if pre {
+			return Semver{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+		}
+		return Semver{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+	default:
+		return v
+	}
+}
+
+// NextPrerelease returns the next prerelease named id, such as rc.1, of the
+// release Next(level) would give. Another prerelease of the same release
+// counts on: 2.0.0-rc.1 is followed by 2.0.0-rc.2.
+func (v Semver) NextPrerelease(level Level, id string) (Semver, error) {
+	if !prereleasePattern.MatchString(id) {
+		return Semver{}, fmt.Errorf("invalid prerelease name %q", id)
+	}
+	next := v.Next(level)
+	next.Prerelease = id + ".1"
+
+	if v.Prerelease == "" || v.Major != next.Major || v.Minor != next.Minor || v.Patch != next.Patch {
+		return next, nil
+	}
+	name, number, found := strings.Cut(v.Prerelease, ".")
+	if n, err := strconv.Atoi(number); found && name == id && err == nil {
+		next.Prerelease = fmt.Sprintf("%s.%d", id, n+1)
+	}
+	return next, nil
+}
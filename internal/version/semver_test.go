@@ -0,0 +1,100 @@
+package version
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSemver(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Semver
+		err  bool
+	}{
+		{in: "1.2.3", want: Semver{Major: 1, Minor: 2, Patch: 3}},
+		{in: "v0.10.0", want: Semver{Minor: 10}},
+		{in: "v2.0.0-rc.1", want: Semver{Major: 2, Prerelease: "rc.1"}},
+		{in: "1.0.0-beta+exp.sha.5114f85", want: Semver{Major: 1, Prerelease: "beta"}},
+		{in: "1.2", err: true},
+		{in: "v01.2.3", err: true},
+		{in: "vendor-1", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseSemver(tt.in)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSemver_Next(t *testing.T) {
+	tests := []struct {
+		from  string
+		level Level
+		want  string
+	}{
+		{from: "1.2.3", level: Major, want: "2.0.0"},
+		{from: "1.2.3", level: Minor, want: "1.3.0"},
+		{from: "1.2.3", level: Patch, want: "1.2.4"},
+		{from: "1.2.3", level: None, want: "1.2.3"},
+		{from: "2.0.0-rc.2", level: Major, want: "2.0.0"},
+		{from: "2.1.0-rc.1", level: Major, want: "3.0.0"},
+		{from: "1.3.0-beta.1", level: Minor, want: "1.3.0"},
+		{from: "1.3.1-beta.1", level: Minor, want: "1.4.0"},
+		{from: "1.3.1-beta.1", level: Patch, want: "1.3.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+" "+tt.level.String(), func(t *testing.T) {
+			v, err := ParseSemver(tt.from)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, v.Next(tt.level).String())
+		})
+	}
+}
+
+func TestSemver_NextPrerelease(t *testing.T) {
+	tests := []struct {
+		from  string
+		level Level
+		id    string
+		want  string
+	}{
+		{from: "1.2.3", level: Minor, id: "rc", want: "1.3.0-rc.1"},
+		{from: "1.3.0-rc.1", level: Patch, id: "rc", want: "1.3.0-rc.2"},
+		{from: "1.3.0-rc.9", level: Minor, id: "rc", want: "1.3.0-rc.10"},
+		{from: "1.3.0-beta.3", level: Minor, id: "rc", want: "1.3.0-rc.1"},
+		{from: "1.3.0-rc.2", level: Major, id: "rc", want: "2.0.0-rc.1"},
+		{from: "1.0.0-beta", level: Patch, id: "beta", want: "1.0.0-beta.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+" "+tt.id, func(t *testing.T) {
+			v, err := ParseSemver(tt.from)
+			require.NoError(t, err)
+			got, err := v.NextPrerelease(tt.level, tt.id)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+
+	_, err := Semver{Major: 1}.NextPrerelease(Patch, "rc.1")
+	assert.EqualError(t, err, `invalid prerelease name "rc.1"`)
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := ParseLevel("Minor")
+	require.NoError(t, err)
+	assert.Equal(t, Minor, level)
+
+	_, err = ParseLevel("huge")
+	assert.EqualError(t, err, `invalid bump "huge", use major, minor or patch`)
+}
@@ -2,6 +2,7 @@ package apierrors
 
 import (
 	"fmt"
+	"strings"
 )
 
 // ErrorType represents the category of error
@@ -13,6 +14,12 @@ const (
 	ErrInvalidAPIKey      ErrorType = "invalid_api_key"
 	ErrInvailidModel      ErrorType = "invalid_model"
 	ErrGitNotInitialized  ErrorType = "git_not_initialized"
+	ErrNothingToCommit    ErrorType = "nothing_to_commit"
+	ErrCancelled          ErrorType = "cancelled"
+	ErrModifyNotAllowed   ErrorType = "modify_not_allowed"
+	ErrNothingToUndo      ErrorType = "nothing_to_undo"
+	ErrSecretDetected     ErrorType = "secret_detected"
+	ErrPublishedCommit    ErrorType = "published_commit"
 )
 
 // AppError represents an application error with context
@@ -75,3 +82,51 @@ func NewInvalidModelError() *AppError {
 		Message: "Model is invalid",
 	}
 }
+
+func NewNothingToCommitError() *AppError {
+	return &AppError{
+		Type:    ErrNothingToCommit,
+		Message: "No staged changes to commit",
+	}
+}
+
+func NewCancelledError() *AppError {
+	return &AppError{
+		Type:    ErrCancelled,
+		Message: "Operation cancelled",
+	}
+}
+
+func NewModifyNotAllowedError() *AppError {
+	return &AppError{
+		Type:    ErrModifyNotAllowed,
+		Message: "The query requires commands that modify the repository, rerun with --allow-modify to execute them",
+	}
+}
+
+func NewNothingToUndoError() *AppError {
+	return &AppError{
+		Type:    ErrNothingToUndo,
+		Message: "No recorded operations to undo",
+	}
+}
+
+func NewSecretDetectedError(locations []string) *AppError {
+	return &AppError{
+		Type:    ErrSecretDetected,
+		Message: fmt.Sprintf("Commit aborted, the staged changes add possible secrets: %s", strings.Join(locations, ", ")),
+		Metadata: map[string]interface{}{
+			"locations": locations,
+		},
+	}
+}
+
+func NewPublishedCommitError(rev string, branches []string) *AppError {
+	return &AppError{
+		Type:    ErrPublishedCommit,
+		Message: fmt.Sprintf("%s is already on %s; rewording it would rewrite published history", rev, strings.Join(branches, ", ")),
+		Metadata: map[string]interface{}{
+			"branches": branches,
+		},
+	}
+}
@@ -32,7 +32,7 @@ func (l *LoggerImpl) Debug(format string, args ...interface{}) {
 	if l.mode >= LogModeDebug {
 		_, file, line, _ := runtime.Caller(1)
 		msg := fmt.Sprintf(format, args...)
-		fmt.Printf("DEBUG [%s:%d]: %s\n", filepath.Base(file), line, msg)
+		fmt.Fprintf(os.Stderr, "DEBUG [%s:%d]: %s\n", filepath.Base(file), line, msg)
 	}
 }
 